@@ -15,9 +15,125 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/signin": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "JSON Web Key Set (RFC 7517) with the public part of the asymmetric signing keys. HMAC secrets are never published",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Misc"
+                ],
+                "summary": "Public keys to validate the tokens issued by this service",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jwt.JSONWebKeySet"
+                        }
+                    }
+                }
+            }
+        },
+        "/access/grants/{grantId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Only for those with the admin permission on the resource",
+                "tags": [
+                    "Access"
+                ],
+                "summary": "Take away a permission on a resource",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the grant",
+                        "name": "grantId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Permission taken"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not allowed to manage the resource"
+                    },
+                    "404": {
+                        "description": "Grant not found"
+                    },
+                    "500": {
+                        "description": "Error taking the permission"
+                    }
+                }
+            }
+        },
+        "/access/resources/{resourceType}/{resourceId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Users and groups given permissions on the resource. Only for those with the admin permission on it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Access"
+                ],
+                "summary": "Get the grants on a resource",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Type of the resource, e.g. document",
+                        "name": "resourceType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the resource",
+                        "name": "resourceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.Grant"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not allowed to manage the resource"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            },
             "post": {
-                "description": "Receives login credentials and returns a token",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "To a user (by id) or to a group (by name). Only for those with the admin permission on the resource",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,154 +141,2914 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Access"
                 ],
-                "summary": "Sign in the system",
+                "summary": "Give a permission on a resource",
                 "parameters": [
                     {
-                        "description": "Credentials",
-                        "name": "loginData",
+                        "type": "string",
+                        "description": "Type of the resource, e.g. document",
+                        "name": "resourceType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the resource",
+                        "name": "resourceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Who and which permission",
+                        "name": "grantData",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.LoginCredentials"
+                            "$ref": "#/definitions/dtos.GrantCreate"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dtos.LoggedUser"
+                            "$ref": "#/definitions/dtos.Grant"
                         }
                     },
                     "400": {
                         "description": "Invalid data"
                     },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not allowed to manage the resource"
+                    },
+                    "404": {
+                        "description": "Group not found"
+                    },
+                    "409": {
+                        "description": "The permission was already given"
+                    },
                     "500": {
-                        "description": "Error generating response or token"
+                        "description": "Error giving the permission"
                     }
                 }
             }
         },
-        "/health": {
+        "/admin/groups": {
             "get": {
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Groups of users that receive grants on resources together. Only for administrators",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Misc"
+                    "Admin"
                 ],
-                "summary": "Health checking URL",
+                "summary": "Get all groups",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.Group"
                             }
                         }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "500": {
+                        "description": "Error generating response"
                     }
                 }
-            }
-        },
-        "/user/": {
-            "get": {
-                "description": "Get all Users in the system",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Only for administrators",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
+                ],
+                "summary": "Create a group",
+                "parameters": [
+                    {
+                        "description": "Name of the group",
+                        "name": "groupData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GroupCreate"
+                        }
+                    }
                 ],
-                "summary": "Get all Users",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dtos.User"
-                            }
+                            "$ref": "#/definitions/dtos.Group"
                         }
                     },
                     "400": {
                         "description": "Invalid data"
                     },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "409": {
+                        "description": "The group already exists"
+                    },
                     "500": {
-                        "description": "Error generating response"
+                        "description": "Error creating the group"
                     }
                 }
             }
         },
-        "/user/{userId}": {
-            "get": {
-                "description": "Get all Users in the system",
-                "produces": [
+        "/admin/groups/{groupName}/members": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adding a user already in the group is not an error. Only for administrators",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Get all Users",
+                "summary": "Add a user to a group",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID del usuario",
+                        "description": "Name of the group",
+                        "name": "groupName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User to add",
+                        "name": "memberData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GroupMemberAdd"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User added"
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "404": {
+                        "description": "User or group not found"
+                    },
+                    "500": {
+                        "description": "Error adding the user"
+                    }
+                }
+            }
+        },
+        "/admin/groups/{groupName}/members/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Only for administrators",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Remove a user from a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the group",
+                        "name": "groupName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
                         "name": "userId",
                         "in": "path",
                         "required": true
                     }
                 ],
+                "responses": {
+                    "204": {
+                        "description": "User removed"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "404": {
+                        "description": "Group not found"
+                    },
+                    "500": {
+                        "description": "Error removing the user"
+                    }
+                }
+            }
+        },
+        "/admin/roles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Roles that can be given to users, with their permissions. Only for administrators",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get all roles",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.Role"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds a role with a set of permissions. Only for administrators",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create a custom role",
+                "parameters": [
+                    {
+                        "description": "Name and permissions of the role",
+                        "name": "roleData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RoleCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.Role"
                         }
                     },
                     "400": {
                         "description": "Invalid data"
                     },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "409": {
+                        "description": "The role already exists"
+                    },
                     "500": {
-                        "description": "Error generating response or token"
+                        "description": "Error creating the role"
                     }
                 }
             }
-        }
-    },
+        },
+        "/admin/users/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Every user, oldest first, as a CSV or JSON Lines file that can be imported again. Only for administrators",
+                "produces": [
+                    "text/csv",
+                    "application/jsonl"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Export users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (by default) or jsonl",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The file"
+                    },
+                    "400": {
+                        "description": "Unknown format"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "500": {
+                        "description": "Error exporting the users"
+                    }
+                }
+            }
+        },
+        "/admin/users/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates the users of a CSV or JSON Lines file, or updates them if the email is already registered. The CSV files need a header naming the columns: email, first_name, last_name, second_last_name, password. Users without password can not sign in until they reset it. Every line is reported, the wrong ones do not stop the import. Only for administrators",
+                "consumes": [
+                    "text/csv",
+                    "application/jsonl"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Import users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or jsonl. If not set, taken from the Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Check the file and report what would be done, without storing anything",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Unreadable file"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "500": {
+                        "description": "Error importing the users"
+                    }
+                }
+            }
+        },
+        "/admin/users/{userId}/roles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Only for administrators",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get the roles of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.Role"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Giving a role the user already has is not an error. Only for administrators",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Give a role to a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role to give",
+                        "name": "grantData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RoleGrant"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Role given"
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "404": {
+                        "description": "User or role not found"
+                    },
+                    "500": {
+                        "description": "Error giving the role"
+                    }
+                }
+            }
+        },
+        "/admin/users/{userId}/roles/{roleName}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Administrators can not take the admin permission from themselves. Only for administrators",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Take a role from a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the role",
+                        "name": "roleName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Role taken"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "404": {
+                        "description": "Role not found"
+                    },
+                    "409": {
+                        "description": "Own admin permission"
+                    },
+                    "500": {
+                        "description": "Error taking the role"
+                    }
+                }
+            }
+        },
+        "/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Security relevant events, newest first. Only for administrators",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Get the audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only events of this type, e.g. account_locked or ip_locked",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events on this target: an email, an IP address...",
+                        "name": "target",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events after this moment (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of events (100 by default, 1000 at most)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.AuditEvent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            }
+        },
+        "/auth/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Keys not revoked yet, expired ones included. The keys themselves are never returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get the API keys of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.APIKey"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mints a key for machine clients acting on behalf of the user. The key is only returned now, keep it safe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "description": "Name, scopes and duration of the key",
+                        "name": "keyData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.APIKeyCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreatedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "409": {
+                        "description": "Too many API keys"
+                    },
+                    "500": {
+                        "description": "Error creating the key"
+                    }
+                }
+            }
+        },
+        "/auth/api-keys/{keyId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The key stops working at once on this server, and within a minute on the others",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the key",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Key revoked"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "404": {
+                        "description": "Key not found"
+                    },
+                    "500": {
+                        "description": "Error revoking the key"
+                    }
+                }
+            }
+        },
+        "/auth/confirm-email": {
+            "post": {
+                "description": "Switches the user to the new email using the token received in it",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Confirm a change of email",
+                "parameters": [
+                    {
+                        "description": "Token received by email",
+                        "name": "confirmData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EmailChangeConfirm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Email changed"
+                    },
+                    "400": {
+                        "description": "Invalid data or invalid token"
+                    },
+                    "409": {
+                        "description": "Email already registered"
+                    },
+                    "500": {
+                        "description": "Error changing the email"
+                    }
+                }
+            }
+        },
+        "/auth/confirm-reset": {
+            "post": {
+                "description": "Sets a new password using the token received by email. Every session of the user is closed",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Confirm a password reset",
+                "parameters": [
+                    {
+                        "description": "Token and new password",
+                        "name": "resetData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PasswordResetConfirm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Password changed"
+                    },
+                    "400": {
+                        "description": "Invalid data, weak password or invalid token"
+                    },
+                    "500": {
+                        "description": "Error changing the password"
+                    }
+                }
+            }
+        },
+        "/auth/email": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sends a link to the new address. The email of the signed in user changes when the link is followed",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Request a change of email",
+                "parameters": [
+                    {
+                        "description": "New email and current password",
+                        "name": "emailData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EmailChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Request accepted"
+                    },
+                    "400": {
+                        "description": "Invalid data or user of an external provider"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Invalid password"
+                    },
+                    "409": {
+                        "description": "Email already registered"
+                    },
+                    "429": {
+                        "description": "Too many failed attempts for the account or the address"
+                    },
+                    "500": {
+                        "description": "Error requesting the change"
+                    }
+                }
+            }
+        },
+        "/auth/mfa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enables two-factor authentication with a code of the authenticator app. Returns the recovery codes, which are shown only once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Confirm the two-factor authentication enrollment",
+                "parameters": [
+                    {
+                        "description": "Code of the authenticator app",
+                        "name": "codeData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MFACode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MFARecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data, invalid code or enrollment not started"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "409": {
+                        "description": "MFA is already enabled"
+                    }
+                }
+            }
+        },
+        "/auth/mfa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the second factor of the user. Requires a code of the authenticator app or one of the recovery codes",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Disable two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Code of the authenticator app or recovery code",
+                        "name": "codeData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MFACode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "MFA disabled"
+                    },
+                    "400": {
+                        "description": "Invalid data, invalid code or MFA not enabled"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    }
+                }
+            }
+        },
+        "/auth/mfa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generates the secret to add to the authenticator app. Nothing changes until the enrollment is confirmed with a code",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Start the two-factor authentication enrollment",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MFAEnrollment"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "409": {
+                        "description": "MFA is already enabled"
+                    }
+                }
+            }
+        },
+        "/auth/mfa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the recovery codes of the user. Requires a code of the authenticator app or one of the current recovery codes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Generate new recovery codes",
+                "parameters": [
+                    {
+                        "description": "Code of the authenticator app or recovery code",
+                        "name": "codeData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MFACode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MFARecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data, invalid code or MFA not enabled"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    }
+                }
+            }
+        },
+        "/auth/mfa/verify": {
+            "post": {
+                "description": "Exchanges the MFA token returned by the sign in and a code of the authenticator app, or a recovery code, for the tokens",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Complete a sign in with two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "MFA token and code",
+                        "name": "verifyData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MFAVerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoggedUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid code, or invalid or expired MFA token"
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, try again later"
+                    },
+                    "500": {
+                        "description": "Error generating response or token"
+                    }
+                }
+            }
+        },
+        "/auth/oidc/callback": {
+            "get": {
+                "description": "The OIDC provider redirects the browser here after the user signs in. Returns a token for the user, who is created the first time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Complete the sign in with the OIDC provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State of the sign in",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoggedUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired state, or the sign in was started in another browser"
+                    },
+                    "401": {
+                        "description": "The provider did not confirm the sign in"
+                    },
+                    "403": {
+                        "description": "The provider has not verified the email"
+                    },
+                    "404": {
+                        "description": "OIDC sign in is not configured"
+                    }
+                }
+            }
+        },
+        "/auth/oidc/start": {
+            "get": {
+                "description": "Redirects the browser to the sign in page of the configured OIDC provider (authorization code flow with PKCE). The state of the sign in is set in a cookie the callback requires",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Start the sign in with the OIDC provider",
+                "responses": {
+                    "302": {
+                        "description": "Redirection to the provider"
+                    },
+                    "404": {
+                        "description": "OIDC sign in is not configured"
+                    },
+                    "502": {
+                        "description": "The provider is not available"
+                    }
+                }
+            }
+        },
+        "/auth/password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets a new password for the signed in user, who must send the current one. Every other session of the user is closed and the tokens of a new one are returned",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Change the password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "passwordData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PasswordChange"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoggedUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data, weak password or user of an external provider"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Invalid password"
+                    },
+                    "429": {
+                        "description": "Too many failed attempts for the account or the address"
+                    },
+                    "500": {
+                        "description": "Error changing the password"
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Receives a refresh token and returns a new access token and a new refresh token. Each refresh token can be used only once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh the access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "refreshData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoggedUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid, expired or reused refresh token"
+                    },
+                    "403": {
+                        "description": "Email not verified, when verified emails are required"
+                    },
+                    "500": {
+                        "description": "Error generating response or token"
+                    }
+                }
+            }
+        },
+        "/auth/request-reset": {
+            "post": {
+                "description": "Sends an email with a link to set a new password. The answer is the same whether the email is registered or not",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "description": "Email of the account",
+                        "name": "resetData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PasswordResetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Request accepted"
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "500": {
+                        "description": "Error creating the token"
+                    }
+                }
+            }
+        },
+        "/auth/resend-verification": {
+            "post": {
+                "description": "Sends again the email with the link to verify the address. The answer is the same whether the email is registered or not",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Request the email verification",
+                "parameters": [
+                    {
+                        "description": "Email to verify",
+                        "name": "verificationData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.EmailVerificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Request accepted"
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    }
+                }
+            }
+        },
+        "/auth/signin": {
+            "post": {
+                "description": "Receives login credentials and returns a token. Users with two-factor authentication get an MFA token instead, to be sent with the code to /auth/mfa/verify",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Sign in the system",
+                "parameters": [
+                    {
+                        "description": "Credentials",
+                        "name": "loginData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoginCredentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoggedUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid credentials"
+                    },
+                    "403": {
+                        "description": "Email not verified"
+                    },
+                    "429": {
+                        "description": "Too many failed attempts for the account or the address"
+                    },
+                    "500": {
+                        "description": "Error generating response or token"
+                    }
+                }
+            }
+        },
+        "/auth/signout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes the access token used in the call and, if it is sent, the refresh token of the session",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Sign out of the system",
+                "parameters": [
+                    {
+                        "description": "Session refresh token",
+                        "name": "signOutData",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.SignOutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Session closed"
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "500": {
+                        "description": "Error revoking the tokens"
+                    }
+                }
+            }
+        },
+        "/auth/signup": {
+            "post": {
+                "description": "Creates a user with password authentication and returns a token for it. If verified emails are required, only the id of the user is returned, with no token until the email sent is followed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Sign up in the system",
+                "parameters": [
+                    {
+                        "description": "New user data",
+                        "name": "signUpData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserSignUp"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoggedUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data, weak password or user already exists"
+                    },
+                    "500": {
+                        "description": "Error generating response or token"
+                    }
+                }
+            }
+        },
+        "/auth/tenant": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts a new session whose tokens carry the organization. The user must be a member of it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Act on behalf of an organization",
+                "parameters": [
+                    {
+                        "description": "Organization",
+                        "name": "tenantData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.TenantSwitch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoggedUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not a member of the organization"
+                    },
+                    "500": {
+                        "description": "Error generating response or token"
+                    }
+                }
+            }
+        },
+        "/auth/verify-email": {
+            "post": {
+                "description": "Marks the email of the user as verified using the token received by email",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Verify an email",
+                "parameters": [
+                    {
+                        "description": "Token received by email",
+                        "name": "verifyData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Email verified"
+                    },
+                    "400": {
+                        "description": "Invalid data or invalid token"
+                    },
+                    "500": {
+                        "description": "Error verifying the email"
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Misc"
+                ],
+                "summary": "Health checking URL",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Get the organizations of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.Organization"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The user creating it becomes its owner. Use /auth/tenant to act on behalf of it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Create an organization",
+                "parameters": [
+                    {
+                        "description": "Name of the organization",
+                        "name": "organizationData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.OrganizationCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.Organization"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "500": {
+                        "description": "Error creating the organization"
+                    }
+                }
+            }
+        },
+        "/orgs/current/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The organization is the one the token acts on behalf of",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Get the members of the current organization",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.Member"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "No organization selected"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not a member of the organization"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Also changes the role of a user already in it. Only for owners and admins of the organization",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Add a member to the current organization",
+                "parameters": [
+                    {
+                        "description": "User and role",
+                        "name": "memberData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MemberAdd"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Member added"
+                    },
+                    "400": {
+                        "description": "Invalid data or no organization selected"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an owner or admin of the organization"
+                    },
+                    "404": {
+                        "description": "User not found"
+                    },
+                    "409": {
+                        "description": "The user is the owner"
+                    },
+                    "500": {
+                        "description": "Error adding the member"
+                    }
+                }
+            }
+        },
+        "/orgs/current/members/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Owners and admins can remove anyone but the owner. Members can only leave",
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Remove a member from the current organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Member removed"
+                    },
+                    "400": {
+                        "description": "No organization selected"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not allowed"
+                    },
+                    "404": {
+                        "description": "Membership not found"
+                    },
+                    "409": {
+                        "description": "The user is the owner"
+                    },
+                    "500": {
+                        "description": "Error removing the member"
+                    }
+                }
+            }
+        },
+        "/user/": {
+            "get": {
+                "description": "Get a page of the Users in the system, oldest first unless sorted otherwise. Follow next_cursor to get the next page",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get the Users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Users per page (20 by default, 100 at most)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of the page, starting at 1, instead of the cursor",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fields separated by commas, a leading - sorts descending: created_at, email, first_name, last_name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count the users matching the filters",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the user with this email",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only users whose email starts with this, case insensitive",
+                        "name": "email[prefix]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only users whose first or last names contain this, case insensitive",
+                        "name": "name[contains]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only users created at or after this moment (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_at[gte]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only users created at or before this moment (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_at[lte]",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserList"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "403": {
+                        "description": "Without the read permission"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The user signs in with the password. Only for administrators",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Create a user",
+                "parameters": [
+                    {
+                        "description": "Data of the user",
+                        "name": "userData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data, weak password or the user already exists"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Not an administrator"
+                    },
+                    "500": {
+                        "description": "Error creating the user"
+                    }
+                }
+            }
+        },
+        "/user/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get the signed in user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "404": {
+                        "description": "User not found"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            }
+        },
+        "/user/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Everything stored about the user, as a JSON file with a section per module (GDPR right of access)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Export the data of the signed in user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserDataExport"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "404": {
+                        "description": "User not found"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            }
+        },
+        "/user/{userId}": {
+            "get": {
+                "description": "Get all Users in the system",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get all Users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "403": {
+                        "description": "Another user, without the read permission"
+                    },
+                    "500": {
+                        "description": "Error generating response or token"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The user is hidden and can no longer sign in, but it can be restored. Users can delete themselves, deleting others needs the delete permission",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User deleted"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Another user, without the delete permission"
+                    },
+                    "404": {
+                        "description": "User not found"
+                    },
+                    "500": {
+                        "description": "Error deleting the user"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Only the fields sent are changed. Users can update their own profile, the profile of others needs the write permission",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update the profile of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "userData",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Another user, without the write permission"
+                    },
+                    "404": {
+                        "description": "User not found"
+                    },
+                    "500": {
+                        "description": "Error updating the user"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/erase": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes for good the user and everything stored about it, deleted users included (GDPR right to erasure). The audit log keeps the events, without the user. It can not be undone. Users can erase themselves, erasing others is only for administrators",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Erase a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User erased"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Another user, not being an administrator"
+                    },
+                    "404": {
+                        "description": "User not found"
+                    },
+                    "500": {
+                        "description": "Error erasing the user"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Everything stored about the user, deleted users included, as a JSON file with a section per module. Users can export themselves, exporting others is only for administrators",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Export the data of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserDataExport"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Another user, not being an administrator"
+                    },
+                    "404": {
+                        "description": "User not found"
+                    },
+                    "500": {
+                        "description": "Error generating response"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Only for users with the delete permission",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Restore a deleted user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User restored"
+                    },
+                    "401": {
+                        "description": "Invalid token"
+                    },
+                    "403": {
+                        "description": "Without the delete permission"
+                    },
+                    "404": {
+                        "description": "Deleted user not found"
+                    },
+                    "500": {
+                        "description": "Error restoring the user"
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
+        "dtos.APIKey": {
+            "description": "API key of a machine client. The key itself is only returned when created",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CI pipeline"
+                },
+                "prefix": {
+                    "description": "First characters of the key",
+                    "type": "string",
+                    "example": "gmk_Xb3kT9aQ"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read"
+                    ]
+                }
+            }
+        },
+        "dtos.APIKeyCreate": {
+            "description": "Data to create an API key",
+            "type": "object",
+            "required": [
+                "expires_in_days",
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "Days until the key stops working",
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1,
+                    "example": 90
+                },
+                "name": {
+                    "description": "To remember what the key is for",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "CI pipeline"
+                },
+                "scopes": {
+                    "description": "\"read\" allows only GET requests, \"write\" any request",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read"
+                    ]
+                }
+            }
+        },
+        "dtos.AuditEvent": {
+            "description": "Security relevant action",
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "description": "User that did it, if any",
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "ip": {
+                    "description": "Address the request came from",
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "target": {
+                    "description": "What it was done to",
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "type": {
+                    "description": "What happened",
+                    "type": "string",
+                    "example": "account_locked"
+                }
+            }
+        },
+        "dtos.CreatedAPIKey": {
+            "description": "New API key. Keep the key safe, it can not be retrieved again",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "key": {
+                    "description": "Send it as \"Authorization: Bearer \u003ckey\u003e\" or \"X-API-Key: \u003ckey\u003e\"",
+                    "type": "string",
+                    "example": "gmk_Xb3kT9aQ..."
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CI pipeline"
+                },
+                "prefix": {
+                    "description": "First characters of the key",
+                    "type": "string",
+                    "example": "gmk_Xb3kT9aQ"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read"
+                    ]
+                }
+            }
+        },
+        "dtos.EmailChangeConfirm": {
+            "description": "Request to switch to the new email using the token received in it",
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "description": "Token received by email",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.EmailChangeRequest": {
+            "description": "Request of a signed in user to change the email. It changes when the link sent to the new address is followed",
+            "type": "object",
+            "required": [
+                "email",
+                "secret"
+            ],
+            "properties": {
+                "email": {
+                    "description": "New email",
+                    "type": "string",
+                    "example": "john.doe@example.org"
+                },
+                "secret": {
+                    "description": "Current password",
+                    "type": "string",
+                    "example": "password"
+                }
+            }
+        },
+        "dtos.EmailVerificationRequest": {
+            "description": "Request to receive again the email with the link to verify the address",
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email to verify",
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                }
+            }
+        },
+        "dtos.Grant": {
+            "description": "Permission of a user, or of the members of a group, on a single resource",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "permission": {
+                    "description": "1 read, 100 write, 200 delete, 300 admin",
+                    "type": "integer",
+                    "example": 100
+                },
+                "resource_id": {
+                    "type": "string",
+                    "example": "42"
+                },
+                "resource_type": {
+                    "type": "string",
+                    "example": "document"
+                },
+                "subject_id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "subject_type": {
+                    "description": "user or group",
+                    "type": "string",
+                    "example": "user"
+                }
+            }
+        },
+        "dtos.GrantCreate": {
+            "description": "Permission to give on a resource",
+            "type": "object",
+            "required": [
+                "permission",
+                "subject",
+                "subject_type"
+            ],
+            "properties": {
+                "permission": {
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        100,
+                        200,
+                        300
+                    ],
+                    "example": 100
+                },
+                "subject": {
+                    "description": "Id of the user or name of the group",
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "subject_type": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "group"
+                    ],
+                    "example": "user"
+                }
+            }
+        },
+        "dtos.Group": {
+            "description": "Group of users that receive grants together",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "night-watch"
+                }
+            }
+        },
+        "dtos.GroupCreate": {
+            "description": "Data to create a group",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 2,
+                    "example": "night-watch"
+                }
+            }
+        },
+        "dtos.GroupMemberAdd": {
+            "description": "User to add to a group",
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                }
+            }
+        },
         "dtos.LoggedUser": {
             "description": "Logged user information",
             "type": "object",
             "properties": {
-                "access_token": {
-                    "description": "Authenticaton token",
-                    "type": "string"
+                "access_token": {
+                    "description": "Authenticaton token",
+                    "type": "string"
+                },
+                "email_verification_required": {
+                    "description": "No token is issued until the email is verified",
+                    "type": "boolean"
+                },
+                "expires_in": {
+                    "description": "Seconds until the access token expires",
+                    "type": "integer",
+                    "example": 900
+                },
+                "mfa_required": {
+                    "description": "The user has two-factor authentication. No token is issued until the code is sent with the MFA token",
+                    "type": "boolean"
+                },
+                "mfa_token": {
+                    "description": "Short lived token to send along with the code to /auth/mfa/verify",
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "description": "Single use token to get a new access token when it expires",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Id of the new user, when signing up gives no tokens",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.LoginCredentials": {
+            "description": "Request to sign in the program",
+            "type": "object",
+            "required": [
+                "email",
+                "secret"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email of the user signing in",
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "secret": {
+                    "description": "Password or third party token of the user signing in",
+                    "type": "string",
+                    "example": "password"
+                }
+            }
+        },
+        "dtos.MFACode": {
+            "description": "Code proving the user has the authenticator app, or one of the recovery codes where allowed",
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code of the authenticator app",
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "dtos.MFAEnrollment": {
+            "description": "Secret to add to the authenticator app. The enrollment must be confirmed with a code before it takes effect",
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "description": "Base32 secret, for apps that can not scan the QR code",
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+                },
+                "uri": {
+                    "description": "Payload of the QR code to scan with the authenticator app",
+                    "type": "string",
+                    "example": "otpauth://totp/Gommence:john.doe%40example.com?secret=JBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "dtos.MFARecoveryCodes": {
+            "description": "Single use codes to sign in when the authenticator app is not available. They are shown only once",
+            "type": "object",
+            "properties": {
+                "codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "k3n4p-7qz2m"
+                    ]
+                }
+            }
+        },
+        "dtos.MFAVerifyRequest": {
+            "description": "Second step of the sign in of users with two-factor authentication",
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code of the authenticator app or one of the recovery codes",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "mfa_token": {
+                    "description": "Token received in the first step of the sign in",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.Member": {
+            "description": "User belonging to an organization",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "owner, admin or member",
+                    "type": "string",
+                    "example": "member"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                }
+            }
+        },
+        "dtos.MemberAdd": {
+            "description": "User to add to the current organization, or whose role to change",
+            "type": "object",
+            "required": [
+                "role",
+                "user_id"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "member"
+                    ],
+                    "example": "member"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                }
+            }
+        },
+        "dtos.Organization": {
+            "description": "Tenant whose data is kept apart from the others",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Ankh-Morpork City Watch"
+                }
+            }
+        },
+        "dtos.OrganizationCreate": {
+            "description": "Data to create an organization",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "Ankh-Morpork City Watch"
                 }
             }
         },
-        "dtos.LoginCredentials": {
-            "description": "Request to sign in the program",
+        "dtos.PasswordChange": {
+            "description": "Request of a signed in user to change the password",
             "type": "object",
             "required": [
-                "email",
+                "new_secret",
                 "secret"
             ],
             "properties": {
-                "email": {
-                    "description": "Email of the user signing in",
+                "new_secret": {
+                    "description": "New password",
                     "type": "string",
-                    "example": "john.doe@example.com"
+                    "example": "my new password"
                 },
                 "secret": {
-                    "description": "Password or third party token of the user signing in",
+                    "description": "Current password",
+                    "type": "string",
+                    "example": "password"
+                }
+            }
+        },
+        "dtos.PasswordResetConfirm": {
+            "description": "Request to set a new password using the token received by email",
+            "type": "object",
+            "required": [
+                "secret",
+                "token"
+            ],
+            "properties": {
+                "secret": {
+                    "description": "New password",
                     "type": "string",
                     "example": "password"
+                },
+                "token": {
+                    "description": "Token received by email",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.PasswordResetRequest": {
+            "description": "Request to receive an email with a link to reset the password",
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email of the user who forgot the password",
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                }
+            }
+        },
+        "dtos.RefreshTokenRequest": {
+            "description": "Request to get a new access token",
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "description": "Refresh token received when signing in or in the last refresh",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.Role": {
+            "description": "Named set of permissions assigned to users",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "editor"
+                },
+                "permissions": {
+                    "description": "1 read, 100 write, 200 delete, 300 admin",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        100
+                    ]
+                }
+            }
+        },
+        "dtos.RoleCreate": {
+            "description": "Data to create a custom role",
+            "type": "object",
+            "required": [
+                "name",
+                "permissions"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 2,
+                    "example": "auditor"
+                },
+                "permissions": {
+                    "description": "1 read, 100 write, 200 delete, 300 admin",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1
+                    ]
+                }
+            }
+        },
+        "dtos.RoleGrant": {
+            "description": "Role to give to a user",
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "description": "Name of the role",
+                    "type": "string",
+                    "example": "editor"
+                }
+            }
+        },
+        "dtos.SignOutRequest": {
+            "description": "Request to close the current session",
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "description": "Optional. Refresh token of the session, it will be revoked along with the access token",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.TenantSwitch": {
+            "description": "Organization to act on behalf of",
+            "type": "object",
+            "required": [
+                "tenant"
+            ],
+            "properties": {
+                "tenant": {
+                    "description": "Id of the organization",
+                    "type": "string",
+                    "example": "23GfxRTs"
                 }
             }
         },
@@ -185,6 +3061,11 @@ const docTemplate = `{
                     "type": "string",
                     "example": "john.doe@example.com"
                 },
+                "email_verified": {
+                    "description": "Whether the user has proved the email is theirs",
+                    "type": "boolean",
+                    "example": true
+                },
                 "first_name": {
                     "description": "First name of the new user",
                     "type": "string",
@@ -200,12 +3081,295 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Doe"
                 },
+                "mfa_enabled": {
+                    "description": "Whether the user signs in with a second factor",
+                    "type": "boolean",
+                    "example": false
+                },
+                "second_last_name": {
+                    "description": "Second last name of the new user",
+                    "type": "string",
+                    "example": "Smith"
+                }
+            }
+        },
+        "dtos.UserCreate": {
+            "description": "Data of a user created by an administrator, who signs in with the password",
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "secret"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email of the new user",
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "first_name": {
+                    "description": "First name of the new user",
+                    "type": "string",
+                    "example": "John"
+                },
+                "last_name": {
+                    "description": "First last name of the new user",
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "second_last_name": {
+                    "description": "Second last name of the new user",
+                    "type": "string",
+                    "example": "Smith"
+                },
+                "secret": {
+                    "description": "Password of the new user",
+                    "type": "string",
+                    "example": "password"
+                }
+            }
+        },
+        "dtos.UserDataExport": {
+            "description": "Everything stored about a user, one section per module of the application",
+            "type": "object",
+            "properties": {
+                "generated_at": {
+                    "type": "string"
+                },
+                "sections": {
+                    "description": "Data of each module, e.g. user, api_keys or audit",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                }
+            }
+        },
+        "dtos.UserImportAction": {
+            "type": "string",
+            "enum": [
+                "created",
+                "updated",
+                "unchanged",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "UserImportCreated",
+                "UserImportUpdated",
+                "UserImportUnchanged",
+                "UserImportFailed"
+            ]
+        },
+        "dtos.UserImportReport": {
+            "description": "Result of an import, line by line. In a dry run nothing is stored, the report tells what would be done",
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 40
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.UserImportResult"
+                    }
+                },
+                "unchanged": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "dtos.UserImportResult": {
+            "description": "What was done with a line of the import file",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "created, updated, unchanged or failed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dtos.UserImportAction"
+                        }
+                    ],
+                    "example": "created"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Invalid email"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "23GfxRTs"
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "dtos.UserList": {
+            "description": "Page of users",
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.User"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Pass it as cursor to get the next page",
+                    "type": "string",
+                    "example": "WyIyMDI1LTAxLTAxVDAwOjAwOjAwWiIsIjIzR2Z4UlRzIl0"
+                },
+                "total": {
+                    "description": "Number of users matching the filters, when asked for",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "dtos.UserSignUp": {
+            "description": "Request to create a new user in the platform",
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "secret"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email of the new user",
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "first_name": {
+                    "description": "First name of the new user",
+                    "type": "string",
+                    "example": "John"
+                },
+                "last_name": {
+                    "description": "First last name of the new user",
+                    "type": "string",
+                    "example": "Doe"
+                },
                 "second_last_name": {
                     "description": "Second last name of the new user",
                     "type": "string",
                     "example": "Smith"
+                },
+                "secret": {
+                    "description": "Password of the new user",
+                    "type": "string",
+                    "example": "password"
+                }
+            }
+        },
+        "dtos.UserUpdate": {
+            "description": "Changes to the profile of a user. Missing fields are left as they are",
+            "type": "object",
+            "properties": {
+                "first_name": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "John"
+                },
+                "last_name": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "Doe"
+                },
+                "second_last_name": {
+                    "description": "Empty to remove it",
+                    "type": "string",
+                    "example": "Smith"
+                }
+            }
+        },
+        "dtos.VerifyEmailRequest": {
+            "description": "Request to verify an email address using the token received by email",
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "description": "Token received by email",
+                    "type": "string"
+                }
+            }
+        },
+        "jwt.JSONWebKey": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "Ed25519 curve",
+                    "type": "string"
+                },
+                "e": {
+                    "description": "RSA exponent",
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA modulus",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "description": "Ed25519 public key",
+                    "type": "string"
                 }
             }
+        },
+        "jwt.JSONWebKeySet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jwt.JSONWebKey"
+                    }
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "Type \"Bearer\" followed by a space and the access token",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -217,7 +3381,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api/v1",
 	Schemes:          []string{},
 	Title:            "Gommence",
-	Description:      "Go Web Server push starter",
+	Description:      "Go Web Server starter kit",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+// Config chooses the mailer and holds its settings
+type Config struct {
+	Kind      string // "smtp", "file" or "memory". If empty, "smtp" when the SMTP host is set and "file" otherwise
+	SMTP      SMTPConfig
+	OutboxDir string // Folder where the file mailer writes the emails ("mail_outbox" if empty)
+}
+
+// LoadMailer builds the mailer chosen by the config
+func LoadMailer(config Config) (ports.Mailer, error) {
+	kind := config.Kind
+	if kind == "" {
+		kind = "file"
+		if config.SMTP.Host != "" {
+			kind = "smtp"
+		}
+	}
+	switch kind {
+	case "smtp":
+		if config.SMTP.Port == 0 {
+			config.SMTP.Port = 587
+		}
+		return NewSMTPMailer(config.SMTP)
+	case "file":
+		dir := config.OutboxDir
+		if dir == "" {
+			dir = "mail_outbox"
+		}
+		return NewFileOutbox(dir)
+	case "memory":
+		return NewMemoryOutbox(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %s", kind)
+	}
+}
@@ -0,0 +1,72 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMessage = ports.MailMessage{To: "john@mail.com", Subject: "Verify your email", Body: "Follow this link:\n\nhttp://localhost/verify"}
+
+func TestFormatMessage(t *testing.T) {
+	data, err := formatMessage("app@mail.com", testMessage, time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	text := string(data)
+	assert.Contains(t, text, "From: app@mail.com\r\n")
+	assert.Contains(t, text, "To: john@mail.com\r\n")
+	assert.Contains(t, text, "Subject: Verify your email\r\n")
+	assert.Contains(t, text, "Date: Sun, 01 Jun 2025 10:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(text, "\r\n\r\nFollow this link:\r\n\r\nhttp://localhost/verify"))
+
+	injected := testMessage
+	injected.Subject = "Hello\r\nBcc: victim@mail.com"
+	_, err = formatMessage("app@mail.com", injected, time.Now())
+	assert.NotNil(t, err)
+}
+
+func TestMemoryOutbox(t *testing.T) {
+	outbox := NewMemoryOutbox()
+	assert.Nil(t, outbox.Send(context.Background(), testMessage))
+	messages := outbox.Messages()
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, testMessage, messages[0])
+}
+
+func TestFileOutbox(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	outbox, err := NewFileOutbox(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, outbox.Send(context.Background(), testMessage))
+	assert.Nil(t, outbox.Send(context.Background(), testMessage))
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Equal(t, 2, len(files))
+	data, _ := os.ReadFile(files[0])
+	assert.Contains(t, string(data), "To: john@mail.com")
+}
+
+func TestLoadMailer(t *testing.T) {
+	config := Config{OutboxDir: t.TempDir()}
+	mailer, err := LoadMailer(config)
+	assert.Nil(t, err)
+	assert.IsType(t, &FileOutbox{}, mailer)
+
+	config.SMTP = SMTPConfig{Host: "smtp.mail.com", From: "app@mail.com"}
+	mailer, err = LoadMailer(config)
+	assert.Nil(t, err)
+	assert.IsType(t, &SMTPMailer{}, mailer)
+
+	config.Kind = "memory"
+	mailer, err = LoadMailer(config)
+	assert.Nil(t, err)
+	assert.IsType(t, &MemoryOutbox{}, mailer)
+
+	config.Kind = "pigeon"
+	_, err = LoadMailer(config)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+const outboxFrom = "gommence@localhost"
+
+// MemoryOutbox keeps the messages instead of sending them. Meant for tests
+type MemoryOutbox struct {
+	lock     sync.Mutex
+	messages []ports.MailMessage
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+func (o *MemoryOutbox) Send(ctx context.Context, message ports.MailMessage) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far, oldest first
+func (o *MemoryOutbox) Messages() []ports.MailMessage {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return append([]ports.MailMessage(nil), o.messages...)
+}
+
+// FileOutbox writes every message to a .eml file that any mail client can open. Meant for development
+type FileOutbox struct {
+	dir     string
+	counter atomic.Int64
+}
+
+func NewFileOutbox(dir string) (*FileOutbox, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("creating mail outbox folder: %w", err)
+	}
+	return &FileOutbox{dir: dir}, nil
+}
+
+func (o *FileOutbox) Send(ctx context.Context, message ports.MailMessage) error {
+	now := time.Now()
+	data, err := formatMessage(outboxFrom, message, now)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405"), o.counter.Add(1))
+	return os.WriteFile(filepath.Join(o.dir, name), data, 0640)
+}
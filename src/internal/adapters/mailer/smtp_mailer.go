@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // No authentication if empty
+	Password string
+	From     string
+}
+
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) (ports.Mailer, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, fmt.Errorf("the smtp mailer needs a host and a from address")
+	}
+	return &SMTPMailer{config: config}, nil
+}
+
+// Send delivers the message to the SMTP server. net/smtp upgrades the connection with STARTTLS when the server offers it
+func (m *SMTPMailer) Send(ctx context.Context, message ports.MailMessage) error {
+	data, err := formatMessage(m.config.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	done := make(chan error, 1)
+	go func() { // net/smtp knows nothing about contexts
+		done <- smtp.SendMail(addr, auth, m.config.From, []string{message.To}, data)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// formatMessage builds a RFC 5322 plain text message. Line breaks in the headers are rejected to prevent header injection
+func formatMessage(from string, message ports.MailMessage, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, fmt.Errorf("line breaks are not allowed in email headers")
+		}
+	}
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("\r\n")
+	buffer.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,279 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Keys are fetched again when a token comes with an unknown kid, but not more often than this
+const minJWKSRefreshInterval = 1 * time.Minute
+
+type Config struct {
+	Issuer       string // e.g. https://accounts.google.com
+	ClientID     string
+	ClientSecret string   // Empty for public clients, PKCE protects the flow anyway
+	RedirectURL  string   // Our callback endpoint, as registered in the provider
+	Scopes       []string // "openid email profile" if empty
+}
+
+// discoveryDocument is the subset of the provider metadata (/.well-known/openid-configuration) we use
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Provider signs users in with any OIDC compliant provider. The metadata is discovered on first use,
+// so the server can start while the provider is unreachable
+type Provider struct {
+	config Config
+	client *http.Client
+
+	lock          sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(config Config, client *http.Client) (*Provider, error) {
+	if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("the OIDC provider needs an issuer, a client id and a redirect url")
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	return &Provider{config: config, client: client}, nil
+}
+
+// LoadProvider builds the provider of the config. It returns nil, and OIDC sign in is disabled, if there is no issuer
+func LoadProvider(config Config) (ports.IdentityProvider, error) {
+	if config.Issuer == "" {
+		return nil, nil
+	}
+	provider, err := NewProvider(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*domain.ExternalIdentity, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(request, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("calling the token endpoint: %w", err)
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("token endpoint answered %d: %s %s", status, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("the token endpoint did not return an ID token")
+	}
+	return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// boolClaim accepts true and "true": some providers send email_verified as a string
+type boolClaim bool
+
+func (b *boolClaim) UnmarshalJSON(data []byte) error {
+	*b = boolClaim(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string    `json:"nonce"`
+	AuthorizedParty string    `json:"azp"`
+	Email           string    `json:"email"`
+	EmailVerified   boolClaim `json:"email_verified"`
+	GivenName       string    `json:"given_name"`
+	FamilyName      string    `json:"family_name"`
+	Name            string    `json:"name"`
+}
+
+// verifyIDToken checks the signature against the provider keys and the claims as required by OIDC Core 3.1.3.7
+func (p *Provider) verifyIDToken(ctx context.Context, rawToken string, nonce string) (*domain.ExternalIdentity, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(rawToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "EdDSA"}), // never "none" nor HMAC
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("invalid ID token: it was not issued to this client")
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid ID token: no subject")
+	}
+	return &domain.ExternalIdentity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery discoveryDocument
+	status, err := p.doJSON(request, &discovery)
+	if err != nil || status != http.StatusOK {
+		return nil, fmt.Errorf("fetching the OIDC discovery document of %s: status %d %v", p.config.Issuer, status, err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("the OIDC discovery document is for issuer %s instead of %s", discovery.Issuer, p.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksURI == "" {
+		return nil, fmt.Errorf("the OIDC discovery document of %s is incomplete", p.config.Issuer)
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// getKey returns the provider key with the given id, fetching the key set again if it is unknown (the provider rotated its keys)
+func (p *Provider) getKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if key, found := p.findKey(kid); found {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < minJWKSRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %s", kid)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jsonWebKeySet
+	status, err := p.doJSON(request, &set)
+	if err != nil || status != http.StatusOK {
+		return nil, fmt.Errorf("fetching the keys of %s: status %d %v", p.config.Issuer, status, err)
+	}
+	p.keys = set.publicKeys()
+	p.keysFetchedAt = time.Now()
+	if key, found := p.findKey(kid); found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %s", kid)
+}
+
+// findKey looks the key up by kid. Tokens without kid are accepted only if the provider has a single key
+func (p *Provider) findKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, found := p.keys[kid]
+	return key, found
+}
+
+func (p *Provider) doJSON(request *http.Request, target interface{}) (int, error) {
+	response, err := p.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return response.StatusCode, err
+	}
+	if err := json.Unmarshal(body, target); err != nil && response.StatusCode == http.StatusOK {
+		return response.StatusCode, err
+	}
+	return response.StatusCode, nil
+}
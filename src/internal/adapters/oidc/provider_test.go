@@ -0,0 +1,130 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/tests/libtest"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testClientID    = "gommence-test"
+	testRedirectURL = "http://localhost:5080/api/v1/auth/oidc/callback"
+)
+
+var testUser = libtest.FakeOIDCUser{Subject: "1234567890", Email: "john@mail.com", EmailVerified: true, GivenName: "John", FamilyName: "Doe"}
+
+// authorize follows the provider redirection and returns the code sent to our callback
+func authorize(t *testing.T, provider *Provider, state string, nonce string, verifier string) string {
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, verifier)
+	assert.Nil(t, err)
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse }}
+	response, err := client.Get(authURL)
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusFound, response.StatusCode)
+	callback, err := url.Parse(response.Header.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, state, callback.Query().Get("state"))
+	return callback.Query().Get("code")
+}
+
+func newTestProvider(t *testing.T, issuer *libtest.FakeOIDCIssuer) *Provider {
+	provider, err := NewProvider(Config{Issuer: issuer.URL(), ClientID: testClientID, RedirectURL: testRedirectURL}, nil)
+	assert.Nil(t, err)
+	return provider
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	issuer := libtest.NewFakeOIDCIssuer(testClientID, testUser)
+	defer issuer.Close()
+	provider := newTestProvider(t, issuer)
+
+	code := authorize(t, provider, "the-state", "the-nonce", "the-verifier-with-enough-entropy-to-be-valid")
+	identity, err := provider.Exchange(context.Background(), code, "the-verifier-with-enough-entropy-to-be-valid", "the-nonce")
+	assert.Nil(t, err)
+	assert.Equal(t, issuer.URL(), identity.Issuer)
+	assert.Equal(t, testUser.Subject, identity.Subject)
+	assert.Equal(t, testUser.Email, identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "Doe", identity.FamilyName)
+
+	_, err = provider.Exchange(context.Background(), code, "the-verifier-with-enough-entropy-to-be-valid", "the-nonce") // codes are single use
+	assert.NotNil(t, err)
+}
+
+func TestPKCEVerifierMustMatch(t *testing.T) {
+	issuer := libtest.NewFakeOIDCIssuer(testClientID, testUser)
+	defer issuer.Close()
+	provider := newTestProvider(t, issuer)
+
+	code := authorize(t, provider, "the-state", "the-nonce", "the-verifier-with-enough-entropy-to-be-valid")
+	_, err := provider.Exchange(context.Background(), code, "a-stolen-code-without-the-verifier-is-useless", "the-nonce")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid_grant")
+}
+
+func TestIDTokenVerification(t *testing.T) {
+	issuer := libtest.NewFakeOIDCIssuer(testClientID, testUser)
+	defer issuer.Close()
+	provider := newTestProvider(t, issuer)
+	ctx := context.Background()
+
+	_, err := provider.verifyIDToken(ctx, issuer.IDToken("the-nonce"), "the-nonce")
+	assert.Nil(t, err)
+	_, err = provider.verifyIDToken(ctx, issuer.IDToken("the-nonce"), "other-nonce") // replayed token
+	assert.NotNil(t, err)
+
+	invalidClaims := []map[string]interface{}{
+		{"aud": "other-client"},
+		{"iss": "https://evil.example.com"},
+		{"exp": time.Now().Add(-time.Minute).Unix()},
+		{"aud": []string{testClientID, "other-client"}, "azp": "other-client"},
+		{"sub": ""},
+	}
+	for _, claims := range invalidClaims {
+		issuer.Claims = claims
+		_, err = provider.verifyIDToken(ctx, issuer.IDToken("the-nonce"), "the-nonce")
+		assert.NotNil(t, err, claims)
+	}
+	issuer.Claims = map[string]interface{}{"email_verified": "true"} // some providers send it as a string
+	identity, err := provider.verifyIDToken(ctx, issuer.IDToken("the-nonce"), "the-nonce")
+	assert.Nil(t, err)
+	assert.True(t, identity.EmailVerified)
+}
+
+func TestProviderKeyRotation(t *testing.T) {
+	issuer := libtest.NewFakeOIDCIssuer(testClientID, testUser)
+	defer issuer.Close()
+	provider := newTestProvider(t, issuer)
+	ctx := context.Background()
+
+	_, err := provider.verifyIDToken(ctx, issuer.IDToken("the-nonce"), "the-nonce")
+	assert.Nil(t, err)
+	issuer.RotateKey("new-key")
+	_, err = provider.verifyIDToken(ctx, issuer.IDToken("the-nonce"), "the-nonce") // fetched less than a minute ago
+	assert.NotNil(t, err)
+	provider.keysFetchedAt = time.Now().Add(-minJWKSRefreshInterval)
+	_, err = provider.verifyIDToken(ctx, issuer.IDToken("the-nonce"), "the-nonce")
+	assert.Nil(t, err)
+}
+
+func TestLoadProvider(t *testing.T) {
+	provider, err := LoadProvider(Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, provider) // disabled
+
+	config := Config{Issuer: "https://accounts.google.com"}
+	_, err = LoadProvider(config)
+	assert.NotNil(t, err) // no client
+
+	config.ClientID = testClientID
+	config.RedirectURL = testRedirectURL
+	provider, err = LoadProvider(config)
+	assert.Nil(t, err)
+	assert.NotNil(t, provider)
+}
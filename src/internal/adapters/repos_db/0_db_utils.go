@@ -11,7 +11,8 @@ import (
 	"github.com/Manolo-Esc/gommence/src/internal/infra/opo_uid"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -22,10 +23,17 @@ type BaseDBModel struct {
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
+// The drivers in use. Their Translate turns their own errors into the gorm ones, e.g. gorm.ErrDuplicatedKey
+var errorTranslators = []gorm.ErrorTranslator{postgres.Dialector{}, sqlite.Dialector{}}
+
+// IsUniqueViolation tells whether the error is the violation of a unique constraint, whatever the driver
 func IsUniqueViolation(err error) bool {
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		return pgErr.Code == "23505" // Postgres error code for unique constraint violation
+	for unwrapped := err; unwrapped != nil; unwrapped = errors.Unwrap(unwrapped) {
+		for _, translator := range errorTranslators {
+			if errors.Is(translator.Translate(unwrapped), gorm.ErrDuplicatedKey) {
+				return true
+			}
+		}
 	}
 	return false
 }
@@ -46,23 +54,82 @@ func CreateEntityWithPID[T any](ctx context.Context, db *gorm.DB, entity *T) por
 	}
 	times := 0
 	for {
-		result := db.WithContext(ctx).Create(entity)
-		if result.Error == nil {
+		err := create(db.WithContext(ctx), entity)
+		if err == nil {
 			break
 		}
 		if times++; times > 3 { // after several times we have not generated a unique id. It is likely something else is causing the error
-			return ports.NewAPIError(http.StatusConflict, result.Error.Error())
+			return ports.NewAPIError(http.StatusConflict, err.Error())
 		}
-		if IsUniqueViolation(result.Error) { // any violation of unique constraint, not just pk
+		if IsUniqueViolation(err) { // any violation of unique constraint, not just pk
 			publicId.SetString(opo_uid.New())
 		} else {
-			return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+			return ports.NewAPIError(http.StatusInternalServerError, err.Error())
 		}
 	}
 	return nil
 }
 
+// CreateEntitiesWithPID creates the entities in a single statement, assigning their IDs as CreateEntityWithPID does.
+// If a unique constraint is violated, by an ID or any other field, they are created one by one with CreateEntityWithPID
+// to find out which ones fail. It returns nil if all were created, or the error of each entity otherwise
+func CreateEntitiesWithPID[T any](ctx context.Context, db *gorm.DB, entities []*T) []ports.APIError {
+	if len(entities) == 0 {
+		return nil
+	}
+	for _, entity := range entities {
+		publicId := reflect.ValueOf(entity).Elem().FieldByName("ID")
+		if !publicId.IsValid() || !publicId.CanSet() || publicId.Kind() != reflect.String {
+			return entitiesError(len(entities), ports.NewAPIError(http.StatusInternalServerError, fmt.Sprintf("'ID' field of type %s can not be set", reflect.TypeOf(*entity).Name())))
+		}
+		if publicId.String() == "" {
+			publicId.SetString(opo_uid.New())
+		}
+	}
+	err := create(db.WithContext(ctx), entities)
+	if err == nil {
+		return nil
+	}
+	if !IsUniqueViolation(err) {
+		return entitiesError(len(entities), ports.NewAPIError(http.StatusInternalServerError, err.Error()))
+	}
+	errs := make([]ports.APIError, len(entities))
+	for i, entity := range entities {
+		errs[i] = CreateEntityWithPID(ctx, db, entity)
+	}
+	return errs
+}
+
+// create inserts the value. Inside a transaction it does so in a savepoint: Postgres aborts the whole transaction when
+// a statement fails, and the callers try again after unique violations
+func create(db *gorm.DB, value any) error {
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return db.Transaction(func(tx *gorm.DB) error {
+			return tx.Create(value).Error
+		})
+	}
+	return db.Create(value).Error
+}
+
+func entitiesError(count int, err ports.APIError) []ports.APIError {
+	errs := make([]ports.APIError, count)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
 type DBReposInfra struct {
 	Db     *gorm.DB
 	Logger logger.LoggerService
 }
+
+type txContextKey struct{}
+
+// DB returns the database for the context: the transaction open by TxManager.WithinTx, if any
+func (infra *DBReposInfra) DB(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return infra.Db.WithContext(ctx)
+}
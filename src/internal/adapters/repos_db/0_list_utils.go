@@ -0,0 +1,218 @@
+package repos_db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ListColumns maps the fields of a dtos.ListQuery to the columns of a table. Filtering a field with several columns
+// matches any of them, e.g. a name against the first and last names. Only fields with a single column can be sorted by
+type ListColumns map[string][]string
+
+type listKey struct {
+	field *schema.Field
+	desc  bool
+}
+
+// FindPage reads the page of entities selected by the query, sorted by defaultSort when the query does not say.
+// The primary key is always the last sort key, so that the cursors point to a single row
+func FindPage[T any](db *gorm.DB, query dtos.ListQuery, columns ListColumns, defaultSort ...dtos.ListSort) ([]T, *dtos.ListResult, ports.APIError) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, nil, ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	db = db.Model(new(T))
+	for _, filter := range query.Filters {
+		condition, args, err := filterCondition(stmt.Schema, columns, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		db = db.Where(condition, args...)
+	}
+	db = db.Session(&gorm.Session{}) // the filters are shared by the count and the find
+
+	result := &dtos.ListResult{}
+	if query.WithTotal {
+		var total int64
+		if res := db.Count(&total); res.Error != nil {
+			return nil, nil, ports.NewAPIError(http.StatusInternalServerError, res.Error.Error())
+		}
+		result.Total = &total
+	}
+
+	sort := query.Sort
+	if len(sort) == 0 {
+		sort = defaultSort
+	}
+	keys, err := sortKeys(stmt.Schema, columns, sort)
+	if err != nil {
+		return nil, nil, err
+	}
+	if query.Cursor != "" {
+		condition, args, err := cursorCondition(keys, query.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		db = db.Where(condition, args...)
+	}
+	for _, key := range keys {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: key.field.DBName}, Desc: key.desc})
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = dtos.DefaultListLimit
+	}
+	if query.Page > 0 {
+		db = db.Offset((query.Page - 1) * limit).Limit(limit)
+	} else {
+		db = db.Limit(limit + 1) // the extra one tells whether there is a next page
+	}
+
+	var records []T
+	if res := db.Find(&records); res.Error != nil {
+		return nil, nil, ports.NewAPIError(http.StatusInternalServerError, res.Error.Error())
+	}
+	if query.Page == 0 && len(records) > limit {
+		records = records[:limit]
+		result.NextCursor = encodeCursor(db, keys, &records[limit-1])
+	}
+	return records, result, nil
+}
+
+func filterCondition(entity *schema.Schema, columns ListColumns, filter dtos.ListFilter) (string, []interface{}, ports.APIError) {
+	fieldColumns, ok := columns[filter.Field]
+	if !ok {
+		return "", nil, ports.NewAPIError(http.StatusBadRequest, "Can not filter by "+filter.Field)
+	}
+	conditions := make([]string, len(fieldColumns))
+	args := make([]interface{}, len(fieldColumns))
+	for i, column := range fieldColumns {
+		field := entity.LookUpField(column)
+		if field == nil {
+			return "", nil, ports.NewAPIError(http.StatusInternalServerError, "Unknown column "+column)
+		}
+		switch filter.Op {
+		case dtos.ListOpPrefix, dtos.ListOpContains:
+			pattern := strings.ToLower(likeEscaper.Replace(filter.Value)) + "%"
+			if filter.Op == dtos.ListOpContains {
+				pattern = "%" + pattern
+			}
+			conditions[i], args[i] = fmt.Sprintf(`LOWER(%s) LIKE ? ESCAPE '\'`, column), pattern
+		case dtos.ListOpEq, dtos.ListOpGte, dtos.ListOpLte:
+			value, err := listValue(field, filter.Value)
+			if err != nil {
+				return "", nil, ports.NewAPIError(http.StatusBadRequest, fmt.Sprintf("Invalid %s: %s", filter.Field, err.Error()))
+			}
+			conditions[i], args[i] = fmt.Sprintf("%s %s ?", column, listOperators[filter.Op]), value
+		default:
+			return "", nil, ports.NewAPIError(http.StatusBadRequest, "Unknown filter "+string(filter.Op))
+		}
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, nil
+}
+
+var listOperators = map[dtos.ListOp]string{dtos.ListOpEq: "=", dtos.ListOpGte: ">=", dtos.ListOpLte: "<="}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func sortKeys(entity *schema.Schema, columns ListColumns, sort []dtos.ListSort) ([]listKey, ports.APIError) {
+	primary := entity.PrioritizedPrimaryField
+	if primary == nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, entity.Name+" has no single primary key")
+	}
+	keys := make([]listKey, 0, len(sort)+1)
+	for _, s := range sort {
+		fieldColumns := columns[s.Field]
+		if len(fieldColumns) != 1 {
+			return nil, ports.NewAPIError(http.StatusBadRequest, "Can not sort by "+s.Field)
+		}
+		field := entity.LookUpField(fieldColumns[0])
+		if field == nil {
+			return nil, ports.NewAPIError(http.StatusInternalServerError, "Unknown column "+fieldColumns[0])
+		}
+		keys = append(keys, listKey{field: field, desc: s.Desc})
+		if field == primary { // nothing can go after it
+			return keys, nil
+		}
+	}
+	return append(keys, listKey{field: primary}), nil
+}
+
+// The cursor has the values of the sort keys in the last row of the page. The next page starts after them:
+// (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?)
+func cursorCondition(keys []listKey, cursor string) (string, []interface{}, ports.APIError) {
+	invalid := ports.NewAPIError(http.StatusBadRequest, "Invalid cursor")
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", nil, invalid
+	}
+	var texts []string
+	if err := json.Unmarshal(data, &texts); err != nil || len(texts) != len(keys) {
+		return "", nil, invalid // probably from a list with another sort
+	}
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if values[i], err = listValue(key.field, texts[i]); err != nil {
+			return "", nil, invalid
+		}
+	}
+	var conditions []string
+	var args []interface{}
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].field.DBName+" = ?")
+			args = append(args, values[j])
+		}
+		operator := ">"
+		if key.desc {
+			operator = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s ?", key.field.DBName, operator))
+		args = append(args, values[i])
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, nil
+}
+
+func encodeCursor[T any](db *gorm.DB, keys []listKey, record *T) string {
+	texts := make([]string, len(keys))
+	for i, key := range keys {
+		value, _ := key.field.ValueOf(db.Statement.Context, reflect.ValueOf(record).Elem())
+		if t, ok := value.(time.Time); ok {
+			texts[i] = t.UTC().Format(time.RFC3339Nano)
+		} else {
+			texts[i] = fmt.Sprint(value)
+		}
+	}
+	data, _ := json.Marshal(texts)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// listValue converts the text of a query, or of a cursor, to the type of the field
+func listValue(field *schema.Field, text string) (interface{}, error) {
+	switch field.DataType {
+	case schema.Time:
+		if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.DateOnly, text)
+	case schema.Int, schema.Uint:
+		return strconv.ParseInt(text, 10, 64)
+	case schema.Bool:
+		return strconv.ParseBool(text)
+	}
+	return text, nil
+}
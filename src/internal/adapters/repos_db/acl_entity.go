@@ -0,0 +1,92 @@
+package repos_db
+
+import (
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// This will be a table in the database
+type Group struct {
+	BaseDBModel
+	Name string `gorm:"uniqueIndex"`
+}
+
+// Users belonging to each group
+type GroupMember struct {
+	GroupID   string `gorm:"primaryKey"`
+	UserID    string `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// This will be a table in the database. A subject has each permission on a resource only once
+type Grant struct {
+	BaseDBModel
+	SubjectType  domain.SubjectType `gorm:"uniqueIndex:idx_grant"`
+	SubjectID    string             `gorm:"uniqueIndex:idx_grant;index"`
+	ResourceType string             `gorm:"uniqueIndex:idx_grant;index:idx_grant_resource"`
+	ResourceID   string             `gorm:"uniqueIndex:idx_grant;index:idx_grant_resource"`
+	Permission   domain.Permission  `gorm:"uniqueIndex:idx_grant"`
+}
+
+// ResourceFilterScope restricts a query to the resources allowed by the filter. The column holds the id of the resource
+//
+//	filter, err := permissions.AllowedResources(ctx, byUser, domain.PermissionRead, "document")
+//	db.WithContext(ctx).Scopes(ResourceFilterScope(filter, "id")).Find(&documents)
+func ResourceFilterScope(filter *domain.ResourceFilter, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.All {
+			return db
+		}
+		if len(filter.IDs) == 0 {
+			return db.Where("1 = 0") // IN of an empty list is not valid SQL everywhere
+		}
+		return db.Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Values: toAnySlice(filter.IDs)})
+	}
+}
+
+func toAnySlice(values []string) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+func fromDomainGrant(grant *domain.Grant) *Grant {
+	dbGrant := &Grant{
+		SubjectType:  grant.SubjectType,
+		SubjectID:    grant.SubjectID,
+		ResourceType: grant.ResourceType,
+		ResourceID:   grant.ResourceID,
+		Permission:   grant.Permission,
+	}
+	dbGrant.ID = grant.ID
+	return dbGrant
+}
+
+func (g *Grant) toDomainGrant() *domain.Grant {
+	return &domain.Grant{
+		ID:           g.ID,
+		SubjectType:  g.SubjectType,
+		SubjectID:    g.SubjectID,
+		ResourceType: g.ResourceType,
+		ResourceID:   g.ResourceID,
+		Permission:   g.Permission,
+		CreatedAt:    g.CreatedAt,
+	}
+}
+
+func toDomainGrants(grants []Grant) []*domain.Grant {
+	domainGrants := make([]*domain.Grant, len(grants))
+	for i := range grants {
+		domainGrants[i] = grants[i].toDomainGrant()
+	}
+	return domainGrants
+}
+
+func (g *Group) toDomainGroup() *domain.Group {
+	return &domain.Group{ID: g.ID, Name: g.Name, CreatedAt: g.CreatedAt}
+}
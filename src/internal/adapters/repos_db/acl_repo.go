@@ -0,0 +1,157 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	opentelemetry "github.com/Manolo-Esc/gommence/src/pkg/open_telemetry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Groups and grants on single resources. The roles are in permission_repo.go
+
+func (r *PermissionRepositoryDB) GetGroups(ctx context.Context) ([]*domain.Group, ports.APIError) {
+	var groups []Group
+	result := r.dbInfra.DB(ctx).Order("name").Find(&groups)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	domainGroups := make([]*domain.Group, len(groups))
+	for i := range groups {
+		domainGroups[i] = groups[i].toDomainGroup()
+	}
+	return domainGroups, nil
+}
+
+func (r *PermissionRepositoryDB) GetGroupByName(ctx context.Context, name string) (*domain.Group, ports.APIError) {
+	var group Group
+	r.dbInfra.DB(ctx).Where("name = ?", name).First(&group)
+	if group.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Group not found")
+	}
+	return group.toDomainGroup(), nil
+}
+
+func (r *PermissionRepositoryDB) CreateGroup(ctx context.Context, group *domain.Group) (string, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "PermissionRepositoryDB.CreateGroup")
+	defer span.End()
+
+	dbGroup := &Group{Name: group.Name}
+	dbGroup.ID = group.ID
+	err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbGroup)
+	return dbGroup.ID, err
+}
+
+func (r *PermissionRepositoryDB) GetGroupMembers(ctx context.Context, idGroup string) ([]string, ports.APIError) {
+	var members []string
+	result := r.dbInfra.DB(ctx).Model(&GroupMember{}).Where("group_id = ?", idGroup).Order("user_id").Pluck("user_id", &members)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return members, nil
+}
+
+func (r *PermissionRepositoryDB) AddGroupMember(ctx context.Context, idGroup string, idUser string) ports.APIError {
+	var count int64
+	if result := r.dbInfra.DB(ctx).Model(&User{}).Where("id = ?", idUser).Count(&count); result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if count == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	member := GroupMember{GroupID: idGroup, UserID: idUser}
+	result := r.dbInfra.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *PermissionRepositoryDB) RemoveGroupMember(ctx context.Context, idGroup string, idUser string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Where("group_id = ? AND user_id = ?", idGroup, idUser).Delete(&GroupMember{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *PermissionRepositoryDB) CreateGrant(ctx context.Context, grant *domain.Grant) (string, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "PermissionRepositoryDB.CreateGrant")
+	defer span.End()
+
+	dbGrant := fromDomainGrant(grant)
+	err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbGrant)
+	return dbGrant.ID, err
+}
+
+func (r *PermissionRepositoryDB) GetGrant(ctx context.Context, idGrant string) (*domain.Grant, ports.APIError) {
+	var grant Grant
+	r.dbInfra.DB(ctx).Where("id = ?", idGrant).First(&grant)
+	if grant.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Grant not found")
+	}
+	return grant.toDomainGrant(), nil
+}
+
+func (r *PermissionRepositoryDB) DeleteGrant(ctx context.Context, idGrant string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Unscoped().Where("id = ?", idGrant).Delete(&Grant{}) // the unique index would refuse granting it again
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *PermissionRepositoryDB) GetResourceGrants(ctx context.Context, resourceType string, resourceID string) ([]*domain.Grant, ports.APIError) {
+	var grants []Grant
+	result := r.dbInfra.DB(ctx).Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).Order("created_at").Find(&grants)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return toDomainGrants(grants), nil
+}
+
+func (r *PermissionRepositoryDB) GetUserGrants(ctx context.Context, idUser string) ([]*domain.Grant, ports.APIError) {
+	var grants []Grant
+	db := r.dbInfra.DB(ctx)
+	result := db.
+		Where("subject_type = ? AND subject_id = ?", domain.SubjectUser, idUser).
+		Or("subject_type = ? AND subject_id IN (?)", domain.SubjectGroup, db.Model(&GroupMember{}).Select("group_id").Where("user_id = ?", idUser)).
+		Find(&grants)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return toDomainGrants(grants), nil
+}
+
+func (r *PermissionRepositoryDB) GetUserGroups(ctx context.Context, idUser string) ([]*domain.Group, ports.APIError) {
+	var groups []Group
+	db := r.dbInfra.DB(ctx)
+	result := db.Where("id IN (?)", db.Model(&GroupMember{}).Select("group_id").Where("user_id = ?", idUser)).Order("name").Find(&groups)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	domainGroups := make([]*domain.Group, len(groups))
+	for i := range groups {
+		domainGroups[i] = groups[i].toDomainGroup()
+	}
+	return domainGroups, nil
+}
+
+func (r *PermissionRepositoryDB) DeleteUserPermissions(ctx context.Context, idUser string) ports.APIError {
+	err := r.dbInfra.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", idUser).Delete(&UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", idUser).Delete(&GroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("subject_type = ? AND subject_id = ?", domain.SubjectUser, idUser).Delete(&Grant{}).Error
+	})
+	if err != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
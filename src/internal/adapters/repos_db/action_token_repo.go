@@ -0,0 +1,74 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	opentelemetry "github.com/Manolo-Esc/gommence/src/pkg/open_telemetry"
+)
+
+type ActionTokenRepositoryDB struct {
+	dbInfra *DBReposInfra
+}
+
+func NewActionTokenRepository(dbInfra *DBReposInfra) ports.ActionTokenRepository {
+	return &ActionTokenRepositoryDB{dbInfra: dbInfra}
+}
+
+func (r *ActionTokenRepositoryDB) CreateActionToken(ctx context.Context, token *domain.ActionToken) (string, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "ActionTokenRepositoryDB.CreateActionToken")
+	defer span.End()
+
+	dbToken := fromDomainActionToken(token)
+	err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbToken)
+	return dbToken.ID, err
+}
+
+func (r *ActionTokenRepositoryDB) GetActionTokenByHash(ctx context.Context, purpose domain.ActionTokenPurpose, tokenHash string) (*domain.ActionToken, ports.APIError) {
+	var token ActionToken
+	r.dbInfra.DB(ctx).Where("token_hash = ? AND purpose = ?", tokenHash, purpose).First(&token)
+	if token.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Token not found")
+	}
+	return token.toDomainActionToken(), nil
+}
+
+// MarkActionTokenUsed flags the token as used in a single statement, so it can not be consumed twice
+func (r *ActionTokenRepositoryDB) MarkActionTokenUsed(ctx context.Context, idToken string) (bool, ports.APIError) {
+	result := r.dbInfra.DB(ctx).Model(&ActionToken{}).
+		Where("id = ? AND used_at IS NULL", idToken).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *ActionTokenRepositoryDB) InvalidateActionTokens(ctx context.Context, userId string, purpose domain.ActionTokenPurpose) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&ActionToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userId, purpose).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *ActionTokenRepositoryDB) DeleteUserActionTokens(ctx context.Context, userId string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Unscoped().Where("user_id = ?", userId).Delete(&ActionToken{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *ActionTokenRepositoryDB) DeleteExpiredActionTokens(ctx context.Context, before time.Time) ports.APIError {
+	result := r.dbInfra.DB(ctx).Unscoped().Where("expires_at < ? OR used_at IS NOT NULL", before).Delete(&ActionToken{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package repos_db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// This will be a table in the database
+type APIKey struct {
+	BaseDBModel
+	UserID     string `gorm:"index"`
+	Name       string
+	Prefix     string
+	KeyHash    string `gorm:"uniqueIndex"`
+	Scopes     string // comma separated
+	ExpiresAt  time.Time
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+func fromDomainAPIKey(key *domain.APIKey) *APIKey {
+	dbKey := &APIKey{
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.KeyHash,
+		Scopes:    strings.Join(key.Scopes, ","),
+		ExpiresAt: key.ExpiresAt,
+	}
+	dbKey.ID = key.ID
+	return dbKey
+}
+
+func (k *APIKey) toDomainAPIKey() *domain.APIKey {
+	var scopes []string
+	if k.Scopes != "" {
+		scopes = strings.Split(k.Scopes, ",")
+	}
+	return &domain.APIKey{
+		ID:         k.ID,
+		UserID:     k.UserID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		KeyHash:    k.KeyHash,
+		Scopes:     scopes,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: nullTimeToPtr(k.LastUsedAt),
+		RevokedAt:  nullTimeToPtr(k.RevokedAt),
+		CreatedAt:  k.CreatedAt,
+	}
+}
@@ -0,0 +1,77 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	opentelemetry "github.com/Manolo-Esc/gommence/src/pkg/open_telemetry"
+)
+
+type APIKeyRepositoryDB struct {
+	dbInfra *DBReposInfra
+}
+
+func NewAPIKeyRepository(dbInfra *DBReposInfra) ports.APIKeyRepository {
+	return &APIKeyRepositoryDB{dbInfra: dbInfra}
+}
+
+func (r *APIKeyRepositoryDB) CreateAPIKey(ctx context.Context, key *domain.APIKey) (string, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "APIKeyRepositoryDB.CreateAPIKey")
+	defer span.End()
+
+	dbKey := fromDomainAPIKey(key)
+	err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbKey)
+	return dbKey.ID, err
+}
+
+func (r *APIKeyRepositoryDB) GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, ports.APIError) {
+	var key APIKey
+	db := r.dbInfra.DB(ctx)
+	db.Where("key_hash = ? AND user_id IN (?)", keyHash, db.Model(&User{}).Select("id")).First(&key) // deleted users can not use their keys
+	if key.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "API key not found")
+	}
+	return key.toDomainAPIKey(), nil
+}
+
+func (r *APIKeyRepositoryDB) GetUserAPIKeys(ctx context.Context, idUser string) ([]*domain.APIKey, ports.APIError) {
+	var keys []APIKey
+	result := r.dbInfra.DB(ctx).Where("user_id = ? AND revoked_at IS NULL", idUser).Order("created_at").Find(&keys)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	domainKeys := make([]*domain.APIKey, len(keys))
+	for i := range keys {
+		domainKeys[i] = keys[i].toDomainAPIKey()
+	}
+	return domainKeys, nil
+}
+
+func (r *APIKeyRepositoryDB) RevokeAPIKey(ctx context.Context, idKey string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", idKey).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *APIKeyRepositoryDB) TouchAPIKey(ctx context.Context, idKey string, usedAt time.Time) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&APIKey{}).Where("id = ?", idKey).Update("last_used_at", usedAt)
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *APIKeyRepositoryDB) DeleteUserAPIKeys(ctx context.Context, idUser string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Unscoped().Where("user_id = ?", idUser).Delete(&APIKey{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
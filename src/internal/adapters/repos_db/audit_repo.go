@@ -0,0 +1,87 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	opentelemetry "github.com/Manolo-Esc/gommence/src/pkg/open_telemetry"
+	"gorm.io/gorm"
+)
+
+type AuditRepositoryDB struct {
+	dbInfra *DBReposInfra
+}
+
+func NewAuditRepository(dbInfra *DBReposInfra) ports.AuditRepository {
+	return &AuditRepositoryDB{dbInfra: dbInfra}
+}
+
+func (r *AuditRepositoryDB) CreateAuditEvent(ctx context.Context, event *domain.AuditEvent) (string, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "AuditRepositoryDB.CreateAuditEvent")
+	defer span.End()
+
+	dbEvent := fromDomainAuditEvent(event)
+	err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbEvent)
+	return dbEvent.ID, err
+}
+
+func (r *AuditRepositoryDB) GetAuditEvents(ctx context.Context, query dtos.AuditQuery) ([]*domain.AuditEvent, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "AuditRepositoryDB.GetAuditEvents")
+	defer span.End()
+
+	db := r.dbInfra.DB(ctx)
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.Target != "" {
+		db = db.Where("target = ?", query.Target)
+	}
+	if !query.Since.IsZero() {
+		db = db.Where("created_at > ?", query.Since)
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = dtos.DefaultAuditLimit
+	}
+	var records []AuditEvent
+	if result := db.Order("created_at DESC").Limit(limit).Find(&records); result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	events := make([]*domain.AuditEvent, len(records))
+	for i := range records {
+		events[i] = records[i].toDomainAuditEvent()
+	}
+	return events, nil
+}
+
+func (r *AuditRepositoryDB) GetUserAuditEvents(ctx context.Context, idUser string, email string) ([]*domain.AuditEvent, ports.APIError) {
+	var records []AuditEvent
+	result := r.dbInfra.DB(ctx).
+		Where("actor_id = ? OR target IN ?", idUser, []string{idUser, email}).
+		Order("created_at DESC").
+		Find(&records)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	events := make([]*domain.AuditEvent, len(records))
+	for i := range records {
+		events[i] = records[i].toDomainAuditEvent()
+	}
+	return events, nil
+}
+
+func (r *AuditRepositoryDB) AnonymizeUserAuditEvents(ctx context.Context, idUser string, email string) ports.APIError {
+	err := r.dbInfra.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&AuditEvent{}).Where("actor_id = ?", idUser).Update("actor_id", "").Error; err != nil {
+			return err
+		}
+		return tx.Model(&AuditEvent{}).Where("target IN ?", []string{idUser, email}).Update("target", "").Error
+	})
+	if err != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
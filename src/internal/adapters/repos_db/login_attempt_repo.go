@@ -0,0 +1,71 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type LoginAttemptRepositoryDB struct {
+	dbInfra *DBReposInfra
+}
+
+func NewLoginAttemptRepository(dbInfra *DBReposInfra) ports.LoginAttemptRepository {
+	return &LoginAttemptRepositoryDB{dbInfra: dbInfra}
+}
+
+func (r *LoginAttemptRepositoryDB) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, ports.APIError) {
+	var attempts LoginAttempt
+	r.dbInfra.DB(ctx).Where("id = ?", key).First(&attempts)
+	if attempts.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "No login attempts")
+	}
+	return attempts.toDomainLoginAttempts(), nil
+}
+
+// AddLoginFailure inserts the attempts of the key or adds the failure to them in the same statement, so concurrent
+// failures are all counted
+func (r *LoginAttemptRepositoryDB) AddLoginFailure(ctx context.Context, key string, at time.Time, windowStart time.Time) (*domain.LoginAttempts, ports.APIError) {
+	var attempts LoginAttempt
+	result := r.dbInfra.DB(ctx).Raw(`INSERT INTO login_attempts (id, failures, last_failure_at, updated_at) VALUES (?, 1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = excluded.last_failure_at,
+			updated_at = excluded.updated_at
+		RETURNING id, failures, last_failure_at, locked_until, updated_at`, key, at, at, windowStart).Scan(&attempts)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return attempts.toDomainLoginAttempts(), nil
+}
+
+// LockLoginAttempts checks the failures and locks in the same statement, so only one of concurrent failures locks
+func (r *LoginAttemptRepositoryDB) LockLoginAttempts(ctx context.Context, key string, minFailures int, lockedUntil time.Time) (bool, ports.APIError) {
+	result := r.dbInfra.DB(ctx).Model(&LoginAttempt{}).
+		Where("id = ? AND failures >= ?", key, minFailures).
+		Updates(map[string]interface{}{"failures": 0, "locked_until": lockedUntil})
+	if result.Error != nil {
+		return false, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *LoginAttemptRepositoryDB) DeleteLoginAttempts(ctx context.Context, key string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Where("id = ?", key).Delete(&LoginAttempt{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepositoryDB) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) ports.APIError {
+	result := r.dbInfra.DB(ctx).Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, before).
+		Delete(&LoginAttempt{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepositoryDB struct {
+	dbInfra *DBReposInfra
+}
+
+func NewMFARepository(dbInfra *DBReposInfra) ports.MFARepository {
+	return &MFARepositoryDB{dbInfra: dbInfra}
+}
+
+func (r *MFARepositoryDB) CreateMFAChallenge(ctx context.Context, challenge *domain.MFAChallenge) ports.APIError {
+	result := r.dbInfra.DB(ctx).Create(fromDomainMFAChallenge(challenge))
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+// AddMFAChallengeAttempt counts the attempt in the same statement that reads it, so concurrent attempts are all counted
+func (r *MFARepositoryDB) AddMFAChallengeAttempt(ctx context.Context, tokenHash string, now time.Time) (*domain.MFAChallenge, ports.APIError) {
+	var challenge MFAChallenge
+	result := r.dbInfra.DB(ctx).Raw(`UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = ? AND expires_at > ?
+		RETURNING id, user_id, attempts, expires_at, created_at`, tokenHash, now).Scan(&challenge)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if challenge.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "MFA challenge not found")
+	}
+	return challenge.toDomainMFAChallenge(), nil
+}
+
+func (r *MFARepositoryDB) DeleteMFAChallenge(ctx context.Context, tokenHash string) (bool, ports.APIError) {
+	result := r.dbInfra.DB(ctx).Where("id = ?", tokenHash).Delete(&MFAChallenge{})
+	if result.Error != nil {
+		return false, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UseTOTPStep inserts the step unless the user already has it, in a single statement, so a code is accepted once
+func (r *MFARepositoryDB) UseTOTPStep(ctx context.Context, userId string, step int64, expiresAt time.Time) (bool, ports.APIError) {
+	used := UsedTOTPCode{UserID: userId, Step: step, ExpiresAt: expiresAt}
+	result := r.dbInfra.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&used)
+	if result.Error != nil {
+		return false, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *MFARepositoryDB) DeleteUserMFA(ctx context.Context, userId string) ports.APIError {
+	if result := r.dbInfra.DB(ctx).Where("user_id = ?", userId).Delete(&MFAChallenge{}); result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result := r.dbInfra.DB(ctx).Where("user_id = ?", userId).Delete(&UsedTOTPCode{}); result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *MFARepositoryDB) DeleteExpiredMFA(ctx context.Context, before time.Time) ports.APIError {
+	if result := r.dbInfra.DB(ctx).Where("expires_at < ?", before).Delete(&MFAChallenge{}); result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result := r.dbInfra.DB(ctx).Where("expires_at < ?", before).Delete(&UsedTOTPCode{}); result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type OIDCFlowRepositoryDB struct {
+	dbInfra *DBReposInfra
+}
+
+func NewOIDCFlowRepository(dbInfra *DBReposInfra) ports.OIDCFlowRepository {
+	return &OIDCFlowRepositoryDB{dbInfra: dbInfra}
+}
+
+func (r *OIDCFlowRepositoryDB) CreateOIDCFlow(ctx context.Context, flow *domain.OIDCFlow) ports.APIError {
+	result := r.dbInfra.DB(ctx).Create(fromDomainOIDCFlow(flow))
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+// TakeOIDCFlow deletes and reads the flow in a single statement, so two callbacks with the same state can't both use it
+func (r *OIDCFlowRepositoryDB) TakeOIDCFlow(ctx context.Context, stateHash string, now time.Time) (*domain.OIDCFlow, ports.APIError) {
+	var flow OIDCFlow
+	result := r.dbInfra.DB(ctx).Raw(`DELETE FROM oidc_flows WHERE id = ? AND expires_at > ?
+		RETURNING id, nonce, code_verifier, expires_at, created_at`, stateHash, now).Scan(&flow)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if flow.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "OIDC flow not found")
+	}
+	return flow.toDomainOIDCFlow(), nil
+}
+
+func (r *OIDCFlowRepositoryDB) DeleteExpiredOIDCFlows(ctx context.Context, before time.Time) ports.APIError {
+	if result := r.dbInfra.DB(ctx).Where("expires_at < ?", before).Delete(&OIDCFlow{}); result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
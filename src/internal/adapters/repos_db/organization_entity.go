@@ -0,0 +1,151 @@
+package repos_db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// This will be a table in the database
+type Organization struct {
+	BaseDBModel
+	Name string
+}
+
+// Users belonging to each organization
+type Membership struct {
+	TenantID  string `gorm:"primaryKey"`
+	UserID    string `gorm:"primaryKey;index"`
+	Role      domain.OrgRole
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+var ErrNoTenant = errors.New("no tenant in the context")
+
+// TenantModel is embedded by the entities owned by an organization. The tenant is taken from the context when they are created,
+// so nobody can forget to set it (or set someone else's), and the TenantPlugin filters by it every query, update and delete
+type TenantModel struct {
+	TenantID string `gorm:"index"`
+}
+
+func (m *TenantModel) BeforeCreate(tx *gorm.DB) error {
+	tenant, found := domain.TenantFromContext(tx.Statement.Context)
+	if !found {
+		return ErrNoTenant
+	}
+	m.TenantID = tenant
+	return nil
+}
+
+func (TenantModel) ownedByTenant() {}
+
+// tenantOwned is implemented by the entities that embed TenantModel
+type tenantOwned interface {
+	ownedByTenant()
+}
+
+// TenantPlugin restricts every query, update and delete of the entities that embed TenantModel to the rows of the tenant in
+// the context, so a repository can't reach the data of other organizations by mistake. Without tenant they fail instead of
+// reaching every row. Raw SQL is not filtered
+type TenantPlugin struct{}
+
+func (TenantPlugin) Name() string {
+	return "gommence:tenant"
+}
+
+func (TenantPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("gommence:tenant_query", filterByTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("gommence:tenant_row", filterByTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("gommence:tenant_update", filterWritesByTenant); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("gommence:tenant_delete", filterWritesByTenant)
+}
+
+func filterByTenant(db *gorm.DB) {
+	if db.Error != nil || !isTenantOwned(db.Statement) {
+		return
+	}
+	tenant, found := domain.TenantFromContext(db.Statement.Context)
+	if !found {
+		db.AddError(ErrNoTenant)
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{tenantCondition(tenant)}})
+}
+
+// filterWritesByTenant keeps the check of gorm against updates and deletes without conditions, the tenant would count as one
+func filterWritesByTenant(db *gorm.DB) {
+	if db.Error != nil || !isTenantOwned(db.Statement) {
+		return
+	}
+	if _, hasWhere := db.Statement.Clauses["WHERE"]; !hasWhere && !db.AllowGlobalUpdate {
+		_, keys := schema.GetIdentityFieldValuesMap(db.Statement.Context, db.Statement.ReflectValue, db.Statement.Schema.PrimaryFields)
+		if len(keys) == 0 {
+			db.AddError(gorm.ErrMissingWhereClause)
+			return
+		}
+	}
+	filterByTenant(db)
+}
+
+func isTenantOwned(statement *gorm.Statement) bool {
+	if statement.Schema == nil {
+		return false
+	}
+	_, owned := reflect.New(statement.Schema.ModelType).Interface().(tenantOwned)
+	return owned
+}
+
+// TenantScope restricts a query to the rows of the tenant in the context, for the tables keyed by tenant that are not owned by
+// it, like the memberships. Without tenant the query fails instead of returning everything
+//
+//	db.WithContext(ctx).Scopes(TenantScope(ctx)).Find(&memberships)
+func TenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		tenant, found := domain.TenantFromContext(ctx)
+		if !found {
+			db.AddError(ErrNoTenant)
+			return db
+		}
+		return db.Where(tenantCondition(tenant))
+	}
+}
+
+func tenantCondition(tenant string) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: tenant}
+}
+
+func fromDomainOrganization(organization *domain.Organization) *Organization {
+	dbOrganization := &Organization{Name: organization.Name}
+	dbOrganization.ID = organization.ID
+	return dbOrganization
+}
+
+func (o *Organization) toDomainOrganization() *domain.Organization {
+	return &domain.Organization{ID: o.ID, Name: o.Name, CreatedAt: o.CreatedAt}
+}
+
+func (m *Membership) toDomainMembership() *domain.Membership {
+	return &domain.Membership{OrganizationID: m.TenantID, UserID: m.UserID, Role: m.Role, CreatedAt: m.CreatedAt}
+}
+
+func toDomainMemberships(memberships []Membership) []*domain.Membership {
+	domainMemberships := make([]*domain.Membership, len(memberships))
+	for i := range memberships {
+		domainMemberships[i] = memberships[i].toDomainMembership()
+	}
+	return domainMemberships
+}
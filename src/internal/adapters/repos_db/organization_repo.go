@@ -0,0 +1,103 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	opentelemetry "github.com/Manolo-Esc/gommence/src/pkg/open_telemetry"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationRepositoryDB struct {
+	dbInfra *DBReposInfra
+}
+
+func NewOrganizationRepository(dbInfra *DBReposInfra) ports.OrganizationRepository {
+	return &OrganizationRepositoryDB{dbInfra: dbInfra}
+}
+
+func (r *OrganizationRepositoryDB) CreateOrganization(ctx context.Context, organization *domain.Organization) (string, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "OrganizationRepositoryDB.CreateOrganization")
+	defer span.End()
+
+	dbOrganization := fromDomainOrganization(organization)
+	err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbOrganization)
+	return dbOrganization.ID, err
+}
+
+func (r *OrganizationRepositoryDB) GetUserOrganizations(ctx context.Context, idUser string) ([]*domain.Organization, ports.APIError) {
+	var organizations []Organization
+	result := r.dbInfra.DB(ctx).
+		Joins("JOIN memberships ON memberships.tenant_id = organizations.id").
+		Where("memberships.user_id = ?", idUser).
+		Order("memberships.created_at").
+		Find(&organizations)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	domainOrganizations := make([]*domain.Organization, len(organizations))
+	for i := range organizations {
+		domainOrganizations[i] = organizations[i].toDomainOrganization()
+	}
+	return domainOrganizations, nil
+}
+
+func (r *OrganizationRepositoryDB) GetMembership(ctx context.Context, idOrganization string, idUser string) (*domain.Membership, ports.APIError) {
+	var membership Membership
+	r.dbInfra.DB(ctx).Where("tenant_id = ? AND user_id = ?", idOrganization, idUser).First(&membership)
+	if membership.UserID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Membership not found")
+	}
+	return membership.toDomainMembership(), nil
+}
+
+func (r *OrganizationRepositoryDB) SaveMembership(ctx context.Context, membership *domain.Membership) ports.APIError {
+	var count int64
+	if result := r.dbInfra.DB(ctx).Model(&User{}).Where("id = ?", membership.UserID).Count(&count); result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if count == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	dbMembership := Membership{TenantID: membership.OrganizationID, UserID: membership.UserID, Role: membership.Role}
+	result := r.dbInfra.DB(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+		}).
+		Create(&dbMembership)
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *OrganizationRepositoryDB) GetMembers(ctx context.Context) ([]*domain.Membership, ports.APIError) {
+	var memberships []Membership
+	result := r.dbInfra.DB(ctx).Scopes(TenantScope(ctx)).Order("created_at").Find(&memberships)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return toDomainMemberships(memberships), nil
+}
+
+func (r *OrganizationRepositoryDB) DeleteMembership(ctx context.Context, idUser string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Scopes(TenantScope(ctx)).Where("user_id = ?", idUser).Delete(&Membership{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "Membership not found")
+	}
+	return nil
+}
+
+func (r *OrganizationRepositoryDB) DeleteUserMemberships(ctx context.Context, idUser string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Where("user_id = ?", idUser).Delete(&Membership{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package repos_db
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// This will be a table in the database
+type Role struct {
+	BaseDBModel
+	Name        string `gorm:"uniqueIndex"`
+	Permissions string // comma separated
+}
+
+// Roles assigned to each user
+type UserRole struct {
+	UserID    string `gorm:"primaryKey"`
+	RoleID    string `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+func fromDomainRole(role *domain.Role) *Role {
+	permissions := make([]string, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = strconv.Itoa(int(permission))
+	}
+	dbRole := &Role{
+		Name:        role.Name,
+		Permissions: strings.Join(permissions, ","),
+	}
+	dbRole.ID = role.ID
+	return dbRole
+}
+
+func (r *Role) toDomainRole() *domain.Role {
+	role := &domain.Role{ID: r.ID, Name: r.Name}
+	for _, permission := range strings.Split(r.Permissions, ",") {
+		if value, err := strconv.Atoi(permission); err == nil {
+			role.Permissions = append(role.Permissions, domain.Permission(value))
+		}
+	}
+	return role
+}
+
+func toDomainRoles(roles []Role) []*domain.Role {
+	domainRoles := make([]*domain.Role, len(roles))
+	for i := range roles {
+		domainRoles[i] = roles[i].toDomainRole()
+	}
+	return domainRoles
+}
@@ -1,7 +1,13 @@
 package repos_db
 
 import (
+	"context"
+	"net/http"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	opentelemetry "github.com/Manolo-Esc/gommence/src/pkg/open_telemetry"
+	"gorm.io/gorm/clause"
 )
 
 type PermissionRepositoryDB struct {
@@ -12,7 +18,66 @@ func NewPermissionRepository(dbInfra *DBReposInfra) ports.PermissionRepository {
 	return &PermissionRepositoryDB{dbInfra: dbInfra}
 }
 
-// func (r *PermissionRepositoryDB) GetByID(ctx context.Context, id string) (*domain.User, error) {
+func (r *PermissionRepositoryDB) GetRoles(ctx context.Context) ([]*domain.Role, ports.APIError) {
+	var roles []Role
+	result := r.dbInfra.DB(ctx).Order("name").Find(&roles)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return toDomainRoles(roles), nil
+}
+
+func (r *PermissionRepositoryDB) GetRoleByName(ctx context.Context, name string) (*domain.Role, ports.APIError) {
+	var role Role
+	r.dbInfra.DB(ctx).Where("name = ?", name).First(&role)
+	if role.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Role not found")
+	}
+	return role.toDomainRole(), nil
+}
+
+func (r *PermissionRepositoryDB) CreateRole(ctx context.Context, role *domain.Role) (string, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "PermissionRepositoryDB.CreateRole")
+	defer span.End()
+
+	dbRole := fromDomainRole(role)
+	err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbRole)
+	return dbRole.ID, err
+}
+
+func (r *PermissionRepositoryDB) GetUserRoles(ctx context.Context, idUser string) ([]*domain.Role, ports.APIError) {
+	var roles []Role
+	result := r.dbInfra.DB(ctx).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", idUser).
+		Order("roles.name").
+		Find(&roles)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return toDomainRoles(roles), nil
+}
+
+func (r *PermissionRepositoryDB) AddUserRole(ctx context.Context, idUser string, idRole string) ports.APIError {
+	var count int64
+	if result := r.dbInfra.DB(ctx).Model(&User{}).Where("id = ?", idUser).Count(&count); result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if count == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	userRole := UserRole{UserID: idUser, RoleID: idRole}
+	result := r.dbInfra.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&userRole)
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
 
-// 	return nil, nil
-// }
+func (r *PermissionRepositoryDB) RemoveUserRole(ctx context.Context, idUser string, idRole string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Where("user_id = ? AND role_id = ?", idUser, idRole).Delete(&UserRole{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
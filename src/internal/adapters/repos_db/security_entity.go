@@ -0,0 +1,58 @@
+package repos_db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// Failed sign ins of an account or an IP. Counted in the table, so every server sees them and they survive restarts
+type LoginAttempt struct {
+	ID            string `gorm:"primaryKey"` // "account:<email>" or "ip:<address>"
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+	UpdatedAt     time.Time
+}
+
+// This will be a table in the database
+type AuditEvent struct {
+	BaseDBModel
+	Type    domain.AuditEventType `gorm:"index"`
+	ActorID string                `gorm:"index"`
+	Target  string                `gorm:"index"`
+	IP      string
+	Details string
+}
+
+func (a *LoginAttempt) toDomainLoginAttempts() *domain.LoginAttempts {
+	return &domain.LoginAttempts{
+		Key:           a.ID,
+		Failures:      a.Failures,
+		LastFailureAt: a.LastFailureAt,
+		LockedUntil:   nullTimeToPtr(a.LockedUntil),
+	}
+}
+
+func fromDomainAuditEvent(event *domain.AuditEvent) *AuditEvent {
+	return &AuditEvent{
+		Type:    event.Type,
+		ActorID: event.ActorID,
+		Target:  event.Target,
+		IP:      event.IP,
+		Details: event.Details,
+	}
+}
+
+func (e *AuditEvent) toDomainAuditEvent() *domain.AuditEvent {
+	return &domain.AuditEvent{
+		ID:        e.ID,
+		Type:      e.Type,
+		ActorID:   e.ActorID,
+		Target:    e.Target,
+		IP:        e.IP,
+		Details:   e.Details,
+		CreatedAt: e.CreatedAt,
+	}
+}
@@ -0,0 +1,168 @@
+package repos_db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// This will be a table in the database
+type RefreshToken struct {
+	BaseDBModel
+	UserID    string `gorm:"index"`
+	FamilyID  string `gorm:"index"`
+	TenantID  string
+	TokenHash string `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	RevokedAt sql.NullTime
+}
+
+// Access tokens revoked before their expiration. Rows can be purged once ExpiresAt has passed
+type RevokedAccessToken struct {
+	ID        string `gorm:"primaryKey"` // "jti" claim of the token
+	UserID    string
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// Challenges of the two-factor sign ins, between the password and the code
+type MFAChallenge struct {
+	ID        string `gorm:"primaryKey"` // Hash of the token given to the client
+	UserID    string `gorm:"index"`
+	Attempts  int
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// Codes of the authenticator apps already used, so they can not be replayed. Rows can be purged once ExpiresAt has passed
+type UsedTOTPCode struct {
+	UserID    string    `gorm:"primaryKey"`
+	Step      int64     `gorm:"primaryKey;autoIncrement:false"` // Time step of the code
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// Sign ins with the OIDC provider in progress, between the start and the callback
+type OIDCFlow struct {
+	ID           string `gorm:"primaryKey"` // Hash of the state sent to the provider
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time `gorm:"index"`
+	CreatedAt    time.Time
+}
+
+// TableName is needed, gorm would name it o_id_c_flows
+func (OIDCFlow) TableName() string {
+	return "oidc_flows"
+}
+
+// This will be a table in the database
+type ActionToken struct {
+	BaseDBModel
+	UserID    string `gorm:"index"`
+	Purpose   domain.ActionTokenPurpose
+	NewEmail  string
+	TokenHash string `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+func fromDomainRefreshToken(token *domain.RefreshToken) *RefreshToken {
+	dbToken := &RefreshToken{
+		UserID:    token.UserID,
+		FamilyID:  token.FamilyID,
+		TenantID:  token.TenantID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+	}
+	dbToken.ID = token.ID
+	return dbToken
+}
+
+func (t *RefreshToken) toDomainRefreshToken() *domain.RefreshToken {
+	return &domain.RefreshToken{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		FamilyID:  t.FamilyID,
+		TenantID:  t.TenantID,
+		TokenHash: t.TokenHash,
+		ExpiresAt: t.ExpiresAt,
+		UsedAt:    nullTimeToPtr(t.UsedAt),
+		RevokedAt: nullTimeToPtr(t.RevokedAt),
+	}
+}
+
+func fromDomainActionToken(token *domain.ActionToken) *ActionToken {
+	dbToken := &ActionToken{
+		UserID:    token.UserID,
+		Purpose:   token.Purpose,
+		NewEmail:  token.NewEmail,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+	}
+	dbToken.ID = token.ID
+	return dbToken
+}
+
+func (t *ActionToken) toDomainActionToken() *domain.ActionToken {
+	return &domain.ActionToken{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Purpose:   t.Purpose,
+		NewEmail:  t.NewEmail,
+		TokenHash: t.TokenHash,
+		ExpiresAt: t.ExpiresAt,
+		UsedAt:    nullTimeToPtr(t.UsedAt),
+	}
+}
+
+func fromDomainMFAChallenge(challenge *domain.MFAChallenge) *MFAChallenge {
+	return &MFAChallenge{
+		ID:        challenge.TokenHash,
+		UserID:    challenge.UserID,
+		Attempts:  challenge.Attempts,
+		ExpiresAt: challenge.ExpiresAt,
+	}
+}
+
+func (c *MFAChallenge) toDomainMFAChallenge() *domain.MFAChallenge {
+	return &domain.MFAChallenge{
+		TokenHash: c.ID,
+		UserID:    c.UserID,
+		Attempts:  c.Attempts,
+		ExpiresAt: c.ExpiresAt,
+	}
+}
+
+func fromDomainOIDCFlow(flow *domain.OIDCFlow) *OIDCFlow {
+	return &OIDCFlow{
+		ID:           flow.StateHash,
+		Nonce:        flow.Nonce,
+		CodeVerifier: flow.CodeVerifier,
+		ExpiresAt:    flow.ExpiresAt,
+	}
+}
+
+func (f *OIDCFlow) toDomainOIDCFlow() *domain.OIDCFlow {
+	return &domain.OIDCFlow{
+		StateHash:    f.ID,
+		Nonce:        f.Nonce,
+		CodeVerifier: f.CodeVerifier,
+		ExpiresAt:    f.ExpiresAt,
+	}
+}
+
+func nullTimeToPtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func ptrToNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,116 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	opentelemetry "github.com/Manolo-Esc/gommence/src/pkg/open_telemetry"
+	"gorm.io/gorm/clause"
+)
+
+type TokenRepositoryDB struct {
+	dbInfra *DBReposInfra
+}
+
+func NewTokenRepository(dbInfra *DBReposInfra) ports.TokenRepository {
+	return &TokenRepositoryDB{dbInfra: dbInfra}
+}
+
+func (r *TokenRepositoryDB) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) (string, ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "TokenRepositoryDB.CreateRefreshToken")
+	defer span.End()
+
+	dbToken := fromDomainRefreshToken(token)
+	err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbToken)
+	return dbToken.ID, err
+}
+
+func (r *TokenRepositoryDB) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, ports.APIError) {
+	var token RefreshToken
+	r.dbInfra.DB(ctx).Where("token_hash = ?", tokenHash).First(&token)
+	if token.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Refresh token not found")
+	}
+	return token.toDomainRefreshToken(), nil
+}
+
+// MarkRefreshTokenUsed flags the token as used in a single statement, so two concurrent refreshes can not both succeed
+func (r *TokenRepositoryDB) MarkRefreshTokenUsed(ctx context.Context, idToken string) (bool, ports.APIError) {
+	result := r.dbInfra.DB(ctx).Model(&RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", idToken).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *TokenRepositoryDB) RevokeRefreshTokenFamily(ctx context.Context, familyId string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens closes every session of the user
+func (r *TokenRepositoryDB) RevokeUserRefreshTokens(ctx context.Context, userId string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *TokenRepositoryDB) RevokeAccessToken(ctx context.Context, tokenId string, userId string, expiresAt time.Time) ports.APIError {
+	revoked := RevokedAccessToken{ID: tokenId, UserID: userId, ExpiresAt: expiresAt}
+	result := r.dbInfra.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked) // revoking twice is not an error
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *TokenRepositoryDB) IsAccessTokenRevoked(ctx context.Context, tokenId string) (bool, ports.APIError) {
+	var count int64
+	result := r.dbInfra.DB(ctx).Model(&RevokedAccessToken{}).Where("id = ?", tokenId).Count(&count)
+	if result.Error != nil {
+		return false, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return count > 0, nil
+}
+
+// DeleteUserRefreshTokens removes the sessions of the user. The revoked access tokens are still needed until they expire,
+// they lose the user instead
+func (r *TokenRepositoryDB) DeleteUserRefreshTokens(ctx context.Context, userId string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Unscoped().Where("user_id = ?", userId).Delete(&RefreshToken{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	result = r.dbInfra.DB(ctx).Model(&RevokedAccessToken{}).Where("user_id = ?", userId).Update("user_id", "")
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+// DeleteExpiredTokens removes what can no longer be used. The used refresh tokens are kept until they expire: presenting
+// one again revokes its family
+func (r *TokenRepositoryDB) DeleteExpiredTokens(ctx context.Context, before time.Time) ports.APIError {
+	result := r.dbInfra.DB(ctx).Unscoped().Where("expires_at < ? OR revoked_at IS NOT NULL", before).Delete(&RefreshToken{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	result = r.dbInfra.DB(ctx).Where("expires_at < ?", before).Delete(&RevokedAccessToken{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package repos_db
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"gorm.io/gorm"
+)
+
+// TxManagerImpl keeps the transaction in the context, where DBReposInfra.DB finds it. gorm turns the nested
+// transactions into savepoints
+type TxManagerImpl struct {
+	dbInfra *DBReposInfra
+}
+
+func NewTxManager(dbInfra *DBReposInfra) ports.TxManager {
+	return &TxManagerImpl{dbInfra: dbInfra}
+}
+
+func (m *TxManagerImpl) WithinTx(ctx context.Context, fn func(ctx context.Context) ports.APIError) ports.APIError {
+	var fnErr ports.APIError
+	err := m.dbInfra.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		fnErr = fn(context.WithValue(ctx, txContextKey{}, tx))
+		if fnErr != nil {
+			return fnErr
+		}
+		return nil
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
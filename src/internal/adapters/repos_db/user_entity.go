@@ -2,6 +2,7 @@ package repos_db
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
@@ -10,12 +11,25 @@ import (
 // This will be a table in the database
 type User struct {
 	BaseDBModel
-	FirstName      string
-	FirstLastName  string
-	SecondLastName sql.NullString
-	Email          string `gorm:"uniqueIndex"`
-	AuthMethod     domain.AuthMethod
-	HashedPassword sql.NullString // Can be null depending on the AuthMethod
+	FirstName       string
+	FirstLastName   string
+	SecondLastName  sql.NullString
+	Email           string `gorm:"uniqueIndex"`
+	AuthMethod      domain.AuthMethod
+	HashedPassword  sql.NullString // Can be null depending on the AuthMethod
+	EmailVerifiedAt sql.NullTime
+	MFASecret       sql.NullString
+	MFAEnabledAt    sql.NullTime
+	RecoveryCodes   sql.NullString // Comma separated hashes
+}
+
+// Identities of external providers linked to a user. A user can have several, one per provider
+type UserIdentity struct {
+	BaseDBModel
+	UserID  string `gorm:"index"`
+	Issuer  string `gorm:"uniqueIndex:idx_user_identity"`
+	Subject string `gorm:"uniqueIndex:idx_user_identity"`
+	Email   string // Email of the user in the provider when the link was made
 }
 
 func fromDtosUserCreate(creationData *dtos.InternalUserCreate) *User {
@@ -31,12 +45,27 @@ func fromDtosUserCreate(creationData *dtos.InternalUserCreate) *User {
 
 func (u *User) toDomainUser() *domain.User {
 	return &domain.User{
-		ID:             u.ID,
-		FirstName:      u.FirstName,
-		FirstLastName:  u.FirstLastName,
-		SecondLastName: u.SecondLastName.String,
-		Email:          u.Email,
-		AuthMethod:     u.AuthMethod,
-		HashedPassword: u.HashedPassword.String,
+		ID:              u.ID,
+		FirstName:       u.FirstName,
+		FirstLastName:   u.FirstLastName,
+		SecondLastName:  u.SecondLastName.String,
+		Email:           u.Email,
+		AuthMethod:      u.AuthMethod,
+		HashedPassword:  u.HashedPassword.String,
+		EmailVerifiedAt: nullTimeToPtr(u.EmailVerifiedAt),
+		MFASecret:       u.MFASecret.String,
+		MFAEnabledAt:    nullTimeToPtr(u.MFAEnabledAt),
+		RecoveryCodes:   splitRecoveryCodes(u.RecoveryCodes.String),
+	}
+}
+
+func joinRecoveryCodes(codes []string) sql.NullString {
+	return sql.NullString{String: strings.Join(codes, ","), Valid: len(codes) > 0}
+}
+
+func splitRecoveryCodes(joined string) []string {
+	if joined == "" {
+		return nil
 	}
+	return strings.Split(joined, ",")
 }
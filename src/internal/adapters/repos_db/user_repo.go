@@ -2,12 +2,17 @@ package repos_db
 
 import (
 	"context"
+	"database/sql"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	opentelemetry "github.com/Manolo-Esc/gommence/src/pkg/open_telemetry"
+	"gorm.io/gorm"
 )
 
 type UserRepositoryDB struct {
@@ -23,13 +28,50 @@ func (r *UserRepositoryDB) Create(ctx context.Context, creationData *dtos.Intern
 	defer span.End()
 
 	dbUser := fromDtosUserCreate(creationData)
-	err := CreateEntityWithPID(ctx, r.dbInfra.Db, dbUser)
-	return dbUser.ID, err
+	if err := CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), dbUser); err != nil {
+		if err.Status() == http.StatusConflict {
+			return "", ports.NewAPIError(http.StatusConflict, "Email already registered") // maybe by a deleted user
+		}
+		return "", err
+	}
+	return dbUser.ID, nil
+}
+
+func (r *UserRepositoryDB) CreateUsers(ctx context.Context, creationData []*dtos.InternalUserCreate) ([]string, []ports.APIError) {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "UserRepositoryDB.CreateUsers")
+	defer span.End()
+
+	dbUsers := make([]*User, len(creationData))
+	for i, user := range creationData {
+		dbUsers[i] = fromDtosUserCreate(user)
+	}
+	errs := CreateEntitiesWithPID(ctx, r.dbInfra.DB(ctx), dbUsers)
+	if errs == nil {
+		errs = make([]ports.APIError, len(dbUsers))
+	}
+	ids := make([]string, len(dbUsers))
+	for i, user := range dbUsers {
+		if errs[i] == nil {
+			ids[i] = user.ID
+		} else if errs[i].Status() == http.StatusConflict {
+			errs[i] = ports.NewAPIError(http.StatusConflict, "Email already registered") // maybe by a deleted user
+		}
+	}
+	return ids, errs
 }
 
 func (r *UserRepositoryDB) GetUserById(ctx context.Context, idUser string) (*domain.User, ports.APIError) {
 	var user User
-	r.dbInfra.Db.WithContext(ctx).Where("id = ?", idUser).First(&user)
+	r.dbInfra.DB(ctx).Where("id = ?", idUser).First(&user)
+	if user.ID == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return user.toDomainUser(), nil
+}
+
+func (r *UserRepositoryDB) GetUserByIdUnscoped(ctx context.Context, idUser string) (*domain.User, ports.APIError) {
+	var user User
+	r.dbInfra.DB(ctx).Unscoped().Where("id = ?", idUser).First(&user)
 	if user.ID == "" {
 		return nil, ports.NewAPIError(http.StatusNotFound, "User not found")
 	}
@@ -39,33 +81,218 @@ func (r *UserRepositoryDB) GetUserById(ctx context.Context, idUser string) (*dom
 // GetUserIdByEmail returns the user ID associated with the given email. If the email is not found, it returns an empty string.
 func (r *UserRepositoryDB) GetUserIdByEmail(ctx context.Context, email string) string {
 	var user User
-	r.dbInfra.Db.WithContext(ctx).Where("email ILIKE ?", email).First(&user)
+	r.dbInfra.DB(ctx).Where("LOWER(email) = LOWER(?)", email).First(&user)
 	return user.ID // If not found, it will return an empty string
 }
 
+func (r *UserRepositoryDB) GetUsersByEmails(ctx context.Context, emails []string) ([]*domain.User, ports.APIError) {
+	lowerEmails := make([]string, len(emails))
+	for i, email := range emails {
+		lowerEmails[i] = strings.ToLower(email)
+	}
+	var users []User
+	result := r.dbInfra.DB(ctx).Where("LOWER(email) IN ?", lowerEmails).Find(&users)
+	if result.Error != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	domainUsers := make([]*domain.User, len(users))
+	for i := range users {
+		domainUsers[i] = users[i].toDomainUser()
+	}
+	return domainUsers, nil
+}
+
 // GetUserByEmail retrieves a domain.User by its email or nil if not found
 func (r *UserRepositoryDB) GetUserByEmail(ctx context.Context, email string) (*domain.User, ports.APIError) {
 	var user User
-	r.dbInfra.Db.WithContext(ctx).Where("email ILIKE ?", email).First(&user)
+	r.dbInfra.DB(ctx).Where("LOWER(email) = LOWER(?)", email).First(&user)
 	if user.ID == "" {
 		return nil, ports.NewAPIError(http.StatusNotFound, "User not found")
 	}
 	return user.toDomainUser(), nil
 }
 
-func (r *UserRepositoryDB) GetUsers(ctx context.Context) ([]*domain.User, ports.APIError) {
+// Fields of the user lists and their columns
+var userListColumns = ListColumns{
+	"email":      {"email"},
+	"first_name": {"first_name"},
+	"last_name":  {"first_last_name"},
+	"name":       {"first_name", "first_last_name", "second_last_name"},
+	"created_at": {"created_at"},
+}
+
+func (r *UserRepositoryDB) GetUsers(ctx context.Context, query dtos.ListQuery) ([]*domain.User, *dtos.ListResult, ports.APIError) {
 	ctx, span := opentelemetry.GetTracer().Start(ctx, "UserRepositoryDB.GetUsers")
 	defer span.End()
 
-	var records []User
-	result := r.dbInfra.Db.WithContext(ctx).Find(&records)
+	records, result, err := FindPage[User](r.dbInfra.DB(ctx), query, userListColumns, dtos.ListSort{Field: "created_at"})
+	if err != nil {
+		return nil, nil, err
+	}
+	users := make([]*domain.User, len(records))
+	for i := range records {
+		users[i] = records[i].toDomainUser()
+	}
+	return users, result, nil
+}
 
+func (r *UserRepositoryDB) UpdateUser(ctx context.Context, idUser string, update *dtos.UserUpdate) ports.APIError {
+	changes := map[string]interface{}{}
+	if update.FirstName != nil {
+		changes["first_name"] = *update.FirstName
+	}
+	if update.FirstLastName != nil {
+		changes["first_last_name"] = *update.FirstLastName
+	}
+	if update.SecondLastName != nil {
+		changes["second_last_name"] = sql.NullString{String: *update.SecondLastName, Valid: *update.SecondLastName != ""}
+	}
+	result := r.dbInfra.DB(ctx).Model(&User{}).Where("id = ?", idUser).Updates(changes)
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return nil
+}
+
+func (r *UserRepositoryDB) DeleteUser(ctx context.Context, idUser string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Where("id = ?", idUser).Delete(&User{})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return nil
+}
+
+func (r *UserRepositoryDB) RestoreUser(ctx context.Context, idUser string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Unscoped().Model(&User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", idUser).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "Deleted user not found")
+	}
+	return nil
+}
+
+// SetEmailVerified keeps the first verification date if the email was already verified
+func (r *UserRepositoryDB) SetEmailVerified(ctx context.Context, idUser string, verifiedAt time.Time) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&User{}).
+		Where("id = ? AND email_verified_at IS NULL", idUser).
+		Update("email_verified_at", verifiedAt)
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	return nil
+}
+
+func (r *UserRepositoryDB) UpdatePassword(ctx context.Context, idUser string, hashedPassword string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&User{}).Where("id = ?", idUser).Update("hashed_password", hashedPassword)
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return nil
+}
+
+func (r *UserRepositoryDB) UpdateEmail(ctx context.Context, idUser string, email string, verifiedAt time.Time) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&User{}).Where("id = ?", idUser).
+		Updates(map[string]interface{}{"email": email, "email_verified_at": verifiedAt})
+	if result.Error != nil {
+		if IsUniqueViolation(result.Error) {
+			return ports.NewAPIError(http.StatusConflict, "Email already registered")
+		}
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return nil
+}
+
+func (r *UserRepositoryDB) GetUserIdentities(ctx context.Context, idUser string) ([]*domain.ExternalIdentity, ports.APIError) {
+	var records []UserIdentity
+	result := r.dbInfra.DB(ctx).Where("user_id = ?", idUser).Order("created_at").Find(&records)
 	if result.Error != nil {
 		return nil, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
 	}
-	users := make([]*domain.User, len(records))
-	for i, _ := range records {
-		users[i] = records[i].toDomainUser()
+	identities := make([]*domain.ExternalIdentity, len(records))
+	for i, record := range records {
+		identities[i] = &domain.ExternalIdentity{Issuer: record.Issuer, Subject: record.Subject, Email: record.Email}
+	}
+	return identities, nil
+}
+
+func (r *UserRepositoryDB) EraseUser(ctx context.Context, idUser string) ports.APIError {
+	err := r.dbInfra.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", idUser).Delete(&UserIdentity{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", idUser).Delete(&User{}).Error
+	})
+	if err != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+// GetUserIdByIdentity returns the ID of the user linked to the identity or an empty string if there is none
+func (r *UserRepositoryDB) GetUserIdByIdentity(ctx context.Context, issuer string, subject string) string {
+	var identity UserIdentity
+	r.dbInfra.DB(ctx).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity)
+	return identity.UserID
+}
+
+func (r *UserRepositoryDB) LinkIdentity(ctx context.Context, idUser string, identity *domain.ExternalIdentity) ports.APIError {
+	ctx, span := opentelemetry.GetTracer().Start(ctx, "UserRepositoryDB.LinkIdentity")
+	defer span.End()
+
+	dbIdentity := UserIdentity{UserID: idUser, Issuer: identity.Issuer, Subject: identity.Subject, Email: identity.Email}
+	return CreateEntityWithPID(ctx, r.dbInfra.DB(ctx), &dbIdentity)
+}
+
+// UpdateMFA replaces the two-factor authentication state of the user. An empty secret disables it
+func (r *UserRepositoryDB) UpdateMFA(ctx context.Context, idUser string, secret string, enabledAt *time.Time, recoveryCodes []string) ports.APIError {
+	result := r.dbInfra.DB(ctx).Model(&User{}).Where("id = ?", idUser).Updates(map[string]interface{}{
+		"mfa_secret":     sql.NullString{String: secret, Valid: secret != ""},
+		"mfa_enabled_at": ptrToNullTime(enabledAt),
+		"recovery_codes": joinRecoveryCodes(recoveryCodes),
+	})
+	if result.Error != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return nil
+}
+
+// UseRecoveryCode removes the code from the user's recovery codes. It returns false if the user does not have it,
+// which includes the case of two requests racing to use the same code
+func (r *UserRepositoryDB) UseRecoveryCode(ctx context.Context, idUser string, codeHash string) (bool, ports.APIError) {
+	var user User
+	r.dbInfra.DB(ctx).Where("id = ?", idUser).First(&user)
+	if user.ID == "" {
+		return false, ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	codes := splitRecoveryCodes(user.RecoveryCodes.String)
+	index := slices.Index(codes, codeHash)
+	if index < 0 {
+		return false, nil
+	}
+	result := r.dbInfra.DB(ctx).Model(&User{}).
+		Where("id = ? AND recovery_codes = ?", idUser, user.RecoveryCodes.String). // only if nobody changed them since we read them
+		Update("recovery_codes", joinRecoveryCodes(slices.Delete(codes, index, index+1)))
+	if result.Error != nil {
+		return false, ports.NewAPIError(http.StatusInternalServerError, result.Error.Error())
 	}
-	return users, nil
+	return result.RowsAffected == 1, nil
 }
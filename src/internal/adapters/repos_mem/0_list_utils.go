@@ -0,0 +1,204 @@
+package repos_mem
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+// ListFields maps the fields of a dtos.ListQuery to the values of a record, as repos_db.ListColumns does to the columns
+// of a table. The values are strings, times, int64 or bools
+type ListFields[T any] map[string][]func(record *T) any
+
+type listKey[T any] struct {
+	value func(record *T) any
+	desc  bool
+}
+
+// findPage selects a page of the records as repos_db.FindPage does, with the same cursors. The id is always the last
+// sort key, so that the cursors point to a single record
+func findPage[T any](records []T, id func(record *T) any, query dtos.ListQuery, fields ListFields[T], defaultSort ...dtos.ListSort) ([]T, *dtos.ListResult, ports.APIError) {
+	for _, filter := range query.Filters {
+		match, err := filterMatch(fields, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		records = slices.DeleteFunc(records, func(record T) bool { return !match(&record) })
+	}
+
+	result := &dtos.ListResult{}
+	if query.WithTotal {
+		total := int64(len(records))
+		result.Total = &total
+	}
+
+	sort := query.Sort
+	if len(sort) == 0 {
+		sort = defaultSort
+	}
+	keys := make([]listKey[T], 0, len(sort)+1)
+	for _, s := range sort {
+		values := fields[s.Field]
+		if len(values) != 1 {
+			return nil, nil, ports.NewAPIError(http.StatusBadRequest, "Can not sort by "+s.Field)
+		}
+		keys = append(keys, listKey[T]{value: values[0], desc: s.Desc})
+	}
+	keys = append(keys, listKey[T]{value: id})
+	slices.SortFunc(records, func(a, b T) int {
+		for _, key := range keys {
+			if c := compareKey(key, key.value(&a), key.value(&b)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	if query.Cursor != "" {
+		after, err := decodeCursor(keys, query.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		start, _ := slices.BinarySearchFunc(records, after, func(record T, after []any) int {
+			for i, key := range keys {
+				if c := compareKey(key, key.value(&record), after[i]); c != 0 {
+					return c
+				}
+			}
+			return -1 // the record of the cursor goes before the next page too
+		})
+		records = records[start:]
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = dtos.DefaultListLimit
+	}
+	if query.Page > 0 {
+		start := min((query.Page-1)*limit, len(records))
+		records = records[start:min(start+limit, len(records))]
+	} else if len(records) > limit {
+		records = records[:limit]
+		result.NextCursor = encodeCursor(keys, &records[limit-1])
+	}
+	return records, result, nil
+}
+
+func filterMatch[T any](fields ListFields[T], filter dtos.ListFilter) (func(record *T) bool, ports.APIError) {
+	values, ok := fields[filter.Field]
+	if !ok {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "Can not filter by "+filter.Field)
+	}
+	conditions := make([]func(record *T) bool, len(values))
+	for i, value := range values {
+		switch filter.Op {
+		case dtos.ListOpPrefix, dtos.ListOpContains:
+			pattern := strings.ToLower(filter.Value)
+			contains := strings.HasPrefix
+			if filter.Op == dtos.ListOpContains {
+				contains = strings.Contains
+			}
+			conditions[i] = func(record *T) bool {
+				return contains(strings.ToLower(fmt.Sprint(value(record))), pattern)
+			}
+		case dtos.ListOpEq, dtos.ListOpGte, dtos.ListOpLte:
+			var zero T
+			operand, err := listValue(value(&zero), filter.Value)
+			if err != nil {
+				return nil, ports.NewAPIError(http.StatusBadRequest, fmt.Sprintf("Invalid %s: %s", filter.Field, err.Error()))
+			}
+			accepts := listOperators[filter.Op]
+			conditions[i] = func(record *T) bool {
+				return accepts(compareValues(value(record), operand))
+			}
+		default:
+			return nil, ports.NewAPIError(http.StatusBadRequest, "Unknown filter "+string(filter.Op))
+		}
+	}
+	return func(record *T) bool {
+		return slices.ContainsFunc(conditions, func(condition func(record *T) bool) bool { return condition(record) })
+	}, nil
+}
+
+var listOperators = map[dtos.ListOp]func(c int) bool{
+	dtos.ListOpEq:  func(c int) bool { return c == 0 },
+	dtos.ListOpGte: func(c int) bool { return c >= 0 },
+	dtos.ListOpLte: func(c int) bool { return c <= 0 },
+}
+
+func compareKey[T any](key listKey[T], a, b any) int {
+	if key.desc {
+		return compareValues(b, a)
+	}
+	return compareValues(a, b)
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case bool:
+		return cmp.Compare(strconv.FormatBool(a), strconv.FormatBool(b.(bool)))
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func decodeCursor[T any](keys []listKey[T], cursor string) ([]any, ports.APIError) {
+	invalid := ports.NewAPIError(http.StatusBadRequest, "Invalid cursor")
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var texts []string
+	if err := json.Unmarshal(data, &texts); err != nil || len(texts) != len(keys) {
+		return nil, invalid // probably from a list with another sort
+	}
+	values := make([]any, len(keys))
+	var zero T
+	for i, key := range keys {
+		if values[i], err = listValue(key.value(&zero), texts[i]); err != nil {
+			return nil, invalid
+		}
+	}
+	return values, nil
+}
+
+func encodeCursor[T any](keys []listKey[T], record *T) string {
+	texts := make([]string, len(keys))
+	for i, key := range keys {
+		value := key.value(record)
+		if t, ok := value.(time.Time); ok {
+			texts[i] = t.UTC().Format(time.RFC3339Nano)
+		} else {
+			texts[i] = fmt.Sprint(value)
+		}
+	}
+	data, _ := json.Marshal(texts)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// listValue converts the text of a query, or of a cursor, to the type of the sample
+func listValue(sample any, text string) (any, error) {
+	switch sample.(type) {
+	case time.Time:
+		if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.DateOnly, text)
+	case int64:
+		return strconv.ParseInt(text, 10, 64)
+	case bool:
+		return strconv.ParseBool(text)
+	}
+	return text, nil
+}
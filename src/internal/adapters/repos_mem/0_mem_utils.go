@@ -0,0 +1,159 @@
+package repos_mem
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opo_uid"
+)
+
+// MemReposInfra holds the data of every in-memory repository, so they can see each other's data as the tables of the
+// database do. It is the Store of the TxManager:
+//
+//	memInfra := repos_mem.NewMemReposInfra()
+//	users := repos_mem.NewUserRepository(memInfra)
+//	tx := repos_mem.NewTxManager(memInfra)
+type MemReposInfra struct {
+	txLock sync.Mutex // held by the open transaction
+	lock   sync.RWMutex
+	data   memData
+}
+
+// memData is copied by the snapshots, that get their own maps and slices of records. The slices and pointers inside the
+// records are shared by the copies though, so they are replaced instead of modified
+type memData struct {
+	users               map[string]userRecord
+	identities          []identityRecord
+	roles               map[string]domain.Role
+	userRoles           map[userRoleKey]time.Time
+	groups              map[string]domain.Group
+	groupMembers        map[groupMemberKey]time.Time
+	grants              map[string]domain.Grant
+	organizations       map[string]domain.Organization
+	memberships         map[membershipKey]domain.Membership
+	apiKeys             map[string]domain.APIKey
+	auditEvents         []domain.AuditEvent
+	refreshTokens       map[string]domain.RefreshToken
+	revokedAccessTokens map[string]time.Time
+	actionTokens        map[string]domain.ActionToken
+	loginAttempts       map[string]domain.LoginAttempts
+	mfaChallenges       map[string]domain.MFAChallenge
+	usedTOTPCodes       map[usedTOTPKey]time.Time
+	oidcFlows           map[string]domain.OIDCFlow
+}
+
+func NewMemReposInfra() *MemReposInfra {
+	return &MemReposInfra{data: memData{
+		users:               map[string]userRecord{},
+		roles:               map[string]domain.Role{},
+		userRoles:           map[userRoleKey]time.Time{},
+		groups:              map[string]domain.Group{},
+		groupMembers:        map[groupMemberKey]time.Time{},
+		grants:              map[string]domain.Grant{},
+		organizations:       map[string]domain.Organization{},
+		memberships:         map[membershipKey]domain.Membership{},
+		apiKeys:             map[string]domain.APIKey{},
+		refreshTokens:       map[string]domain.RefreshToken{},
+		revokedAccessTokens: map[string]time.Time{},
+		actionTokens:        map[string]domain.ActionToken{},
+		loginAttempts:       map[string]domain.LoginAttempts{},
+		mfaChallenges:       map[string]domain.MFAChallenge{},
+		usedTOTPCodes:       map[usedTOTPKey]time.Time{},
+		oidcFlows:           map[string]domain.OIDCFlow{},
+	}}
+}
+
+func (infra *MemReposInfra) Snapshot() any {
+	infra.lock.RLock()
+	defer infra.lock.RUnlock()
+	return memData{
+		users:               maps.Clone(infra.data.users),
+		identities:          slices.Clone(infra.data.identities),
+		roles:               maps.Clone(infra.data.roles),
+		userRoles:           maps.Clone(infra.data.userRoles),
+		groups:              maps.Clone(infra.data.groups),
+		groupMembers:        maps.Clone(infra.data.groupMembers),
+		grants:              maps.Clone(infra.data.grants),
+		organizations:       maps.Clone(infra.data.organizations),
+		memberships:         maps.Clone(infra.data.memberships),
+		apiKeys:             maps.Clone(infra.data.apiKeys),
+		auditEvents:         slices.Clone(infra.data.auditEvents),
+		refreshTokens:       maps.Clone(infra.data.refreshTokens),
+		revokedAccessTokens: maps.Clone(infra.data.revokedAccessTokens),
+		actionTokens:        maps.Clone(infra.data.actionTokens),
+		loginAttempts:       maps.Clone(infra.data.loginAttempts),
+		mfaChallenges:       maps.Clone(infra.data.mfaChallenges),
+		usedTOTPCodes:       maps.Clone(infra.data.usedTOTPCodes),
+		oidcFlows:           maps.Clone(infra.data.oidcFlows),
+	}
+}
+
+// Restore takes the snapshot as the current data. It is not used again by the TxManager, so it is not copied
+func (infra *MemReposInfra) Restore(snapshot any) {
+	infra.lock.Lock()
+	defer infra.lock.Unlock()
+	infra.data = snapshot.(memData)
+}
+
+// Lock is taken by the transactions, see Store
+func (infra *MemReposInfra) Lock() {
+	infra.txLock.Lock()
+}
+
+func (infra *MemReposInfra) Unlock() {
+	infra.txLock.Unlock()
+}
+
+// read runs fn with the data locked for reading
+func (infra *MemReposInfra) read(fn func(data *memData)) {
+	infra.lock.RLock()
+	defer infra.lock.RUnlock()
+	fn(&infra.data)
+}
+
+// write runs fn with the data locked for writing. Out of a transaction it waits for the open one to end
+func (infra *MemReposInfra) write(ctx context.Context, fn func(data *memData)) {
+	if !inTxOf(ctx, infra) {
+		infra.txLock.Lock()
+		defer infra.txLock.Unlock()
+	}
+	infra.lock.Lock()
+	defer infra.lock.Unlock()
+	fn(&infra.data)
+}
+
+// isActiveUser tells whether the user exists and has not been deleted
+func (data *memData) isActiveUser(idUser string) bool {
+	user, found := data.users[idUser]
+	return found && user.deletedAt == nil
+}
+
+// newID respects the id of the entity, as repos_db.CreateEntityWithPID does, unless it is empty or taken
+func newID[T any](records map[string]T, id string) string {
+	if _, taken := records[id]; id != "" && !taken {
+		return id
+	}
+	for {
+		id = opo_uid.New()
+		if _, taken := records[id]; !taken {
+			return id
+		}
+	}
+}
+
+// now returns the current time without the monotonic reading, so it compares as the times read from the cursors
+func now() time.Time {
+	return time.Now().Round(0)
+}
+
+func clonePtr[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	clone := *value
+	return &clone
+}
@@ -0,0 +1,194 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+// Groups and grants on single resources. The roles are in permission_repo.go
+
+// Users belonging to each group
+type groupMemberKey struct {
+	groupID string
+	userID  string
+}
+
+func (r *PermissionRepositoryMem) GetGroups(ctx context.Context) ([]*domain.Group, ports.APIError) {
+	return r.groups(func(data *memData, group *domain.Group) bool { return true }), nil
+}
+
+func (r *PermissionRepositoryMem) GetGroupByName(ctx context.Context, name string) (*domain.Group, ports.APIError) {
+	groups := r.groups(func(data *memData, group *domain.Group) bool { return group.Name == name })
+	if len(groups) == 0 {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Group not found")
+	}
+	return groups[0], nil
+}
+
+func (r *PermissionRepositoryMem) CreateGroup(ctx context.Context, group *domain.Group) (string, ports.APIError) {
+	var id string
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		for _, record := range data.groups {
+			if record.Name == group.Name {
+				err = ports.NewAPIError(http.StatusConflict, "Group already exists")
+				return
+			}
+		}
+		id = newID(data.groups, group.ID)
+		data.groups[id] = domain.Group{ID: id, Name: group.Name, CreatedAt: now()}
+	})
+	return id, err
+}
+
+func (r *PermissionRepositoryMem) GetGroupMembers(ctx context.Context, idGroup string) ([]string, ports.APIError) {
+	members := []string{}
+	r.memInfra.read(func(data *memData) {
+		for key := range data.groupMembers {
+			if key.groupID == idGroup {
+				members = append(members, key.userID)
+			}
+		}
+	})
+	slices.Sort(members)
+	return members, nil
+}
+
+func (r *PermissionRepositoryMem) AddGroupMember(ctx context.Context, idGroup string, idUser string) ports.APIError {
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		if !data.isActiveUser(idUser) {
+			err = ports.NewAPIError(http.StatusNotFound, "User not found")
+			return
+		}
+		key := groupMemberKey{groupID: idGroup, userID: idUser}
+		if _, found := data.groupMembers[key]; !found {
+			data.groupMembers[key] = now()
+		}
+	})
+	return err
+}
+
+func (r *PermissionRepositoryMem) RemoveGroupMember(ctx context.Context, idGroup string, idUser string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		delete(data.groupMembers, groupMemberKey{groupID: idGroup, userID: idUser})
+	})
+	return nil
+}
+
+func (r *PermissionRepositoryMem) CreateGrant(ctx context.Context, grant *domain.Grant) (string, ports.APIError) {
+	var id string
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		for _, record := range data.grants {
+			if record.SubjectType == grant.SubjectType && record.SubjectID == grant.SubjectID &&
+				record.ResourceType == grant.ResourceType && record.ResourceID == grant.ResourceID && record.Permission == grant.Permission {
+				err = ports.NewAPIError(http.StatusConflict, "Grant already exists")
+				return
+			}
+		}
+		record := *grant
+		record.ID = newID(data.grants, grant.ID)
+		record.CreatedAt = now()
+		data.grants[record.ID] = record
+		id = record.ID
+	})
+	return id, err
+}
+
+func (r *PermissionRepositoryMem) GetGrant(ctx context.Context, idGrant string) (*domain.Grant, ports.APIError) {
+	grants := r.grants(func(data *memData, grant *domain.Grant) bool { return grant.ID == idGrant })
+	if len(grants) == 0 {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Grant not found")
+	}
+	return grants[0], nil
+}
+
+func (r *PermissionRepositoryMem) DeleteGrant(ctx context.Context, idGrant string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		delete(data.grants, idGrant)
+	})
+	return nil
+}
+
+func (r *PermissionRepositoryMem) GetResourceGrants(ctx context.Context, resourceType string, resourceID string) ([]*domain.Grant, ports.APIError) {
+	return r.grants(func(data *memData, grant *domain.Grant) bool {
+		return grant.ResourceType == resourceType && grant.ResourceID == resourceID
+	}), nil
+}
+
+func (r *PermissionRepositoryMem) GetUserGrants(ctx context.Context, idUser string) ([]*domain.Grant, ports.APIError) {
+	return r.grants(func(data *memData, grant *domain.Grant) bool {
+		if grant.SubjectType == domain.SubjectGroup {
+			_, member := data.groupMembers[groupMemberKey{groupID: grant.SubjectID, userID: idUser}]
+			return member
+		}
+		return grant.SubjectType == domain.SubjectUser && grant.SubjectID == idUser
+	}), nil
+}
+
+func (r *PermissionRepositoryMem) GetUserGroups(ctx context.Context, idUser string) ([]*domain.Group, ports.APIError) {
+	return r.groups(func(data *memData, group *domain.Group) bool {
+		_, member := data.groupMembers[groupMemberKey{groupID: group.ID, userID: idUser}]
+		return member
+	}), nil
+}
+
+func (r *PermissionRepositoryMem) DeleteUserPermissions(ctx context.Context, idUser string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for key := range data.userRoles {
+			if key.userID == idUser {
+				delete(data.userRoles, key)
+			}
+		}
+		for key := range data.groupMembers {
+			if key.userID == idUser {
+				delete(data.groupMembers, key)
+			}
+		}
+		for id, grant := range data.grants {
+			if grant.SubjectType == domain.SubjectUser && grant.SubjectID == idUser {
+				delete(data.grants, id)
+			}
+		}
+	})
+	return nil
+}
+
+// groups returns the groups that match, by name
+func (r *PermissionRepositoryMem) groups(match func(data *memData, group *domain.Group) bool) []*domain.Group {
+	groups := []*domain.Group{}
+	r.memInfra.read(func(data *memData) {
+		for _, group := range data.groups {
+			if match(data, &group) {
+				groups = append(groups, &group)
+			}
+		}
+	})
+	slices.SortFunc(groups, func(a, b *domain.Group) int { return strings.Compare(a.Name, b.Name) })
+	return groups
+}
+
+// grants returns the grants that match, by creation date
+func (r *PermissionRepositoryMem) grants(match func(data *memData, grant *domain.Grant) bool) []*domain.Grant {
+	grants := []*domain.Grant{}
+	r.memInfra.read(func(data *memData) {
+		for _, grant := range data.grants {
+			if match(data, &grant) {
+				grants = append(grants, &grant)
+			}
+		}
+	})
+	slices.SortFunc(grants, func(a, b *domain.Grant) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return grants
+}
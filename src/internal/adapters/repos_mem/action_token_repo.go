@@ -0,0 +1,95 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type ActionTokenRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewActionTokenRepository(memInfra *MemReposInfra) ports.ActionTokenRepository {
+	return &ActionTokenRepositoryMem{memInfra: memInfra}
+}
+
+func (r *ActionTokenRepositoryMem) CreateActionToken(ctx context.Context, token *domain.ActionToken) (string, ports.APIError) {
+	var id string
+	r.memInfra.write(ctx, func(data *memData) {
+		record := *token
+		record.ID = newID(data.actionTokens, token.ID)
+		record.UsedAt = clonePtr(token.UsedAt)
+		data.actionTokens[record.ID] = record
+		id = record.ID
+	})
+	return id, nil
+}
+
+func (r *ActionTokenRepositoryMem) GetActionTokenByHash(ctx context.Context, purpose domain.ActionTokenPurpose, tokenHash string) (*domain.ActionToken, ports.APIError) {
+	var token *domain.ActionToken
+	r.memInfra.read(func(data *memData) {
+		for _, record := range data.actionTokens {
+			if record.TokenHash == tokenHash && record.Purpose == purpose {
+				record.UsedAt = clonePtr(record.UsedAt)
+				token = &record
+			}
+		}
+	})
+	if token == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Token not found")
+	}
+	return token, nil
+}
+
+// MarkActionTokenUsed flags the token as used while holding the lock, so it can not be consumed twice
+func (r *ActionTokenRepositoryMem) MarkActionTokenUsed(ctx context.Context, idToken string) (bool, ports.APIError) {
+	marked := false
+	r.memInfra.write(ctx, func(data *memData) {
+		if record, found := data.actionTokens[idToken]; found && record.UsedAt == nil {
+			usedAt := now()
+			record.UsedAt = &usedAt
+			data.actionTokens[idToken] = record
+			marked = true
+		}
+	})
+	return marked, nil
+}
+
+func (r *ActionTokenRepositoryMem) InvalidateActionTokens(ctx context.Context, userId string, purpose domain.ActionTokenPurpose) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		usedAt := now()
+		for id, record := range data.actionTokens {
+			if record.UserID == userId && record.Purpose == purpose && record.UsedAt == nil {
+				record.UsedAt = &usedAt
+				data.actionTokens[id] = record
+			}
+		}
+	})
+	return nil
+}
+
+func (r *ActionTokenRepositoryMem) DeleteUserActionTokens(ctx context.Context, userId string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for id, record := range data.actionTokens {
+			if record.UserID == userId {
+				delete(data.actionTokens, id)
+			}
+		}
+	})
+	return nil
+}
+
+func (r *ActionTokenRepositoryMem) DeleteExpiredActionTokens(ctx context.Context, before time.Time) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for id, record := range data.actionTokens {
+			if record.ExpiresAt.Before(before) || record.UsedAt != nil {
+				delete(data.actionTokens, id)
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,104 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type APIKeyRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewAPIKeyRepository(memInfra *MemReposInfra) ports.APIKeyRepository {
+	return &APIKeyRepositoryMem{memInfra: memInfra}
+}
+
+func (r *APIKeyRepositoryMem) CreateAPIKey(ctx context.Context, key *domain.APIKey) (string, ports.APIError) {
+	var id string
+	r.memInfra.write(ctx, func(data *memData) {
+		record := cloneAPIKey(*key)
+		record.ID = newID(data.apiKeys, key.ID)
+		record.CreatedAt = now()
+		data.apiKeys[record.ID] = *record
+		id = record.ID
+	})
+	return id, nil
+}
+
+func (r *APIKeyRepositoryMem) GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, ports.APIError) {
+	var key *domain.APIKey
+	r.memInfra.read(func(data *memData) {
+		for _, record := range data.apiKeys {
+			if record.KeyHash == keyHash && data.isActiveUser(record.UserID) { // deleted users can not use their keys
+				key = cloneAPIKey(record)
+			}
+		}
+	})
+	if key == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "API key not found")
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepositoryMem) GetUserAPIKeys(ctx context.Context, idUser string) ([]*domain.APIKey, ports.APIError) {
+	keys := []*domain.APIKey{}
+	r.memInfra.read(func(data *memData) {
+		for _, record := range data.apiKeys {
+			if record.UserID == idUser && record.RevokedAt == nil {
+				keys = append(keys, cloneAPIKey(record))
+			}
+		}
+	})
+	slices.SortFunc(keys, func(a, b *domain.APIKey) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return keys, nil
+}
+
+func (r *APIKeyRepositoryMem) RevokeAPIKey(ctx context.Context, idKey string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		if record, found := data.apiKeys[idKey]; found && record.RevokedAt == nil {
+			revokedAt := now()
+			record.RevokedAt = &revokedAt
+			data.apiKeys[idKey] = record
+		}
+	})
+	return nil
+}
+
+func (r *APIKeyRepositoryMem) TouchAPIKey(ctx context.Context, idKey string, usedAt time.Time) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		if record, found := data.apiKeys[idKey]; found {
+			record.LastUsedAt = &usedAt
+			data.apiKeys[idKey] = record
+		}
+	})
+	return nil
+}
+
+func (r *APIKeyRepositoryMem) DeleteUserAPIKeys(ctx context.Context, idUser string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for id, record := range data.apiKeys {
+			if record.UserID == idUser {
+				delete(data.apiKeys, id)
+			}
+		}
+	})
+	return nil
+}
+
+func cloneAPIKey(key domain.APIKey) *domain.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	key.LastUsedAt = clonePtr(key.LastUsedAt)
+	key.RevokedAt = clonePtr(key.RevokedAt)
+	return &key
+}
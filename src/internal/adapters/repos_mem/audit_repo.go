@@ -0,0 +1,77 @@
+package repos_mem
+
+import (
+	"context"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opo_uid"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type AuditRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewAuditRepository(memInfra *MemReposInfra) ports.AuditRepository {
+	return &AuditRepositoryMem{memInfra: memInfra}
+}
+
+func (r *AuditRepositoryMem) CreateAuditEvent(ctx context.Context, event *domain.AuditEvent) (string, ports.APIError) {
+	record := *event
+	if record.ID == "" {
+		record.ID = opo_uid.New()
+	}
+	record.CreatedAt = now()
+	r.memInfra.write(ctx, func(data *memData) {
+		data.auditEvents = append(data.auditEvents, record)
+	})
+	return record.ID, nil
+}
+
+func (r *AuditRepositoryMem) GetAuditEvents(ctx context.Context, query dtos.AuditQuery) ([]*domain.AuditEvent, ports.APIError) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = dtos.DefaultAuditLimit
+	}
+	events := r.events(func(event *domain.AuditEvent) bool {
+		return (query.Type == "" || string(event.Type) == query.Type) &&
+			(query.Target == "" || event.Target == query.Target) &&
+			(query.Since.IsZero() || event.CreatedAt.After(query.Since))
+	})
+	return events[:min(limit, len(events))], nil
+}
+
+func (r *AuditRepositoryMem) GetUserAuditEvents(ctx context.Context, idUser string, email string) ([]*domain.AuditEvent, ports.APIError) {
+	return r.events(func(event *domain.AuditEvent) bool {
+		return event.ActorID == idUser || event.Target == idUser || event.Target == email
+	}), nil
+}
+
+func (r *AuditRepositoryMem) AnonymizeUserAuditEvents(ctx context.Context, idUser string, email string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for i := range data.auditEvents {
+			event := &data.auditEvents[i]
+			if event.ActorID == idUser {
+				event.ActorID = ""
+			}
+			if event.Target == idUser || event.Target == email {
+				event.Target = ""
+			}
+		}
+	})
+	return nil
+}
+
+// events returns the events that match, the newest first
+func (r *AuditRepositoryMem) events(match func(event *domain.AuditEvent) bool) []*domain.AuditEvent {
+	events := []*domain.AuditEvent{}
+	r.memInfra.read(func(data *memData) {
+		for i := len(data.auditEvents) - 1; i >= 0; i-- { // they are kept in the order they were recorded
+			if event := data.auditEvents[i]; match(&event) {
+				events = append(events, &event)
+			}
+		}
+	})
+	return events
+}
@@ -0,0 +1,79 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type LoginAttemptRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewLoginAttemptRepository(memInfra *MemReposInfra) ports.LoginAttemptRepository {
+	return &LoginAttemptRepositoryMem{memInfra: memInfra}
+}
+
+func (r *LoginAttemptRepositoryMem) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, ports.APIError) {
+	var attempts domain.LoginAttempts
+	var found bool
+	r.memInfra.read(func(data *memData) {
+		attempts, found = data.loginAttempts[key]
+	})
+	if !found {
+		return nil, ports.NewAPIError(http.StatusNotFound, "No login attempts")
+	}
+	attempts.LockedUntil = clonePtr(attempts.LockedUntil)
+	return &attempts, nil
+}
+
+// AddLoginFailure adds the failure while holding the lock, so concurrent failures are all counted
+func (r *LoginAttemptRepositoryMem) AddLoginFailure(ctx context.Context, key string, at time.Time, windowStart time.Time) (*domain.LoginAttempts, ports.APIError) {
+	var attempts domain.LoginAttempts
+	r.memInfra.write(ctx, func(data *memData) {
+		record, found := data.loginAttempts[key]
+		if !found || record.LastFailureAt.Before(windowStart) {
+			record.Key, record.Failures = key, 0
+		}
+		record.Failures++
+		record.LastFailureAt = at
+		data.loginAttempts[key] = record
+		attempts = record
+	})
+	attempts.LockedUntil = clonePtr(attempts.LockedUntil)
+	return &attempts, nil
+}
+
+// LockLoginAttempts checks the failures and locks while holding the lock, so only one of concurrent failures locks
+func (r *LoginAttemptRepositoryMem) LockLoginAttempts(ctx context.Context, key string, minFailures int, lockedUntil time.Time) (bool, ports.APIError) {
+	locked := false
+	r.memInfra.write(ctx, func(data *memData) {
+		if record, found := data.loginAttempts[key]; found && record.Failures >= minFailures {
+			record.Failures, record.LockedUntil = 0, &lockedUntil
+			data.loginAttempts[key] = record
+			locked = true
+		}
+	})
+	return locked, nil
+}
+
+func (r *LoginAttemptRepositoryMem) DeleteLoginAttempts(ctx context.Context, key string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		delete(data.loginAttempts, key)
+	})
+	return nil
+}
+
+func (r *LoginAttemptRepositoryMem) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for key, record := range data.loginAttempts {
+			if record.LastFailureAt.Before(before) && (record.LockedUntil == nil || record.LockedUntil.Before(before)) {
+				delete(data.loginAttempts, key)
+			}
+		}
+	})
+	return nil
+}
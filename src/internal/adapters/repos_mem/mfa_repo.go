@@ -0,0 +1,99 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type usedTOTPKey struct {
+	userID string
+	step   int64
+}
+
+type MFARepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewMFARepository(memInfra *MemReposInfra) ports.MFARepository {
+	return &MFARepositoryMem{memInfra: memInfra}
+}
+
+func (r *MFARepositoryMem) CreateMFAChallenge(ctx context.Context, challenge *domain.MFAChallenge) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		data.mfaChallenges[challenge.TokenHash] = *challenge
+	})
+	return nil
+}
+
+// AddMFAChallengeAttempt counts the attempt while holding the lock, so concurrent attempts are all counted
+func (r *MFARepositoryMem) AddMFAChallengeAttempt(ctx context.Context, tokenHash string, now time.Time) (*domain.MFAChallenge, ports.APIError) {
+	var challenge *domain.MFAChallenge
+	r.memInfra.write(ctx, func(data *memData) {
+		if record, found := data.mfaChallenges[tokenHash]; found && record.ExpiresAt.After(now) {
+			record.Attempts++
+			data.mfaChallenges[tokenHash] = record
+			challenge = &record
+		}
+	})
+	if challenge == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "MFA challenge not found")
+	}
+	return challenge, nil
+}
+
+func (r *MFARepositoryMem) DeleteMFAChallenge(ctx context.Context, tokenHash string) (bool, ports.APIError) {
+	var found bool
+	r.memInfra.write(ctx, func(data *memData) {
+		_, found = data.mfaChallenges[tokenHash]
+		delete(data.mfaChallenges, tokenHash)
+	})
+	return found, nil
+}
+
+// UseTOTPStep records the step while holding the lock, so a code is accepted once
+func (r *MFARepositoryMem) UseTOTPStep(ctx context.Context, userId string, step int64, expiresAt time.Time) (bool, ports.APIError) {
+	var used bool
+	r.memInfra.write(ctx, func(data *memData) {
+		key := usedTOTPKey{userID: userId, step: step}
+		if _, used = data.usedTOTPCodes[key]; !used {
+			data.usedTOTPCodes[key] = expiresAt
+		}
+	})
+	return !used, nil
+}
+
+func (r *MFARepositoryMem) DeleteUserMFA(ctx context.Context, userId string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for hash, record := range data.mfaChallenges {
+			if record.UserID == userId {
+				delete(data.mfaChallenges, hash)
+			}
+		}
+		for key := range data.usedTOTPCodes {
+			if key.userID == userId {
+				delete(data.usedTOTPCodes, key)
+			}
+		}
+	})
+	return nil
+}
+
+func (r *MFARepositoryMem) DeleteExpiredMFA(ctx context.Context, before time.Time) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for hash, record := range data.mfaChallenges {
+			if record.ExpiresAt.Before(before) {
+				delete(data.mfaChallenges, hash)
+			}
+		}
+		for key, expiresAt := range data.usedTOTPCodes {
+			if expiresAt.Before(before) {
+				delete(data.usedTOTPCodes, key)
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,53 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type OIDCFlowRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewOIDCFlowRepository(memInfra *MemReposInfra) ports.OIDCFlowRepository {
+	return &OIDCFlowRepositoryMem{memInfra: memInfra}
+}
+
+func (r *OIDCFlowRepositoryMem) CreateOIDCFlow(ctx context.Context, flow *domain.OIDCFlow) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		data.oidcFlows[flow.StateHash] = *flow
+	})
+	return nil
+}
+
+// TakeOIDCFlow deletes the flow while holding the lock, so two callbacks with the same state can't both use it
+func (r *OIDCFlowRepositoryMem) TakeOIDCFlow(ctx context.Context, stateHash string, now time.Time) (*domain.OIDCFlow, ports.APIError) {
+	var flow *domain.OIDCFlow
+	r.memInfra.write(ctx, func(data *memData) {
+		if record, found := data.oidcFlows[stateHash]; found {
+			delete(data.oidcFlows, stateHash)
+			if record.ExpiresAt.After(now) {
+				flow = &record
+			}
+		}
+	})
+	if flow == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "OIDC flow not found")
+	}
+	return flow, nil
+}
+
+func (r *OIDCFlowRepositoryMem) DeleteExpiredOIDCFlows(ctx context.Context, before time.Time) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for hash, record := range data.oidcFlows {
+			if record.ExpiresAt.Before(before) {
+				delete(data.oidcFlows, hash)
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,136 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type membershipKey struct {
+	organizationID string
+	userID         string
+}
+
+type OrganizationRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewOrganizationRepository(memInfra *MemReposInfra) ports.OrganizationRepository {
+	return &OrganizationRepositoryMem{memInfra: memInfra}
+}
+
+func (r *OrganizationRepositoryMem) CreateOrganization(ctx context.Context, organization *domain.Organization) (string, ports.APIError) {
+	var id string
+	r.memInfra.write(ctx, func(data *memData) {
+		id = newID(data.organizations, organization.ID)
+		data.organizations[id] = domain.Organization{ID: id, Name: organization.Name, CreatedAt: now()}
+	})
+	return id, nil
+}
+
+func (r *OrganizationRepositoryMem) GetUserOrganizations(ctx context.Context, idUser string) ([]*domain.Organization, ports.APIError) {
+	memberships := r.memberships(func(membership *domain.Membership) bool { return membership.UserID == idUser })
+	organizations := make([]*domain.Organization, 0, len(memberships))
+	r.memInfra.read(func(data *memData) {
+		for _, membership := range memberships {
+			if organization, found := data.organizations[membership.OrganizationID]; found {
+				organizations = append(organizations, &organization)
+			}
+		}
+	})
+	return organizations, nil
+}
+
+func (r *OrganizationRepositoryMem) GetMembership(ctx context.Context, idOrganization string, idUser string) (*domain.Membership, ports.APIError) {
+	var membership domain.Membership
+	var found bool
+	r.memInfra.read(func(data *memData) {
+		membership, found = data.memberships[membershipKey{organizationID: idOrganization, userID: idUser}]
+	})
+	if !found {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Membership not found")
+	}
+	return &membership, nil
+}
+
+func (r *OrganizationRepositoryMem) SaveMembership(ctx context.Context, membership *domain.Membership) ports.APIError {
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		if !data.isActiveUser(membership.UserID) {
+			err = ports.NewAPIError(http.StatusNotFound, "User not found")
+			return
+		}
+		key := membershipKey{organizationID: membership.OrganizationID, userID: membership.UserID}
+		record, found := data.memberships[key]
+		if !found {
+			record = domain.Membership{OrganizationID: membership.OrganizationID, UserID: membership.UserID, CreatedAt: now()}
+		}
+		record.Role = membership.Role
+		data.memberships[key] = record
+	})
+	return err
+}
+
+func (r *OrganizationRepositoryMem) GetMembers(ctx context.Context) ([]*domain.Membership, ports.APIError) {
+	tenant, found := domain.TenantFromContext(ctx)
+	if !found {
+		return nil, errNoTenant()
+	}
+	return r.memberships(func(membership *domain.Membership) bool { return membership.OrganizationID == tenant }), nil
+}
+
+func (r *OrganizationRepositoryMem) DeleteMembership(ctx context.Context, idUser string) ports.APIError {
+	tenant, found := domain.TenantFromContext(ctx)
+	if !found {
+		return errNoTenant()
+	}
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		key := membershipKey{organizationID: tenant, userID: idUser}
+		if _, found := data.memberships[key]; !found {
+			err = ports.NewAPIError(http.StatusNotFound, "Membership not found")
+			return
+		}
+		delete(data.memberships, key)
+	})
+	return err
+}
+
+func (r *OrganizationRepositoryMem) DeleteUserMemberships(ctx context.Context, idUser string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for key := range data.memberships {
+			if key.userID == idUser {
+				delete(data.memberships, key)
+			}
+		}
+	})
+	return nil
+}
+
+// memberships returns the memberships that match, by creation date
+func (r *OrganizationRepositoryMem) memberships(match func(membership *domain.Membership) bool) []*domain.Membership {
+	memberships := []*domain.Membership{}
+	r.memInfra.read(func(data *memData) {
+		for _, membership := range data.memberships {
+			if match(&membership) {
+				memberships = append(memberships, &membership)
+			}
+		}
+	})
+	slices.SortFunc(memberships, func(a, b *domain.Membership) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.OrganizationID+a.UserID, b.OrganizationID+b.UserID)
+	})
+	return memberships
+}
+
+// errNoTenant is the error of the database when a query of the data of an organization has no tenant in the context
+func errNoTenant() ports.APIError {
+	return ports.NewAPIError(http.StatusInternalServerError, "no tenant in the context")
+}
@@ -0,0 +1,112 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+// Roles assigned to each user
+type userRoleKey struct {
+	userID string
+	roleID string
+}
+
+type PermissionRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewPermissionRepository(memInfra *MemReposInfra) ports.PermissionRepository {
+	return &PermissionRepositoryMem{memInfra: memInfra}
+}
+
+func (r *PermissionRepositoryMem) GetRoles(ctx context.Context) ([]*domain.Role, ports.APIError) {
+	var roles []*domain.Role
+	r.memInfra.read(func(data *memData) {
+		for _, role := range data.roles {
+			roles = append(roles, cloneRole(role))
+		}
+	})
+	return sortRoles(roles), nil
+}
+
+func (r *PermissionRepositoryMem) GetRoleByName(ctx context.Context, name string) (*domain.Role, ports.APIError) {
+	var role *domain.Role
+	r.memInfra.read(func(data *memData) {
+		for _, record := range data.roles {
+			if record.Name == name {
+				role = cloneRole(record)
+			}
+		}
+	})
+	if role == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Role not found")
+	}
+	return role, nil
+}
+
+func (r *PermissionRepositoryMem) CreateRole(ctx context.Context, role *domain.Role) (string, ports.APIError) {
+	var id string
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		for _, record := range data.roles {
+			if record.Name == role.Name {
+				err = ports.NewAPIError(http.StatusConflict, "Role already exists")
+				return
+			}
+		}
+		record := cloneRole(*role)
+		record.ID = newID(data.roles, role.ID)
+		data.roles[record.ID] = *record
+		id = record.ID
+	})
+	return id, err
+}
+
+func (r *PermissionRepositoryMem) GetUserRoles(ctx context.Context, idUser string) ([]*domain.Role, ports.APIError) {
+	roles := []*domain.Role{}
+	r.memInfra.read(func(data *memData) {
+		for key := range data.userRoles {
+			if role, found := data.roles[key.roleID]; found && key.userID == idUser {
+				roles = append(roles, cloneRole(role))
+			}
+		}
+	})
+	return sortRoles(roles), nil
+}
+
+func (r *PermissionRepositoryMem) AddUserRole(ctx context.Context, idUser string, idRole string) ports.APIError {
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		if !data.isActiveUser(idUser) {
+			err = ports.NewAPIError(http.StatusNotFound, "User not found")
+			return
+		}
+		key := userRoleKey{userID: idUser, roleID: idRole}
+		if _, found := data.userRoles[key]; !found {
+			data.userRoles[key] = now()
+		}
+	})
+	return err
+}
+
+func (r *PermissionRepositoryMem) RemoveUserRole(ctx context.Context, idUser string, idRole string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		delete(data.userRoles, userRoleKey{userID: idUser, roleID: idRole})
+	})
+	return nil
+}
+
+func cloneRole(role domain.Role) *domain.Role {
+	role.Permissions = slices.Clone(role.Permissions)
+	return &role
+}
+
+func sortRoles(roles []*domain.Role) []*domain.Role {
+	slices.SortFunc(roles, func(a, b *domain.Role) int { return strings.Compare(a.Name, b.Name) })
+	return roles
+}
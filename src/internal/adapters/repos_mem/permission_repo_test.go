@@ -0,0 +1,92 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionRepositoryRoles(t *testing.T) {
+	ctx := context.Background()
+	memInfra := NewMemReposInfra()
+	users := NewUserRepository(memInfra)
+	repo := NewPermissionRepository(memInfra)
+	idUser, _ := users.Create(ctx, newUser("john@mail.com"))
+	idRoles := map[string]string{}
+	for _, role := range domain.DefaultRoles() {
+		idRoles[role.Name], _ = repo.CreateRole(ctx, role)
+	}
+	_, err := repo.CreateRole(ctx, &domain.Role{Name: domain.RoleAdmin})
+	assert.Equal(t, http.StatusConflict, err.Status())
+
+	roles, err := repo.GetRoles(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"admin", "editor", "user"}, []string{roles[0].Name, roles[1].Name, roles[2].Name})
+	role, err := repo.GetRoleByName(ctx, domain.RoleEditor)
+	assert.Nil(t, err)
+	assert.Equal(t, domain.Roles.Editor, role.Permissions)
+	_, err = repo.GetRoleByName(ctx, "nobody")
+	assert.Equal(t, http.StatusNotFound, err.Status())
+
+	assert.Nil(t, repo.AddUserRole(ctx, idUser, idRoles[domain.RoleUser]))
+	assert.Nil(t, repo.AddUserRole(ctx, idUser, idRoles[domain.RoleUser])) // duplicates are ignored
+	assert.Nil(t, repo.AddUserRole(ctx, idUser, idRoles[domain.RoleEditor]))
+	assert.Equal(t, http.StatusNotFound, repo.AddUserRole(ctx, "nobody", idRoles[domain.RoleUser]).Status())
+	roles, _ = repo.GetUserRoles(ctx, idUser)
+	assert.Len(t, roles, 2)
+	assert.Equal(t, domain.RoleEditor, roles[0].Name)
+
+	assert.Nil(t, repo.RemoveUserRole(ctx, idUser, idRoles[domain.RoleEditor]))
+	roles, _ = repo.GetUserRoles(ctx, idUser)
+	assert.Len(t, roles, 1)
+	users.DeleteUser(ctx, idUser)
+	assert.Equal(t, http.StatusNotFound, repo.AddUserRole(ctx, idUser, idRoles[domain.RoleEditor]).Status())
+}
+
+func TestPermissionRepositoryGrants(t *testing.T) {
+	ctx := context.Background()
+	memInfra := NewMemReposInfra()
+	users := NewUserRepository(memInfra)
+	repo := NewPermissionRepository(memInfra)
+	idUser, _ := users.Create(ctx, newUser("john@mail.com"))
+	idGroup, err := repo.CreateGroup(ctx, &domain.Group{Name: "writers"})
+	assert.Nil(t, err)
+	_, err = repo.CreateGroup(ctx, &domain.Group{Name: "writers"})
+	assert.Equal(t, http.StatusConflict, err.Status())
+	assert.Nil(t, repo.AddGroupMember(ctx, idGroup, idUser))
+	assert.Equal(t, http.StatusNotFound, repo.AddGroupMember(ctx, idGroup, "nobody").Status())
+	members, _ := repo.GetGroupMembers(ctx, idGroup)
+	assert.Equal(t, []string{idUser}, members)
+
+	userGrant := &domain.Grant{SubjectType: domain.SubjectUser, SubjectID: idUser, ResourceType: "document", ResourceID: "1", Permission: domain.PermissionRead}
+	idGrant, err := repo.CreateGrant(ctx, userGrant)
+	assert.Nil(t, err)
+	_, err = repo.CreateGrant(ctx, userGrant)
+	assert.Equal(t, http.StatusConflict, err.Status())
+	_, err = repo.CreateGrant(ctx, &domain.Grant{SubjectType: domain.SubjectGroup, SubjectID: idGroup, ResourceType: "document", ResourceID: "2", Permission: domain.PermissionWrite})
+	assert.Nil(t, err)
+	_, err = repo.CreateGrant(ctx, &domain.Grant{SubjectType: domain.SubjectUser, SubjectID: "other", ResourceType: "document", ResourceID: "1", Permission: domain.PermissionRead})
+	assert.Nil(t, err)
+
+	grants, _ := repo.GetUserGrants(ctx, idUser)
+	assert.Len(t, grants, 2) // its own and the one of its group
+	grants, _ = repo.GetResourceGrants(ctx, "document", "1")
+	assert.Len(t, grants, 2)
+	assert.Equal(t, idGrant, grants[0].ID) // the oldest first
+	grant, err := repo.GetGrant(ctx, idGrant)
+	assert.Nil(t, err)
+	assert.False(t, grant.CreatedAt.IsZero())
+
+	assert.Nil(t, repo.DeleteUserPermissions(ctx, idUser))
+	grants, _ = repo.GetUserGrants(ctx, idUser)
+	assert.Empty(t, grants)
+	groups, _ := repo.GetUserGroups(ctx, idUser)
+	assert.Empty(t, groups)
+	_, err = repo.GetGrant(ctx, idGrant)
+	assert.Equal(t, http.StatusNotFound, err.Status())
+	_, err = repo.CreateGrant(ctx, userGrant) // it can be given again
+	assert.Nil(t, err)
+}
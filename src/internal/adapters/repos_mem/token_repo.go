@@ -0,0 +1,133 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type TokenRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewTokenRepository(memInfra *MemReposInfra) ports.TokenRepository {
+	return &TokenRepositoryMem{memInfra: memInfra}
+}
+
+func (r *TokenRepositoryMem) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) (string, ports.APIError) {
+	var id string
+	r.memInfra.write(ctx, func(data *memData) {
+		record := *token
+		record.ID = newID(data.refreshTokens, token.ID)
+		record.UsedAt = clonePtr(token.UsedAt)
+		record.RevokedAt = clonePtr(token.RevokedAt)
+		data.refreshTokens[record.ID] = record
+		id = record.ID
+	})
+	return id, nil
+}
+
+func (r *TokenRepositoryMem) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, ports.APIError) {
+	var token *domain.RefreshToken
+	r.memInfra.read(func(data *memData) {
+		for _, record := range data.refreshTokens {
+			if record.TokenHash == tokenHash {
+				record.UsedAt = clonePtr(record.UsedAt)
+				record.RevokedAt = clonePtr(record.RevokedAt)
+				token = &record
+			}
+		}
+	})
+	if token == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "Refresh token not found")
+	}
+	return token, nil
+}
+
+// MarkRefreshTokenUsed flags the token as used while holding the lock, so two concurrent refreshes can not both succeed
+func (r *TokenRepositoryMem) MarkRefreshTokenUsed(ctx context.Context, idToken string) (bool, ports.APIError) {
+	marked := false
+	r.memInfra.write(ctx, func(data *memData) {
+		if record, found := data.refreshTokens[idToken]; found && record.UsedAt == nil && record.RevokedAt == nil {
+			usedAt := now()
+			record.UsedAt = &usedAt
+			data.refreshTokens[idToken] = record
+			marked = true
+		}
+	})
+	return marked, nil
+}
+
+func (r *TokenRepositoryMem) RevokeRefreshTokenFamily(ctx context.Context, familyId string) ports.APIError {
+	r.revokeRefreshTokens(ctx, func(token *domain.RefreshToken) bool { return token.FamilyID == familyId })
+	return nil
+}
+
+// RevokeUserRefreshTokens closes every session of the user
+func (r *TokenRepositoryMem) RevokeUserRefreshTokens(ctx context.Context, userId string) ports.APIError {
+	r.revokeRefreshTokens(ctx, func(token *domain.RefreshToken) bool { return token.UserID == userId })
+	return nil
+}
+
+func (r *TokenRepositoryMem) revokeRefreshTokens(ctx context.Context, match func(token *domain.RefreshToken) bool) {
+	r.memInfra.write(ctx, func(data *memData) {
+		revokedAt := now()
+		for id, record := range data.refreshTokens {
+			if record.RevokedAt == nil && match(&record) {
+				record.RevokedAt = &revokedAt
+				data.refreshTokens[id] = record
+			}
+		}
+	})
+}
+
+func (r *TokenRepositoryMem) RevokeAccessToken(ctx context.Context, tokenId string, userId string, expiresAt time.Time) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		if _, found := data.revokedAccessTokens[tokenId]; !found { // revoking twice is not an error
+			data.revokedAccessTokens[tokenId] = expiresAt
+		}
+	})
+	return nil
+}
+
+func (r *TokenRepositoryMem) IsAccessTokenRevoked(ctx context.Context, tokenId string) (bool, ports.APIError) {
+	var revoked bool
+	r.memInfra.read(func(data *memData) {
+		_, revoked = data.revokedAccessTokens[tokenId]
+	})
+	return revoked, nil
+}
+
+// DeleteUserRefreshTokens removes the sessions of the user. The revoked access tokens are kept, they are still needed
+// and do not know the user
+func (r *TokenRepositoryMem) DeleteUserRefreshTokens(ctx context.Context, userId string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for id, record := range data.refreshTokens {
+			if record.UserID == userId {
+				delete(data.refreshTokens, id)
+			}
+		}
+	})
+	return nil
+}
+
+// DeleteExpiredTokens removes what can no longer be used. The used refresh tokens are kept until they expire: presenting
+// one again revokes its family
+func (r *TokenRepositoryMem) DeleteExpiredTokens(ctx context.Context, before time.Time) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		for id, record := range data.refreshTokens {
+			if record.ExpiresAt.Before(before) || record.RevokedAt != nil {
+				delete(data.refreshTokens, id)
+			}
+		}
+		for id, expiresAt := range data.revokedAccessTokens {
+			if expiresAt.Before(before) {
+				delete(data.revokedAccessTokens, id)
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package repos_mem keeps the data of the repositories in memory. It is meant for unit tests and for trying the
+// server without a database, nothing is kept between runs
+package repos_mem
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+// Store is the data of an in-memory repository, that a failed transaction restores. The outermost transaction holds
+// its lock, and the writes out of the transaction have to wait for it, or a rollback would undo them too
+type Store interface {
+	sync.Locker
+	Snapshot() any
+	Restore(snapshot any)
+}
+
+// TxManagerImpl undoes the changes of a failed transaction by restoring the stores to the snapshots taken when it
+// began, or when the savepoint was set for the nested ones. Transactions run one at a time. The reads out of them
+// do not wait, so they may see changes that are rolled back later
+type TxManagerImpl struct {
+	stores []Store
+}
+
+type txContextKey struct{}
+
+func NewTxManager(stores ...Store) ports.TxManager {
+	return &TxManagerImpl{stores: stores}
+}
+
+func (m *TxManagerImpl) WithinTx(ctx context.Context, fn func(ctx context.Context) ports.APIError) ports.APIError {
+	if ctx.Value(txContextKey{}) != m {
+		for _, store := range m.stores { // always in the same order, so two transactions can't wait for each other
+			store.Lock()
+			defer store.Unlock()
+		}
+		ctx = context.WithValue(ctx, txContextKey{}, m)
+	}
+	snapshots := make([]any, len(m.stores))
+	for i, store := range m.stores {
+		snapshots[i] = store.Snapshot()
+	}
+	committed := false
+	defer func() { // on panics too
+		if !committed {
+			for i, store := range m.stores {
+				store.Restore(snapshots[i])
+			}
+		}
+	}()
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// inTxOf tells whether the context is in a transaction that holds the lock of the store
+func inTxOf(ctx context.Context, store Store) bool {
+	m, inTx := ctx.Value(txContextKey{}).(*TxManagerImpl)
+	return inTx && slices.Contains(m.stores, store)
+}
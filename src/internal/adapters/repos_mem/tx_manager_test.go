@@ -0,0 +1,93 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+type counterStore struct {
+	sync.Mutex
+	value int
+}
+
+func (s *counterStore) Snapshot() any {
+	return s.value
+}
+
+func (s *counterStore) Restore(snapshot any) {
+	s.value = snapshot.(int)
+}
+
+func TestTxManager(t *testing.T) {
+	ctx := context.Background()
+	store := &counterStore{}
+	tx := NewTxManager(store)
+	failure := ports.NewAPIError(http.StatusConflict, "failed")
+
+	assert.Nil(t, tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		store.value = 1
+		return nil
+	}))
+	assert.Equal(t, 1, store.value)
+
+	err := tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		store.value = 2
+		return failure
+	})
+	assert.Equal(t, failure, err)
+	assert.Equal(t, 1, store.value) // rolled back
+
+	assert.Nil(t, tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		store.value = 3
+		err := tx.WithinTx(ctx, func(ctx context.Context) ports.APIError { // a savepoint, it does not wait for the outer transaction
+			store.value = 4
+			return failure
+		})
+		assert.Equal(t, failure, err)
+		assert.Equal(t, 3, store.value) // only the nested work is undone
+		return nil
+	}))
+	assert.Equal(t, 3, store.value)
+
+	assert.Panics(t, func() {
+		tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+			store.value = 5
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 3, store.value)
+}
+
+func TestTxManagerWritesOutOfTx(t *testing.T) {
+	ctx := context.Background()
+	memInfra := NewMemReposInfra()
+	repo := NewUserRepository(memInfra)
+	tx := NewTxManager(memInfra)
+	written := make(chan struct{})
+
+	err := tx.WithinTx(ctx, func(txCtx context.Context) ports.APIError {
+		_, err := repo.Create(txCtx, newUser("in@mail.com"))
+		assert.Nil(t, err)
+		go func() {
+			_, err := repo.Create(ctx, newUser("out@mail.com"))
+			assert.Nil(t, err)
+			close(written)
+		}()
+		select {
+		case <-written:
+			t.Error("written while the transaction was open")
+		case <-time.After(50 * time.Millisecond):
+		}
+		return ports.NewAPIError(http.StatusConflict, "rolled back")
+	})
+	assert.NotNil(t, err)
+	<-written
+	assert.Equal(t, "", repo.GetUserIdByEmail(ctx, "in@mail.com"))
+	assert.NotEqual(t, "", repo.GetUserIdByEmail(ctx, "out@mail.com")) // not undone by the rollback
+}
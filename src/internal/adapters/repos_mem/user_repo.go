@@ -0,0 +1,369 @@
+package repos_mem
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type userRecord struct {
+	domain.User
+	createdAt time.Time
+	deletedAt *time.Time // Soft delete, as the database does. The email stays taken
+}
+
+type identityRecord struct {
+	userID  string
+	issuer  string
+	subject string
+	email   string
+}
+
+type UserRepositoryMem struct {
+	memInfra *MemReposInfra
+}
+
+func NewUserRepository(memInfra *MemReposInfra) ports.UserRepository {
+	return &UserRepositoryMem{memInfra: memInfra}
+}
+
+func (r *UserRepositoryMem) Create(ctx context.Context, creationData *dtos.InternalUserCreate) (string, ports.APIError) {
+	var id string
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		id, err = data.createUser(creationData)
+	})
+	return id, err
+}
+
+func (r *UserRepositoryMem) CreateUsers(ctx context.Context, creationData []*dtos.InternalUserCreate) ([]string, []ports.APIError) {
+	ids := make([]string, len(creationData))
+	errs := make([]ports.APIError, len(creationData))
+	r.memInfra.write(ctx, func(data *memData) {
+		for i, user := range creationData {
+			ids[i], errs[i] = data.createUser(user)
+		}
+	})
+	return ids, errs
+}
+
+func (data *memData) createUser(creationData *dtos.InternalUserCreate) (string, ports.APIError) {
+	if data.emailOwner(creationData.Email) != nil {
+		return "", ports.NewAPIError(http.StatusConflict, "Email already registered") // maybe by a deleted user
+	}
+	user := userRecord{
+		User: domain.User{
+			FirstName:      creationData.FirstName,
+			FirstLastName:  creationData.FirstLastName,
+			SecondLastName: creationData.SecondLastName,
+			Email:          creationData.Email,
+			AuthMethod:     creationData.AuthMethod,
+			HashedPassword: creationData.HashedPassword,
+		},
+		createdAt: now(),
+	}
+	user.ID = newID(data.users, "")
+	data.users[user.ID] = user
+	return user.ID, nil
+}
+
+// emailOwner returns the user with the email, deleted or not, or nil if there is none. Emails are compared ignoring case
+func (data *memData) emailOwner(email string) *userRecord {
+	for _, user := range data.users {
+		if strings.EqualFold(user.Email, email) {
+			return &user
+		}
+	}
+	return nil
+}
+
+// activeUser returns the user if it exists and has not been deleted
+func (data *memData) activeUser(idUser string) (userRecord, ports.APIError) {
+	user, found := data.users[idUser]
+	if !found || user.deletedAt != nil {
+		return user, ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return user, nil
+}
+
+// updateUser stores a copy of the user changed by fn
+func (r *UserRepositoryMem) updateUser(ctx context.Context, idUser string, fn func(data *memData, user *userRecord) ports.APIError) ports.APIError {
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		var user userRecord
+		if user, err = data.activeUser(idUser); err != nil {
+			return
+		}
+		if err = fn(data, &user); err == nil {
+			data.users[idUser] = user
+		}
+	})
+	return err
+}
+
+func (r *UserRepositoryMem) GetUserById(ctx context.Context, idUser string) (*domain.User, ports.APIError) {
+	var user userRecord
+	var err ports.APIError
+	r.memInfra.read(func(data *memData) {
+		user, err = data.activeUser(idUser)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user.toDomainUser(), nil
+}
+
+func (r *UserRepositoryMem) GetUserByIdUnscoped(ctx context.Context, idUser string) (*domain.User, ports.APIError) {
+	var user userRecord
+	var found bool
+	r.memInfra.read(func(data *memData) {
+		user, found = data.users[idUser]
+	})
+	if !found {
+		return nil, ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return user.toDomainUser(), nil
+}
+
+// GetUserIdByEmail returns the user ID associated with the given email. If the email is not found, it returns an empty string.
+func (r *UserRepositoryMem) GetUserIdByEmail(ctx context.Context, email string) string {
+	user, _ := r.GetUserByEmail(ctx, email)
+	if user == nil {
+		return ""
+	}
+	return user.ID
+}
+
+func (r *UserRepositoryMem) GetUsersByEmails(ctx context.Context, emails []string) ([]*domain.User, ports.APIError) {
+	lowerEmails := make([]string, len(emails))
+	for i, email := range emails {
+		lowerEmails[i] = strings.ToLower(email)
+	}
+	users := r.activeUsers(func(user *userRecord) bool {
+		return slices.Contains(lowerEmails, strings.ToLower(user.Email))
+	})
+	domainUsers := make([]*domain.User, len(users))
+	for i := range users {
+		domainUsers[i] = users[i].toDomainUser()
+	}
+	return domainUsers, nil
+}
+
+// GetUserByEmail retrieves a domain.User by its email or nil if not found
+func (r *UserRepositoryMem) GetUserByEmail(ctx context.Context, email string) (*domain.User, ports.APIError) {
+	var user *userRecord
+	r.memInfra.read(func(data *memData) {
+		user = data.emailOwner(email)
+	})
+	if user == nil || user.deletedAt != nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return user.toDomainUser(), nil
+}
+
+// activeUsers returns the users not deleted that match, by creation date
+func (r *UserRepositoryMem) activeUsers(match func(user *userRecord) bool) []userRecord {
+	var users []userRecord
+	r.memInfra.read(func(data *memData) {
+		for _, user := range data.users {
+			if user.deletedAt == nil && match(&user) {
+				users = append(users, user)
+			}
+		}
+	})
+	slices.SortFunc(users, func(a, b userRecord) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return users
+}
+
+// Fields of the user lists, the same as in the database
+var userListFields = ListFields[userRecord]{
+	"email":      {func(u *userRecord) any { return u.Email }},
+	"first_name": {func(u *userRecord) any { return u.FirstName }},
+	"last_name":  {func(u *userRecord) any { return u.FirstLastName }},
+	"name": {
+		func(u *userRecord) any { return u.FirstName },
+		func(u *userRecord) any { return u.FirstLastName },
+		func(u *userRecord) any { return u.SecondLastName },
+	},
+	"created_at": {func(u *userRecord) any { return u.createdAt }},
+}
+
+func (r *UserRepositoryMem) GetUsers(ctx context.Context, query dtos.ListQuery) ([]*domain.User, *dtos.ListResult, ports.APIError) {
+	records := r.activeUsers(func(user *userRecord) bool { return true })
+	records, result, err := findPage(records, func(u *userRecord) any { return u.ID }, query, userListFields, dtos.ListSort{Field: "created_at"})
+	if err != nil {
+		return nil, nil, err
+	}
+	users := make([]*domain.User, len(records))
+	for i := range records {
+		users[i] = records[i].toDomainUser()
+	}
+	return users, result, nil
+}
+
+func (r *UserRepositoryMem) UpdateUser(ctx context.Context, idUser string, update *dtos.UserUpdate) ports.APIError {
+	return r.updateUser(ctx, idUser, func(data *memData, user *userRecord) ports.APIError {
+		if update.FirstName != nil {
+			user.FirstName = *update.FirstName
+		}
+		if update.FirstLastName != nil {
+			user.FirstLastName = *update.FirstLastName
+		}
+		if update.SecondLastName != nil {
+			user.SecondLastName = *update.SecondLastName
+		}
+		return nil
+	})
+}
+
+func (r *UserRepositoryMem) DeleteUser(ctx context.Context, idUser string) ports.APIError {
+	return r.updateUser(ctx, idUser, func(data *memData, user *userRecord) ports.APIError {
+		deletedAt := now()
+		user.deletedAt = &deletedAt
+		return nil
+	})
+}
+
+func (r *UserRepositoryMem) RestoreUser(ctx context.Context, idUser string) ports.APIError {
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		user, found := data.users[idUser]
+		if !found || user.deletedAt == nil {
+			err = ports.NewAPIError(http.StatusNotFound, "Deleted user not found")
+			return
+		}
+		user.deletedAt = nil
+		data.users[idUser] = user
+	})
+	return err
+}
+
+// SetEmailVerified keeps the first verification date if the email was already verified
+func (r *UserRepositoryMem) SetEmailVerified(ctx context.Context, idUser string, verifiedAt time.Time) ports.APIError {
+	r.updateUser(ctx, idUser, func(data *memData, user *userRecord) ports.APIError {
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &verifiedAt
+		}
+		return nil
+	})
+	return nil
+}
+
+func (r *UserRepositoryMem) UpdatePassword(ctx context.Context, idUser string, hashedPassword string) ports.APIError {
+	return r.updateUser(ctx, idUser, func(data *memData, user *userRecord) ports.APIError {
+		user.HashedPassword = hashedPassword
+		return nil
+	})
+}
+
+func (r *UserRepositoryMem) UpdateEmail(ctx context.Context, idUser string, email string, verifiedAt time.Time) ports.APIError {
+	return r.updateUser(ctx, idUser, func(data *memData, user *userRecord) ports.APIError {
+		if owner := data.emailOwner(email); owner != nil && owner.ID != idUser {
+			return ports.NewAPIError(http.StatusConflict, "Email already registered")
+		}
+		user.Email = email
+		user.EmailVerifiedAt = &verifiedAt
+		return nil
+	})
+}
+
+func (r *UserRepositoryMem) GetUserIdentities(ctx context.Context, idUser string) ([]*domain.ExternalIdentity, ports.APIError) {
+	identities := []*domain.ExternalIdentity{}
+	r.memInfra.read(func(data *memData) {
+		for _, record := range data.identities { // in the order they were linked
+			if record.userID == idUser {
+				identities = append(identities, &domain.ExternalIdentity{Issuer: record.issuer, Subject: record.subject, Email: record.email})
+			}
+		}
+	})
+	return identities, nil
+}
+
+func (r *UserRepositoryMem) EraseUser(ctx context.Context, idUser string) ports.APIError {
+	r.memInfra.write(ctx, func(data *memData) {
+		data.identities = slices.DeleteFunc(data.identities, func(record identityRecord) bool {
+			return record.userID == idUser
+		})
+		delete(data.users, idUser)
+	})
+	return nil
+}
+
+// GetUserIdByIdentity returns the ID of the user linked to the identity or an empty string if there is none
+func (r *UserRepositoryMem) GetUserIdByIdentity(ctx context.Context, issuer string, subject string) string {
+	var idUser string
+	r.memInfra.read(func(data *memData) {
+		if index := data.identityIndex(issuer, subject); index >= 0 {
+			idUser = data.identities[index].userID
+		}
+	})
+	return idUser
+}
+
+func (data *memData) identityIndex(issuer string, subject string) int {
+	return slices.IndexFunc(data.identities, func(record identityRecord) bool {
+		return record.issuer == issuer && record.subject == subject
+	})
+}
+
+func (r *UserRepositoryMem) LinkIdentity(ctx context.Context, idUser string, identity *domain.ExternalIdentity) ports.APIError {
+	var err ports.APIError
+	r.memInfra.write(ctx, func(data *memData) {
+		if data.identityIndex(identity.Issuer, identity.Subject) >= 0 {
+			err = ports.NewAPIError(http.StatusConflict, "Identity already linked")
+			return
+		}
+		record := identityRecord{userID: idUser, issuer: identity.Issuer, subject: identity.Subject, email: identity.Email}
+		data.identities = append(data.identities, record)
+	})
+	return err
+}
+
+// UpdateMFA replaces the two-factor authentication state of the user. An empty secret disables it
+func (r *UserRepositoryMem) UpdateMFA(ctx context.Context, idUser string, secret string, enabledAt *time.Time, recoveryCodes []string) ports.APIError {
+	return r.updateUser(ctx, idUser, func(data *memData, user *userRecord) ports.APIError {
+		user.MFASecret = secret
+		user.MFAEnabledAt = clonePtr(enabledAt)
+		user.RecoveryCodes = nil
+		if len(recoveryCodes) > 0 {
+			user.RecoveryCodes = slices.Clone(recoveryCodes)
+		}
+		return nil
+	})
+}
+
+// UseRecoveryCode removes the code from the user's recovery codes. It returns false if the user does not have it
+func (r *UserRepositoryMem) UseRecoveryCode(ctx context.Context, idUser string, codeHash string) (bool, ports.APIError) {
+	used := false
+	err := r.updateUser(ctx, idUser, func(data *memData, user *userRecord) ports.APIError {
+		index := slices.Index(user.RecoveryCodes, codeHash)
+		if index >= 0 {
+			user.RecoveryCodes = slices.Delete(slices.Clone(user.RecoveryCodes), index, index+1)
+			if len(user.RecoveryCodes) == 0 {
+				user.RecoveryCodes = nil
+			}
+			used = true
+		}
+		return nil
+	})
+	return used, err
+}
+
+func (u *userRecord) toDomainUser() *domain.User {
+	user := u.User
+	user.EmailVerifiedAt = clonePtr(u.EmailVerifiedAt)
+	user.MFAEnabledAt = clonePtr(u.MFAEnabledAt)
+	user.RecoveryCodes = slices.Clone(u.RecoveryCodes)
+	return &user
+}
@@ -0,0 +1,180 @@
+package repos_mem
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+func newUser(email string) *dtos.InternalUserCreate {
+	return &dtos.InternalUserCreate{FirstName: "John", FirstLastName: "Doe", Email: email, AuthMethod: domain.AuthMethPassword, HashedPassword: "hashedPassword"}
+}
+
+func TestUserRepositoryEmails(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(NewMemReposInfra())
+	idUser, err := repo.Create(ctx, newUser("john@mail.com"))
+	assert.Nil(t, err)
+	assert.Equal(t, idUser, repo.GetUserIdByEmail(ctx, "John@Mail.com"))
+
+	_, err = repo.Create(ctx, newUser("JOHN@mail.com"))
+	assert.Equal(t, http.StatusConflict, err.Status())
+	ids, errs := repo.CreateUsers(ctx, []*dtos.InternalUserCreate{newUser("ann@mail.com"), newUser("john@mail.com"), newUser("ANN@mail.com")})
+	assert.NotEmpty(t, ids[0])
+	assert.Equal(t, []string{ids[0], "", ""}, ids)
+	assert.Nil(t, errs[0])
+	assert.Equal(t, http.StatusConflict, errs[1].Status())
+	assert.Equal(t, http.StatusConflict, errs[2].Status())
+
+	assert.Equal(t, http.StatusConflict, repo.UpdateEmail(ctx, ids[0], "john@mail.com", now()).Status())
+	assert.Nil(t, repo.UpdateEmail(ctx, idUser, "JOHN@mail.com", now())) // its own
+	users, err := repo.GetUsersByEmails(ctx, []string{"john@mail.com", "ann@mail.com", "nobody@mail.com"})
+	assert.Nil(t, err)
+	assert.Len(t, users, 2)
+}
+
+func TestUserRepositorySoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(NewMemReposInfra())
+	idUser, _ := repo.Create(ctx, newUser("john@mail.com"))
+
+	assert.Nil(t, repo.DeleteUser(ctx, idUser))
+	_, err := repo.GetUserById(ctx, idUser)
+	assert.Equal(t, http.StatusNotFound, err.Status())
+	assert.Empty(t, repo.GetUserIdByEmail(ctx, "john@mail.com"))
+	assert.Equal(t, http.StatusNotFound, repo.UpdatePassword(ctx, idUser, "other").Status())
+	assert.Equal(t, http.StatusNotFound, repo.DeleteUser(ctx, idUser).Status())
+	user, err := repo.GetUserByIdUnscoped(ctx, idUser)
+	assert.Nil(t, err)
+	assert.Equal(t, "john@mail.com", user.Email)
+	_, err = repo.Create(ctx, newUser("john@mail.com"))
+	assert.Equal(t, http.StatusConflict, err.Status()) // the deleted user keeps it
+
+	assert.Nil(t, repo.RestoreUser(ctx, idUser))
+	assert.Equal(t, http.StatusNotFound, repo.RestoreUser(ctx, idUser).Status()) // not deleted
+	_, err = repo.GetUserById(ctx, idUser)
+	assert.Nil(t, err)
+
+	assert.Nil(t, repo.EraseUser(ctx, idUser))
+	_, err = repo.GetUserByIdUnscoped(ctx, idUser)
+	assert.Equal(t, http.StatusNotFound, err.Status())
+	_, err = repo.Create(ctx, newUser("john@mail.com"))
+	assert.Nil(t, err)
+}
+
+func TestUserRepositoryUpdates(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(NewMemReposInfra())
+	idUser, _ := repo.Create(ctx, newUser("john@mail.com"))
+
+	second := "Smith"
+	assert.Nil(t, repo.UpdateUser(ctx, idUser, &dtos.UserUpdate{SecondLastName: &second}))
+	first := now()
+	assert.Nil(t, repo.SetEmailVerified(ctx, idUser, first))
+	assert.Nil(t, repo.SetEmailVerified(ctx, idUser, first.Add(1)))
+	assert.Nil(t, repo.UpdateMFA(ctx, idUser, "secret", &first, []string{"a", "b"}))
+	used, err := repo.UseRecoveryCode(ctx, idUser, "a")
+	assert.Nil(t, err)
+	assert.True(t, used)
+	used, _ = repo.UseRecoveryCode(ctx, idUser, "a")
+	assert.False(t, used)
+
+	user, _ := repo.GetUserById(ctx, idUser)
+	assert.Equal(t, "Smith", user.SecondLastName)
+	assert.Equal(t, first, *user.EmailVerifiedAt) // the first one is kept
+	assert.Equal(t, []string{"b"}, user.RecoveryCodes)
+	user.RecoveryCodes[0] = "changed" // the caller gets a copy
+	user, _ = repo.GetUserById(ctx, idUser)
+	assert.Equal(t, []string{"b"}, user.RecoveryCodes)
+
+	identity := &domain.ExternalIdentity{Issuer: "https://issuer", Subject: "123", Email: "john@mail.com"}
+	assert.Nil(t, repo.LinkIdentity(ctx, idUser, identity))
+	assert.Equal(t, http.StatusConflict, repo.LinkIdentity(ctx, "other", identity).Status())
+	assert.Equal(t, idUser, repo.GetUserIdByIdentity(ctx, "https://issuer", "123"))
+}
+
+func TestUserRepositoryGetUsers(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(NewMemReposInfra())
+	for _, email := range []string{"b@mail.com", "e@mail.com", "a@mail.com", "d@mail.com", "c@mail.com"} {
+		repo.Create(ctx, newUser(email))
+	}
+	idDeleted, _ := repo.Create(ctx, newUser("f@mail.com"))
+	repo.DeleteUser(ctx, idDeleted)
+
+	var emails []string
+	query := dtos.ListQuery{Limit: 2, WithTotal: true, Sort: []dtos.ListSort{{Field: "email"}},
+		Filters: []dtos.ListFilter{{Field: "email", Op: dtos.ListOpContains, Value: "@MAIL"}}}
+	for {
+		users, result, err := repo.GetUsers(ctx, query)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5), *result.Total)
+		for _, user := range users {
+			emails = append(emails, user.Email[:1])
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, emails)
+
+	users, result, err := repo.GetUsers(ctx, dtos.ListQuery{Limit: 2, Page: 2, Sort: []dtos.ListSort{{Field: "email", Desc: true}}})
+	assert.Nil(t, err)
+	assert.Empty(t, result.NextCursor)
+	assert.Equal(t, []string{"c@mail.com", "b@mail.com"}, []string{users[0].Email, users[1].Email})
+
+	users, _, err = repo.GetUsers(ctx, dtos.ListQuery{Sort: []dtos.ListSort{{Field: "created_at", Desc: true}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "c@mail.com", users[0].Email) // the last created not deleted
+
+	_, _, err = repo.GetUsers(ctx, dtos.ListQuery{Cursor: "not a cursor"})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	_, _, err = repo.GetUsers(ctx, dtos.ListQuery{Sort: []dtos.ListSort{{Field: "name"}}}) // several fields
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	_, _, err = repo.GetUsers(ctx, dtos.ListQuery{Filters: []dtos.ListFilter{{Field: "created_at", Op: dtos.ListOpGte, Value: "yesterday"}}})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func TestUserRepositoryConcurrency(t *testing.T) {
+	ctx := context.Background()
+	memInfra := NewMemReposInfra()
+	repo := NewUserRepository(memInfra)
+	tx := NewTxManager(memInfra)
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.Create(ctx, newUser("same@mail.com")); err == nil {
+				created.Add(1)
+			}
+			repo.GetUsers(ctx, dtos.ListQuery{})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), created.Load())
+
+	for i := 0; i < 20; i++ { // the transactions do not mix with the calls out of them
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+				repo.Create(ctx, newUser(fmt.Sprintf("user%d@mail.com", i)))
+				return ports.NewAPIError(http.StatusConflict, "rolled back")
+			})
+		}()
+	}
+	wg.Wait()
+	_, result, _ := repo.GetUsers(ctx, dtos.ListQuery{WithTotal: true})
+	assert.Equal(t, int64(1), *result.Total)
+}
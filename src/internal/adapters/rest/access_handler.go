@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/pkg/netw"
+	"github.com/go-chi/chi/v5"
+)
+
+type AccessHandler struct {
+	permissions ports.PermissionService
+	logger      logger.LoggerService
+}
+
+func NewAccessHandler(permissions ports.PermissionService, logger logger.LoggerService) *AccessHandler {
+	return &AccessHandler{
+		permissions: permissions,
+		logger:      logger,
+	}
+}
+
+// @Summary Get the grants on a resource
+// @Description Users and groups given permissions on the resource. Only for those with the admin permission on it
+// @Tags Access
+// @Produce json
+// @Param 	resourceType path string true  "Type of the resource, e.g. document"
+// @Param 	resourceId path string true  "Id of the resource"
+// @Success 200 {array} dtos.Grant
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not allowed to manage the resource"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /access/resources/{resourceType}/{resourceId} [get]
+func (h *AccessHandler) GetResourceGrants(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	resourceType := chi.URLParam(r, "resourceType")
+	resourceID := chi.URLParam(r, "resourceId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	grants, errGrants := h.permissions.GetResourceGrants(ctx, byUser, resourceType, resourceID)
+	if errGrants != nil {
+		http.Error(w, errGrants.Error(), errGrants.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainGrants(grants)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Give a permission on a resource
+// @Description To a user (by id) or to a group (by name). Only for those with the admin permission on the resource
+// @Tags Access
+// @Accept  json
+// @Produce  json
+// @Param 	resourceType path string true  "Type of the resource, e.g. document"
+// @Param 	resourceId path string true  "Id of the resource"
+// @Param   grantData  body dtos.GrantCreate  true  "Who and which permission"
+// @Success 201 {object} dtos.Grant
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not allowed to manage the resource"
+// @Failure 404 "Group not found"
+// @Failure 409 "The permission was already given"
+// @Failure 500 "Error giving the permission"
+// @Security BearerAuth
+// @Router /access/resources/{resourceType}/{resourceId} [post]
+func (h *AccessHandler) GrantAccess(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.GrantCreate](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	resourceType := chi.URLParam(r, "resourceType")
+	resourceID := chi.URLParam(r, "resourceId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	grant, errGrant := h.permissions.GrantAccess(ctx, byUser, resourceType, resourceID, request)
+	if errGrant != nil {
+		http.Error(w, errGrant.Error(), errGrant.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusCreated, dtos.FromDomainGrant(grant)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Take away a permission on a resource
+// @Description Only for those with the admin permission on the resource
+// @Tags Access
+// @Param 	grantId path string true  "Id of the grant"
+// @Success 204 "Permission taken"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not allowed to manage the resource"
+// @Failure 404 "Grant not found"
+// @Failure 500 "Error taking the permission"
+// @Security BearerAuth
+// @Router /access/grants/{grantId} [delete]
+func (h *AccessHandler) RevokeAccess(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	idGrant := chi.URLParam(r, "grantId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if errRevoke := h.permissions.RevokeAccess(ctx, byUser, idGrant); errRevoke != nil {
+		http.Error(w, errRevoke.Error(), errRevoke.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
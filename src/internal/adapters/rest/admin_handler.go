@@ -0,0 +1,305 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/pkg/netw"
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminHandler struct {
+	permissions ports.PermissionService
+	logger      logger.LoggerService
+}
+
+func NewAdminHandler(permissions ports.PermissionService, logger logger.LoggerService) *AdminHandler {
+	return &AdminHandler{
+		permissions: permissions,
+		logger:      logger,
+	}
+}
+
+// @Summary Get all roles
+// @Description Roles that can be given to users, with their permissions. Only for administrators
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} dtos.Role
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /admin/roles [get]
+func (h *AdminHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	roles, errRoles := h.permissions.GetRoles(ctx, byUser)
+	if errRoles != nil {
+		http.Error(w, errRoles.Error(), errRoles.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainRoles(roles)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Create a custom role
+// @Description Adds a role with a set of permissions. Only for administrators
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param   roleData  body dtos.RoleCreate  true  "Name and permissions of the role"
+// @Success 201 {object} dtos.Role
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 409 "The role already exists"
+// @Failure 500 "Error creating the role"
+// @Security BearerAuth
+// @Router /admin/roles [post]
+func (h *AdminHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.RoleCreate](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	role, errCreate := h.permissions.CreateRole(ctx, byUser, request)
+	if errCreate != nil {
+		http.Error(w, errCreate.Error(), errCreate.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusCreated, dtos.FromDomainRole(role)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get the roles of a user
+// @Description Only for administrators
+// @Tags Admin
+// @Produce json
+// @Param 	userId path string true  "Id of the user"
+// @Success 200 {array} dtos.Role
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /admin/users/{userId}/roles [get]
+func (h *AdminHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	forUser := chi.URLParam(r, "userId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	roles, errRoles := h.permissions.GetUserRoles(ctx, forUser, byUser)
+	if errRoles != nil {
+		http.Error(w, errRoles.Error(), errRoles.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainRoles(roles)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Give a role to a user
+// @Description Giving a role the user already has is not an error. Only for administrators
+// @Tags Admin
+// @Accept  json
+// @Param 	userId path string true  "Id of the user"
+// @Param   grantData  body dtos.RoleGrant  true  "Role to give"
+// @Success 204 "Role given"
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 404 "User or role not found"
+// @Failure 500 "Error giving the role"
+// @Security BearerAuth
+// @Router /admin/users/{userId}/roles [post]
+func (h *AdminHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.RoleGrant](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	forUser := chi.URLParam(r, "userId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if errGrant := h.permissions.AssignRole(ctx, byUser, forUser, request.Role); errGrant != nil {
+		http.Error(w, errGrant.Error(), errGrant.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Take a role from a user
+// @Description Administrators can not take the admin permission from themselves. Only for administrators
+// @Tags Admin
+// @Param 	userId path string true  "Id of the user"
+// @Param 	roleName path string true  "Name of the role"
+// @Success 204 "Role taken"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 404 "Role not found"
+// @Failure 409 "Own admin permission"
+// @Failure 500 "Error taking the role"
+// @Security BearerAuth
+// @Router /admin/users/{userId}/roles/{roleName} [delete]
+func (h *AdminHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	forUser := chi.URLParam(r, "userId")
+	roleName := chi.URLParam(r, "roleName")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if errRevoke := h.permissions.RemoveRole(ctx, byUser, forUser, roleName); errRevoke != nil {
+		http.Error(w, errRevoke.Error(), errRevoke.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get all groups
+// @Description Groups of users that receive grants on resources together. Only for administrators
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} dtos.Group
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /admin/groups [get]
+func (h *AdminHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	groups, errGroups := h.permissions.GetGroups(ctx, byUser)
+	if errGroups != nil {
+		http.Error(w, errGroups.Error(), errGroups.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainGroups(groups)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Create a group
+// @Description Only for administrators
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param   groupData  body dtos.GroupCreate  true  "Name of the group"
+// @Success 201 {object} dtos.Group
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 409 "The group already exists"
+// @Failure 500 "Error creating the group"
+// @Security BearerAuth
+// @Router /admin/groups [post]
+func (h *AdminHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.GroupCreate](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	group, errCreate := h.permissions.CreateGroup(ctx, byUser, request)
+	if errCreate != nil {
+		http.Error(w, errCreate.Error(), errCreate.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusCreated, dtos.FromDomainGroup(group)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Add a user to a group
+// @Description Adding a user already in the group is not an error. Only for administrators
+// @Tags Admin
+// @Accept  json
+// @Param 	groupName path string true  "Name of the group"
+// @Param   memberData  body dtos.GroupMemberAdd  true  "User to add"
+// @Success 204 "User added"
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 404 "User or group not found"
+// @Failure 500 "Error adding the user"
+// @Security BearerAuth
+// @Router /admin/groups/{groupName}/members [post]
+func (h *AdminHandler) AddGroupMember(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.GroupMemberAdd](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	groupName := chi.URLParam(r, "groupName")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if errAdd := h.permissions.AddGroupMember(ctx, byUser, groupName, request.UserID); errAdd != nil {
+		http.Error(w, errAdd.Error(), errAdd.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Remove a user from a group
+// @Description Only for administrators
+// @Tags Admin
+// @Param 	groupName path string true  "Name of the group"
+// @Param 	userId path string true  "Id of the user"
+// @Success 204 "User removed"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 404 "Group not found"
+// @Failure 500 "Error removing the user"
+// @Security BearerAuth
+// @Router /admin/groups/{groupName}/members/{userId} [delete]
+func (h *AdminHandler) RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	groupName := chi.URLParam(r, "groupName")
+	forUser := chi.URLParam(r, "userId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if errRemove := h.permissions.RemoveGroupMember(ctx, byUser, groupName, forUser); errRemove != nil {
+		http.Error(w, errRemove.Error(), errRemove.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
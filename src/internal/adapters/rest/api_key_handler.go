@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/pkg/netw"
+	"github.com/go-chi/chi/v5"
+)
+
+type APIKeyHandler struct {
+	service ports.APIKeyService
+	logger  logger.LoggerService
+}
+
+func NewAPIKeyHandler(service ports.APIKeyService, logger logger.LoggerService) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// @Summary Create an API key
+// @Description Mints a key for machine clients acting on behalf of the user. The key is only returned now, keep it safe
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   keyData  body dtos.APIKeyCreate  true  "Name, scopes and duration of the key"
+// @Success 201 {object} dtos.CreatedAPIKey
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 409 "Too many API keys"
+// @Failure 500 "Error creating the key"
+// @Security BearerAuth
+// @Router /auth/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.APIKeyCreate](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	created, errCreate := h.service.CreateAPIKey(ctx, byUser, request)
+	if errCreate != nil {
+		http.Error(w, errCreate.Error(), errCreate.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusCreated, created); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get the API keys of the user
+// @Description Keys not revoked yet, expired ones included. The keys themselves are never returned
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} dtos.APIKey
+// @Failure 401 "Invalid token"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /auth/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	keys, errKeys := h.service.GetAPIKeys(ctx, byUser)
+	if errKeys != nil {
+		http.Error(w, errKeys.Error(), errKeys.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainAPIKeys(keys)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Revoke an API key
+// @Description The key stops working at once on this server, and within a minute on the others
+// @Tags Auth
+// @Param 	keyId path string true  "Id of the key"
+// @Success 204 "Key revoked"
+// @Failure 401 "Invalid token"
+// @Failure 404 "Key not found"
+// @Failure 500 "Error revoking the key"
+// @Security BearerAuth
+// @Router /auth/api-keys/{keyId} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	id := chi.URLParam(r, "keyId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if errRevoke := h.service.RevokeAPIKey(ctx, byUser, id); errRevoke != nil {
+		http.Error(w, errRevoke.Error(), errRevoke.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/pkg/netw"
+)
+
+type AuditHandler struct {
+	service ports.AuditService
+	logger  logger.LoggerService
+}
+
+func NewAuditHandler(service ports.AuditService, logger logger.LoggerService) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// @Summary Get the audit log
+// @Description Security relevant events, newest first. Only for administrators
+// @Tags Audit
+// @Produce json
+// @Param   type    query string  false  "Only events of this type, e.g. account_locked or ip_locked"
+// @Param   target  query string  false  "Only events on this target: an email, an IP address..."
+// @Param   since   query string  false  "Only events after this moment (RFC 3339)"
+// @Param   limit   query int     false  "Maximum number of events (100 by default, 1000 at most)"
+// @Success 200 {array} dtos.AuditEvent
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /audit [get]
+func (h *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	auditQuery := dtos.AuditQuery{Type: query.Get("type"), Target: query.Get("target")}
+	var err error
+	if since := query.Get("since"); since != "" {
+		if auditQuery.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if auditQuery.Limit, err = strconv.Atoi(limit); err != nil {
+			http.Error(w, "Invalid limit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	events, errEvents := h.service.GetEvents(ctx, byUser, auditQuery)
+	if errEvents != nil {
+		http.Error(w, errEvents.Error(), errEvents.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainAuditEvents(events)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
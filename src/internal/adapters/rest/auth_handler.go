@@ -3,6 +3,7 @@ package rest
 import (
 	"context"
 	"net/http"
+	"path"
 	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
@@ -11,6 +12,9 @@ import (
 	"github.com/Manolo-Esc/gommence/src/pkg/netw"
 )
 
+// Cookie that ties the OIDC sign in to the browser that started it
+const oidcStateCookie = "oidc_state"
+
 type AuthHandler struct {
 	service ports.AuthService
 	logger  logger.LoggerService
@@ -24,13 +28,16 @@ func NewAuthHandler(service ports.AuthService, logger logger.LoggerService) *Aut
 }
 
 // @Summary Sign in the system
-// @Description Receives login credentials and returns a token
+// @Description Receives login credentials and returns a token. Users with two-factor authentication get an MFA token instead, to be sent with the code to /auth/mfa/verify
 // @Tags Auth
 // @Accept  json
 // @Produce  json
 // @Param   loginData  body dtos.LoginCredentials  true  "Credentials"
 // @Success 200 {object} dtos.LoggedUser
 // @Failure 400 "Invalid data"
+// @Failure 401 "Invalid credentials"
+// @Failure 403 "Email not verified"
+// @Failure 429 "Too many failed attempts for the account or the address"
 // @Failure 500 "Error generating response or token"
 // @Router /auth/signin [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -43,13 +50,542 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
-	response, errLogin := h.service.Login(ctx, credentials)
+	response, errLogin := h.service.Login(ctx, credentials, netw.ClientIP(r))
+	if errLogin != nil {
+		http.Error(w, errLogin.Error(), errLogin.Status())
+		return
+	}
+
+	if err = netw.Encode(w, r, http.StatusOK, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Sign up in the system
+// @Description Creates a user with password authentication and returns a token for it. If verified emails are required, only the id of the user is returned, with no token until the email sent is followed
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   signUpData  body dtos.UserSignUp  true  "New user data"
+// @Success 201 {object} dtos.LoggedUser
+// @Failure 400 "Invalid data, weak password or user already exists"
+// @Failure 500 "Error generating response or token"
+// @Router /auth/signup [post]
+func (h *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
+	signUp, err := netw.Decode[dtos.UserSignUp](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second) // sending the email may take a while
+	defer cancel()
+
+	response, errSignUp := h.service.SignUp(ctx, signUp)
+	if errSignUp != nil {
+		http.Error(w, errSignUp.Error(), errSignUp.Status())
+		return
+	}
+
+	if err = netw.Encode(w, r, http.StatusCreated, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Refresh the access token
+// @Description Receives a refresh token and returns a new access token and a new refresh token. Each refresh token can be used only once
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   refreshData  body dtos.RefreshTokenRequest  true  "Refresh token"
+// @Success 200 {object} dtos.LoggedUser
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid, expired or reused refresh token"
+// @Failure 403 "Email not verified, when verified emails are required"
+// @Failure 500 "Error generating response or token"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.RefreshTokenRequest](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	response, errRefresh := h.service.Refresh(ctx, request)
+	if errRefresh != nil {
+		http.Error(w, errRefresh.Error(), errRefresh.Status())
+		return
+	}
+
+	if err = netw.Encode(w, r, http.StatusOK, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Act on behalf of an organization
+// @Description Starts a new session whose tokens carry the organization. The user must be a member of it
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   tenantData  body dtos.TenantSwitch  true  "Organization"
+// @Success 200 {object} dtos.LoggedUser
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not a member of the organization"
+// @Failure 500 "Error generating response or token"
+// @Security BearerAuth
+// @Router /auth/tenant [post]
+func (h *AuthHandler) SwitchTenant(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.TenantSwitch](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	response, errSwitch := h.service.SwitchTenant(ctx, byUser, request)
+	if errSwitch != nil {
+		http.Error(w, errSwitch.Error(), errSwitch.Status())
+		return
+	}
+
+	if err = netw.Encode(w, r, http.StatusOK, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Sign out of the system
+// @Description Revokes the access token used in the call and, if it is sent, the refresh token of the session
+// @Tags Auth
+// @Accept  json
+// @Param   signOutData  body dtos.SignOutRequest  false  "Session refresh token"
+// @Success 204 "Session closed"
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 500 "Error revoking the tokens"
+// @Security BearerAuth
+// @Router /auth/signout [post]
+func (h *AuthHandler) SignOut(w http.ResponseWriter, r *http.Request) {
+	var request dtos.SignOutRequest
+	if r.ContentLength != 0 { // the body is optional
+		var err error
+		if request, err = netw.Decode[dtos.SignOutRequest](r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ctx := r.Context()
+	claims, _ := netw.JwtGetClaims(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+
+	errSignOut := h.service.SignOut(ctx, claims.Subject, claims.ID, claims.ExpiresAt.Time, request)
+	if errSignOut != nil {
+		http.Error(w, errSignOut.Error(), errSignOut.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Request a password reset
+// @Description Sends an email with a link to set a new password. The answer is the same whether the email is registered or not
+// @Tags Auth
+// @Accept  json
+// @Param   resetData  body dtos.PasswordResetRequest  true  "Email of the account"
+// @Success 202 "Request accepted"
+// @Failure 400 "Invalid data"
+// @Failure 500 "Error creating the token"
+// @Router /auth/request-reset [post]
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.PasswordResetRequest](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second) // sending the email may take a while
+	defer cancel()
+
+	if errReset := h.service.RequestPasswordReset(ctx, request); errReset != nil {
+		http.Error(w, errReset.Error(), errReset.Status())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Confirm a password reset
+// @Description Sets a new password using the token received by email. Every session of the user is closed
+// @Tags Auth
+// @Accept  json
+// @Param   resetData  body dtos.PasswordResetConfirm  true  "Token and new password"
+// @Success 204 "Password changed"
+// @Failure 400 "Invalid data, weak password or invalid token"
+// @Failure 500 "Error changing the password"
+// @Router /auth/confirm-reset [post]
+func (h *AuthHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.PasswordResetConfirm](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
+	defer cancel()
+
+	if errReset := h.service.ConfirmPasswordReset(ctx, request); errReset != nil {
+		http.Error(w, errReset.Error(), errReset.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Request the email verification
+// @Description Sends again the email with the link to verify the address. The answer is the same whether the email is registered or not
+// @Tags Auth
+// @Accept  json
+// @Param   verificationData  body dtos.EmailVerificationRequest  true  "Email to verify"
+// @Success 202 "Request accepted"
+// @Failure 400 "Invalid data"
+// @Router /auth/resend-verification [post]
+func (h *AuthHandler) RequestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.EmailVerificationRequest](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second) // sending the email may take a while
+	defer cancel()
+
+	if errRequest := h.service.RequestEmailVerification(ctx, request); errRequest != nil {
+		http.Error(w, errRequest.Error(), errRequest.Status())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Verify an email
+// @Description Marks the email of the user as verified using the token received by email
+// @Tags Auth
+// @Accept  json
+// @Param   verifyData  body dtos.VerifyEmailRequest  true  "Token received by email"
+// @Success 204 "Email verified"
+// @Failure 400 "Invalid data or invalid token"
+// @Failure 500 "Error verifying the email"
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.VerifyEmailRequest](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
+	defer cancel()
+
+	if errVerify := h.service.VerifyEmail(ctx, request); errVerify != nil {
+		http.Error(w, errVerify.Error(), errVerify.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Change the password
+// @Description Sets a new password for the signed in user, who must send the current one. Every other session of the user is closed and the tokens of a new one are returned
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   passwordData  body dtos.PasswordChange  true  "Current and new password"
+// @Success 200 {object} dtos.LoggedUser
+// @Failure 400 "Invalid data, weak password or user of an external provider"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Invalid password"
+// @Failure 429 "Too many failed attempts for the account or the address"
+// @Failure 500 "Error changing the password"
+// @Security BearerAuth
+// @Router /auth/password [post]
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.PasswordChange](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second) // sending the email may take a while
+	defer cancel()
+
+	response, errChange := h.service.ChangePassword(ctx, byUser, request, netw.ClientIP(r))
+	if errChange != nil {
+		http.Error(w, errChange.Error(), errChange.Status())
+		return
+	}
+
+	if err = netw.Encode(w, r, http.StatusOK, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Request a change of email
+// @Description Sends a link to the new address. The email of the signed in user changes when the link is followed
+// @Tags Auth
+// @Accept  json
+// @Param   emailData  body dtos.EmailChangeRequest  true  "New email and current password"
+// @Success 202 "Request accepted"
+// @Failure 400 "Invalid data or user of an external provider"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Invalid password"
+// @Failure 409 "Email already registered"
+// @Failure 429 "Too many failed attempts for the account or the address"
+// @Failure 500 "Error requesting the change"
+// @Security BearerAuth
+// @Router /auth/email [post]
+func (h *AuthHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.EmailChangeRequest](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second) // sending the emails may take a while
+	defer cancel()
+
+	if errRequest := h.service.RequestEmailChange(ctx, byUser, request, netw.ClientIP(r)); errRequest != nil {
+		http.Error(w, errRequest.Error(), errRequest.Status())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Confirm a change of email
+// @Description Switches the user to the new email using the token received in it
+// @Tags Auth
+// @Accept  json
+// @Param   confirmData  body dtos.EmailChangeConfirm  true  "Token received by email"
+// @Success 204 "Email changed"
+// @Failure 400 "Invalid data or invalid token"
+// @Failure 409 "Email already registered"
+// @Failure 500 "Error changing the email"
+// @Router /auth/confirm-email [post]
+func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.EmailChangeConfirm](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
+	defer cancel()
+
+	if errConfirm := h.service.ConfirmEmailChange(ctx, request); errConfirm != nil {
+		http.Error(w, errConfirm.Error(), errConfirm.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Start the sign in with the OIDC provider
+// @Description Redirects the browser to the sign in page of the configured OIDC provider (authorization code flow with PKCE). The state of the sign in is set in a cookie the callback requires
+// @Tags Auth
+// @Success 302 "Redirection to the provider"
+// @Failure 404 "OIDC sign in is not configured"
+// @Failure 502 "The provider is not available"
+// @Router /auth/oidc/start [get]
+func (h *AuthHandler) OIDCStart(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second) // the provider metadata may have to be fetched
+	defer cancel()
+
+	start, errStart := h.service.StartOIDCLogin(ctx)
+	if errStart != nil {
+		http.Error(w, errStart.Error(), errStart.Status())
+		return
+	}
+	http.SetCookie(w, oidcCookie(r, start.State, int(time.Until(start.ExpiresAt).Seconds())))
+	http.Redirect(w, r, start.AuthURL, http.StatusFound)
+}
+
+// @Summary Complete the sign in with the OIDC provider
+// @Description The OIDC provider redirects the browser here after the user signs in. Returns a token for the user, who is created the first time
+// @Tags Auth
+// @Produce  json
+// @Param   code   query string true  "Authorization code"
+// @Param   state  query string true  "State of the sign in"
+// @Success 200 {object} dtos.LoggedUser
+// @Failure 400 "Invalid or expired state, or the sign in was started in another browser"
+// @Failure 401 "The provider did not confirm the sign in"
+// @Failure 403 "The provider has not verified the email"
+// @Failure 404 "OIDC sign in is not configured"
+// @Router /auth/oidc/callback [get]
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	callback := dtos.OIDCCallback{
+		Code:             query.Get("code"),
+		State:            query.Get("state"),
+		Error:            query.Get("error"),
+		ErrorDescription: query.Get("error_description"),
+	}
+	if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+		callback.BrowserState = cookie.Value
+	}
+	http.SetCookie(w, oidcCookie(r, "", -1))                       // used once, whatever the result
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second) // the provider is called to exchange the code
+	defer cancel()
+
+	response, errLogin := h.service.CompleteOIDCLogin(ctx, callback)
 	if errLogin != nil {
 		http.Error(w, errLogin.Error(), errLogin.Status())
 		return
 	}
+	if err := netw.Encode(w, r, http.StatusOK, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
 
+// oidcCookie is sent only to the OIDC endpoints, the folder of the start and the callback. A negative maxAge deletes it
+func oidcCookie(r *http.Request, state string, maxAge int) *http.Cookie {
+	return &http.Cookie{Name: oidcStateCookie, Value: state, Path: path.Dir(r.URL.Path), MaxAge: maxAge,
+		HttpOnly: true, Secure: true, SameSite: http.SameSiteLaxMode} // Lax, the provider sends the browser back with a top level GET
+}
+
+// @Summary Complete a sign in with two-factor authentication
+// @Description Exchanges the MFA token returned by the sign in and a code of the authenticator app, or a recovery code, for the tokens
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   verifyData  body dtos.MFAVerifyRequest  true  "MFA token and code"
+// @Success 200 {object} dtos.LoggedUser
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid code, or invalid or expired MFA token"
+// @Failure 429 "Too many wrong codes, try again later"
+// @Failure 500 "Error generating response or token"
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.MFAVerifyRequest](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
+	defer cancel()
+
+	response, errVerify := h.service.VerifyMFA(ctx, request, netw.ClientIP(r))
+	if errVerify != nil {
+		http.Error(w, errVerify.Error(), errVerify.Status())
+		return
+	}
+	if err = netw.Encode(w, r, http.StatusOK, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Start the two-factor authentication enrollment
+// @Description Generates the secret to add to the authenticator app. Nothing changes until the enrollment is confirmed with a code
+// @Tags Auth
+// @Produce  json
+// @Success 200 {object} dtos.MFAEnrollment
+// @Failure 401 "Invalid token"
+// @Failure 409 "MFA is already enabled"
+// @Security BearerAuth
+// @Router /auth/mfa/enroll [post]
+func (h *AuthHandler) StartMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
+	defer cancel()
+
+	response, errEnroll := h.service.StartMFAEnrollment(ctx, netw.JwtGetUserInToken(ctx))
+	if errEnroll != nil {
+		http.Error(w, errEnroll.Error(), errEnroll.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Confirm the two-factor authentication enrollment
+// @Description Enables two-factor authentication with a code of the authenticator app. Returns the recovery codes, which are shown only once
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   codeData  body dtos.MFACode  true  "Code of the authenticator app"
+// @Success 200 {object} dtos.MFARecoveryCodes
+// @Failure 400 "Invalid data, invalid code or enrollment not started"
+// @Failure 401 "Invalid token"
+// @Failure 409 "MFA is already enabled"
+// @Security BearerAuth
+// @Router /auth/mfa/confirm [post]
+func (h *AuthHandler) ConfirmMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.MFACode](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
+	defer cancel()
+
+	response, errConfirm := h.service.ConfirmMFAEnrollment(ctx, netw.JwtGetUserInToken(ctx), request)
+	if errConfirm != nil {
+		http.Error(w, errConfirm.Error(), errConfirm.Status())
+		return
+	}
 	if err = netw.Encode(w, r, http.StatusOK, response); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// @Summary Generate new recovery codes
+// @Description Replaces the recovery codes of the user. Requires a code of the authenticator app or one of the current recovery codes
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   codeData  body dtos.MFACode  true  "Code of the authenticator app or recovery code"
+// @Success 200 {object} dtos.MFARecoveryCodes
+// @Failure 400 "Invalid data, invalid code or MFA not enabled"
+// @Failure 401 "Invalid token"
+// @Security BearerAuth
+// @Router /auth/mfa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.MFACode](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
+	defer cancel()
+
+	response, errCodes := h.service.RegenerateRecoveryCodes(ctx, netw.JwtGetUserInToken(ctx), request)
+	if errCodes != nil {
+		http.Error(w, errCodes.Error(), errCodes.Status())
+		return
+	}
+	if err = netw.Encode(w, r, http.StatusOK, response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Disable two-factor authentication
+// @Description Removes the second factor of the user. Requires a code of the authenticator app or one of the recovery codes
+// @Tags Auth
+// @Accept  json
+// @Param   codeData  body dtos.MFACode  true  "Code of the authenticator app or recovery code"
+// @Success 204 "MFA disabled"
+// @Failure 400 "Invalid data, invalid code or MFA not enabled"
+// @Failure 401 "Invalid token"
+// @Security BearerAuth
+// @Router /auth/mfa/disable [post]
+func (h *AuthHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.MFACode](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
+	defer cancel()
+
+	if errDisable := h.service.DisableMFA(ctx, netw.JwtGetUserInToken(ctx), request); errDisable != nil {
+		http.Error(w, errDisable.Error(), errDisable.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,173 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/pkg/netw"
+	"github.com/go-chi/chi/v5"
+)
+
+type OrganizationHandler struct {
+	service ports.OrganizationService
+	logger  logger.LoggerService
+}
+
+func NewOrganizationHandler(service ports.OrganizationService, logger logger.LoggerService) *OrganizationHandler {
+	return &OrganizationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// @Summary Create an organization
+// @Description The user creating it becomes its owner. Use /auth/tenant to act on behalf of it
+// @Tags Organizations
+// @Accept  json
+// @Produce  json
+// @Param   organizationData  body dtos.OrganizationCreate  true  "Name of the organization"
+// @Success 201 {object} dtos.Organization
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 500 "Error creating the organization"
+// @Security BearerAuth
+// @Router /orgs [post]
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.OrganizationCreate](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	organization, errCreate := h.service.CreateOrganization(ctx, byUser, request)
+	if errCreate != nil {
+		http.Error(w, errCreate.Error(), errCreate.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusCreated, dtos.FromDomainOrganization(organization)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get the organizations of the user
+// @Tags Organizations
+// @Produce json
+// @Success 200 {array} dtos.Organization
+// @Failure 401 "Invalid token"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /orgs [get]
+func (h *OrganizationHandler) GetMyOrganizations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	organizations, errGet := h.service.GetUserOrganizations(ctx, byUser, byUser)
+	if errGet != nil {
+		http.Error(w, errGet.Error(), errGet.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainOrganizations(organizations)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get the members of the current organization
+// @Description The organization is the one the token acts on behalf of
+// @Tags Organizations
+// @Produce json
+// @Success 200 {array} dtos.Member
+// @Failure 400 "No organization selected"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not a member of the organization"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /orgs/current/members [get]
+func (h *OrganizationHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	members, errGet := h.service.GetMembers(ctx, byUser)
+	if errGet != nil {
+		http.Error(w, errGet.Error(), errGet.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainMembers(members)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Add a member to the current organization
+// @Description Also changes the role of a user already in it. Only for owners and admins of the organization
+// @Tags Organizations
+// @Accept  json
+// @Param   memberData  body dtos.MemberAdd  true  "User and role"
+// @Success 204 "Member added"
+// @Failure 400 "Invalid data or no organization selected"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an owner or admin of the organization"
+// @Failure 404 "User not found"
+// @Failure 409 "The user is the owner"
+// @Failure 500 "Error adding the member"
+// @Security BearerAuth
+// @Router /orgs/current/members [post]
+func (h *OrganizationHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.MemberAdd](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if errAdd := h.service.AddMember(ctx, byUser, request); errAdd != nil {
+		http.Error(w, errAdd.Error(), errAdd.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Remove a member from the current organization
+// @Description Owners and admins can remove anyone but the owner. Members can only leave
+// @Tags Organizations
+// @Param 	userId path string true  "Id of the user"
+// @Success 204 "Member removed"
+// @Failure 400 "No organization selected"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not allowed"
+// @Failure 404 "Membership not found"
+// @Failure 409 "The user is the owner"
+// @Failure 500 "Error removing the member"
+// @Security BearerAuth
+// @Router /orgs/current/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	idUser := chi.URLParam(r, "userId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if errRemove := h.service.RemoveMember(ctx, byUser, idUser); errRemove != nil {
+		http.Error(w, errRemove.Error(), errRemove.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
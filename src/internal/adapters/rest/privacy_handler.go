@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/pkg/netw"
+	"github.com/go-chi/chi/v5"
+)
+
+type PrivacyHandler struct {
+	service ports.PrivacyService
+	logger  logger.LoggerService
+}
+
+func NewPrivacyHandler(service ports.PrivacyService, logger logger.LoggerService) *PrivacyHandler {
+	return &PrivacyHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// @Summary Export the data of the signed in user
+// @Description Everything stored about the user, as a JSON file with a section per module (GDPR right of access)
+// @Tags Users
+// @Produce json
+// @Success 200 {object} dtos.UserDataExport
+// @Failure 401 "Invalid token"
+// @Failure 404 "User not found"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /user/me/export [get]
+func (h *PrivacyHandler) ExportMe(w http.ResponseWriter, r *http.Request) {
+	h.export(w, r, netw.JwtGetUserInToken(r.Context()))
+}
+
+// @Summary Export the data of a user
+// @Description Everything stored about the user, deleted users included, as a JSON file with a section per module. Users can export themselves, exporting others is only for administrators
+// @Tags Users
+// @Produce json
+// @Param 	userId path string true  "Id of the user"
+// @Success 200 {object} dtos.UserDataExport
+// @Failure 401 "Invalid token"
+// @Failure 403 "Another user, not being an administrator"
+// @Failure 404 "User not found"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /user/{userId}/export [get]
+func (h *PrivacyHandler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	h.export(w, r, chi.URLParam(r, "userId"))
+}
+
+func (h *PrivacyHandler) export(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second) // it reads from every module
+	defer cancel()
+
+	export, err := h.service.ExportUserData(ctx, id, byUser)
+	if err != nil {
+		http.Error(w, err.Error(), err.Status())
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"user-data-%s.json\"", export.UserID))
+	if err := netw.Encode(w, r, http.StatusOK, export); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Erase a user
+// @Description Deletes for good the user and everything stored about it, deleted users included (GDPR right to erasure). The audit log keeps the events, without the user. It can not be undone. Users can erase themselves, erasing others is only for administrators
+// @Tags Users
+// @Param 	userId path string true  "Id of the user"
+// @Success 204 "User erased"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Another user, not being an administrator"
+// @Failure 404 "User not found"
+// @Failure 500 "Error erasing the user"
+// @Security BearerAuth
+// @Router /user/{userId}/erase [post]
+func (h *PrivacyHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	id := chi.URLParam(r, "userId")
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := h.service.EraseUser(ctx, id, byUser); err != nil {
+		http.Error(w, err.Error(), err.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,14 +2,19 @@ package rest
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/user_file"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
 	"github.com/Manolo-Esc/gommence/src/pkg/netw"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 type UserHandler struct {
@@ -24,28 +29,54 @@ func NewUserHandler(service ports.UserService, logger logger.LoggerService) *Use
 	}
 }
 
-// @Summary Get all Users
-// @Description Get all Users in the system
+// Fields of GET /user that can be sorted and filtered
+var userListSpec = netw.ListSpec{
+	Sort: []string{"created_at", "email", "first_name", "last_name"},
+	Filters: map[string][]dtos.ListOp{
+		"email":      {dtos.ListOpEq, dtos.ListOpPrefix},
+		"name":       {dtos.ListOpContains},
+		"created_at": {dtos.ListOpGte, dtos.ListOpLte},
+	},
+}
+
+// @Summary Get the Users
+// @Description Get a page of the Users in the system, oldest first unless sorted otherwise. Follow next_cursor to get the next page
 // @Tags Users
 // @Produce json
-// @Success 200 {array} dtos.User
+// @Param   limit              query int     false  "Users per page (20 by default, 100 at most)"
+// @Param   cursor             query string  false  "next_cursor of the previous page"
+// @Param   page               query int     false  "Number of the page, starting at 1, instead of the cursor"
+// @Param   sort               query string  false  "Fields separated by commas, a leading - sorts descending: created_at, email, first_name, last_name"
+// @Param   total              query bool    false  "Count the users matching the filters"
+// @Param   email              query string  false  "Only the user with this email"
+// @Param   email[prefix]      query string  false  "Only users whose email starts with this, case insensitive"
+// @Param   name[contains]     query string  false  "Only users whose first or last names contain this, case insensitive"
+// @Param   created_at[gte]    query string  false  "Only users created at or after this moment (RFC 3339 or YYYY-MM-DD)"
+// @Param   created_at[lte]    query string  false  "Only users created at or before this moment (RFC 3339 or YYYY-MM-DD)"
+// @Success 200 {object} dtos.UserList
 // @Failure 400 "Invalid data"
+// @Failure 403 "Without the read permission"
 // @Failure 500 "Error generating response"
 // @Router /user/ [get]
 func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	query, errQuery := netw.ParseListQuery(r, userListSpec)
+	if errQuery != nil {
+		http.Error(w, errQuery.Error(), errQuery.Status())
+		return
+	}
 	ctx := r.Context()
 	byUser := netw.JwtGetUserInToken(ctx)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second) // set a timeout for the request
 	defer cancel()
 
-	response, errLogin := h.service.GetUsers(ctx, byUser)
+	response, result, errLogin := h.service.GetUsers(ctx, byUser, query)
 	if errLogin != nil {
 		http.Error(w, errLogin.Error(), errLogin.Status())
 		return
 	}
 
-	dtosResponse := dtos.FromDomainUsers(response)
+	dtosResponse := dtos.FromDomainUserList(response, result)
 
 	if err := netw.Encode(w, r, http.StatusOK, dtosResponse); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -59,6 +90,7 @@ func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 // @Param 	userId path string true  "ID del usuario"
 // @Success 200 {object} dtos.User
 // @Failure 400 "Invalid data"
+// @Failure 403 "Another user, without the read permission"
 // @Failure 500 "Error generating response or token"
 // @Router /user/{userId} [get]
 func (h *UserHandler) GetUserById(w http.ResponseWriter, r *http.Request) {
@@ -81,3 +113,247 @@ func (h *UserHandler) GetUserById(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// @Summary Get the signed in user
+// @Tags Users
+// @Produce json
+// @Success 200 {object} dtos.User
+// @Failure 401 "Invalid token"
+// @Failure 404 "User not found"
+// @Failure 500 "Error generating response"
+// @Security BearerAuth
+// @Router /user/me [get]
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second) // set a timeout for the request
+	defer cancel()
+
+	response, err := h.service.GetUserById(ctx, byUser, byUser)
+	if err != nil {
+		http.Error(w, err.Error(), err.Status())
+		return
+	}
+
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainUser(response)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Create a user
+// @Description The user signs in with the password. Only for administrators
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param   userData  body dtos.UserCreate  true  "Data of the user"
+// @Success 201 {object} dtos.User
+// @Failure 400 "Invalid data, weak password or the user already exists"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 500 "Error creating the user"
+// @Security BearerAuth
+// @Router /user/ [post]
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.UserCreate](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second) // set a timeout for the request
+	defer cancel()
+
+	response, errCreate := h.service.AddUser(ctx, byUser, request)
+	if errCreate != nil {
+		http.Error(w, errCreate.Error(), errCreate.Status())
+		return
+	}
+
+	if err := netw.Encode(w, r, http.StatusCreated, dtos.FromDomainUser(response)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Update the profile of a user
+// @Description Only the fields sent are changed. Users can update their own profile, the profile of others needs the write permission
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param 	userId path string true  "Id of the user"
+// @Param   userData  body dtos.UserUpdate  true  "Fields to change"
+// @Success 200 {object} dtos.User
+// @Failure 400 "Invalid data"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Another user, without the write permission"
+// @Failure 404 "User not found"
+// @Failure 500 "Error updating the user"
+// @Security BearerAuth
+// @Router /user/{userId} [patch]
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	request, err := netw.Decode[dtos.UserUpdate](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	id := chi.URLParam(r, "userId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second) // set a timeout for the request
+	defer cancel()
+
+	response, errUpdate := h.service.UpdateUser(ctx, id, byUser, request)
+	if errUpdate != nil {
+		http.Error(w, errUpdate.Error(), errUpdate.Status())
+		return
+	}
+
+	if err := netw.Encode(w, r, http.StatusOK, dtos.FromDomainUser(response)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Delete a user
+// @Description The user is hidden and can no longer sign in, but it can be restored. Users can delete themselves, deleting others needs the delete permission
+// @Tags Users
+// @Param 	userId path string true  "Id of the user"
+// @Success 204 "User deleted"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Another user, without the delete permission"
+// @Failure 404 "User not found"
+// @Failure 500 "Error deleting the user"
+// @Security BearerAuth
+// @Router /user/{userId} [delete]
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	id := chi.URLParam(r, "userId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second) // set a timeout for the request
+	defer cancel()
+
+	if err := h.service.DeleteUser(ctx, id, byUser); err != nil {
+		http.Error(w, err.Error(), err.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Restore a deleted user
+// @Description Only for users with the delete permission
+// @Tags Users
+// @Param 	userId path string true  "Id of the user"
+// @Success 204 "User restored"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Without the delete permission"
+// @Failure 404 "Deleted user not found"
+// @Failure 500 "Error restoring the user"
+// @Security BearerAuth
+// @Router /user/{userId}/restore [post]
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	byUser := netw.JwtGetUserInToken(ctx)
+	id := chi.URLParam(r, "userId")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second) // set a timeout for the request
+	defer cancel()
+
+	if err := h.service.RestoreUser(ctx, id, byUser); err != nil {
+		http.Error(w, err.Error(), err.Status())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Largest file accepted by the import. Bigger loads can be imported with the command line
+const maxImportSize = 64 << 20
+
+// @Summary Import users
+// @Description Creates the users of a CSV or JSON Lines file, or updates them if the email is already registered. The CSV files need a header naming the columns: email, first_name, last_name, second_last_name, password. Users without password can not sign in until they reset it. Every line is reported, the wrong ones do not stop the import. Only for administrators
+// @Tags Users
+// @Accept  text/csv
+// @Accept  application/jsonl
+// @Produce json
+// @Param   format   query string  false  "csv or jsonl. If not set, taken from the Content-Type"
+// @Param   dry_run  query bool    false  "Check the file and report what would be done, without storing anything"
+// @Success 200 {object} dtos.UserImportReport
+// @Failure 400 "Unreadable file"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 500 "Error importing the users"
+// @Security BearerAuth
+// @Router /admin/users/import [post]
+func (h *UserHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	formatName := r.URL.Query().Get("format")
+	if formatName == "" {
+		formatName, _, _ = strings.Cut(r.Header.Get("Content-Type"), ";")
+	}
+	format, err := user_file.ParseFormat(strings.TrimSpace(formatName))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	rows, err := user_file.NewReader(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute) // the passwords take a while to hash
+	defer cancel()
+
+	byUser := netw.JwtGetUserInToken(ctx)
+	report, errImport := h.service.ImportUsers(ctx, byUser, rows, dryRun)
+	if errImport != nil {
+		http.Error(w, errImport.Error(), errImport.Status())
+		return
+	}
+	if err := netw.Encode(w, r, http.StatusOK, report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Export users
+// @Description Every user, oldest first, as a CSV or JSON Lines file that can be imported again. Only for administrators
+// @Tags Users
+// @Produce text/csv
+// @Produce application/jsonl
+// @Param   format   query string  false  "csv (by default) or jsonl"
+// @Success 200 "The file"
+// @Failure 400 "Unknown format"
+// @Failure 401 "Invalid token"
+// @Failure 403 "Not an administrator"
+// @Failure 500 "Error exporting the users"
+// @Security BearerAuth
+// @Router /admin/users/export [get]
+func (h *UserHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	format := user_file.FormatCSV
+	if formatName := r.URL.Query().Get("format"); formatName != "" {
+		var err error
+		if format, err = user_file.ParseFormat(formatName); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	byUser := netw.JwtGetUserInToken(ctx)
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor) // to know whether the status has been sent
+	ww.Header().Set("Content-Type", format.ContentType())
+	ww.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"users.%s\"", format))
+	if err := h.service.ExportUsers(ctx, byUser, user_file.NewWriter(ww, format)); err != nil {
+		if ww.Status() == 0 { // nothing sent yet, e.g. not an administrator
+			ww.Header().Del("Content-Disposition")
+			http.Error(ww, err.Error(), err.Status())
+			return
+		}
+		// the status has been sent with the first users, the file is cut short
+		h.logger.Info(fmt.Sprintf("Error exporting the users: %s", err.Error()))
+	}
+}
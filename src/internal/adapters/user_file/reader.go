@@ -0,0 +1,117 @@
+package user_file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+// Longest line of a JSON Lines file
+const maxLineSize = 1024 * 1024
+
+// NewReader reads the users from the file as they are needed, so files of any size can be imported
+func NewReader(r io.Reader, format Format) (ports.UserRowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+		return &jsonlReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %s", format)
+	}
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int // position of each known column
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // byte order mark, as written by some spreadsheets
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"email", "first_name", "last_name"} {
+		if _, found := columns[required]; !found {
+			return nil, fmt.Errorf("the header has no %s column", required)
+		}
+	}
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvReader) Next() (*dtos.UserImportRow, error) {
+	record, err := c.reader.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &dtos.UserImportRow{Line: parseErr.StartLine, Problem: parseErr.Err.Error()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	line, _ := c.reader.FieldPos(0)
+	return &dtos.UserImportRow{
+		Line:           line,
+		Email:          c.field(record, "email"),
+		FirstName:      c.field(record, "first_name"),
+		FirstLastName:  c.field(record, "last_name"),
+		SecondLastName: c.field(record, "second_last_name"),
+		Password:       c.field(record, "password"),
+	}, nil
+}
+
+func (c *csvReader) field(record []string, name string) string {
+	if i, found := c.columns[name]; found && i < len(record) {
+		return strings.TrimSpace(record[i])
+	}
+	return ""
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (j *jsonlReader) Next() (*dtos.UserImportRow, error) {
+	for j.scanner.Scan() {
+		j.line++
+		data := bytes.TrimSpace(j.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		row := &dtos.UserImportRow{}
+		if err := json.Unmarshal(data, row); err != nil {
+			return &dtos.UserImportRow{Line: j.line, Problem: "invalid JSON: " + err.Error()}, nil
+		}
+		row.Line = j.line
+		return row, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
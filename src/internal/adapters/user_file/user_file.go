@@ -0,0 +1,43 @@
+package user_file
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Formats of the files to import and export users
+type Format string
+
+const (
+	FormatCSV   Format = "csv"   // With a header line naming the columns: email, first_name, last_name, second_last_name, password
+	FormatJSONL Format = "jsonl" // JSON Lines: one JSON object per line, with the same names
+)
+
+// ParseFormat accepts the names of the formats and some common aliases
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "csv", "text/csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson", "application/jsonl", "application/x-ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unknown format %s, use csv or jsonl", name)
+	}
+}
+
+// FormatOfFile guesses the format from the extension of the file, csv if unknown
+func FormatOfFile(path string) Format {
+	if format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(path), ".")); err == nil {
+		return format
+	}
+	return FormatCSV
+}
+
+// ContentType is the media type of the files of the format
+func (f Format) ContentType() string {
+	if f == FormatJSONL {
+		return "application/jsonl"
+	}
+	return "text/csv"
+}
@@ -0,0 +1,98 @@
+package user_file
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, rows ports.UserRowReader) []*dtos.UserImportRow {
+	var all []*dtos.UserImportRow
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			return all
+		}
+		assert.Nil(t, err)
+		all = append(all, row)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	file := "\ufeffEmail, first_name,last_name,password\n" +
+		"john@mail.com,John,Doe,\n" +
+		"jane@mail.com,Jane,\"Roe, Smith\",a long password\n" +
+		"broken@mail.com,Broken\n"
+	reader, err := NewReader(strings.NewReader(file), FormatCSV)
+	assert.Nil(t, err)
+	rows := readAll(t, reader)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, &dtos.UserImportRow{Line: 2, Email: "john@mail.com", FirstName: "John", FirstLastName: "Doe"}, rows[0])
+	assert.Equal(t, "Roe, Smith", rows[1].FirstLastName)
+	assert.Equal(t, "a long password", rows[1].Password)
+	assert.Equal(t, 4, rows[2].Line)
+	assert.NotEmpty(t, rows[2].Problem) // too few fields, the lines after it are still read
+
+	_, err = NewReader(strings.NewReader("email,first_name\n"), FormatCSV)
+	assert.NotNil(t, err) // without last_name
+	_, err = NewReader(strings.NewReader(""), FormatCSV)
+	assert.NotNil(t, err)
+}
+
+func TestReadJSONL(t *testing.T) {
+	file := `{"email": "john@mail.com", "first_name": "John", "last_name": "Doe"}
+
+{"email": "jane@mail.com", "first_name": "Jane", "last_name": "Roe", "unknown": 1}
+not json
+`
+	reader, err := NewReader(strings.NewReader(file), FormatJSONL)
+	assert.Nil(t, err)
+	rows := readAll(t, reader)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, &dtos.UserImportRow{Line: 1, Email: "john@mail.com", FirstName: "John", FirstLastName: "Doe"}, rows[0])
+	assert.Equal(t, 3, rows[1].Line) // blank lines are skipped, but counted
+	assert.Equal(t, "jane@mail.com", rows[1].Email)
+	assert.Equal(t, 4, rows[2].Line)
+	assert.Contains(t, rows[2].Problem, "invalid JSON")
+}
+
+func TestExportCanBeImported(t *testing.T) {
+	users := []*dtos.User{
+		{ID: "1", Email: "john@mail.com", FirstName: "John", FirstLastName: "Doe", EmailVerified: true},
+		{ID: "2", Email: "jane@mail.com", FirstName: "Jane", FirstLastName: "Roe", SecondLastName: "Smith"},
+	}
+	for _, format := range []Format{FormatCSV, FormatJSONL} {
+		var file bytes.Buffer
+		writer := NewWriter(&file, format)
+		for _, user := range users {
+			assert.Nil(t, writer.Write(user))
+		}
+		assert.Nil(t, writer.Flush())
+		reader, err := NewReader(&file, format)
+		assert.Nil(t, err)
+		rows := readAll(t, reader)
+		assert.Len(t, rows, 2, format)
+		assert.Equal(t, "jane@mail.com", rows[1].Email, format)
+		assert.Equal(t, "Smith", rows[1].SecondLastName, format)
+		assert.Empty(t, rows[1].Problem, format)
+	}
+
+	var empty bytes.Buffer
+	assert.Nil(t, NewWriter(&empty, FormatCSV).Flush())
+	assert.Equal(t, strings.Join(csvColumns, ",")+"\n", empty.String()) // still a valid file
+}
+
+func TestFormats(t *testing.T) {
+	format, err := ParseFormat("application/x-ndjson")
+	assert.Nil(t, err)
+	assert.Equal(t, FormatJSONL, format)
+	_, err = ParseFormat("xml")
+	assert.NotNil(t, err)
+	assert.Equal(t, FormatJSONL, FormatOfFile("users.JSONL"))
+	assert.Equal(t, FormatCSV, FormatOfFile("users.txt"))
+}
@@ -0,0 +1,65 @@
+package user_file
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+// Columns of the exported CSV files. The files can be imported again: the columns the import does not know are ignored
+var csvColumns = []string{"id", "email", "first_name", "last_name", "second_last_name", "email_verified", "mfa_enabled"}
+
+// NewWriter writes the users as they are given, any unknown format is written as CSV
+func NewWriter(w io.Writer, format Format) ports.UserRowWriter {
+	if format == FormatJSONL {
+		buffered := bufio.NewWriter(w)
+		return &jsonlWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}
+	}
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+type csvWriter struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(user *dtos.User) error {
+	if !c.wroteHeader {
+		if err := c.writer.Write(csvColumns); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+	return c.writer.Write([]string{user.ID, user.Email, user.FirstName, user.FirstLastName, user.SecondLastName,
+		strconv.FormatBool(user.EmailVerified), strconv.FormatBool(user.MFAEnabled)})
+}
+
+// Flush writes the header too if there were no users, so the file is still valid
+func (c *csvWriter) Flush() error {
+	if !c.wroteHeader {
+		if err := c.writer.Write(csvColumns); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (j *jsonlWriter) Write(user *dtos.User) error {
+	return j.encoder.Encode(user) // it ends every object with a new line
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.buffered.Flush()
+}
@@ -1,6 +1,9 @@
 package app
 
 import (
+	"context"
+	"time"
+
 	"github.com/Manolo-Esc/gommence/src/internal/mocks"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/cache"
@@ -10,15 +13,47 @@ import (
 
 // Some dependencies that most business modules will need
 type ServiceInfra struct {
-	Logger      logger.LoggerService
-	Cache       cache.CacheService
-	Permissions ports.PermissionService
+	Logger        logger.LoggerService
+	Cache         cache.CacheService
+	Permissions   ports.PermissionService
+	Organizations ports.OrganizationService
+	Tx            ports.TxManager
 }
 
 func mockServiceInfra(ctrl *gomock.Controller) *ServiceInfra {
 	return &ServiceInfra{
-		Logger:      logger.GetNopLogger(),
-		Cache:       cache.NewCache(),
-		Permissions: mocks.NewMockPermissionService(ctrl),
+		Logger:        logger.GetNopLogger(),
+		Cache:         newSyncCache(),
+		Permissions:   mocks.NewMockPermissionService(ctrl),
+		Organizations: mocks.NewMockOrganizationService(ctrl),
+		Tx:            passThroughTx(ctrl),
 	}
 }
+
+// passThroughTx runs the functions with the same context, so the expectations on it still match
+func passThroughTx(ctrl *gomock.Controller) ports.TxManager {
+	tx := mocks.NewMockTxManager(ctrl)
+	tx.EXPECT().WithinTx(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) ports.APIError) ports.APIError {
+		return fn(ctx)
+	}).AnyTimes()
+	return tx
+}
+
+// syncCache applies every write before returning, so the tests can count on what was cached
+type syncCache struct {
+	cache.CacheService
+}
+
+func newSyncCache() cache.CacheService {
+	return syncCache{cache.NewCache()}
+}
+
+func (c syncCache) Set(key string, value interface{}) bool {
+	defer c.Wait()
+	return c.CacheService.Set(key, value)
+}
+
+func (c syncCache) SetWithTTL(key string, value interface{}, ttl time.Duration) bool {
+	defer c.Wait()
+	return c.CacheService.SetWithTTL(key, value, ttl)
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+// API keys start with this, so they are easy to spot in logs and by secret scanners
+const apiKeyPrefix = "gmk_"
+
+// Characters of the key stored in clear to tell the keys apart
+const apiKeyShownChars = 12
+
+// A user can not have more active keys
+const maxAPIKeysPerUser = 25
+
+// How long an authenticated key is kept in the cache. It is also how often its last use is recorded
+const apiKeyCacheDuration = time.Minute
+
+type APIKeyServiceImpl struct {
+	repo  ports.APIKeyRepository
+	audit ports.AuditService
+	si    *ServiceInfra
+}
+
+func NewAPIKeyService(repo ports.APIKeyRepository, audit ports.AuditService, serviceInfra *ServiceInfra) ports.APIKeyService {
+	return &APIKeyServiceImpl{repo: repo, audit: audit, si: serviceInfra}
+}
+
+// CreateAPIKey mints a new key for the user. The returned key can not be retrieved again
+func (s *APIKeyServiceImpl) CreateAPIKey(ctx context.Context, byUser string, request dtos.APIKeyCreate) (*dtos.CreatedAPIKey, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	keys, err := s.repo.GetUserAPIKeys(ctx, byUser)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) >= maxAPIKeysPerUser {
+		return nil, ports.NewAPIError(http.StatusConflict, "Too many API keys, revoke some first")
+	}
+	secret, errSecret := opaque_token.New()
+	if errSecret != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, "Error generating the API key")
+	}
+	key := apiKeyPrefix + secret
+	scopes := slices.Clone(request.Scopes)
+	slices.Sort(scopes)
+	now := time.Now()
+	apiKey := &domain.APIKey{
+		UserID:    byUser,
+		Name:      request.Name,
+		Prefix:    key[:apiKeyShownChars],
+		KeyHash:   opaque_token.Hash(key),
+		Scopes:    slices.Compact(scopes),
+		ExpiresAt: now.AddDate(0, 0, request.ExpiresInDays),
+		CreatedAt: now,
+	}
+	if apiKey.ID, err = s.repo.CreateAPIKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditAPIKeyCreated, ActorID: byUser, Target: apiKey.ID, Details: apiKey.Name})
+	return &dtos.CreatedAPIKey{APIKey: *dtos.FromDomainAPIKey(apiKey), Key: key}, nil
+}
+
+// GetAPIKeys returns the keys of the user not revoked yet
+func (s *APIKeyServiceImpl) GetAPIKeys(ctx context.Context, byUser string) ([]*domain.APIKey, ports.APIError) {
+	return s.repo.GetUserAPIKeys(ctx, byUser)
+}
+
+// RevokeAPIKey stops a key of the user from working
+func (s *APIKeyServiceImpl) RevokeAPIKey(ctx context.Context, byUser string, idKey string) ports.APIError {
+	keys, err := s.repo.GetUserAPIKeys(ctx, byUser)
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(keys, func(key *domain.APIKey) bool { return key.ID == idKey })
+	if index < 0 { // other users' keys are not found either
+		return ports.NewAPIError(http.StatusNotFound, "API key not found")
+	}
+	if err := s.repo.RevokeAPIKey(ctx, idKey); err != nil {
+		return err
+	}
+	s.si.Cache.Del(apiKeyCacheKey(keys[index].KeyHash))
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditAPIKeyRevoked, ActorID: byUser, Target: idKey, Details: keys[index].Name})
+	return nil
+}
+
+// AuthenticateAPIKey is used by the middleware for every request carrying an API key
+func (s *APIKeyServiceImpl) AuthenticateAPIKey(ctx context.Context, key string) (*jwt.Claims, bool) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, false
+	}
+	keyHash := opaque_token.Hash(key)
+	cacheKey := apiKeyCacheKey(keyHash)
+	var apiKey *domain.APIKey
+	if cached, found := s.si.Cache.Get(cacheKey); found {
+		apiKey, _ = cached.(*domain.APIKey)
+	}
+	if apiKey == nil {
+		loaded, err := s.repo.GetAPIKeyByHash(ctx, keyHash)
+		if err != nil {
+			return nil, false
+		}
+		apiKey = loaded
+		if now := time.Now(); apiKey.IsActive(now) {
+			if err := s.repo.TouchAPIKey(ctx, apiKey.ID, now); err != nil {
+				s.si.Logger.Info(fmt.Sprintf("Error recording the use of API key %s: %s", apiKey.ID, err.Error()))
+			}
+			s.si.Cache.SetWithTTL(cacheKey, apiKey, apiKeyCacheDuration)
+		}
+	}
+	if !apiKey.IsActive(time.Now()) {
+		return nil, false
+	}
+	claims := jwt.APIKeyClaims(apiKey.UserID, apiKey.Scopes, apiKey.ExpiresAt)
+	return &claims, true
+}
+
+func apiKeyCacheKey(keyHash string) string {
+	return "auth.apikey." + keyHash
+}
+
+func (s *APIKeyServiceImpl) ExportUserData(ctx context.Context, user *domain.User) (interface{}, ports.APIError) {
+	keys, err := s.repo.GetUserAPIKeys(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return dtos.FromDomainAPIKeys(keys), nil
+}
+
+// EraseUserData deletes the keys of the user, the revoked ones too, and drops them from the cache
+func (s *APIKeyServiceImpl) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	keys, err := s.repo.GetUserAPIKeys(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteUserAPIKeys(ctx, user.ID); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		s.si.Cache.Del(apiKeyCacheKey(key.KeyHash))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_APIKey_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockAPIKeyRepository(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewAPIKeyService(repo, audit, mockServiceInfra(ctrl))
+
+	_, err := svc.CreateAPIKey(ctx, "SampleID", dtos.APIKeyCreate{Name: "CI", Scopes: []string{"admin"}, ExpiresInDays: 30})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+
+	var stored *domain.APIKey
+	repo.EXPECT().GetUserAPIKeys(gomock.Eq(ctx), "SampleID").Return(nil, nil)
+	repo.EXPECT().
+		CreateAPIKey(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, key *domain.APIKey) (string, error) {
+			stored = key
+			return "KeyID", nil
+		})
+	audit.EXPECT().Record(gomock.Eq(ctx), gomock.Any())
+	created, err := svc.CreateAPIKey(ctx, "SampleID", dtos.APIKeyCreate{Name: "CI", Scopes: []string{"write", "read", "write"}, ExpiresInDays: 30})
+	assert.Nil(t, err)
+	assert.Equal(t, "KeyID", created.ID)
+	assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+	assert.True(t, opaque_token.Matches(created.Key, stored.KeyHash))
+	assert.Equal(t, []string{"read", "write"}, stored.Scopes)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), stored.ExpiresAt, time.Minute)
+}
+
+func Test_APIKey_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockAPIKeyRepository(ctrl)
+	svc := NewAPIKeyService(repo, mocks.NewMockAuditService(ctrl), mockServiceInfra(ctrl))
+
+	_, found := svc.AuthenticateAPIKey(ctx, "not-a-key") // not even looked up
+	assert.False(t, found)
+
+	key := apiKeyPrefix + "the-secret"
+	active := &domain.APIKey{ID: "KeyID", UserID: "SampleID", KeyHash: opaque_token.Hash(key), Scopes: []string{"read"}, ExpiresAt: time.Now().Add(time.Hour)}
+	repo.EXPECT().GetAPIKeyByHash(gomock.Eq(ctx), active.KeyHash).Return(active, nil).Times(1)
+	repo.EXPECT().TouchAPIKey(gomock.Eq(ctx), "KeyID", gomock.Any()).Return(nil).Times(1)
+	for range 2 { // the second time comes from the cache
+		claims, found := svc.AuthenticateAPIKey(ctx, key)
+		assert.True(t, found)
+		assert.Equal(t, "SampleID", claims.Subject)
+		assert.Equal(t, []string{"read"}, claims.Scopes)
+		assert.Empty(t, claims.ID)
+	}
+
+	expiredKey := apiKeyPrefix + "expired"
+	expired := &domain.APIKey{ID: "OldID", UserID: "SampleID", KeyHash: opaque_token.Hash(expiredKey), ExpiresAt: time.Now().Add(-time.Hour)}
+	repo.EXPECT().GetAPIKeyByHash(gomock.Eq(ctx), expired.KeyHash).Return(expired, nil)
+	_, found = svc.AuthenticateAPIKey(ctx, expiredKey)
+	assert.False(t, found)
+}
+
+func Test_APIKey_Revoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockAPIKeyRepository(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewAPIKeyService(repo, audit, mockServiceInfra(ctrl))
+
+	key := apiKeyPrefix + "the-secret"
+	apiKey := &domain.APIKey{ID: "KeyID", UserID: "SampleID", KeyHash: opaque_token.Hash(key), ExpiresAt: time.Now().Add(time.Hour)}
+	repo.EXPECT().GetAPIKeyByHash(gomock.Eq(ctx), apiKey.KeyHash).Return(apiKey, nil)
+	repo.EXPECT().TouchAPIKey(gomock.Eq(ctx), "KeyID", gomock.Any()).Return(nil)
+	_, found := svc.AuthenticateAPIKey(ctx, key) // now it is cached
+	assert.True(t, found)
+
+	repo.EXPECT().GetUserAPIKeys(gomock.Eq(ctx), "OtherID").Return(nil, nil)
+	err := svc.RevokeAPIKey(ctx, "OtherID", "KeyID")
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.Status())
+
+	repo.EXPECT().GetUserAPIKeys(gomock.Eq(ctx), "SampleID").Return([]*domain.APIKey{apiKey}, nil)
+	repo.EXPECT().RevokeAPIKey(gomock.Eq(ctx), "KeyID").Return(nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), gomock.Any())
+	assert.Nil(t, svc.RevokeAPIKey(ctx, "SampleID", "KeyID"))
+
+	revoked := *apiKey
+	revokedAt := time.Now()
+	revoked.RevokedAt = &revokedAt
+	repo.EXPECT().GetAPIKeyByHash(gomock.Eq(ctx), apiKey.KeyHash).Return(&revoked, nil) // not served from the cache anymore
+	_, found = svc.AuthenticateAPIKey(ctx, key)
+	assert.False(t, found)
+}
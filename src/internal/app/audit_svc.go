@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+type AuditServiceImpl struct {
+	repo ports.AuditRepository
+	si   *ServiceInfra
+}
+
+func NewAuditService(repo ports.AuditRepository, serviceInfra *ServiceInfra) ports.AuditService {
+	return &AuditServiceImpl{repo: repo, si: serviceInfra}
+}
+
+// Record stores the event and writes it to the log too
+func (s *AuditServiceImpl) Record(ctx context.Context, event *domain.AuditEvent) {
+	s.si.Logger.Info(fmt.Sprintf("Audit %s: actor=%q target=%q ip=%q %s", event.Type, event.ActorID, event.Target, event.IP, event.Details))
+	if _, err := s.repo.CreateAuditEvent(ctx, event); err != nil {
+		s.si.Logger.Info(fmt.Sprintf("Error recording audit event %s: %s", event.Type, err.Error()))
+	}
+}
+
+// GetEvents returns the newest events matching the query. Only for administrators
+func (s *AuditServiceImpl) GetEvents(ctx context.Context, byUser string, query dtos.AuditQuery) ([]*domain.AuditEvent, ports.APIError) {
+	if err := validator.ValidateStruct(query); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, "", []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAuditEvents(ctx, query)
+}
+
+// ExportUserData returns the events done by the user or about the user
+func (s *AuditServiceImpl) ExportUserData(ctx context.Context, user *domain.User) (interface{}, ports.APIError) {
+	events, err := s.repo.GetUserAuditEvents(ctx, user.ID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return dtos.FromDomainAuditEvents(events), nil
+}
+
+// EraseUserData keeps the events, as the trail must stay complete, but removes the user from them
+func (s *AuditServiceImpl) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	return s.repo.AnonymizeUserAuditEvents(ctx, user.ID, user.Email)
+}
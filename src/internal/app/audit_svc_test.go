@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/cache"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_Audit_OnlyForAdmins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	perm := mocks.NewMockPermissionService(ctrl)
+	repo := mocks.NewMockAuditRepository(ctrl)
+	svc := NewAuditService(repo, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()})
+
+	query := dtos.AuditQuery{Type: string(domain.AuditAccountLocked)}
+	perm.EXPECT().
+		IsSameUserOrHasSomePermission(gomock.Eq(ctx), "admin", "", []domain.Permission{domain.PermissionAdmin}).
+		Return(true, nil)
+	repo.EXPECT().GetAuditEvents(gomock.Eq(ctx), query).Return([]*domain.AuditEvent{{ID: "1", Type: domain.AuditAccountLocked}}, nil)
+	events, err := svc.GetEvents(ctx, "admin", query)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+
+	perm.EXPECT().
+		IsSameUserOrHasSomePermission(gomock.Eq(ctx), "john", "", gomock.Any()).
+		Return(false, ports.NewAPIError(http.StatusForbidden, "The data is not accessible"))
+	_, err = svc.GetEvents(ctx, "john", query)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+
+	_, err = svc.GetEvents(ctx, "admin", dtos.AuditQuery{Limit: 5000})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_Audit_RecordNeverFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockAuditRepository(ctrl)
+	svc := NewAuditService(repo, mockServiceInfra(ctrl))
+	repo.EXPECT().CreateAuditEvent(gomock.Eq(ctx), gomock.Any()).Return("", ports.NewAPIError(http.StatusInternalServerError, "database down"))
+	svc.Record(ctx, &domain.AuditEvent{Type: domain.AuditIPLocked, Target: testClientIP})
+}
@@ -0,0 +1,25 @@
+package app
+
+import "time"
+
+// AuthConfig holds the settings of the authentication module that can change between deployments
+type AuthConfig struct {
+	PasswordPolicy             PasswordPolicy
+	RequireVerifiedEmail       bool   // Login refuses the users that have not verified their email
+	PublicURL                  string // Base URL of the frontend, used to build the links sent by email
+	VerifyEmailTokenDuration   time.Duration
+	ResetPasswordTokenDuration time.Duration
+	ChangeEmailTokenDuration   time.Duration
+	MFAIssuer                  string // Name of the service shown by the authenticator apps
+	Lockout                    LockoutPolicy
+}
+
+var DefaultAuthConfig = AuthConfig{
+	PasswordPolicy:             DefaultPasswordPolicy,
+	PublicURL:                  "http://localhost:5080",
+	VerifyEmailTokenDuration:   48 * time.Hour,
+	ResetPasswordTokenDuration: 1 * time.Hour,
+	ChangeEmailTokenDuration:   24 * time.Hour,
+	MFAIssuer:                  "Gommence",
+	Lockout:                    DefaultLockoutPolicy,
+}
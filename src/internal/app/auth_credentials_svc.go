@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opo_uid"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+// Changes of the credentials requested by signed in users. They ask for the current password, so a stolen access token
+// is not enough to take over the account
+
+// ChangePassword sets the new password and closes every session of the user. The caller gets the tokens of a new session,
+// acting on behalf of the same tenant. The access tokens already issued keep working until they expire
+func (s *AuthServiceImpl) ChangePassword(ctx context.Context, byUser string, request dtos.PasswordChange, clientIP string) (*dtos.LoggedUser, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := s.config.PasswordPolicy.Check(request.NewSecret); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	user, errUser := s.checkCurrentPassword(ctx, byUser, request.Secret, clientIP)
+	if errUser != nil {
+		return nil, errUser
+	}
+	hashedPassword, err := HashPassword(request.NewSecret)
+	if err != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	errTx := s.si.Tx.WithinTx(ctx, func(ctx context.Context) ports.APIError { // not the new password with the old sessions
+		if err := s.userSvc.SetPassword(ctx, user.ID, hashedPassword); err != nil {
+			return err
+		}
+		return s.tokenRepo.RevokeUserRefreshTokens(ctx, user.ID)
+	})
+	if errTx != nil {
+		return nil, errTx
+	}
+	s.sendMail(ctx, ports.MailMessage{
+		To:      user.Email,
+		Subject: "Your password has changed",
+		Body:    "The password of your account has just been changed. If it was not you, reset it right away.\n",
+	})
+	tenant, _ := domain.TenantFromContext(ctx)
+	return s.startSession(ctx, user.ID, opo_uid.New(), tenant)
+}
+
+// RequestEmailChange sends a link to the new address. The email does not change until the link is followed, proving the
+// address belongs to the user. The current address is warned, in case the request was not made by the user
+func (s *AuthServiceImpl) RequestEmailChange(ctx context.Context, byUser string, request dtos.EmailChangeRequest, clientIP string) ports.APIError {
+	if err := validator.ValidateStruct(request); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	user, errUser := s.checkCurrentPassword(ctx, byUser, request.Secret, clientIP)
+	if errUser != nil {
+		return errUser
+	}
+	if request.Email == user.Email {
+		return ports.NewAPIError(http.StatusBadRequest, "That is already the email of the user")
+	}
+	if owner, err := s.userSvc.GetUserByEmail(ctx, request.Email); err == nil && owner.ID != user.ID { // a change of case is fine
+		return ports.NewAPIError(http.StatusConflict, "Email already registered")
+	}
+	pending := domain.ActionToken{UserID: user.ID, Purpose: domain.ActionChangeEmail, NewEmail: request.Email}
+	token, errToken := s.createActionToken(ctx, pending, s.config.ChangeEmailTokenDuration)
+	if errToken != nil {
+		return errToken
+	}
+	s.sendMail(ctx, ports.MailMessage{
+		To:      request.Email,
+		Subject: "Confirm your new email",
+		Body: fmt.Sprintf("Follow this link in the next %s to use this address in your account:\n\n%s\n",
+			durationText(s.config.ChangeEmailTokenDuration), s.actionLink("confirm-email", token)),
+	})
+	s.sendMail(ctx, ports.MailMessage{
+		To:      user.Email,
+		Subject: "Change of your email",
+		Body: fmt.Sprintf("Someone asked to change the email of your account to %s. It will change once the new address is confirmed.\n\n"+
+			"If it was not you, change your password right away.\n", request.Email),
+	})
+	return nil
+}
+
+// ConfirmEmailChange switches the user to the address the token was sent to. The address is checked again, as another
+// user may have taken it since the change was requested
+func (s *AuthServiceImpl) ConfirmEmailChange(ctx context.Context, request dtos.EmailChangeConfirm) ports.APIError {
+	if err := validator.ValidateStruct(request); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	return s.si.Tx.WithinTx(ctx, func(ctx context.Context) ports.APIError { // the link is not spent if the change fails
+		actionToken, errToken := s.consumeActionToken(ctx, domain.ActionChangeEmail, request.Token)
+		if errToken != nil {
+			return errToken
+		}
+		return s.userSvc.ChangeEmail(ctx, actionToken.UserID, actionToken.NewEmail)
+	})
+}
+
+// SetUserPassword replaces the password of another user, e.g. one that can not get the email to reset it. Only for
+// administrators. Every session of the user is closed and the failed sign ins of the account are forgotten
+func (s *AuthServiceImpl) SetUserPassword(ctx context.Context, byUser string, forUser string, password string) ports.APIError {
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, "", []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return err
+	}
+	if err := s.config.PasswordPolicy.Check(password); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	user, errUser := s.userSvc.GetUserById(ctx, forUser, byUser)
+	if errUser != nil {
+		return errUser
+	}
+	if user.AuthMethod != domain.AuthMethPassword {
+		return ports.NewAPIError(http.StatusBadRequest, "The credentials of the user are managed by an external provider")
+	}
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	errTx := s.si.Tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		if err := s.userSvc.SetPassword(ctx, user.ID, hashedPassword); err != nil {
+			return err
+		}
+		return s.tokenRepo.RevokeUserRefreshTokens(ctx, user.ID)
+	})
+	if errTx != nil {
+		return errTx
+	}
+	s.throttler.Succeeded(ctx, user.Email, "")
+	return nil
+}
+
+// checkCurrentPassword returns the user if the secret is their password. Wrong passwords count as failed sign ins,
+// so they lock the account as well
+func (s *AuthServiceImpl) checkCurrentPassword(ctx context.Context, byUser string, secret string, clientIP string) (*domain.User, ports.APIError) {
+	user, err := s.userSvc.GetUserById(ctx, byUser, byUser)
+	if err != nil {
+		return nil, err
+	}
+	if user.AuthMethod != domain.AuthMethPassword {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "The credentials of the user are managed by an external provider")
+	}
+	if err := s.throttler.Check(ctx, user.Email, clientIP); err != nil {
+		return nil, err
+	}
+	if !CheckPassword(secret, user.HashedPassword) {
+		s.throttler.Failed(ctx, user.Email, clientIP)
+		return nil, ports.NewAPIError(http.StatusForbidden, "Invalid password")
+	}
+	s.throttler.Succeeded(ctx, user.Email, clientIP)
+	return user, nil
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func passwordUser(t *testing.T) *domain.User {
+	hashedPassword, err := HashPassword("password")
+	assert.Nil(t, err)
+	return &domain.User{ID: "SampleID", Email: "j1@mail.com", AuthMethod: domain.AuthMethPassword, HashedPassword: hashedPassword}
+}
+
+func Test_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := domain.WithTenant(context.Background(), "OrgID")
+
+	user := passwordUser(t)
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), "SampleID", "SampleID").Return(user, nil).Times(2)
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Eq(ctx), user.Email, "1.2.3.4").Return(nil).Times(2)
+	throttler.EXPECT().Failed(gomock.Eq(ctx), user.Email, "1.2.3.4")
+	throttler.EXPECT().Succeeded(gomock.Eq(ctx), user.Email, "1.2.3.4")
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	mailer := mocks.NewMockMailer(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mailer, nil, DefaultAuthConfig)
+
+	_, err := svc.ChangePassword(ctx, "SampleID", dtos.PasswordChange{Secret: "password", NewSecret: "short"}, "1.2.3.4")
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	_, err = svc.ChangePassword(ctx, "SampleID", dtos.PasswordChange{Secret: "wrong password", NewSecret: "my new password"}, "1.2.3.4")
+	assert.Equal(t, http.StatusForbidden, err.Status())
+
+	userSvc.EXPECT().
+		SetPassword(gomock.Eq(ctx), "SampleID", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, idUser string, hashedPassword string) ports.APIError {
+			assert.True(t, CheckPassword("my new password", hashedPassword))
+			return nil
+		})
+	tokenRepo.EXPECT().RevokeUserRefreshTokens(gomock.Eq(ctx), "SampleID").Return(nil)
+	mailer.EXPECT().Send(gomock.Eq(ctx), gomock.Any()).Return(nil)
+	tokenRepo.EXPECT().
+		CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, token *domain.RefreshToken) (string, ports.APIError) {
+			assert.Equal(t, "SampleID", token.UserID)
+			assert.Equal(t, "OrgID", token.TenantID) // the new session keeps the tenant
+			return "TokenID", nil
+		})
+	logged, err := svc.ChangePassword(ctx, "SampleID", dtos.PasswordChange{Secret: "password", NewSecret: "my new password"}, "1.2.3.4")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, logged.AccessToken)
+	assert.NotEmpty(t, logged.RefreshToken)
+}
+
+func Test_ChangePassword_ExternalUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), "SampleID", "SampleID").Return(&domain.User{ID: "SampleID", AuthMethod: domain.AuthMethGoogle}, nil)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	_, err := svc.ChangePassword(ctx, "SampleID", dtos.PasswordChange{Secret: "password", NewSecret: "my new password"}, "1.2.3.4")
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_EmailChange_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	user := passwordUser(t)
+	newEmail := "j1@new-mail.com"
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), "SampleID", "SampleID").Return(user, nil)
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), newEmail).Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found"))
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Eq(ctx), user.Email, "1.2.3.4").Return(nil)
+	throttler.EXPECT().Succeeded(gomock.Eq(ctx), user.Email, "1.2.3.4")
+	var stored *domain.ActionToken
+	actionTokenRepo := mocks.NewMockActionTokenRepository(ctrl)
+	actionTokenRepo.EXPECT().InvalidateActionTokens(gomock.Eq(ctx), "SampleID", domain.ActionChangeEmail).Return(nil)
+	actionTokenRepo.EXPECT().
+		CreateActionToken(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, token *domain.ActionToken) (string, ports.APIError) {
+			stored = token
+			stored.ID = "ActionID"
+			return stored.ID, nil
+		})
+	sent := map[string]ports.MailMessage{}
+	mailer := mocks.NewMockMailer(ctrl)
+	mailer.EXPECT().
+		Send(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, message ports.MailMessage) error {
+			sent[message.To] = message
+			return nil
+		}).
+		Times(2)
+
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), actionTokenRepo, mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mailer, nil, DefaultAuthConfig)
+	err := svc.RequestEmailChange(ctx, "SampleID", dtos.EmailChangeRequest{Email: newEmail, Secret: "password"}, "1.2.3.4")
+	assert.Nil(t, err)
+	assert.Contains(t, sent[user.Email].Body, newEmail) // the current address is warned, without the link
+	assert.NotContains(t, sent[user.Email].Body, "token=")
+	token := tokenInMail(t, sent[newEmail])
+	assert.Equal(t, opaque_token.Hash(token), stored.TokenHash)
+	assert.Equal(t, newEmail, stored.NewEmail)
+
+	actionTokenRepo.EXPECT().GetActionTokenByHash(gomock.Eq(ctx), domain.ActionChangeEmail, stored.TokenHash).Return(stored, nil)
+	actionTokenRepo.EXPECT().MarkActionTokenUsed(gomock.Eq(ctx), "ActionID").Return(true, nil)
+	userSvc.EXPECT().ChangeEmail(gomock.Eq(ctx), "SampleID", newEmail).Return(nil)
+	err = svc.ConfirmEmailChange(ctx, dtos.EmailChangeConfirm{Token: token})
+	assert.Nil(t, err)
+}
+
+func Test_RequestEmailChange_Refused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	user := passwordUser(t)
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), "SampleID", "SampleID").Return(user, nil).AnyTimes()
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), "taken@mail.com").Return(&domain.User{ID: "OtherID"}, nil)
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Eq(ctx), user.Email, "1.2.3.4").Return(nil).AnyTimes()
+	throttler.EXPECT().Succeeded(gomock.Eq(ctx), user.Email, "1.2.3.4").AnyTimes()
+	throttler.EXPECT().Failed(gomock.Eq(ctx), user.Email, "1.2.3.4")
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+
+	err := svc.RequestEmailChange(ctx, "SampleID", dtos.EmailChangeRequest{Email: "taken@mail.com", Secret: "password"}, "1.2.3.4")
+	assert.Equal(t, http.StatusConflict, err.Status())
+	err = svc.RequestEmailChange(ctx, "SampleID", dtos.EmailChangeRequest{Email: user.Email, Secret: "password"}, "1.2.3.4")
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	err = svc.RequestEmailChange(ctx, "SampleID", dtos.EmailChangeRequest{Email: "free@mail.com", Secret: "wrong password"}, "1.2.3.4")
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	err = svc.RequestEmailChange(ctx, "SampleID", dtos.EmailChangeRequest{Email: "not an email", Secret: "password"}, "1.2.3.4")
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_SetUserPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	user := passwordUser(t)
+	serviceInfra := mockServiceInfra(ctrl)
+	permissions := serviceInfra.Permissions.(*mocks.MockPermissionService)
+	permissions.EXPECT().
+		IsSameUserOrHasSomePermission(gomock.Eq(ctx), "EditorID", "", []domain.Permission{domain.PermissionAdmin}).
+		Return(false, ports.NewAPIError(http.StatusForbidden, "The data is not accessible"))
+	permissions.EXPECT().
+		IsSameUserOrHasSomePermission(gomock.Eq(ctx), "AdminID", "", []domain.Permission{domain.PermissionAdmin}).
+		Return(true, nil).Times(2)
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), "SampleID", "AdminID").Return(user, nil)
+	userSvc.EXPECT().
+		SetPassword(gomock.Eq(ctx), "SampleID", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, idUser string, hashedPassword string) ports.APIError {
+			assert.True(t, CheckPassword("my new password", hashedPassword))
+			return nil
+		})
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().RevokeUserRefreshTokens(gomock.Eq(ctx), "SampleID").Return(nil)
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Succeeded(gomock.Eq(ctx), user.Email, "") // unlocks the account
+	svc := NewAuthService(serviceInfra, userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+
+	err := svc.SetUserPassword(ctx, "EditorID", "SampleID", "my new password")
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	err = svc.SetUserPassword(ctx, "AdminID", "SampleID", "short")
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	assert.Nil(t, svc.SetUserPassword(ctx, "AdminID", "SampleID", "my new password"))
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+// Flows where the user proves the control of the email address with a single use token sent to it.
+// The requests answer the same whether the email exists or not, so they can not be used to find out who is registered
+
+// RequestPasswordReset sends an email with a link to set a new password
+func (s *AuthServiceImpl) RequestPasswordReset(ctx context.Context, request dtos.PasswordResetRequest) ports.APIError {
+	if err := validator.ValidateStruct(request); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	user, err := s.userSvc.GetUserByEmail(ctx, request.Email)
+	if err != nil || user.AuthMethod != domain.AuthMethPassword {
+		return nil
+	}
+	token, errToken := s.createActionToken(ctx, domain.ActionToken{UserID: user.ID, Purpose: domain.ActionResetPassword}, s.config.ResetPasswordTokenDuration)
+	if errToken != nil {
+		return errToken
+	}
+	s.sendMail(ctx, ports.MailMessage{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account. If it was you, follow this link in the next %s:\n\n%s\n\n"+
+			"If it was not you, ignore this email: your password has not changed.\n",
+			durationText(s.config.ResetPasswordTokenDuration), s.actionLink("reset-password", token)),
+	})
+	return nil
+}
+
+// ConfirmPasswordReset sets the new password and closes every session of the user. The token is only spent if
+// everything succeeds, so the link can be used again after a failure
+func (s *AuthServiceImpl) ConfirmPasswordReset(ctx context.Context, request dtos.PasswordResetConfirm) ports.APIError {
+	if err := validator.ValidateStruct(request); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := s.config.PasswordPolicy.Check(request.Secret); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	hashedPassword, err := HashPassword(request.Secret)
+	if err != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	return s.si.Tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		actionToken, err := s.consumeActionToken(ctx, domain.ActionResetPassword, request.Token)
+		if err != nil {
+			return err
+		}
+		if err := s.userSvc.SetPassword(ctx, actionToken.UserID, hashedPassword); err != nil {
+			return err
+		}
+		if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, actionToken.UserID); err != nil {
+			return err
+		}
+		return s.userSvc.MarkEmailVerified(ctx, actionToken.UserID) // the link reached the user, so the email is theirs
+	})
+}
+
+// RequestEmailVerification sends again the email to verify the address, e.g. because the first one expired
+func (s *AuthServiceImpl) RequestEmailVerification(ctx context.Context, request dtos.EmailVerificationRequest) ports.APIError {
+	if err := validator.ValidateStruct(request); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	user, err := s.userSvc.GetUserByEmail(ctx, request.Email)
+	if err != nil || user.EmailVerifiedAt != nil {
+		return nil
+	}
+	s.sendVerificationEmail(ctx, user.ID, user.Email)
+	return nil
+}
+
+func (s *AuthServiceImpl) VerifyEmail(ctx context.Context, request dtos.VerifyEmailRequest) ports.APIError {
+	if err := validator.ValidateStruct(request); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	actionToken, errToken := s.consumeActionToken(ctx, domain.ActionVerifyEmail, request.Token)
+	if errToken != nil {
+		return errToken
+	}
+	return s.userSvc.MarkEmailVerified(ctx, actionToken.UserID)
+}
+
+// sendVerificationEmail only logs the errors: failing to send the email must not fail the operation that triggered it
+func (s *AuthServiceImpl) sendVerificationEmail(ctx context.Context, userId string, email string) {
+	token, err := s.createActionToken(ctx, domain.ActionToken{UserID: userId, Purpose: domain.ActionVerifyEmail}, s.config.VerifyEmailTokenDuration)
+	if err != nil {
+		s.si.Logger.Info(fmt.Sprintf("Error creating the email verification token of user %s: %s", userId, err.Error()))
+		return
+	}
+	s.sendMail(ctx, ports.MailMessage{
+		To:      email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Follow this link in the next %s to verify your email:\n\n%s\n",
+			durationText(s.config.VerifyEmailTokenDuration), s.actionLink("verify-email", token)),
+	})
+}
+
+func (s *AuthServiceImpl) sendMail(ctx context.Context, message ports.MailMessage) {
+	if err := s.mailer.Send(ctx, message); err != nil {
+		s.si.Logger.Info(fmt.Sprintf("Error sending email '%s': %s", message.Subject, err.Error()))
+	}
+}
+
+func (s *AuthServiceImpl) actionLink(page string, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", s.config.PublicURL, page, url.QueryEscape(token))
+}
+
+// durationText writes the validity of the links the way people read it: "1 hour", "48 hours", "30 minutes"
+func durationText(duration time.Duration) string {
+	amount, unit := int(duration.Minutes()), "minute"
+	if duration >= time.Hour {
+		amount, unit = int(duration.Hours()), "hour"
+	}
+	if amount != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", amount, unit)
+}
+
+// createActionToken invalidates the previous tokens of the user for the same purpose and returns a new one.
+// The pending token says the user, the purpose and, for email changes, the new address
+func (s *AuthServiceImpl) createActionToken(ctx context.Context, pending domain.ActionToken, duration time.Duration) (string, ports.APIError) {
+	if err := s.actionTokenRepo.InvalidateActionTokens(ctx, pending.UserID, pending.Purpose); err != nil {
+		return "", err
+	}
+	token, err := opaque_token.New()
+	if err != nil {
+		return "", ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	pending.TokenHash = opaque_token.Hash(token)
+	pending.ExpiresAt = time.Now().Add(duration)
+	if _, errRepo := s.actionTokenRepo.CreateActionToken(ctx, &pending); errRepo != nil {
+		return "", errRepo
+	}
+	return token, nil
+}
+
+// consumeActionToken flags the token as used and returns it, or fails if it is unknown, expired or already used
+func (s *AuthServiceImpl) consumeActionToken(ctx context.Context, purpose domain.ActionTokenPurpose, token string) (*domain.ActionToken, ports.APIError) {
+	invalid := ports.NewAPIError(http.StatusBadRequest, "Invalid or expired token")
+	stored, err := s.actionTokenRepo.GetActionTokenByHash(ctx, purpose, opaque_token.Hash(token))
+	if err != nil {
+		return nil, invalid
+	}
+	if stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, invalid
+	}
+	marked, err := s.actionTokenRepo.MarkActionTokenUsed(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, invalid
+	}
+	return stored, nil
+}
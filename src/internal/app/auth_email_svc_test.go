@@ -0,0 +1,253 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var linkToken = regexp.MustCompile(`\?token=(\S+)`)
+
+// tokenInMail returns the token of the link sent in the message
+func tokenInMail(t *testing.T, message ports.MailMessage) string {
+	match := linkToken.FindStringSubmatch(message.Body)
+	assert.Equal(t, 2, len(match))
+	token, err := url.QueryUnescape(match[1])
+	assert.Nil(t, err)
+	return token
+}
+
+func Test_PasswordReset_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	email := "j1@mail.com"
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), email).Return(&domain.User{ID: "SampleID", Email: email, AuthMethod: domain.AuthMethPassword}, nil)
+	var stored *domain.ActionToken
+	actionTokenRepo := mocks.NewMockActionTokenRepository(ctrl)
+	actionTokenRepo.EXPECT().InvalidateActionTokens(gomock.Eq(ctx), "SampleID", domain.ActionResetPassword).Return(nil)
+	actionTokenRepo.EXPECT().
+		CreateActionToken(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, token *domain.ActionToken) (string, ports.APIError) {
+			stored = token
+			stored.ID = "ActionID"
+			return stored.ID, nil
+		})
+	var sent ports.MailMessage
+	mailer := mocks.NewMockMailer(ctrl)
+	mailer.EXPECT().
+		Send(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, message ports.MailMessage) error {
+			sent = message
+			return nil
+		})
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, actionTokenRepo, mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mailer, nil, DefaultAuthConfig)
+	err := svc.RequestPasswordReset(ctx, dtos.PasswordResetRequest{Email: email})
+	assert.Nil(t, err)
+	assert.Equal(t, email, sent.To)
+	assert.Contains(t, sent.Body, "1 hour")
+	token := tokenInMail(t, sent)
+	assert.Equal(t, opaque_token.Hash(token), stored.TokenHash) // only the hash is stored
+	assert.Equal(t, domain.ActionResetPassword, stored.Purpose)
+
+	newPassword := "my new password"
+	actionTokenRepo.EXPECT().GetActionTokenByHash(gomock.Eq(ctx), domain.ActionResetPassword, stored.TokenHash).Return(stored, nil)
+	actionTokenRepo.EXPECT().MarkActionTokenUsed(gomock.Eq(ctx), "ActionID").Return(true, nil)
+	userSvc.EXPECT().
+		SetPassword(gomock.Eq(ctx), "SampleID", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, idUser string, hashedPassword string) ports.APIError {
+			assert.True(t, CheckPassword(newPassword, hashedPassword))
+			return nil
+		})
+	tokenRepo.EXPECT().RevokeUserRefreshTokens(gomock.Eq(ctx), "SampleID").Return(nil)
+	userSvc.EXPECT().MarkEmailVerified(gomock.Eq(ctx), "SampleID").Return(nil)
+
+	err = svc.ConfirmPasswordReset(ctx, dtos.PasswordResetConfirm{Token: token, Secret: newPassword})
+	assert.Nil(t, err)
+}
+
+func Test_RequestPasswordReset_UnknownEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), "nobody@mail.com").Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found"))
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), "google@mail.com").Return(&domain.User{ID: "SampleID", AuthMethod: domain.AuthMethGoogle}, nil)
+
+	// no token is created and no email is sent, but the answer is the same
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	assert.Nil(t, svc.RequestPasswordReset(ctx, dtos.PasswordResetRequest{Email: "nobody@mail.com"}))
+	assert.Nil(t, svc.RequestPasswordReset(ctx, dtos.PasswordResetRequest{Email: "google@mail.com"}))
+	err := svc.RequestPasswordReset(ctx, dtos.PasswordResetRequest{Email: "not an email"})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_ConfirmPasswordReset_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	usedAt := time.Now().Add(-time.Minute)
+	actionTokenRepo := mocks.NewMockActionTokenRepository(ctrl)
+	actionTokenRepo.EXPECT().GetActionTokenByHash(gomock.Eq(ctx), domain.ActionResetPassword, opaque_token.Hash("unknown")).Return(nil, ports.NewAPIError(http.StatusNotFound, "Token not found"))
+	actionTokenRepo.EXPECT().GetActionTokenByHash(gomock.Eq(ctx), domain.ActionResetPassword, opaque_token.Hash("used")).
+		Return(&domain.ActionToken{ID: "Used", UserID: "SampleID", ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}, nil)
+	actionTokenRepo.EXPECT().GetActionTokenByHash(gomock.Eq(ctx), domain.ActionResetPassword, opaque_token.Hash("expired")).
+		Return(&domain.ActionToken{ID: "Expired", UserID: "SampleID", ExpiresAt: time.Now().Add(-time.Hour)}, nil)
+	actionTokenRepo.EXPECT().GetActionTokenByHash(gomock.Eq(ctx), domain.ActionResetPassword, opaque_token.Hash("raced")).
+		Return(&domain.ActionToken{ID: "Raced", UserID: "SampleID", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	actionTokenRepo.EXPECT().MarkActionTokenUsed(gomock.Eq(ctx), "Raced").Return(false, nil) // used by a concurrent request
+
+	svc := NewAuthService(mockServiceInfra(ctrl), mocks.NewMockUserService(ctrl), mocks.NewMockTokenRepository(ctrl), actionTokenRepo, mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	for _, token := range []string{"unknown", "used", "expired", "raced"} {
+		err := svc.ConfirmPasswordReset(ctx, dtos.PasswordResetConfirm{Token: token, Secret: "my new password"})
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.Status())
+	}
+	err := svc.ConfirmPasswordReset(ctx, dtos.PasswordResetConfirm{Token: "unknown", Secret: "short"}) // the policy is checked first
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Password must have")
+}
+
+func Test_VerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	email := "j1@mail.com"
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), email).Return(&domain.User{ID: "SampleID", Email: email}, nil)
+	var stored *domain.ActionToken
+	actionTokenRepo := mocks.NewMockActionTokenRepository(ctrl)
+	actionTokenRepo.EXPECT().InvalidateActionTokens(gomock.Eq(ctx), "SampleID", domain.ActionVerifyEmail).Return(nil)
+	actionTokenRepo.EXPECT().
+		CreateActionToken(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, token *domain.ActionToken) (string, ports.APIError) {
+			stored = token
+			stored.ID = "ActionID"
+			return stored.ID, nil
+		})
+	var sent ports.MailMessage
+	mailer := mocks.NewMockMailer(ctrl)
+	mailer.EXPECT().
+		Send(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, message ports.MailMessage) error {
+			sent = message
+			return nil
+		})
+
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), actionTokenRepo, mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mailer, nil, DefaultAuthConfig)
+	err := svc.RequestEmailVerification(ctx, dtos.EmailVerificationRequest{Email: email})
+	assert.Nil(t, err)
+	assert.Contains(t, sent.Body, "48 hours")
+	token := tokenInMail(t, sent)
+
+	actionTokenRepo.EXPECT().GetActionTokenByHash(gomock.Eq(ctx), domain.ActionVerifyEmail, opaque_token.Hash(token)).Return(stored, nil)
+	actionTokenRepo.EXPECT().MarkActionTokenUsed(gomock.Eq(ctx), "ActionID").Return(true, nil)
+	userSvc.EXPECT().MarkEmailVerified(gomock.Eq(ctx), "SampleID").Return(nil)
+	err = svc.VerifyEmail(ctx, dtos.VerifyEmailRequest{Token: token})
+	assert.Nil(t, err)
+
+	verifiedAt := time.Now()
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), email).Return(&domain.User{ID: "SampleID", Email: email, EmailVerifiedAt: &verifiedAt}, nil)
+	err = svc.RequestEmailVerification(ctx, dtos.EmailVerificationRequest{Email: email}) // already verified, nothing is sent
+	assert.Nil(t, err)
+}
+
+func Test_Login_RequireVerifiedEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	email := "j1@mail.com"
+	password := "password"
+	hashedPassword, _ := HashPassword(password)
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().
+		GetUserByEmail(gomock.Eq(ctx), email).
+		Return(&domain.User{ID: "SampleID", AuthMethod: domain.AuthMethPassword, HashedPassword: hashedPassword}, nil).
+		Times(2)
+
+	config := DefaultAuthConfig
+	config.RequireVerifiedEmail = true
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), allowLogins(ctrl), mocks.NewMockMailer(ctrl), nil, config)
+	loggedUser, err := svc.Login(ctx, dtos.LoginCredentials{Email: email, Secret: password}, testClientIP)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	assert.Nil(t, loggedUser)
+
+	loggedUser, err = svc.Login(ctx, dtos.LoginCredentials{Email: email, Secret: "wrong-password"}, testClientIP) // the wrong password comes first
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.Status())
+	assert.Nil(t, loggedUser)
+}
+
+func Test_SignUp_RequireVerifiedEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().CreateUser(gomock.Eq(ctx), gomock.Any()).Return("NewUserID", nil)
+	actionTokenRepo := mocks.NewMockActionTokenRepository(ctrl)
+	actionTokenRepo.EXPECT().InvalidateActionTokens(gomock.Eq(ctx), "NewUserID", domain.ActionVerifyEmail).Return(nil)
+	actionTokenRepo.EXPECT().CreateActionToken(gomock.Eq(ctx), gomock.Any()).Return("ActionID", nil)
+	mailer := mocks.NewMockMailer(ctrl)
+	mailer.EXPECT().Send(gomock.Eq(ctx), gomock.Any()).Return(nil)
+
+	config := DefaultAuthConfig
+	config.RequireVerifiedEmail = true
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), actionTokenRepo, mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mailer, nil, config)
+	loggedUser, err := svc.SignUp(ctx, dtos.UserSignUp{FirstName: "John", FirstLastName: "Doe", Email: "john@mail.com", Secret: "a long password"})
+	assert.Nil(t, err)
+	assert.Equal(t, "NewUserID", loggedUser.UserID)
+	assert.True(t, loggedUser.EmailVerificationRequired)
+	assert.Equal(t, "", loggedUser.AccessToken) // no session, the token repository is not even called
+	assert.Equal(t, "", loggedUser.RefreshToken)
+}
+
+func Test_Refresh_RequireVerifiedEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	refreshToken := "a-refresh-token"
+	stored := &domain.RefreshToken{ID: "RefreshID", UserID: "SampleID", FamilyID: "FamilyID", TokenHash: opaque_token.Hash(refreshToken), ExpiresAt: time.Now().Add(time.Hour)}
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), "SampleID", "SampleID").Return(&domain.User{ID: "SampleID"}, nil)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), stored.TokenHash).Return(stored, nil)
+	tokenRepo.EXPECT().MarkRefreshTokenUsed(gomock.Eq(ctx), "RefreshID").Return(true, nil)
+
+	config := DefaultAuthConfig
+	config.RequireVerifiedEmail = true
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, config)
+	loggedUser, err := svc.Refresh(ctx, dtos.RefreshTokenRequest{RefreshToken: refreshToken})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	assert.Nil(t, loggedUser)
+}
+
+func Test_DurationText(t *testing.T) {
+	assert.Equal(t, "1 hour", durationText(time.Hour))
+	assert.Equal(t, "48 hours", durationText(48*time.Hour))
+	assert.Equal(t, "30 minutes", durationText(30*time.Minute))
+	assert.Equal(t, "1 minute", durationText(time.Minute))
+}
@@ -0,0 +1,218 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opo_uid"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/totp"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+const (
+	mfaChallengeDuration = 5 * time.Minute // Time the user has to type the code after the password
+	maxMFAAttempts       = 5               // Codes allowed per challenge. Then the user has to sign in again
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10                                 // 50 bits per code, without the dash
+	recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789" // without the characters that are easy to confuse: l, o, 0, 1
+)
+
+// VerifyMFA completes the sign in of a user with two-factor authentication. The code can be one of the recovery codes.
+// The wrong codes are counted per challenge and per user, so signing in again with the password does not give more tries
+func (s *AuthServiceImpl) VerifyMFA(ctx context.Context, request dtos.MFAVerifyRequest, clientIP string) (*dtos.LoggedUser, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	tokenHash := opaque_token.Hash(request.MFAToken)
+	challenge, err := s.mfaRepo.AddMFAChallengeAttempt(ctx, tokenHash, time.Now())
+	if err != nil {
+		if err.Status() == http.StatusNotFound {
+			return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid or expired MFA token")
+		}
+		return nil, err
+	}
+	if challenge.Attempts > maxMFAAttempts {
+		s.mfaRepo.DeleteMFAChallenge(ctx, tokenHash)
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Too many attempts, sign in again")
+	}
+	if err := s.throttler.CheckMFA(ctx, challenge.UserID); err != nil {
+		return nil, err
+	}
+	user, err := s.userSvc.GetUserById(ctx, challenge.UserID, challenge.UserID)
+	if err != nil {
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+	valid, err := s.checkMFACode(ctx, user, request.Code, true)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		s.throttler.FailedMFA(ctx, user.ID, clientIP)
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid code")
+	}
+	if consumed, err := s.mfaRepo.DeleteMFAChallenge(ctx, tokenHash); err != nil || !consumed { // single use
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+	s.throttler.SucceededMFA(ctx, user.ID)
+	return s.startSession(ctx, user.ID, opo_uid.New(), "")
+}
+
+// StartMFAEnrollment generates a new TOTP secret for the user. It has no effect until it is confirmed with a code
+func (s *AuthServiceImpl) StartMFAEnrollment(ctx context.Context, byUser string) (*dtos.MFAEnrollment, ports.APIError) {
+	user, err := s.userSvc.GetUserById(ctx, byUser, byUser)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabledAt != nil {
+		return nil, ports.NewAPIError(http.StatusConflict, "MFA is already enabled")
+	}
+	secret, errSecret := totp.NewSecret()
+	if errSecret != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, errSecret.Error())
+	}
+	if err := s.userSvc.UpdateMFA(ctx, user.ID, secret, nil, nil); err != nil {
+		return nil, err
+	}
+	return &dtos.MFAEnrollment{Secret: secret, URI: totp.KeyURI(s.config.MFAIssuer, user.Email, secret)}, nil
+}
+
+// ConfirmMFAEnrollment enables two-factor authentication once the user proves the authenticator app works.
+// It returns the recovery codes, which are not stored anywhere in clear
+func (s *AuthServiceImpl) ConfirmMFAEnrollment(ctx context.Context, byUser string, request dtos.MFACode) (*dtos.MFARecoveryCodes, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	user, err := s.userSvc.GetUserById(ctx, byUser, byUser)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabledAt != nil {
+		return nil, ports.NewAPIError(http.StatusConflict, "MFA is already enabled")
+	}
+	if user.MFASecret == "" {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "MFA enrollment not started")
+	}
+	if valid, _ := s.checkMFACode(ctx, user, request.Code, false); !valid {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "Invalid code")
+	}
+	codes, hashes, errCodes := newRecoveryCodes()
+	if errCodes != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, errCodes.Error())
+	}
+	now := time.Now()
+	if err := s.userSvc.UpdateMFA(ctx, user.ID, user.MFASecret, &now, hashes); err != nil {
+		return nil, err
+	}
+	return &dtos.MFARecoveryCodes{Codes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, used or not
+func (s *AuthServiceImpl) RegenerateRecoveryCodes(ctx context.Context, byUser string, request dtos.MFACode) (*dtos.MFARecoveryCodes, ports.APIError) {
+	user, err := s.userWithMFA(ctx, byUser, request)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, errCodes := newRecoveryCodes()
+	if errCodes != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, errCodes.Error())
+	}
+	if err := s.userSvc.UpdateMFA(ctx, user.ID, user.MFASecret, user.MFAEnabledAt, hashes); err != nil {
+		return nil, err
+	}
+	return &dtos.MFARecoveryCodes{Codes: codes}, nil
+}
+
+// DisableMFA removes the second factor of the user. A valid code is required so a stolen session can not do it
+func (s *AuthServiceImpl) DisableMFA(ctx context.Context, byUser string, request dtos.MFACode) ports.APIError {
+	user, err := s.userWithMFA(ctx, byUser, request)
+	if err != nil {
+		return err
+	}
+	return s.userSvc.UpdateMFA(ctx, user.ID, "", nil, nil)
+}
+
+// userWithMFA returns the user if it has two-factor authentication enabled and the code is valid
+func (s *AuthServiceImpl) userWithMFA(ctx context.Context, byUser string, request dtos.MFACode) (*domain.User, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	user, err := s.userSvc.GetUserById(ctx, byUser, byUser)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabledAt == nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "MFA is not enabled")
+	}
+	valid, err := s.checkMFACode(ctx, user, request.Code, true)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "Invalid code")
+	}
+	return user, nil
+}
+
+// startMFAChallenge is the answer to a right password when the user has two-factor authentication
+func (s *AuthServiceImpl) startMFAChallenge(ctx context.Context, userId string) (*dtos.LoggedUser, ports.APIError) {
+	token, err := opaque_token.New()
+	if err != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	challenge := &domain.MFAChallenge{TokenHash: opaque_token.Hash(token), UserID: userId, ExpiresAt: time.Now().Add(mfaChallengeDuration)}
+	if err := s.mfaRepo.CreateMFAChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return &dtos.LoggedUser{MFARequired: true, MFAToken: token}, nil
+}
+
+// checkMFACode accepts a code of the authenticator app or, if allowed, one of the recovery codes, which is consumed.
+// Codes of the app can not be used twice either, someone may have seen it over the user's shoulder
+func (s *AuthServiceImpl) checkMFACode(ctx context.Context, user *domain.User, code string, allowRecovery bool) (bool, ports.APIError) {
+	code = strings.TrimSpace(code)
+	if user.MFASecret == "" {
+		return false, nil
+	}
+	if step, valid := totp.Validate(user.MFASecret, code, time.Now()); valid {
+		return s.mfaRepo.UseTOTPStep(ctx, user.ID, step, time.Now().Add(3*totp.Period)) // a code is accepted at most during three periods
+	}
+	recoveryCode := normalizeRecoveryCode(code)
+	if !allowRecovery || user.MFAEnabledAt == nil || len(recoveryCode) != recoveryCodeLength {
+		return false, nil
+	}
+	return s.userSvc.UseRecoveryCode(ctx, user.ID, opaque_token.Hash(recoveryCode))
+}
+
+// newRecoveryCodes returns the codes to show to the user and the hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, recoveryCodeLength)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var code strings.Builder
+		for j, b := range buf {
+			if j == len(buf)/2 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+		}
+		codes[i] = code.String()
+		hashes[i] = opaque_token.Hash(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode lets users type the codes without the dash or in uppercase
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
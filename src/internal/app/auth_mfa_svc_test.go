@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/repos_mem"
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/totp"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const mfaTestSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+// mfaUser returns a user with two-factor authentication enabled and one recovery code
+func mfaUser(recoveryCode string) *domain.User {
+	hashed, _ := HashPassword("password")
+	enabledAt := time.Now().Add(-time.Hour)
+	return &domain.User{ID: "SampleID", Email: "john@mail.com", AuthMethod: domain.AuthMethPassword, HashedPassword: hashed,
+		MFASecret: mfaTestSecret, MFAEnabledAt: &enabledAt, RecoveryCodes: []string{opaque_token.Hash(normalizeRecoveryCode(recoveryCode))}}
+}
+
+// newMFARepository keeps the challenges and the used codes, as the database would
+func newMFARepository() ports.MFARepository {
+	return repos_mem.NewMFARepository(repos_mem.NewMemReposInfra())
+}
+
+func currentCode() string {
+	code, _ := totp.Code(mfaTestSecret, totp.Step(time.Now()))
+	return code
+}
+
+func Test_MFA_LoginNeedsCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), newMFARepository(), mocks.NewMockOIDCFlowRepository(ctrl), allowLogins(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	user := mfaUser("abcde-fghij")
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), user.Email).Return(user, nil)
+	loggedUser, err := svc.Login(ctx, dtos.LoginCredentials{Email: user.Email, Secret: "password"}, testClientIP)
+	assert.Nil(t, err)
+	assert.True(t, loggedUser.MFARequired)
+	assert.NotEmpty(t, loggedUser.MFAToken)
+	assert.Empty(t, loggedUser.AccessToken) // no token until the code is checked
+	assert.Empty(t, loggedUser.RefreshToken)
+
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), user.ID, user.ID).Return(user, nil).Times(2)
+	_, err = svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: "000000"}, testClientIP)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.Status())
+
+	tokenRepo.EXPECT().CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).Return("RefreshID", nil)
+	session, err := svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: currentCode()}, testClientIP)
+	assert.Nil(t, err)
+	claims, err2 := jwt.ValidateToken(session.AccessToken)
+	assert.Nil(t, err2)
+	assert.Equal(t, user.ID, claims.Subject)
+
+	_, err = svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: currentCode()}, testClientIP) // the MFA token is single use
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.Status())
+}
+
+func Test_MFA_CodesCanNotBeReplayed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), newMFARepository(), mocks.NewMockOIDCFlowRepository(ctrl), allowLogins(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	user := mfaUser("abcde-fghij")
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), user.Email).Return(user, nil).Times(2)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), user.ID, user.ID).Return(user, nil).Times(2)
+	tokenRepo.EXPECT().CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).Return("RefreshID", nil)
+
+	code := currentCode()
+	for i, expected := range []int{http.StatusOK, http.StatusUnauthorized} {
+		loggedUser, _ := svc.Login(ctx, dtos.LoginCredentials{Email: user.Email, Secret: "password"}, testClientIP)
+		_, err := svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: code}, testClientIP)
+		if expected == http.StatusOK {
+			assert.Nil(t, err, i)
+		} else {
+			assert.NotNil(t, err, i)
+			assert.Equal(t, expected, err.Status())
+		}
+	}
+}
+
+func Test_MFA_TooManyAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), newMFARepository(), mocks.NewMockOIDCFlowRepository(ctrl), allowLogins(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	user := mfaUser("abcde-fghij")
+	user.RecoveryCodes = nil
+	loggedUser, _ := svc.(*AuthServiceImpl).startMFAChallenge(ctx, user.ID)
+
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), user.ID, user.ID).Return(user, nil).Times(maxMFAAttempts)
+	for i := 0; i < maxMFAAttempts; i++ {
+		_, err := svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: "wrong"}, testClientIP)
+		assert.Equal(t, "Invalid code", err.Error())
+	}
+	_, err := svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: currentCode()}, testClientIP)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.Status())
+}
+
+func Test_MFA_WrongCodesCountAcrossChallenges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	throttle, _, _ := newTestThrottle(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), newMFARepository(), mocks.NewMockOIDCFlowRepository(ctrl), throttle, mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	user := mfaUser("abcde-fghij")
+	user.RecoveryCodes = nil
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), user.Email).Return(user, nil).AnyTimes()
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), user.ID, user.ID).Return(user, nil).AnyTimes()
+
+	for i := 0; i < testLockoutPolicy.DelayAfter; i++ { // the password again for every code
+		loggedUser, err := svc.Login(ctx, dtos.LoginCredentials{Email: user.Email, Secret: "password"}, testClientIP)
+		assert.Nil(t, err)
+		_, err = svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: "wrong"}, testClientIP)
+		assert.Equal(t, "Invalid code", err.Error())
+	}
+	loggedUser, err := svc.Login(ctx, dtos.LoginCredentials{Email: user.Email, Secret: "password"}, testClientIP)
+	assert.Nil(t, err)
+	_, err = svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: currentCode()}, testClientIP)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, err.Status())
+}
+
+func Test_MFA_RecoveryCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), newMFARepository(), mocks.NewMockOIDCFlowRepository(ctrl), allowLogins(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	user := mfaUser("abcde-fghij")
+	loggedUser, _ := svc.(*AuthServiceImpl).startMFAChallenge(ctx, user.ID)
+
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), user.ID, user.ID).Return(user, nil)
+	userSvc.EXPECT().UseRecoveryCode(gomock.Eq(ctx), user.ID, user.RecoveryCodes[0]).Return(true, nil)
+	tokenRepo.EXPECT().CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).Return("RefreshID", nil)
+	_, err := svc.VerifyMFA(ctx, dtos.MFAVerifyRequest{MFAToken: loggedUser.MFAToken, Code: "ABCDEFGHIJ"}, testClientIP) // typed without the dash
+	assert.Nil(t, err)
+}
+
+func Test_MFA_Enrollment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), newMFARepository(), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	user := &domain.User{ID: "SampleID", Email: "john@mail.com"}
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), user.ID, user.ID).Return(user, nil).AnyTimes()
+
+	_, err := svc.ConfirmMFAEnrollment(ctx, user.ID, dtos.MFACode{Code: "123456"})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status()) // not started
+
+	userSvc.EXPECT().
+		UpdateMFA(gomock.Eq(ctx), user.ID, gomock.Any(), nil, nil).
+		DoAndReturn(func(ctx context.Context, idUser string, secret string, enabledAt *time.Time, recoveryCodes []string) error {
+			user.MFASecret = secret
+			return nil
+		})
+	enrollment, err := svc.StartMFAEnrollment(ctx, user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, user.MFASecret, enrollment.Secret)
+	uri, _ := url.Parse(enrollment.URI)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, DefaultAuthConfig.MFAIssuer, uri.Query().Get("issuer"))
+	assert.Contains(t, uri.Path, user.Email)
+
+	_, err = svc.ConfirmMFAEnrollment(ctx, user.ID, dtos.MFACode{Code: "abcde-fghij"}) // recovery codes don't prove the app works
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+
+	code, _ := totp.Code(user.MFASecret, totp.Step(time.Now()))
+	userSvc.EXPECT().
+		UpdateMFA(gomock.Eq(ctx), user.ID, user.MFASecret, gomock.Not(gomock.Nil()), gomock.Len(recoveryCodeCount)).
+		DoAndReturn(func(ctx context.Context, idUser string, secret string, enabledAt *time.Time, recoveryCodes []string) error {
+			user.MFAEnabledAt, user.RecoveryCodes = enabledAt, recoveryCodes
+			return nil
+		})
+	recovery, err := svc.ConfirmMFAEnrollment(ctx, user.ID, dtos.MFACode{Code: code})
+	assert.Nil(t, err)
+	assert.Equal(t, recoveryCodeCount, len(recovery.Codes))
+	for i, recoveryCode := range recovery.Codes {
+		assert.Equal(t, user.RecoveryCodes[i], opaque_token.Hash(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	_, err = svc.StartMFAEnrollment(ctx, user.ID)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusConflict, err.Status())
+}
+
+func Test_MFA_Disable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), newMFARepository(), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	user := mfaUser("abcde-fghij")
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), user.ID, user.ID).Return(user, nil).AnyTimes()
+
+	userSvc.EXPECT().UseRecoveryCode(gomock.Eq(ctx), user.ID, gomock.Any()).Return(false, nil) // already used
+	err := svc.DisableMFA(ctx, user.ID, dtos.MFACode{Code: "abcde-fghij"})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+
+	userSvc.EXPECT().UpdateMFA(gomock.Eq(ctx), user.ID, "", nil, nil).Return(nil)
+	err = svc.DisableMFA(ctx, user.ID, dtos.MFACode{Code: currentCode()})
+	assert.Nil(t, err)
+}
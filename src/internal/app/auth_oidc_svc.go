@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+// Time the user has to sign in at the provider
+const oidcFlowDuration = 10 * time.Minute
+
+// StartOIDCLogin returns the provider URL where the user has to be sent to sign in, and the state the browser has to keep
+// until the callback, so the sign in can't be completed in another browser
+func (s *AuthServiceImpl) StartOIDCLogin(ctx context.Context) (*dtos.OIDCStart, ports.APIError) {
+	if s.identityProvider == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "OIDC sign in is not configured")
+	}
+	state, errState := opaque_token.New()
+	nonce, errNonce := opaque_token.New()
+	codeVerifier, errVerifier := opaque_token.New()
+	if errState != nil || errNonce != nil || errVerifier != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, "Error generating the sign in state")
+	}
+	authURL, err := s.identityProvider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		s.si.Logger.Info(fmt.Sprintf("Error starting OIDC sign in: %s", err.Error()))
+		return nil, ports.NewAPIError(http.StatusBadGateway, "The identity provider is not available")
+	}
+	flow := domain.OIDCFlow{StateHash: opaque_token.Hash(state), Nonce: nonce, CodeVerifier: codeVerifier, ExpiresAt: time.Now().Add(oidcFlowDuration)}
+	if err := s.oidcFlowRepo.CreateOIDCFlow(ctx, &flow); err != nil {
+		return nil, err
+	}
+	return &dtos.OIDCStart{AuthURL: authURL, State: state, ExpiresAt: flow.ExpiresAt}, nil
+}
+
+// CompleteOIDCLogin finishes the sign in started by StartOIDCLogin. The user is found by the provider identity or,
+// the first time, by email. Users that don't exist are created. Only emails verified by the provider are trusted
+func (s *AuthServiceImpl) CompleteOIDCLogin(ctx context.Context, callback dtos.OIDCCallback) (*dtos.LoggedUser, ports.APIError) {
+	if s.identityProvider == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "OIDC sign in is not configured")
+	}
+	if callback.Error != "" {
+		return nil, ports.NewAPIError(http.StatusUnauthorized, fmt.Sprintf("The identity provider refused the sign in: %s %s", callback.Error, callback.ErrorDescription))
+	}
+	if err := validator.ValidateStruct(callback); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if subtle.ConstantTimeCompare([]byte(callback.State), []byte(callback.BrowserState)) != 1 { // login CSRF
+		return nil, ports.NewAPIError(http.StatusBadRequest, "The sign in was not started by this browser")
+	}
+	flow, errFlow := s.oidcFlowRepo.TakeOIDCFlow(ctx, opaque_token.Hash(callback.State), time.Now())
+	if errFlow != nil {
+		if errFlow.Status() == http.StatusNotFound {
+			return nil, ports.NewAPIError(http.StatusBadRequest, "Invalid or expired sign in state")
+		}
+		return nil, errFlow
+	}
+
+	identity, err := s.identityProvider.Exchange(ctx, callback.Code, flow.CodeVerifier, flow.Nonce)
+	if err != nil {
+		s.si.Logger.Info(fmt.Sprintf("Error completing OIDC sign in: %s", err.Error()))
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "The identity provider did not confirm the sign in")
+	}
+	user, errUser := s.userForIdentity(ctx, identity)
+	if errUser != nil {
+		return nil, errUser
+	}
+	return s.signIn(ctx, user) // the provider does not replace our second factor
+}
+
+// userForIdentity returns the user linked to the identity, linking or creating it if needed
+func (s *AuthServiceImpl) userForIdentity(ctx context.Context, identity *domain.ExternalIdentity) (*domain.User, ports.APIError) {
+	if user, err := s.userSvc.GetUserByIdentity(ctx, identity.Issuer, identity.Subject); err == nil {
+		return user, nil
+	}
+	if identity.Email == "" || !identity.EmailVerified { // linking by an unverified email would let anyone take over the account
+		return nil, ports.NewAPIError(http.StatusForbidden, "The identity provider has not verified the email")
+	}
+	var user *domain.User
+	err := s.si.Tx.WithinTx(ctx, func(ctx context.Context) ports.APIError { // not a user without its identity
+		var err ports.APIError
+		user, err = s.userSvc.GetUserByEmail(ctx, identity.Email)
+		if err != nil {
+			created, errCreate := s.userSvc.CreateUser(ctx, dtos.FromExternalIdentity(identity))
+			if errCreate != nil {
+				return errCreate
+			}
+			user = &domain.User{ID: created} // brand new, no second factor yet
+		}
+		if err := s.userSvc.LinkIdentity(ctx, user.ID, identity); err != nil {
+			return err
+		}
+		return s.userSvc.MarkEmailVerified(ctx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
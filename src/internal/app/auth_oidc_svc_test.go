@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/repos_mem"
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var googleIdentity = domain.ExternalIdentity{Issuer: "https://accounts.google.com", Subject: "1234", Email: "john@gmail.com", EmailVerified: true, GivenName: "John", FamilyName: "Doe"}
+
+func newOIDCFlowRepository() ports.OIDCFlowRepository {
+	return repos_mem.NewOIDCFlowRepository(repos_mem.NewMemReposInfra())
+}
+
+// startOIDC starts a sign in and returns the state the provider will send back
+func startOIDC(t *testing.T, ctx context.Context, svc ports.AuthService, provider *mocks.MockIdentityProvider) string {
+	var state string
+	provider.EXPECT().
+		AuthCodeURL(gomock.Eq(ctx), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, st string, nonce string, codeVerifier string) (string, error) {
+			state = st
+			return "https://accounts.google.com/auth?state=" + url.QueryEscape(st), nil
+		})
+	start, err := svc.StartOIDCLogin(ctx)
+	assert.Nil(t, err)
+	assert.Contains(t, start.AuthURL, "https://accounts.google.com/auth")
+	assert.Equal(t, state, start.State)
+	assert.True(t, start.ExpiresAt.After(time.Now()))
+	return state
+}
+
+func Test_OIDC_NotConfigured(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	svc := NewAuthService(mockServiceInfra(ctrl), mocks.NewMockUserService(ctrl), mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), newOIDCFlowRepository(), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	_, err := svc.StartOIDCLogin(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.Status())
+	_, err = svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "code", State: "state"})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.Status())
+}
+
+func Test_OIDC_LinkedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	provider := mocks.NewMockIdentityProvider(ctrl)
+	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), newOIDCFlowRepository(), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), provider, DefaultAuthConfig)
+	state := startOIDC(t, ctx, svc, provider)
+
+	provider.EXPECT().Exchange(gomock.Eq(ctx), "the-code", gomock.Any(), gomock.Any()).Return(&googleIdentity, nil)
+	userSvc.EXPECT().GetUserByIdentity(gomock.Eq(ctx), googleIdentity.Issuer, googleIdentity.Subject).Return(&domain.User{ID: "SampleID"}, nil)
+	tokenRepo.EXPECT().CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).Return("RefreshID", nil)
+	loggedUser, err := svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "the-code", State: state, BrowserState: state})
+	assert.Nil(t, err)
+	claims, err2 := jwt.ValidateToken(loggedUser.AccessToken)
+	assert.Nil(t, err2)
+	assert.Equal(t, "SampleID", claims.Subject)
+
+	_, err = svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "the-code", State: state, BrowserState: state}) // the state is single use
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_OIDC_LinkByEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	provider := mocks.NewMockIdentityProvider(ctrl)
+	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), newOIDCFlowRepository(), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), provider, DefaultAuthConfig)
+	state := startOIDC(t, ctx, svc, provider)
+
+	provider.EXPECT().Exchange(gomock.Eq(ctx), "the-code", gomock.Any(), gomock.Any()).Return(&googleIdentity, nil)
+	userSvc.EXPECT().GetUserByIdentity(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found"))
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), googleIdentity.Email).Return(&domain.User{ID: "SampleID", AuthMethod: domain.AuthMethPassword}, nil)
+	userSvc.EXPECT().LinkIdentity(gomock.Eq(ctx), "SampleID", &googleIdentity).Return(nil)
+	userSvc.EXPECT().MarkEmailVerified(gomock.Eq(ctx), "SampleID").Return(nil)
+	tokenRepo.EXPECT().CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).Return("RefreshID", nil)
+	_, err := svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "the-code", State: state, BrowserState: state})
+	assert.Nil(t, err)
+}
+
+func Test_OIDC_CreateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	provider := mocks.NewMockIdentityProvider(ctrl)
+	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), newOIDCFlowRepository(), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), provider, DefaultAuthConfig)
+	state := startOIDC(t, ctx, svc, provider)
+
+	provider.EXPECT().Exchange(gomock.Eq(ctx), "the-code", gomock.Any(), gomock.Any()).Return(&googleIdentity, nil)
+	userSvc.EXPECT().GetUserByIdentity(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found"))
+	userSvc.EXPECT().GetUserByEmail(gomock.Eq(ctx), googleIdentity.Email).Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found"))
+	userSvc.EXPECT().
+		CreateUser(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, creationData *dtos.InternalUserCreate) (string, ports.APIError) {
+			assert.Equal(t, domain.AuthMethGoogle, creationData.AuthMethod)
+			assert.Equal(t, "John", creationData.FirstName)
+			assert.Equal(t, "Doe", creationData.FirstLastName)
+			assert.Equal(t, "", creationData.HashedPassword)
+			return "NewUserID", nil
+		})
+	userSvc.EXPECT().LinkIdentity(gomock.Eq(ctx), "NewUserID", &googleIdentity).Return(nil)
+	userSvc.EXPECT().MarkEmailVerified(gomock.Eq(ctx), "NewUserID").Return(nil)
+	tokenRepo.EXPECT().CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).Return("RefreshID", nil)
+	_, err := svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "the-code", State: state, BrowserState: state})
+	assert.Nil(t, err)
+}
+
+func Test_OIDC_Refused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	provider := mocks.NewMockIdentityProvider(ctrl)
+	userSvc := mocks.NewMockUserService(ctrl)
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), newOIDCFlowRepository(), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), provider, DefaultAuthConfig)
+
+	_, err := svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Error: "access_denied"})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.Status())
+	_, err = svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "the-code", State: "never-started", BrowserState: "never-started"})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+
+	state := startOIDC(t, ctx, svc, provider)
+	_, err = svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "the-code", State: state}) // another browser, without the cookie
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	_, err = svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "the-code", State: state, BrowserState: "other-state"})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	provider.EXPECT().Exchange(gomock.Eq(ctx), "bad-code", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("invalid_grant"))
+	_, err = svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "bad-code", State: state, BrowserState: state})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.Status())
+
+	unverified := googleIdentity
+	unverified.EmailVerified = false
+	state = startOIDC(t, ctx, svc, provider)
+	provider.EXPECT().Exchange(gomock.Eq(ctx), "the-code", gomock.Any(), gomock.Any()).Return(&unverified, nil)
+	userSvc.EXPECT().GetUserByIdentity(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found"))
+	_, err = svc.CompleteOIDCLogin(ctx, dtos.OIDCCallback{Code: "the-code", State: state, BrowserState: state})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+}
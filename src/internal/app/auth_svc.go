@@ -2,46 +2,243 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
 	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opo_uid"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/validator"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const refreshTokenDuration = 30 * 24 * time.Hour
+
 type AuthServiceImpl struct {
-	si      *ServiceInfra
-	userSvc ports.UserService
+	si               *ServiceInfra
+	userSvc          ports.UserService
+	tokenRepo        ports.TokenRepository
+	actionTokenRepo  ports.ActionTokenRepository
+	mfaRepo          ports.MFARepository
+	oidcFlowRepo     ports.OIDCFlowRepository
+	throttler        ports.LoginThrottler
+	mailer           ports.Mailer
+	identityProvider ports.IdentityProvider // Nil if OIDC sign in is not configured
+	config           AuthConfig
 }
 
-func NewAuthService(serviceInfra *ServiceInfra, userSvc ports.UserService) ports.AuthService {
-	return &AuthServiceImpl{si: serviceInfra, userSvc: userSvc}
+func NewAuthService(serviceInfra *ServiceInfra, userSvc ports.UserService, tokenRepo ports.TokenRepository, actionTokenRepo ports.ActionTokenRepository,
+	mfaRepo ports.MFARepository, oidcFlowRepo ports.OIDCFlowRepository, throttler ports.LoginThrottler, mailer ports.Mailer, identityProvider ports.IdentityProvider,
+	config AuthConfig) ports.AuthService {
+	return &AuthServiceImpl{si: serviceInfra, userSvc: userSvc, tokenRepo: tokenRepo, actionTokenRepo: actionTokenRepo, mfaRepo: mfaRepo,
+		oidcFlowRepo: oidcFlowRepo, throttler: throttler, mailer: mailer, identityProvider: identityProvider, config: config}
 }
 
-func (s *AuthServiceImpl) Login(ctx context.Context, credentials dtos.LoginCredentials) (*dtos.LoggedUser, ports.APIError) {
+func (s *AuthServiceImpl) Login(ctx context.Context, credentials dtos.LoginCredentials, clientIP string) (*dtos.LoggedUser, ports.APIError) {
 	if err := validator.ValidateStruct(credentials); err != nil {
 		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
 	}
+	if err := s.throttler.Check(ctx, credentials.Email, clientIP); err != nil {
+		return nil, err
+	}
+	// Unknown emails and users of external providers get the same answer, in the same time, as a wrong password
 	user, err := s.userSvc.GetUserByEmail(ctx, credentials.Email)
+	hashedPassword := dummyPasswordHash()
+	if err == nil && user.AuthMethod == domain.AuthMethPassword {
+		hashedPassword = user.HashedPassword
+	}
+	if !CheckPassword(credentials.Secret, hashedPassword) || err != nil || user.AuthMethod != domain.AuthMethPassword {
+		s.throttler.Failed(ctx, credentials.Email, clientIP)
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid credentials")
+	}
+	s.throttler.Succeeded(ctx, credentials.Email, clientIP)
+	if s.config.RequireVerifiedEmail && user.EmailVerifiedAt == nil { // checked after the password so it does not tell which emails exist
+		return nil, ports.NewAPIError(http.StatusForbidden, "Email not verified")
+	}
+
+	return s.signIn(ctx, user)
+}
+
+// SignUp creates a user with password authentication, signs it in and sends the email to verify the address. If verified
+// emails are required, the user gets no session until the address is verified, or anyone could sign up with someone else's
+func (s *AuthServiceImpl) SignUp(ctx context.Context, signUp dtos.UserSignUp) (*dtos.LoggedUser, ports.APIError) {
+	if err := validator.ValidateStruct(signUp); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := s.config.PasswordPolicy.Check(signUp.Secret); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	hashedPassword, err := HashPassword(signUp.Secret)
+	if err != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	userId, errCreate := s.userSvc.CreateUser(ctx, dtos.FromDtosUserSignUp(&signUp, hashedPassword))
+	if errCreate != nil {
+		return nil, errCreate
+	}
+	loggedUser := &dtos.LoggedUser{UserID: userId, EmailVerificationRequired: true}
+	if !s.config.RequireVerifiedEmail {
+		var errSession ports.APIError
+		if loggedUser, errSession = s.startSession(ctx, userId, opo_uid.New(), ""); errSession != nil {
+			return nil, errSession
+		}
+	}
+	s.sendVerificationEmail(ctx, userId, signUp.Email) // last, a slow mail server does not cost the session. It can be asked for again
+	return loggedUser, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token (rotation).
+// Reusing a refresh token revokes every token of its family, closing the session for the thief and for the legit user.
+func (s *AuthServiceImpl) Refresh(ctx context.Context, request dtos.RefreshTokenRequest) (*dtos.LoggedUser, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	stored, err := s.tokenRepo.GetRefreshTokenByHash(ctx, opaque_token.Hash(request.RefreshToken))
+	if err != nil {
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid refresh token")
+	}
+	if stored.RevokedAt != nil {
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid refresh token")
+	}
+	if stored.UsedAt != nil {
+		return nil, s.refreshTokenReused(ctx, stored)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Refresh token expired")
+	}
+	marked, err := s.tokenRepo.MarkRefreshTokenUsed(ctx, stored.ID)
 	if err != nil {
 		return nil, err
 	}
-	if user.AuthMethod == domain.AuthMethPassword {
-		if !CheckPassword(credentials.Secret, user.HashedPassword) {
-			return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid credentials")
+	if !marked { // someone else used it between our read and our update
+		return nil, s.refreshTokenReused(ctx, stored)
+	}
+	user, errUser := s.userSvc.GetUserById(ctx, stored.UserID, stored.UserID)
+	if errUser != nil { // the user may have been removed
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Invalid refresh token")
+	}
+	if s.config.RequireVerifiedEmail && user.EmailVerifiedAt == nil { // sessions started before it was required
+		return nil, ports.NewAPIError(http.StatusForbidden, "Email not verified")
+	}
+	return s.startSession(ctx, stored.UserID, stored.FamilyID, s.sessionTenant(ctx, stored))
+}
+
+// sessionTenant returns the tenant of the session being refreshed, or none if the user no longer belongs to it
+func (s *AuthServiceImpl) sessionTenant(ctx context.Context, stored *domain.RefreshToken) string {
+	if stored.TenantID == "" {
+		return ""
+	}
+	if _, err := s.si.Organizations.GetMembership(ctx, stored.TenantID, stored.UserID); err != nil {
+		return ""
+	}
+	return stored.TenantID
+}
+
+// SwitchTenant starts a new session acting on behalf of one of the organizations of the user.
+// The current session keeps its tenant until it is closed or expires
+func (s *AuthServiceImpl) SwitchTenant(ctx context.Context, byUser string, request dtos.TenantSwitch) (*dtos.LoggedUser, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if _, err := s.si.Organizations.GetMembership(ctx, request.Tenant, byUser); err != nil {
+		if err.Status() == http.StatusNotFound {
+			return nil, ports.NewAPIError(http.StatusForbidden, "The organization is not accessible")
 		}
-	} else {
-		return nil, ports.NewAPIError(http.StatusBadRequest, "Only password authentication is currently supported")
+		return nil, err
 	}
+	return s.startSession(ctx, byUser, opo_uid.New(), request.Tenant)
+}
 
-	token, err2 := jwt.CreateToken(user.ID)
-	if err2 != nil {
-		return nil, ports.NewAPIError(http.StatusInternalServerError, err2.Error())
+func (s *AuthServiceImpl) refreshTokenReused(ctx context.Context, stored *domain.RefreshToken) ports.APIError {
+	s.si.Logger.Info(fmt.Sprintf("Refresh token reuse detected for user %s. Revoking token family %s", stored.UserID, stored.FamilyID))
+	if err := s.tokenRepo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		return err
 	}
-	return &dtos.LoggedUser{AccessToken: token}, nil
+	return ports.NewAPIError(http.StatusUnauthorized, "Invalid refresh token")
+}
+
+// SignOut revokes the access token used in the call and, if provided, the refresh token of the session
+func (s *AuthServiceImpl) SignOut(ctx context.Context, byUser string, tokenId string, tokenExpiration time.Time, request dtos.SignOutRequest) ports.APIError {
+	if tokenId == "" {
+		return ports.NewAPIError(http.StatusBadRequest, "The token can not be revoked")
+	}
+	if err := s.tokenRepo.RevokeAccessToken(ctx, tokenId, byUser, tokenExpiration); err != nil {
+		return err
+	}
+	s.si.Cache.SetWithTTL(revokedTokenCacheKey(tokenId), true, time.Until(tokenExpiration))
+
+	if request.RefreshToken != "" {
+		stored, err := s.tokenRepo.GetRefreshTokenByHash(ctx, opaque_token.Hash(request.RefreshToken))
+		if err == nil && stored.UserID == byUser { // we don't let anyone close other people's sessions
+			if err := s.tokenRepo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IsTokenRevoked tells whether an access token was revoked before its expiration. Errors are considered revocations
+func (s *AuthServiceImpl) IsTokenRevoked(ctx context.Context, tokenId string) bool {
+	cacheKey := revokedTokenCacheKey(tokenId)
+	if _, found := s.si.Cache.Get(cacheKey); found { // only revocations are cached so they are seen immediately by every instance
+		return true
+	}
+	revoked, err := s.tokenRepo.IsAccessTokenRevoked(ctx, tokenId)
+	if err != nil {
+		s.si.Logger.Info(fmt.Sprintf("Error checking token revocation: %s", err.Error()))
+		return true
+	}
+	if revoked {
+		s.si.Cache.SetWithTTL(cacheKey, true, jwt.AccessTokenDuration)
+	}
+	return revoked
+}
+
+func revokedTokenCacheKey(tokenId string) string {
+	return "auth.revoked." + tokenId
+}
+
+// signIn starts a session for the user or, if the user has two-factor authentication, asks for the code first
+func (s *AuthServiceImpl) signIn(ctx context.Context, user *domain.User) (*dtos.LoggedUser, ports.APIError) {
+	if user.MFAEnabledAt != nil {
+		return s.startMFAChallenge(ctx, user.ID)
+	}
+	return s.startSession(ctx, user.ID, opo_uid.New(), "")
+}
+
+// startSession issues an access token and a refresh token belonging to the given family, acting on behalf of the tenant (if any)
+func (s *AuthServiceImpl) startSession(ctx context.Context, userId string, familyId string, tenant string) (*dtos.LoggedUser, ports.APIError) {
+	claims := jwt.UserClaims(userId)
+	claims.Tenant = tenant
+	accessToken, err := jwt.IssueToken(claims, jwt.AccessTokenDuration)
+	if err != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	refreshToken, err := opaque_token.New()
+	if err != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	_, errRepo := s.tokenRepo.CreateRefreshToken(ctx, &domain.RefreshToken{
+		UserID:    userId,
+		FamilyID:  familyId,
+		TenantID:  tenant,
+		TokenHash: opaque_token.Hash(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenDuration),
+	})
+	if errRepo != nil {
+		return nil, errRepo
+	}
+	return &dtos.LoggedUser{
+		AccessToken:  accessToken.Token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(jwt.AccessTokenDuration.Seconds()),
+	}, nil
 }
 
 // HashPassword generates a bcrypt hash of the password
@@ -53,8 +250,55 @@ func HashPassword(password string) (string, error) {
 	return string(hashedBytes), nil
 }
 
+// dummyPasswordHash is checked instead of the real one when there is none, so the answer takes the same time
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hashed, _ := HashPassword("there is no user with this password")
+	return hashed
+})
+
 // CheckPassword compares a password with a hash to check if they match
 func CheckPassword(password, hashedPassword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil // Returns true if passwords match
 }
+
+// ExportUserData returns nothing: the sessions and the links sent by email are secrets, not data of the user
+func (s *AuthServiceImpl) ExportUserData(ctx context.Context, user *domain.User) (interface{}, ports.APIError) {
+	return nil, nil
+}
+
+// EraseUserData deletes the sessions, the links sent by email and the two-factor challenges, and forgets the failed sign
+// ins of the account. The revoked access tokens are kept, without the user, until they expire, so the revocations still hold
+func (s *AuthServiceImpl) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	if err := s.tokenRepo.DeleteUserRefreshTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.actionTokenRepo.DeleteUserActionTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.mfaRepo.DeleteUserMFA(ctx, user.ID); err != nil {
+		return err
+	}
+	s.throttler.Succeeded(ctx, user.Email, "")
+	s.throttler.SucceededMFA(ctx, user.ID)
+	return nil
+}
+
+// PurgeExpired deletes the tokens, two-factor challenges and OIDC sign ins that can no longer be used, and the failed
+// sign ins too old to count
+func (s *AuthServiceImpl) PurgeExpired(ctx context.Context) ports.APIError {
+	now := time.Now()
+	if err := s.tokenRepo.DeleteExpiredTokens(ctx, now); err != nil {
+		return err
+	}
+	if err := s.actionTokenRepo.DeleteExpiredActionTokens(ctx, now); err != nil {
+		return err
+	}
+	if err := s.mfaRepo.DeleteExpiredMFA(ctx, now); err != nil {
+		return err
+	}
+	if err := s.oidcFlowRepo.DeleteExpiredOIDCFlows(ctx, now); err != nil {
+		return err
+	}
+	return s.throttler.Purge(ctx)
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
 	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opaque_token"
 	"github.com/Manolo-Esc/gommence/src/internal/mocks"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/cache"
@@ -27,6 +29,20 @@ func Test_PasswordHashes(t *testing.T) {
 	}
 }
 
+const testClientIP = "203.0.113.7"
+
+// allowLogins returns a throttler that never refuses a sign in
+func allowLogins(ctrl *gomock.Controller) *mocks.MockLoginThrottler {
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	throttler.EXPECT().Failed(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	throttler.EXPECT().Succeeded(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	throttler.EXPECT().CheckMFA(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	throttler.EXPECT().FailedMFA(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	throttler.EXPECT().SucceededMFA(gomock.Any(), gomock.Any()).AnyTimes()
+	return throttler
+}
+
 var invalidLoginCredentials = []dtos.LoginCredentials{
 	{Email: "", Secret: "password"},                // no email
 	{Email: "john@mail.com", Secret: ""},           // no secret
@@ -42,10 +58,11 @@ func Test_LoginWith_InvalidData(t *testing.T) {
 	perm := mocks.NewMockPermissionService(ctrl)
 
 	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
 
-	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc)
+	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
 	for _, loginCredentials := range invalidLoginCredentials {
-		loggedUser, err := svc.Login(ctx, loginCredentials)
+		loggedUser, err := svc.Login(ctx, loginCredentials, testClientIP)
 		assert.NotNil(t, err)
 		assert.Equal(t, http.StatusBadRequest, err.Status())
 		assert.Nil(t, loggedUser)
@@ -59,15 +76,20 @@ func Test_LoginWith_UserNotFound(t *testing.T) {
 	perm := mocks.NewMockPermissionService(ctrl)
 
 	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
 	userSvc.EXPECT().
 		GetUserByEmail(gomock.Eq(ctx), gomock.Any()).
 		Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found")) // to indicate that we don't have a user with that email
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Eq(ctx), "j1@mail.com", testClientIP).Return(nil)
+	throttler.EXPECT().Failed(gomock.Eq(ctx), "j1@mail.com", testClientIP)
 
-	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc)
+	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
 	loginCredentials := dtos.LoginCredentials{Email: "j1@mail.com", Secret: "password"}
-	loggedUser, err := svc.Login(ctx, loginCredentials)
+	loggedUser, err := svc.Login(ctx, loginCredentials, testClientIP)
 	assert.NotNil(t, err)
-	assert.Equal(t, http.StatusNotFound, err.Status())
+	assert.Equal(t, http.StatusUnauthorized, err.Status()) // the same as a wrong password, not to tell which emails exist
+	assert.Equal(t, "Invalid credentials", err.Error())
 	assert.Nil(t, loggedUser)
 }
 
@@ -78,16 +100,21 @@ func Test_LoginWith_BadAuthMethod(t *testing.T) {
 	perm := mocks.NewMockPermissionService(ctrl)
 
 	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
 	userSvc.EXPECT().
 		GetUserByEmail(gomock.Eq(ctx), gomock.Any()).
 		Return(&domain.User{ID: "SampleID", AuthMethod: domain.AuthMethGoogle}, nil)
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Eq(ctx), "j1@mail.com", testClientIP).Return(nil)
+	throttler.EXPECT().Failed(gomock.Eq(ctx), "j1@mail.com", testClientIP)
 
-	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc)
+	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
 	loginCredentials := dtos.LoginCredentials{Email: "j1@mail.com", Secret: "password"}
-	loggedUser, err := svc.Login(ctx, loginCredentials)
+	loggedUser, err := svc.Login(ctx, loginCredentials, testClientIP)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, http.StatusBadRequest, err.Status())
+	assert.Equal(t, http.StatusUnauthorized, err.Status())
+	assert.Equal(t, "Invalid credentials", err.Error())
 	assert.Nil(t, loggedUser)
 }
 
@@ -102,13 +129,17 @@ func Test_LoginWith_WrongPassword(t *testing.T) {
 	hashedPassword, err1 := HashPassword(password)
 	assert.Nil(t, err1)
 	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
 	userSvc.EXPECT().
 		GetUserByEmail(gomock.Eq(ctx), email).
 		Return(&domain.User{ID: "SampleID", AuthMethod: domain.AuthMethPassword, HashedPassword: hashedPassword}, nil)
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Eq(ctx), email, testClientIP).Return(nil)
+	throttler.EXPECT().Failed(gomock.Eq(ctx), email, testClientIP)
 
-	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc)
+	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
 	loginCredentials := dtos.LoginCredentials{Email: email, Secret: "wrong-password"}
-	loggedUser, err := svc.Login(ctx, loginCredentials)
+	loggedUser, err := svc.Login(ctx, loginCredentials, testClientIP)
 
 	assert.NotNil(t, err)
 	assert.Equal(t, http.StatusUnauthorized, err.Status())
@@ -126,16 +157,299 @@ func Test_Login_HappyPath(t *testing.T) {
 	hashedPassword, err1 := HashPassword(password)
 	assert.Nil(t, err1)
 	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
 	userSvc.EXPECT().
 		GetUserByEmail(gomock.Eq(ctx), email).
 		Return(&domain.User{ID: "SampleID", AuthMethod: domain.AuthMethPassword, HashedPassword: hashedPassword}, nil)
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Eq(ctx), email, testClientIP).Return(nil)
+	throttler.EXPECT().Succeeded(gomock.Eq(ctx), email, testClientIP)
+	tokenRepo.EXPECT().
+		CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, token *domain.RefreshToken) (string, ports.APIError) {
+			assert.Equal(t, "SampleID", token.UserID)
+			assert.NotEmpty(t, token.FamilyID)
+			assert.True(t, token.ExpiresAt.After(time.Now()))
+			return "RefreshID", nil
+		})
 
-	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc)
+	svc := NewAuthService(&ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
 	loginCredentials := dtos.LoginCredentials{Email: email, Secret: password}
-	loggedUser, err := svc.Login(ctx, loginCredentials)
+	loggedUser, err := svc.Login(ctx, loginCredentials, testClientIP)
 	assert.Nil(t, err)
 	assert.NotEqual(t, "", loggedUser.AccessToken)
 	claims, err2 := jwt.ValidateToken(loggedUser.AccessToken)
 	assert.Nil(t, err2)
-	assert.Equal(t, "SampleID", claims["user"])
+	assert.Equal(t, "SampleID", claims.Subject)
+	assert.NotEqual(t, "", loggedUser.RefreshToken)
+	assert.Equal(t, int(jwt.AccessTokenDuration.Seconds()), loggedUser.ExpiresIn)
+}
+
+func Test_Login_Throttled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	throttler := mocks.NewMockLoginThrottler(ctrl)
+	throttler.EXPECT().Check(gomock.Eq(ctx), "j1@mail.com", testClientIP).Return(ports.NewAPIError(http.StatusTooManyRequests, "Too many failed attempts, try again later"))
+	svc := NewAuthService(mockServiceInfra(ctrl), mocks.NewMockUserService(ctrl), mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), throttler, mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	_, err := svc.Login(ctx, dtos.LoginCredentials{Email: "j1@mail.com", Secret: "password"}, testClientIP) // the credentials are not even checked
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, err.Status())
+}
+
+var validSignUp = dtos.UserSignUp{FirstName: "John", FirstLastName: "Doe", Email: "john@mail.com", Secret: "a long password"}
+
+func Test_SignUp_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().
+		CreateUser(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, creationData *dtos.InternalUserCreate) (string, ports.APIError) {
+			assert.Equal(t, validSignUp.Email, creationData.Email)
+			assert.Equal(t, domain.AuthMethPassword, creationData.AuthMethod)
+			assert.NotEqual(t, validSignUp.Secret, creationData.HashedPassword) // never store the plain password
+			assert.True(t, CheckPassword(validSignUp.Secret, creationData.HashedPassword))
+			return "NewUserID", nil
+		})
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).Return("RefreshID", nil)
+	actionTokenRepo := mocks.NewMockActionTokenRepository(ctrl)
+	actionTokenRepo.EXPECT().InvalidateActionTokens(gomock.Eq(ctx), "NewUserID", domain.ActionVerifyEmail).Return(nil)
+	actionTokenRepo.EXPECT().CreateActionToken(gomock.Eq(ctx), gomock.Any()).Return("ActionID", nil)
+	mailer := mocks.NewMockMailer(ctrl)
+	mailer.EXPECT().
+		Send(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, message ports.MailMessage) error {
+			assert.Equal(t, validSignUp.Email, message.To)
+			assert.Contains(t, message.Body, "/verify-email?token=")
+			return nil
+		})
+
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, actionTokenRepo, mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mailer, nil, DefaultAuthConfig)
+	loggedUser, err := svc.SignUp(ctx, validSignUp)
+	assert.Nil(t, err)
+	claims, err2 := jwt.ValidateToken(loggedUser.AccessToken)
+	assert.Nil(t, err2)
+	assert.Equal(t, "NewUserID", claims.Subject)
+	assert.NotEqual(t, "", loggedUser.RefreshToken)
+}
+
+func Test_SignUp_InvalidData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	strictConfig := DefaultAuthConfig
+	strictConfig.PasswordPolicy.RequireDigit = true
+	invalidSignUps := []dtos.UserSignUp{
+		{FirstName: "John", FirstLastName: "Doe", Email: "johnmail.com", Secret: "a long password"}, // bad email
+		{FirstName: "", FirstLastName: "Doe", Email: "john@mail.com", Secret: "a long password"},    // no first name
+		{FirstName: "John", FirstLastName: "Doe", Email: "john@mail.com", Secret: ""},               // no secret
+		{FirstName: "John", FirstLastName: "Doe", Email: "john@mail.com", Secret: "short"},          // too short
+		validSignUp, // no digit
+	}
+	svc := NewAuthService(mockServiceInfra(ctrl), mocks.NewMockUserService(ctrl), mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, strictConfig)
+	for _, signUp := range invalidSignUps {
+		loggedUser, err := svc.SignUp(ctx, signUp)
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.Status())
+		assert.Nil(t, loggedUser)
+	}
+}
+
+func Test_SignUp_UserExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().CreateUser(gomock.Eq(ctx), gomock.Any()).Return("", ports.NewAPIError(http.StatusBadRequest, "User already exists"))
+
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, mocks.NewMockTokenRepository(ctrl), mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	loggedUser, err := svc.SignUp(ctx, validSignUp)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	assert.Nil(t, loggedUser)
+}
+
+func Test_Refresh_HappyPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	refreshToken := "a-refresh-token"
+	stored := &domain.RefreshToken{ID: "RefreshID", UserID: "SampleID", FamilyID: "FamilyID", TokenHash: opaque_token.Hash(refreshToken), ExpiresAt: time.Now().Add(time.Hour)}
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), "SampleID", "SampleID").Return(&domain.User{ID: "SampleID"}, nil)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), stored.TokenHash).Return(stored, nil)
+	tokenRepo.EXPECT().MarkRefreshTokenUsed(gomock.Eq(ctx), "RefreshID").Return(true, nil)
+	tokenRepo.EXPECT().
+		CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, token *domain.RefreshToken) (string, ports.APIError) {
+			assert.Equal(t, "FamilyID", token.FamilyID) // rotated tokens stay in the family
+			assert.NotEqual(t, stored.TokenHash, token.TokenHash)
+			return "RefreshID2", nil
+		})
+
+	svc := NewAuthService(mockServiceInfra(ctrl), userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	loggedUser, err := svc.Refresh(ctx, dtos.RefreshTokenRequest{RefreshToken: refreshToken})
+	assert.Nil(t, err)
+	assert.NotEqual(t, refreshToken, loggedUser.RefreshToken)
+	claims, err2 := jwt.ValidateToken(loggedUser.AccessToken)
+	assert.Nil(t, err2)
+	assert.Equal(t, "SampleID", claims.Subject)
+}
+
+func Test_Refresh_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	revokedAt := time.Now().Add(-time.Minute)
+	storedTokens := []*domain.RefreshToken{
+		{ID: "Revoked", UserID: "SampleID", FamilyID: "FamilyID", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+		{ID: "Expired", UserID: "SampleID", FamilyID: "FamilyID", ExpiresAt: time.Now().Add(-time.Hour)},
+	}
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), opaque_token.Hash("unknown")).Return(nil, ports.NewAPIError(http.StatusNotFound, "Refresh token not found"))
+	for _, stored := range storedTokens {
+		tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), opaque_token.Hash(stored.ID)).Return(stored, nil)
+	}
+
+	svc := NewAuthService(mockServiceInfra(ctrl), mocks.NewMockUserService(ctrl), tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	for _, token := range []string{"unknown", "Revoked", "Expired"} {
+		loggedUser, err := svc.Refresh(ctx, dtos.RefreshTokenRequest{RefreshToken: token})
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, err.Status())
+		assert.Nil(t, loggedUser)
+	}
+	_, err := svc.Refresh(ctx, dtos.RefreshTokenRequest{})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_Refresh_ReuseRevokesFamily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	usedAt := time.Now().Add(-time.Minute)
+	used := &domain.RefreshToken{ID: "Used", UserID: "SampleID", FamilyID: "FamilyID", ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+	raced := &domain.RefreshToken{ID: "Raced", UserID: "SampleID", FamilyID: "FamilyID2", ExpiresAt: time.Now().Add(time.Hour)}
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), opaque_token.Hash("Used")).Return(used, nil)
+	tokenRepo.EXPECT().RevokeRefreshTokenFamily(gomock.Eq(ctx), "FamilyID").Return(nil)
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), opaque_token.Hash("Raced")).Return(raced, nil)
+	tokenRepo.EXPECT().MarkRefreshTokenUsed(gomock.Eq(ctx), "Raced").Return(false, nil) // a concurrent call used it first
+	tokenRepo.EXPECT().RevokeRefreshTokenFamily(gomock.Eq(ctx), "FamilyID2").Return(nil)
+
+	svc := NewAuthService(mockServiceInfra(ctrl), mocks.NewMockUserService(ctrl), tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	for _, token := range []string{"Used", "Raced"} {
+		loggedUser, err := svc.Refresh(ctx, dtos.RefreshTokenRequest{RefreshToken: token})
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, err.Status())
+		assert.Nil(t, loggedUser)
+	}
+}
+
+func Test_SwitchTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	serviceInfra := mockServiceInfra(ctrl)
+	organizations := serviceInfra.Organizations.(*mocks.MockOrganizationService)
+	organizations.EXPECT().GetMembership(gomock.Eq(ctx), "OrgID", "SampleID").Return(&domain.Membership{OrganizationID: "OrgID", UserID: "SampleID", Role: domain.OrgRoleMember}, nil)
+	organizations.EXPECT().GetMembership(gomock.Eq(ctx), "OtherOrgID", "SampleID").Return(nil, ports.NewAPIError(http.StatusNotFound, "Membership not found"))
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().
+		CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, token *domain.RefreshToken) (string, ports.APIError) {
+			assert.Equal(t, "OrgID", token.TenantID) // kept when the session is refreshed
+			return "RefreshID", nil
+		})
+
+	svc := NewAuthService(serviceInfra, mocks.NewMockUserService(ctrl), tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	loggedUser, err := svc.SwitchTenant(ctx, "SampleID", dtos.TenantSwitch{Tenant: "OrgID"})
+	assert.Nil(t, err)
+	claims, err2 := jwt.ValidateToken(loggedUser.AccessToken)
+	assert.Nil(t, err2)
+	assert.Equal(t, "OrgID", claims.Tenant)
+
+	_, err = svc.SwitchTenant(ctx, "SampleID", dtos.TenantSwitch{Tenant: "OtherOrgID"})
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	_, err = svc.SwitchTenant(ctx, "SampleID", dtos.TenantSwitch{})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_Refresh_DropsTenantOfFormerMembers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	stored := &domain.RefreshToken{ID: "RefreshID", UserID: "SampleID", FamilyID: "FamilyID", TenantID: "OrgID", TokenHash: opaque_token.Hash("token"), ExpiresAt: time.Now().Add(time.Hour)}
+	serviceInfra := mockServiceInfra(ctrl)
+	organizations := serviceInfra.Organizations.(*mocks.MockOrganizationService)
+	organizations.EXPECT().GetMembership(gomock.Eq(ctx), "OrgID", "SampleID").Return(nil, ports.NewAPIError(http.StatusNotFound, "Membership not found"))
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserById(gomock.Eq(ctx), "SampleID", "SampleID").Return(&domain.User{ID: "SampleID"}, nil)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), stored.TokenHash).Return(stored, nil)
+	tokenRepo.EXPECT().MarkRefreshTokenUsed(gomock.Eq(ctx), "RefreshID").Return(true, nil)
+	tokenRepo.EXPECT().CreateRefreshToken(gomock.Eq(ctx), gomock.Any()).Return("RefreshID2", nil)
+
+	svc := NewAuthService(serviceInfra, userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	loggedUser, err := svc.Refresh(ctx, dtos.RefreshTokenRequest{RefreshToken: "token"})
+	assert.Nil(t, err)
+	claims, err2 := jwt.ValidateToken(loggedUser.AccessToken)
+	assert.Nil(t, err2)
+	assert.Equal(t, "", claims.Tenant) // the session goes on, but no longer on behalf of the organization
+}
+
+func Test_SignOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	expiration := time.Now().Add(time.Minute)
+	mine := &domain.RefreshToken{ID: "Mine", UserID: "SampleID", FamilyID: "FamilyID", ExpiresAt: time.Now().Add(time.Hour)}
+	others := &domain.RefreshToken{ID: "Others", UserID: "OtherID", FamilyID: "OtherFamilyID", ExpiresAt: time.Now().Add(time.Hour)}
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().RevokeAccessToken(gomock.Eq(ctx), "TokenID", "SampleID", expiration).Return(nil).Times(2)
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), opaque_token.Hash("Mine")).Return(mine, nil)
+	tokenRepo.EXPECT().RevokeRefreshTokenFamily(gomock.Eq(ctx), "FamilyID").Return(nil)
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Eq(ctx), opaque_token.Hash("Others")).Return(others, nil) // no family revocation expected
+
+	si := mockServiceInfra(ctrl)
+	svc := NewAuthService(si, mocks.NewMockUserService(ctrl), tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	err := svc.SignOut(ctx, "SampleID", "TokenID", expiration, dtos.SignOutRequest{RefreshToken: "Mine"})
+	assert.Nil(t, err)
+	err = svc.SignOut(ctx, "SampleID", "TokenID", expiration, dtos.SignOutRequest{RefreshToken: "Others"})
+	assert.Nil(t, err)
+	err = svc.SignOut(ctx, "SampleID", "", expiration, dtos.SignOutRequest{})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_IsTokenRevoked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	tokenRepo.EXPECT().IsAccessTokenRevoked(gomock.Eq(ctx), "Valid").Return(false, nil).Times(2) // not cached
+	tokenRepo.EXPECT().IsAccessTokenRevoked(gomock.Eq(ctx), "Revoked").Return(true, nil).Times(1)
+	tokenRepo.EXPECT().IsAccessTokenRevoked(gomock.Eq(ctx), "Failing").Return(false, ports.NewAPIError(http.StatusInternalServerError, "db down"))
+
+	si := mockServiceInfra(ctrl)
+	svc := NewAuthService(si, mocks.NewMockUserService(ctrl), tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), mocks.NewMockOIDCFlowRepository(ctrl), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), nil, DefaultAuthConfig)
+	assert.False(t, svc.IsTokenRevoked(ctx, "Valid"))
+	assert.False(t, svc.IsTokenRevoked(ctx, "Valid"))
+	assert.True(t, svc.IsTokenRevoked(ctx, "Revoked"))
+	time.Sleep(50 * time.Millisecond)                  // let the cache consolidate the write
+	assert.True(t, svc.IsTokenRevoked(ctx, "Revoked")) // from cache
+	assert.True(t, svc.IsTokenRevoked(ctx, "Failing"))
 }
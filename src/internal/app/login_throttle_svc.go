@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+// LockoutPolicy sets how failed sign ins are slowed down and locked. Accounts and IP addresses are counted apart:
+// the first stops the attacks on one account, the second the attacks trying a few passwords on many accounts.
+// Only accounts wait between attempts; an IP address may be a proxy or a NAT shared by many users, a few typos must
+// not slow them all down, so it is only locked at its threshold.
+// The wrong two-factor codes of each user are counted apart too, with the same delays
+type LockoutPolicy struct {
+	FailureWindow    time.Duration // Failures are forgotten after this time without new ones
+	DelayAfter       int           // Failures allowed before the next sign in has to wait
+	BaseDelay        time.Duration // First wait, doubled with every new failure
+	MaxDelay         time.Duration
+	AccountThreshold int // Failures that lock the account
+	IPThreshold      int // Failures that lock the IP address, whatever the accounts
+	MFAThreshold     int // Wrong codes that lock the second factor of the user, whatever the challenges
+	LockoutDuration  time.Duration
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	FailureWindow:    15 * time.Minute,
+	DelayAfter:       3,
+	BaseDelay:        1 * time.Second,
+	MaxDelay:         30 * time.Second,
+	AccountThreshold: 10,
+	IPThreshold:      100,
+	MFAThreshold:     10,
+	LockoutDuration:  15 * time.Minute,
+}
+
+// nextAttemptAt returns when the next sign in is allowed after the failures. Zero if there is no need to wait
+func (p LockoutPolicy) nextAttemptAt(attempts *domain.LoginAttempts) time.Time {
+	if attempts.Failures < p.DelayAfter {
+		return time.Time{}
+	}
+	delay := p.BaseDelay
+	for i := p.DelayAfter; i < attempts.Failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return attempts.LastFailureAt.Add(min(delay, p.MaxDelay))
+}
+
+type LoginThrottleImpl struct {
+	si     *ServiceInfra
+	repo   ports.LoginAttemptRepository
+	audit  ports.AuditService
+	policy LockoutPolicy
+}
+
+func NewLoginThrottle(serviceInfra *ServiceInfra, repo ports.LoginAttemptRepository, audit ports.AuditService, policy LockoutPolicy) ports.LoginThrottler {
+	return &LoginThrottleImpl{si: serviceInfra, repo: repo, audit: audit, policy: policy}
+}
+
+// Check refuses the sign in while the account or the IP are locked or have to wait. The answer is the same for
+// emails that do not exist, so it does not tell which ones do
+func (t *LoginThrottleImpl) Check(ctx context.Context, email string, clientIP string) ports.APIError {
+	return t.check(ctx, attemptKeys(email, clientIP))
+}
+
+// Failed counts the failure in the database, in a single statement, so concurrent sign ins, also on other servers,
+// are all counted. The count it returns decides the lockout
+func (t *LoginThrottleImpl) Failed(ctx context.Context, email string, clientIP string) {
+	t.failed(ctx, attemptKeys(email, clientIP), clientIP)
+}
+
+// Succeeded forgets the failures of the account. Those of the IP are kept, it may be attacking other accounts
+func (t *LoginThrottleImpl) Succeeded(ctx context.Context, email string, clientIP string) {
+	t.forget(ctx, accountKeyPrefix+normalizeEmail(email))
+}
+
+func (t *LoginThrottleImpl) CheckMFA(ctx context.Context, userId string) ports.APIError {
+	return t.check(ctx, []string{mfaKeyPrefix + userId})
+}
+
+func (t *LoginThrottleImpl) FailedMFA(ctx context.Context, userId string, clientIP string) {
+	t.failed(ctx, []string{mfaKeyPrefix + userId}, clientIP)
+}
+
+func (t *LoginThrottleImpl) SucceededMFA(ctx context.Context, userId string) {
+	t.forget(ctx, mfaKeyPrefix+userId)
+}
+
+func (t *LoginThrottleImpl) check(ctx context.Context, keys []string) ports.APIError {
+	now := time.Now()
+	for _, key := range keys {
+		attempts := t.load(ctx, key)
+		if attempts.IsLocked(now) || (!strings.HasPrefix(key, ipKeyPrefix) && now.Before(t.policy.nextAttemptAt(attempts))) {
+			return ports.NewAPIError(http.StatusTooManyRequests, "Too many failed attempts, try again later")
+		}
+	}
+	return nil
+}
+
+func (t *LoginThrottleImpl) failed(ctx context.Context, keys []string, clientIP string) {
+	now := time.Now()
+	for _, key := range keys {
+		attempts, err := t.repo.AddLoginFailure(ctx, key, now, now.Add(-t.policy.FailureWindow))
+		if err != nil {
+			t.si.Logger.Info(fmt.Sprintf("Error saving login attempts: %s", err.Error()))
+			continue
+		}
+		eventType, threshold := t.lockoutOf(key)
+		if attempts.Failures < threshold {
+			continue
+		}
+		lockedUntil := now.Add(t.policy.LockoutDuration)
+		locked, err := t.repo.LockLoginAttempts(ctx, key, threshold, lockedUntil)
+		if err != nil {
+			t.si.Logger.Info(fmt.Sprintf("Error locking login attempts: %s", err.Error()))
+			continue
+		}
+		if !locked {
+			continue // a concurrent failure locked it first
+		}
+		t.audit.Record(ctx, &domain.AuditEvent{
+			Type:    eventType,
+			Target:  strings.SplitN(key, ":", 2)[1],
+			IP:      clientIP,
+			Details: fmt.Sprintf("%d failed sign ins, locked until %s", attempts.Failures, lockedUntil.Format(time.RFC3339)),
+		})
+	}
+}
+
+func (t *LoginThrottleImpl) forget(ctx context.Context, key string) {
+	if attempts := t.load(ctx, key); attempts.Failures == 0 && attempts.LockedUntil == nil {
+		return // nothing to forget, save the write
+	}
+	if err := t.repo.DeleteLoginAttempts(ctx, key); err != nil {
+		t.si.Logger.Info(fmt.Sprintf("Error deleting login attempts: %s", err.Error()))
+	}
+}
+
+// lockoutOf returns the event recorded when the key is locked and the failures that lock it
+func (t *LoginThrottleImpl) lockoutOf(key string) (domain.AuditEventType, int) {
+	switch {
+	case strings.HasPrefix(key, ipKeyPrefix):
+		return domain.AuditIPLocked, t.policy.IPThreshold
+	case strings.HasPrefix(key, mfaKeyPrefix):
+		return domain.AuditMFALocked, t.policy.MFAThreshold
+	}
+	return domain.AuditAccountLocked, t.policy.AccountThreshold
+}
+
+// Purge deletes the attempts whose failures are past the window and whose lockout has ended
+func (t *LoginThrottleImpl) Purge(ctx context.Context) ports.APIError {
+	return t.repo.DeleteLoginAttemptsBefore(ctx, time.Now().Add(-t.policy.FailureWindow))
+}
+
+// load reads the attempts from the database. They are not cached: a copy would miss the failures counted by other
+// servers, and a sign in already costs more than reading a row
+func (t *LoginThrottleImpl) load(ctx context.Context, key string) *domain.LoginAttempts {
+	attempts, err := t.repo.GetLoginAttempts(ctx, key)
+	if err != nil {
+		if err.Status() != http.StatusNotFound {
+			t.si.Logger.Info(fmt.Sprintf("Error reading login attempts: %s", err.Error()))
+		}
+		return &domain.LoginAttempts{Key: key}
+	}
+	return attempts
+}
+
+const (
+	accountKeyPrefix = "account:"
+	ipKeyPrefix      = "ip:"
+	mfaKeyPrefix     = "mfa:"
+)
+
+func attemptKeys(email string, clientIP string) []string {
+	keys := []string{accountKeyPrefix + normalizeEmail(email)}
+	if clientIP != "" {
+		keys = append(keys, ipKeyPrefix+clientIP)
+	}
+	return keys
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
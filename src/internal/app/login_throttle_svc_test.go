@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// attemptsTable plays the database
+type attemptsTable struct {
+	lock sync.Mutex
+	rows map[string]domain.LoginAttempts
+}
+
+func (r *attemptsTable) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, ports.APIError) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	attempts, found := r.rows[key]
+	if !found {
+		return nil, ports.NewAPIError(http.StatusNotFound, "No login attempts")
+	}
+	return &attempts, nil
+}
+
+func (r *attemptsTable) AddLoginFailure(ctx context.Context, key string, at time.Time, windowStart time.Time) (*domain.LoginAttempts, ports.APIError) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	attempts, found := r.rows[key]
+	if !found || attempts.LastFailureAt.Before(windowStart) {
+		attempts.Key, attempts.Failures = key, 0
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = at
+	r.rows[key] = attempts
+	return &attempts, nil
+}
+
+func (r *attemptsTable) LockLoginAttempts(ctx context.Context, key string, minFailures int, lockedUntil time.Time) (bool, ports.APIError) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	attempts, found := r.rows[key]
+	if !found || attempts.Failures < minFailures {
+		return false, nil
+	}
+	attempts.Failures, attempts.LockedUntil = 0, &lockedUntil
+	r.rows[key] = attempts
+	return true, nil
+}
+
+func (r *attemptsTable) DeleteLoginAttempts(ctx context.Context, key string) ports.APIError {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.rows, key)
+	return nil
+}
+
+func (r *attemptsTable) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) ports.APIError {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for key, attempts := range r.rows {
+		if attempts.LastFailureAt.Before(before) && (attempts.LockedUntil == nil || attempts.LockedUntil.Before(before)) {
+			delete(r.rows, key)
+		}
+	}
+	return nil
+}
+
+var testLockoutPolicy = LockoutPolicy{
+	FailureWindow:    time.Hour,
+	DelayAfter:       2,
+	BaseDelay:        time.Hour, // long enough to not expire during the test
+	MaxDelay:         time.Hour,
+	AccountThreshold: 4,
+	IPThreshold:      6,
+	MFAThreshold:     3,
+	LockoutDuration:  time.Hour,
+}
+
+func newTestThrottle(ctrl *gomock.Controller) (*LoginThrottleImpl, *attemptsTable, *mocks.MockAuditService) {
+	table := &attemptsTable{rows: make(map[string]domain.LoginAttempts)}
+	audit := mocks.NewMockAuditService(ctrl)
+	throttle := NewLoginThrottle(mockServiceInfra(ctrl), table, audit, testLockoutPolicy).(*LoginThrottleImpl)
+	return throttle, table, audit
+}
+
+func Test_LockoutPolicy_ProgressiveDelays(t *testing.T) {
+	now := time.Now()
+	expected := map[int]time.Duration{0: 0, 2: 0, 3: time.Second, 4: 2 * time.Second, 5: 4 * time.Second, 8: 30 * time.Second, 50: 30 * time.Second}
+	for failures, delay := range expected {
+		next := DefaultLockoutPolicy.nextAttemptAt(&domain.LoginAttempts{Failures: failures, LastFailureAt: now})
+		if delay == 0 {
+			assert.True(t, next.IsZero(), failures)
+		} else {
+			assert.Equal(t, delay, next.Sub(now), failures)
+		}
+	}
+}
+
+func Test_LoginThrottle_AccountLockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	throttle, table, audit := newTestThrottle(ctrl)
+
+	email := "john@mail.com"
+	for i := 0; i < testLockoutPolicy.DelayAfter; i++ {
+		assert.Nil(t, throttle.Check(ctx, email, testClientIP))
+		throttle.Failed(ctx, email, testClientIP)
+	}
+	err := throttle.Check(ctx, "JOHN@mail.com ", testClientIP) // has to wait. Emails are not case sensitive
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, err.Status())
+	assert.Nil(t, throttle.Check(ctx, "jane@mail.com", "198.51.100.1"))
+
+	audit.EXPECT().
+		Record(gomock.Eq(ctx), gomock.Any()).
+		Do(func(ctx context.Context, event *domain.AuditEvent) {
+			assert.Equal(t, domain.AuditAccountLocked, event.Type)
+			assert.Equal(t, email, event.Target)
+			assert.Equal(t, testClientIP, event.IP)
+		})
+	for i := testLockoutPolicy.DelayAfter; i < testLockoutPolicy.AccountThreshold; i++ {
+		throttle.Failed(ctx, email, testClientIP)
+	}
+	stored := table.rows[accountKeyPrefix+email]
+	assert.True(t, stored.IsLocked(time.Now()))
+	assert.Equal(t, 0, stored.Failures)
+
+	assert.NotNil(t, throttle.Check(ctx, email, "198.51.100.1"))
+
+	expired := time.Now().Add(-time.Second)
+	stored.LockedUntil = &expired
+	table.rows[accountKeyPrefix+email] = stored
+	assert.Nil(t, throttle.Check(ctx, email, "198.51.100.1"))
+
+	throttle.Succeeded(ctx, email, testClientIP)
+	_, found := table.rows[accountKeyPrefix+email]
+	assert.False(t, found)
+}
+
+func Test_LoginThrottle_IPLockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	throttle, _, audit := newTestThrottle(ctrl)
+
+	audit.EXPECT().
+		Record(gomock.Eq(ctx), gomock.Any()).
+		Do(func(ctx context.Context, event *domain.AuditEvent) {
+			assert.Equal(t, domain.AuditIPLocked, event.Type)
+			assert.Equal(t, testClientIP, event.Target)
+		})
+	emails := []string{"a@mail.com", "b@mail.com", "c@mail.com"}
+	for i := 0; i < testLockoutPolicy.IPThreshold; i++ { // a few passwords for each account, below the account limits
+		assert.Nil(t, throttle.Check(ctx, "d@mail.com", testClientIP)) // no delays for the IP, it may be shared by many users
+		throttle.Failed(ctx, emails[i%len(emails)], testClientIP)
+	}
+	assert.NotNil(t, throttle.Check(ctx, "d@mail.com", testClientIP))
+	assert.Nil(t, throttle.Check(ctx, "d@mail.com", "198.51.100.1"))
+	throttle.Succeeded(ctx, "d@mail.com", testClientIP) // a success does not unlock the address
+	assert.NotNil(t, throttle.Check(ctx, "d@mail.com", testClientIP))
+}
+
+func Test_LoginThrottle_ConcurrentFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	throttle, table, audit := newTestThrottle(ctrl)
+
+	audit.EXPECT().Record(gomock.Eq(ctx), gomock.Any()).Times(1) // locked once, whoever gets there first
+	var wg sync.WaitGroup
+	for i := 0; i < 2*testLockoutPolicy.AccountThreshold-1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			throttle.Failed(ctx, "john@mail.com", "")
+		}()
+	}
+	wg.Wait()
+	stored := table.rows[accountKeyPrefix+"john@mail.com"]
+	assert.True(t, stored.IsLocked(time.Now()))
+	assert.Less(t, stored.Failures, testLockoutPolicy.AccountThreshold)
+}
+
+func Test_LoginThrottle_Purge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	throttle, table, _ := newTestThrottle(ctrl)
+
+	old := time.Now().Add(-2 * testLockoutPolicy.FailureWindow)
+	lockedUntil := time.Now().Add(time.Minute)
+	table.rows["account:old@mail.com"] = domain.LoginAttempts{Key: "account:old@mail.com", Failures: 2, LastFailureAt: old}
+	table.rows["account:locked@mail.com"] = domain.LoginAttempts{Key: "account:locked@mail.com", LastFailureAt: old, LockedUntil: &lockedUntil}
+	table.rows["ip:"+testClientIP] = domain.LoginAttempts{Key: "ip:" + testClientIP, Failures: 1, LastFailureAt: time.Now()}
+
+	assert.Nil(t, throttle.Purge(ctx))
+	assert.Len(t, table.rows, 2)
+	_, found := table.rows["account:old@mail.com"]
+	assert.False(t, found)
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+type OrganizationServiceImpl struct {
+	repo ports.OrganizationRepository
+	si   *ServiceInfra
+}
+
+func NewOrganizationService(repo ports.OrganizationRepository, serviceInfra *ServiceInfra) ports.OrganizationService {
+	return &OrganizationServiceImpl{repo: repo, si: serviceInfra}
+}
+
+// CreateOrganization creates an organization owned by the user
+func (s *OrganizationServiceImpl) CreateOrganization(ctx context.Context, byUser string, request dtos.OrganizationCreate) (*domain.Organization, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if byUser == "" {
+		return nil, ports.NewAPIError(http.StatusUnauthorized, "Authentication required")
+	}
+	organization := &domain.Organization{Name: request.Name, CreatedAt: time.Now()}
+	errTx := s.si.Tx.WithinTx(ctx, func(ctx context.Context) ports.APIError { // no organization left without its owner
+		idOrganization, err := s.repo.CreateOrganization(ctx, organization)
+		if err != nil {
+			return err
+		}
+		organization.ID = idOrganization
+		return s.repo.SaveMembership(ctx, &domain.Membership{OrganizationID: idOrganization, UserID: byUser, Role: domain.OrgRoleOwner})
+	})
+	if errTx != nil {
+		return nil, errTx
+	}
+	return organization, nil
+}
+
+func (s *OrganizationServiceImpl) GetUserOrganizations(ctx context.Context, forUser string, byUser string) ([]*domain.Organization, ports.APIError) {
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, forUser, []domain.Permission{domain.PermissionRead}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserOrganizations(ctx, forUser)
+}
+
+// GetMembership tells whether the user belongs to the organization. It is intended to be used only internally
+func (s *OrganizationServiceImpl) GetMembership(ctx context.Context, idOrganization string, idUser string) (*domain.Membership, ports.APIError) {
+	return s.repo.GetMembership(ctx, idOrganization, idUser)
+}
+
+func (s *OrganizationServiceImpl) GetMembers(ctx context.Context, byUser string) ([]*domain.Membership, ports.APIError) {
+	if _, err := s.currentMembership(ctx, byUser); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(ctx)
+}
+
+// AddMember adds a user to the current organization or changes its role. Only for owners and admins
+func (s *OrganizationServiceImpl) AddMember(ctx context.Context, byUser string, request dtos.MemberAdd) ports.APIError {
+	if err := validator.ValidateStruct(request); err != nil {
+		return ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	membership, err := s.managerMembership(ctx, byUser)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNotOwner(ctx, membership.OrganizationID, request.UserID); err != nil {
+		return err
+	}
+	return s.repo.SaveMembership(ctx, &domain.Membership{OrganizationID: membership.OrganizationID, UserID: request.UserID, Role: domain.OrgRole(request.Role)})
+}
+
+// RemoveMember takes a user out of the current organization. Owners and admins can remove anyone but the owner, members only themselves
+func (s *OrganizationServiceImpl) RemoveMember(ctx context.Context, byUser string, idUser string) ports.APIError {
+	membership, err := s.currentMembership(ctx, byUser)
+	if err != nil {
+		return err
+	}
+	if byUser != idUser && !membership.Role.CanManageMembers() {
+		return ports.NewAPIError(http.StatusForbidden, "The data is not accessible")
+	}
+	if err := s.checkNotOwner(ctx, membership.OrganizationID, idUser); err != nil {
+		return err
+	}
+	return s.repo.DeleteMembership(ctx, idUser)
+}
+
+// currentMembership returns the membership of the user in the tenant of the context
+func (s *OrganizationServiceImpl) currentMembership(ctx context.Context, byUser string) (*domain.Membership, ports.APIError) {
+	tenant, found := domain.TenantFromContext(ctx)
+	if !found {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "No organization selected")
+	}
+	membership, err := s.repo.GetMembership(ctx, tenant, byUser)
+	if err != nil {
+		if err.Status() == http.StatusNotFound {
+			return nil, ports.NewAPIError(http.StatusForbidden, "The data is not accessible")
+		}
+		return nil, err
+	}
+	return membership, nil
+}
+
+func (s *OrganizationServiceImpl) managerMembership(ctx context.Context, byUser string) (*domain.Membership, ports.APIError) {
+	membership, err := s.currentMembership(ctx, byUser)
+	if err != nil {
+		return nil, err
+	}
+	if !membership.Role.CanManageMembers() {
+		return nil, ports.NewAPIError(http.StatusForbidden, "The data is not accessible")
+	}
+	return membership, nil
+}
+
+// checkNotOwner refuses changes to the owner, so organizations never end up without one
+func (s *OrganizationServiceImpl) checkNotOwner(ctx context.Context, idOrganization string, idUser string) ports.APIError {
+	membership, err := s.repo.GetMembership(ctx, idOrganization, idUser)
+	if err != nil {
+		if err.Status() == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+	if membership.Role == domain.OrgRoleOwner {
+		return ports.NewAPIError(http.StatusConflict, "The owner of the organization can not be changed")
+	}
+	return nil
+}
+
+func (s *OrganizationServiceImpl) ExportUserData(ctx context.Context, user *domain.User) (interface{}, ports.APIError) {
+	organizations, err := s.repo.GetUserOrganizations(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return dtos.FromDomainOrganizations(organizations), nil
+}
+
+// EraseUserData removes the user from its organizations. The organizations stay, even those the user owned
+func (s *OrganizationServiceImpl) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	return s.repo.DeleteUserMemberships(ctx, user.ID)
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_Organizations_CreatorIsOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockOrganizationRepository(ctrl)
+	repo.EXPECT().CreateOrganization(gomock.Eq(ctx), gomock.Any()).Return("OrgId", nil)
+	repo.EXPECT().SaveMembership(gomock.Eq(ctx), &domain.Membership{OrganizationID: "OrgId", UserID: "JohnId", Role: domain.OrgRoleOwner}).Return(nil)
+
+	svc := NewOrganizationService(repo, mockServiceInfra(ctrl))
+	organization, err := svc.CreateOrganization(ctx, "JohnId", dtos.OrganizationCreate{Name: "City Watch"})
+	assert.Nil(t, err)
+	assert.Equal(t, "OrgId", organization.ID)
+
+	_, err = svc.CreateOrganization(ctx, "JohnId", dtos.OrganizationCreate{})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func Test_Organizations_CreateInTx(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	type txKey struct{}
+	txCtx := context.WithValue(ctx, txKey{}, true)
+
+	si := mockServiceInfra(ctrl)
+	tx := mocks.NewMockTxManager(ctrl)
+	tx.EXPECT().WithinTx(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) ports.APIError) ports.APIError {
+		return fn(txCtx)
+	})
+	si.Tx = tx
+	repo := mocks.NewMockOrganizationRepository(ctrl)
+	repo.EXPECT().CreateOrganization(gomock.Eq(txCtx), gomock.Any()).Return("OrgId", nil)
+	repo.EXPECT().SaveMembership(gomock.Eq(txCtx), gomock.Any()).Return(ports.NewAPIError(http.StatusInternalServerError, "Database error"))
+
+	svc := NewOrganizationService(repo, si)
+	organization, err := svc.CreateOrganization(ctx, "JohnId", dtos.OrganizationCreate{Name: "City Watch"})
+	assert.Nil(t, organization)
+	assert.Equal(t, http.StatusInternalServerError, err.Status()) // the transaction undoes the organization
+}
+
+func Test_Organizations_Members(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := domain.WithTenant(context.Background(), "OrgId")
+
+	repo := mocks.NewMockOrganizationRepository(ctrl)
+	membership := func(idUser string, role domain.OrgRole) *domain.Membership {
+		return &domain.Membership{OrganizationID: "OrgId", UserID: idUser, Role: role}
+	}
+	notFound := ports.NewAPIError(http.StatusNotFound, "Membership not found")
+	repo.EXPECT().GetMembership(gomock.Eq(ctx), "OrgId", "JohnId").Return(membership("JohnId", domain.OrgRoleOwner), nil).AnyTimes()
+	repo.EXPECT().GetMembership(gomock.Eq(ctx), "OrgId", "JaneId").Return(membership("JaneId", domain.OrgRoleMember), nil).AnyTimes()
+	repo.EXPECT().GetMembership(gomock.Eq(ctx), "OrgId", "BobId").Return(nil, notFound).AnyTimes()
+	svc := NewOrganizationService(repo, mockServiceInfra(ctrl))
+
+	repo.EXPECT().SaveMembership(gomock.Eq(ctx), membership("BobId", domain.OrgRoleAdmin)).Return(nil)
+	assert.Nil(t, svc.AddMember(ctx, "JohnId", dtos.MemberAdd{UserID: "BobId", Role: "admin"}))
+	err := svc.AddMember(ctx, "JaneId", dtos.MemberAdd{UserID: "BobId", Role: "member"}) // members can not manage members
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	err = svc.AddMember(ctx, "BobId", dtos.MemberAdd{UserID: "JaneId", Role: "member"}) // not a member (in the mock)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	err = svc.AddMember(ctx, "JohnId", dtos.MemberAdd{UserID: "BobId", Role: "owner"})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+
+	err = svc.RemoveMember(ctx, "JaneId", "JohnId")
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	err = svc.RemoveMember(ctx, "JohnId", "JohnId") // the owner stays
+	assert.Equal(t, http.StatusConflict, err.Status())
+	repo.EXPECT().DeleteMembership(gomock.Eq(ctx), "JaneId").Return(nil)
+	assert.Nil(t, svc.RemoveMember(ctx, "JaneId", "JaneId")) // leaving
+
+	repo.EXPECT().GetMembers(gomock.Eq(ctx)).Return([]*domain.Membership{membership("JohnId", domain.OrgRoleOwner)}, nil)
+	members, err := svc.GetMembers(ctx, "JohnId")
+	assert.Nil(t, err)
+	assert.Len(t, members, 1)
+	_, err = svc.GetMembers(context.Background(), "JohnId")
+	assert.Equal(t, http.StatusBadRequest, err.Status()) // no organization selected
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy are the rules a new password must follow
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int // bcrypt ignores anything beyond 72 bytes
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// Length matters much more than composition rules, so none is required by default
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 8, MaxLength: 72}
+
+// Check returns an error describing every rule the password breaks, or nil if it is acceptable
+func (p PasswordPolicy) Check(password string) error {
+	var problems []string
+	if len(password) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("at most %d bytes", p.MaxLength))
+	}
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		problems = append(problems, "an upper case letter")
+	}
+	if p.RequireLower && !hasLower {
+		problems = append(problems, "a lower case letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		problems = append(problems, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		problems = append(problems, "a symbol")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("Password must have %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
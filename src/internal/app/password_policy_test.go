@@ -0,0 +1,26 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DefaultPasswordPolicy(t *testing.T) {
+	assert.Nil(t, DefaultPasswordPolicy.Check("password"))
+	assert.Nil(t, DefaultPasswordPolicy.Check("a long passphrase with spaces"))
+	assert.NotNil(t, DefaultPasswordPolicy.Check("short"))
+	assert.NotNil(t, DefaultPasswordPolicy.Check(string(make([]byte, 73))))
+}
+
+func Test_StrictPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+	assert.Nil(t, policy.Check("Passw0rd-Long"))
+	assert.Nil(t, policy.Check("ÑandúCorre 2x"))
+	err := policy.Check("password")
+	assert.NotNil(t, err)
+	assert.Equal(t, "Password must have at least 10 characters, an upper case letter, a digit, a symbol", err.Error())
+	assert.NotNil(t, policy.Check("PASSW0RD-LONG"))
+	assert.NotNil(t, policy.Check("Password-Long"))
+	assert.NotNil(t, policy.Check("Passw0rdLong"))
+}
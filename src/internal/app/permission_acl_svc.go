@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+// Permissions on single resources, given to users or to groups. The global permissions of the roles are in permission_svc.go
+
+// Can tells whether the subject can do the action on the resource: administrators can do anything on every resource, the
+// others need a grant of their own, or of one of their groups. The global permissions of the other roles do not count here
+func (s *PermissionServiceImpl) Can(ctx context.Context, subject string, action domain.Permission, resourceType string, resourceID string) (bool, ports.APIError) {
+	filter, err := s.AllowedResources(ctx, subject, action, resourceType)
+	if err != nil {
+		return false, err
+	}
+	return filter.Allows(resourceID), nil
+}
+
+// AllowedResources tells which resources of the type the subject can do the action on, to filter lists in one go
+func (s *PermissionServiceImpl) AllowedResources(ctx context.Context, subject string, action domain.Permission, resourceType string) (*domain.ResourceFilter, ports.APIError) {
+	filter := &domain.ResourceFilter{}
+	if subject == "" {
+		return filter, nil
+	}
+	permissions, err := s.userPermissions(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if domain.HasPermission(permissions, domain.PermissionAdmin) { // the permissions of other roles are not on resources
+		filter.All = true
+		return filter, nil
+	}
+	grants, err := s.userGrants(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		if grant.ResourceType == resourceType && grant.Allows(action) && !filter.Allows(grant.ResourceID) {
+			filter.IDs = append(filter.IDs, grant.ResourceID)
+		}
+	}
+	return filter, nil
+}
+
+// GetResourceGrants returns who has been given permissions on the resource. Only for those with the admin permission on it
+func (s *PermissionServiceImpl) GetResourceGrants(ctx context.Context, byUser string, resourceType string, resourceID string) ([]*domain.Grant, ports.APIError) {
+	if err := s.canManageResource(ctx, byUser, resourceType, resourceID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetResourceGrants(ctx, resourceType, resourceID)
+}
+
+// GrantAccess gives a permission on the resource to a user or a group. Only for those with the admin permission on it
+func (s *PermissionServiceImpl) GrantAccess(ctx context.Context, byUser string, resourceType string, resourceID string, request dtos.GrantCreate) (*domain.Grant, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if resourceType == "" || resourceID == "" {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "The resource is required")
+	}
+	if err := s.canManageResource(ctx, byUser, resourceType, resourceID); err != nil {
+		return nil, err
+	}
+	grant := &domain.Grant{
+		SubjectType:  domain.SubjectType(request.SubjectType),
+		SubjectID:    request.Subject,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Permission:   domain.Permission(request.Permission),
+	}
+	if grant.SubjectType == domain.SubjectGroup {
+		group, err := s.repo.GetGroupByName(ctx, request.Subject)
+		if err != nil {
+			return nil, err
+		}
+		grant.SubjectID = group.ID
+	}
+	existing, err := s.repo.GetResourceGrants(ctx, resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range existing {
+		if other.SubjectType == grant.SubjectType && other.SubjectID == grant.SubjectID && other.Permission == grant.Permission {
+			return nil, ports.NewAPIError(http.StatusConflict, "The permission was already given")
+		}
+	}
+	if grant.ID, err = s.repo.CreateGrant(ctx, grant); err != nil {
+		return nil, err
+	}
+	if err := s.forgetGrants(ctx, grant); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditAccessGranted, ActorID: byUser, Target: grantTarget(grant), Details: grantDetails(grant)})
+	return grant, nil
+}
+
+// RevokeAccess removes a grant. Only for those with the admin permission on its resource
+func (s *PermissionServiceImpl) RevokeAccess(ctx context.Context, byUser string, idGrant string) ports.APIError {
+	grant, err := s.repo.GetGrant(ctx, idGrant)
+	if err != nil {
+		return err
+	}
+	if err := s.canManageResource(ctx, byUser, grant.ResourceType, grant.ResourceID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteGrant(ctx, idGrant); err != nil {
+		return err
+	}
+	if err := s.forgetGrants(ctx, grant); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditAccessRevoked, ActorID: byUser, Target: grantTarget(grant), Details: grantDetails(grant)})
+	return nil
+}
+
+// GetGroups returns every group. Only for administrators
+func (s *PermissionServiceImpl) GetGroups(ctx context.Context, byUser string) ([]*domain.Group, ports.APIError) {
+	if _, err := s.hasSomePermission(ctx, byUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGroups(ctx)
+}
+
+// CreateGroup adds an empty group. Only for administrators
+func (s *PermissionServiceImpl) CreateGroup(ctx context.Context, byUser string, request dtos.GroupCreate) (*domain.Group, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if _, err := s.hasSomePermission(ctx, byUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetGroupByName(ctx, request.Name); err == nil {
+		return nil, ports.NewAPIError(http.StatusConflict, "The group already exists")
+	}
+	group := &domain.Group{Name: request.Name}
+	var err ports.APIError
+	if group.ID, err = s.repo.CreateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// AddGroupMember puts the user in the group, so it gets the grants of the group. Only for administrators
+func (s *PermissionServiceImpl) AddGroupMember(ctx context.Context, byUser string, groupName string, forUser string) ports.APIError {
+	group, err := s.groupToManage(ctx, byUser, groupName)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.AddGroupMember(ctx, group.ID, forUser); err != nil {
+		return err
+	}
+	s.cache.Del(grantsCacheKey(forUser))
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditGroupJoined, ActorID: byUser, Target: forUser, Details: group.Name})
+	return nil
+}
+
+// RemoveGroupMember takes the user out of the group. Only for administrators
+func (s *PermissionServiceImpl) RemoveGroupMember(ctx context.Context, byUser string, groupName string, forUser string) ports.APIError {
+	group, err := s.groupToManage(ctx, byUser, groupName)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RemoveGroupMember(ctx, group.ID, forUser); err != nil {
+		return err
+	}
+	s.cache.Del(grantsCacheKey(forUser))
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditGroupLeft, ActorID: byUser, Target: forUser, Details: group.Name})
+	return nil
+}
+
+func (s *PermissionServiceImpl) groupToManage(ctx context.Context, byUser string, groupName string) (*domain.Group, ports.APIError) {
+	if _, err := s.hasSomePermission(ctx, byUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGroupByName(ctx, groupName)
+}
+
+func (s *PermissionServiceImpl) canManageResource(ctx context.Context, byUser string, resourceType string, resourceID string) ports.APIError {
+	allowed, err := s.Can(ctx, byUser, domain.PermissionAdmin, resourceType, resourceID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ports.NewAPIError(http.StatusForbidden, "The data is not accessible")
+	}
+	return nil
+}
+
+// userGrants reads the grants of the user and of its groups from the cache, or from the database if not there
+func (s *PermissionServiceImpl) userGrants(ctx context.Context, idUser string) ([]*domain.Grant, ports.APIError) {
+	cacheKey := grantsCacheKey(idUser)
+	if cached, found := s.cache.Get(cacheKey); found {
+		if grants, ok := cached.([]*domain.Grant); ok {
+			return grants, nil
+		}
+	}
+	grants, err := s.repo.GetUserGrants(ctx, idUser)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.SetWithTTL(cacheKey, grants, permissionsCacheDuration)
+	return grants, nil
+}
+
+// forgetGrants clears the cached grants of the users affected by a change of the grant
+func (s *PermissionServiceImpl) forgetGrants(ctx context.Context, grant *domain.Grant) ports.APIError {
+	if grant.SubjectType == domain.SubjectUser {
+		s.cache.Del(grantsCacheKey(grant.SubjectID))
+		return nil
+	}
+	members, err := s.repo.GetGroupMembers(ctx, grant.SubjectID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		s.cache.Del(grantsCacheKey(member))
+	}
+	return nil
+}
+
+func grantsCacheKey(idUser string) string {
+	return "permissions.grants." + idUser
+}
+
+func grantTarget(grant *domain.Grant) string {
+	return fmt.Sprintf("%s/%s", grant.ResourceType, grant.ResourceID)
+}
+
+func grantDetails(grant *domain.Grant) string {
+	return fmt.Sprintf("%s %s: %d", grant.SubjectType, grant.SubjectID, grant.Permission)
+}
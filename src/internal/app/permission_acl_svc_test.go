@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_Access_Can(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockPermissionRepository(ctrl)
+	svc := NewPermissionService(repo, mocks.NewMockAuditService(ctrl), newSyncCache(), logger.GetNopLogger())
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "DukeId").Return([]*domain.Role{editorRole}, nil).Times(1)
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return(nil, nil).Times(1)
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JaneId").Return([]*domain.Role{userRole}, nil).Times(1)
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "GrannyId").Return([]*domain.Role{adminRole}, nil).Times(1)
+	repo.EXPECT().GetUserGrants(gomock.Eq(ctx), "JohnId").Return([]*domain.Grant{ // then cached
+		{SubjectType: domain.SubjectUser, SubjectID: "JohnId", ResourceType: "document", ResourceID: "1", Permission: domain.PermissionRead},
+		{SubjectType: domain.SubjectGroup, SubjectID: "WatchId", ResourceType: "document", ResourceID: "2", Permission: domain.PermissionAdmin},
+		{SubjectType: domain.SubjectUser, SubjectID: "JohnId", ResourceType: "folder", ResourceID: "3", Permission: domain.PermissionWrite},
+	}, nil).Times(1)
+	repo.EXPECT().GetUserGrants(gomock.Eq(ctx), "DukeId").Return(nil, nil).Times(1)
+	repo.EXPECT().GetUserGrants(gomock.Eq(ctx), "JaneId").Return(nil, nil).Times(1)
+
+	cases := []struct {
+		user       string
+		action     domain.Permission
+		resourceID string
+		allowed    bool
+	}{
+		{"JohnId", domain.PermissionRead, "1", true},
+		{"JohnId", domain.PermissionWrite, "1", false},
+		{"JohnId", domain.PermissionDelete, "2", true}, // admin on the resource, through a group
+		{"JohnId", domain.PermissionWrite, "3", false}, // a folder, not a document
+		{"DukeId", domain.PermissionWrite, "4", false}, // editors need a grant too
+		{"DukeId", domain.PermissionDelete, "4", false},
+		{"JaneId", domain.PermissionRead, "4", false}, // every user can read, but not every document
+		{"GrannyId", domain.PermissionDelete, "4", true},
+		{"", domain.PermissionRead, "1", false},
+	}
+	for _, c := range cases {
+		allowed, err := svc.Can(ctx, c.user, c.action, "document", c.resourceID)
+		assert.Nil(t, err)
+		assert.Equal(t, c.allowed, allowed, c)
+	}
+
+	filter, err := svc.AllowedResources(ctx, "JohnId", domain.PermissionRead, "document")
+	assert.Nil(t, err)
+	assert.False(t, filter.All)
+	assert.ElementsMatch(t, []string{"1", "2"}, filter.IDs)
+	filter, err = svc.AllowedResources(ctx, "DukeId", domain.PermissionRead, "document")
+	assert.Nil(t, err)
+	assert.False(t, filter.All)
+	assert.Empty(t, filter.IDs)
+	filter, err = svc.AllowedResources(ctx, "GrannyId", domain.PermissionRead, "document")
+	assert.Nil(t, err)
+	assert.True(t, filter.All)
+}
+
+func Test_Access_Grants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockPermissionRepository(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewPermissionService(repo, audit, newSyncCache(), logger.GetNopLogger())
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), gomock.Any()).Return(nil, nil).AnyTimes()
+	ownerGrant := &domain.Grant{ID: "G1", SubjectType: domain.SubjectUser, SubjectID: "JohnId", ResourceType: "document", ResourceID: "1", Permission: domain.PermissionAdmin}
+	repo.EXPECT().GetUserGrants(gomock.Eq(ctx), "JohnId").Return([]*domain.Grant{ownerGrant}, nil).AnyTimes()
+
+	repo.EXPECT().GetUserGrants(gomock.Eq(ctx), "JaneId").Return(nil, nil).Times(2) // read again once the cache is cleared
+	_, err := svc.GrantAccess(ctx, "JaneId", "document", "1", dtos.GrantCreate{SubjectType: "user", Subject: "JaneId", Permission: 100})
+	assert.Equal(t, http.StatusForbidden, err.Status()) // no self service
+
+	request := dtos.GrantCreate{SubjectType: "user", Subject: "JaneId", Permission: 100}
+	repo.EXPECT().GetResourceGrants(gomock.Eq(ctx), "document", "1").Return([]*domain.Grant{ownerGrant}, nil)
+	repo.EXPECT().CreateGrant(gomock.Eq(ctx), gomock.Any()).Return("G2", nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), gomock.Any())
+	grant, err := svc.GrantAccess(ctx, "JohnId", "document", "1", request)
+	assert.Nil(t, err)
+	assert.Equal(t, "G2", grant.ID)
+	allowed, _ := svc.Can(ctx, "JaneId", domain.PermissionWrite, "document", "1")
+	assert.False(t, allowed) // the mock still returns no grants, but it was asked again
+
+	repo.EXPECT().GetResourceGrants(gomock.Eq(ctx), "document", "1").Return([]*domain.Grant{ownerGrant, grant}, nil)
+	_, err = svc.GrantAccess(ctx, "JohnId", "document", "1", request)
+	assert.Equal(t, http.StatusConflict, err.Status())
+	_, err = svc.GrantAccess(ctx, "JohnId", "document", "1", dtos.GrantCreate{SubjectType: "user", Subject: "JaneId", Permission: 7})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+
+	watch := &domain.Group{ID: "WatchId", Name: "watch"}
+	repo.EXPECT().GetGroupByName(gomock.Eq(ctx), "watch").Return(watch, nil)
+	repo.EXPECT().GetResourceGrants(gomock.Eq(ctx), "document", "1").Return(nil, nil)
+	repo.EXPECT().CreateGrant(gomock.Eq(ctx), gomock.Any()).Return("G3", nil)
+	repo.EXPECT().GetGroupMembers(gomock.Eq(ctx), "WatchId").Return([]string{"JaneId"}, nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), gomock.Any())
+	grant, err = svc.GrantAccess(ctx, "JohnId", "document", "1", dtos.GrantCreate{SubjectType: "group", Subject: "watch", Permission: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "WatchId", grant.SubjectID)
+
+	repo.EXPECT().GetGrant(gomock.Eq(ctx), "G2").Return(&domain.Grant{ID: "G2", SubjectType: domain.SubjectUser, SubjectID: "JaneId", ResourceType: "document", ResourceID: "1", Permission: domain.PermissionWrite}, nil)
+	repo.EXPECT().DeleteGrant(gomock.Eq(ctx), "G2").Return(nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), gomock.Any())
+	assert.Nil(t, svc.RevokeAccess(ctx, "JohnId", "G2"))
+}
+
+func Test_Access_Groups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockPermissionRepository(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewPermissionService(repo, audit, newSyncCache(), logger.GetNopLogger())
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "GrannyId").Return([]*domain.Role{adminRole}, nil).AnyTimes()
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return(nil, nil).AnyTimes()
+
+	_, err := svc.CreateGroup(ctx, "JohnId", dtos.GroupCreate{Name: "watch"})
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	repo.EXPECT().GetGroupByName(gomock.Eq(ctx), "watch").Return(nil, ports.NewAPIError(http.StatusNotFound, "Group not found"))
+	repo.EXPECT().CreateGroup(gomock.Eq(ctx), gomock.Any()).Return("WatchId", nil)
+	group, err := svc.CreateGroup(ctx, "GrannyId", dtos.GroupCreate{Name: "watch"})
+	assert.Nil(t, err)
+	assert.Equal(t, "WatchId", group.ID)
+
+	repo.EXPECT().GetGroupByName(gomock.Eq(ctx), "watch").Return(group, nil).Times(2)
+	repo.EXPECT().AddGroupMember(gomock.Eq(ctx), "WatchId", "JohnId").Return(nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), &domain.AuditEvent{Type: domain.AuditGroupJoined, ActorID: "GrannyId", Target: "JohnId", Details: "watch"})
+	assert.Nil(t, svc.AddGroupMember(ctx, "GrannyId", "watch", "JohnId"))
+	repo.EXPECT().RemoveGroupMember(gomock.Eq(ctx), "WatchId", "JohnId").Return(nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), &domain.AuditEvent{Type: domain.AuditGroupLeft, ActorID: "GrannyId", Target: "JohnId", Details: "watch"})
+	assert.Nil(t, svc.RemoveGroupMember(ctx, "GrannyId", "watch", "JohnId"))
+	err = svc.AddGroupMember(ctx, "JohnId", "watch", "JohnId")
+	assert.Equal(t, http.StatusForbidden, err.Status())
+}
@@ -2,34 +2,212 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/cache"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
 )
 
+// How long the roles of a user are cached. Changes made through this service are seen at once
+const permissionsCacheDuration = 5 * time.Minute
+
 type PermissionServiceImpl struct {
-	repo  ports.PermissionRepository
-	cache cache.CacheService
+	repo   ports.PermissionRepository
+	audit  ports.AuditService
+	cache  cache.CacheService
+	logger logger.LoggerService
 }
 
-func NewPermissionService(repo ports.PermissionRepository, cache cache.CacheService, logger logger.LoggerService) ports.PermissionService {
-	return &PermissionServiceImpl{repo: repo, cache: cache}
+func NewPermissionService(repo ports.PermissionRepository, audit ports.AuditService, cache cache.CacheService, logger logger.LoggerService) ports.PermissionService {
+	return &PermissionServiceImpl{repo: repo, audit: audit, cache: cache, logger: logger}
 }
 
+// GetUserGlobalPermissions returns the permissions granted to the user by all its roles
 func (s *PermissionServiceImpl) GetUserGlobalPermissions(ctx context.Context, forUser string, byUser string) ([]domain.Permission, ports.APIError) {
-	return nil, nil
+	if _, err := s.IsSameUserOrHasSomePermission(ctx, byUser, forUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	return s.userPermissions(ctx, forUser)
 }
 
-func (s *PermissionServiceImpl) IsSameUserOrHasSomePermission(byUser string, forUser string, neededPermissions []domain.Permission) (bool, ports.APIError) {
-	if byUser == forUser {
+// IsSameUserOrHasSomePermission lets users act on their own data, and anyone with any of the permissions act on
+// the data of others. An empty forUser means data not owned by anyone
+func (s *PermissionServiceImpl) IsSameUserOrHasSomePermission(ctx context.Context, byUser string, forUser string, neededPermissions []domain.Permission) (bool, ports.APIError) {
+	if byUser != "" && byUser == forUser {
 		return true, nil
 	}
-	// To Do
-	// read permissions from cache
-	// not in cache? read permissions from db and store in cache
-	// check if user has any of the permissions
+	return s.hasSomePermission(ctx, byUser, neededPermissions)
+}
+
+func (s *PermissionServiceImpl) GetUserRoles(ctx context.Context, forUser string, byUser string) ([]*domain.Role, ports.APIError) {
+	if _, err := s.IsSameUserOrHasSomePermission(ctx, byUser, forUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	return s.userRoles(ctx, forUser)
+}
+
+// GetRoles returns every role. Only for administrators
+func (s *PermissionServiceImpl) GetRoles(ctx context.Context, byUser string) ([]*domain.Role, ports.APIError) {
+	if _, err := s.hasSomePermission(ctx, byUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRoles(ctx)
+}
+
+// CreateRole adds a custom role with a set of permissions. Only for administrators
+func (s *PermissionServiceImpl) CreateRole(ctx context.Context, byUser string, request dtos.RoleCreate) (*domain.Role, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if _, err := s.hasSomePermission(ctx, byUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	role := &domain.Role{Name: request.Name}
+	for _, value := range request.Permissions {
+		permission := domain.Permission(value)
+		if !domain.HasPermission(domain.AllPermissions, permission) {
+			return nil, ports.NewAPIError(http.StatusBadRequest, fmt.Sprintf("Unknown permission %d", value))
+		}
+		if !domain.HasPermission(role.Permissions, permission) {
+			role.Permissions = append(role.Permissions, permission)
+		}
+	}
+	if _, err := s.repo.GetRoleByName(ctx, role.Name); err == nil {
+		return nil, ports.NewAPIError(http.StatusConflict, "The role already exists")
+	}
+	var err ports.APIError
+	if role.ID, err = s.repo.CreateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditRoleCreated, ActorID: byUser, Target: role.Name, Details: fmt.Sprint(role.Permissions)})
+	return role, nil
+}
+
+// AssignRole gives the role to the user. Only for administrators, who can not give roles to themselves
+func (s *PermissionServiceImpl) AssignRole(ctx context.Context, byUser string, forUser string, roleName string) ports.APIError {
+	if byUser == forUser && !domain.IsOperator(ctx) {
+		return ports.NewAPIError(http.StatusForbidden, "Users can not give roles to themselves")
+	}
+	role, err := s.roleToManage(ctx, byUser, roleName)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.AddUserRole(ctx, forUser, role.ID); err != nil {
+		return err
+	}
+	s.cache.Del(permissionsCacheKey(forUser))
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditRoleGranted, ActorID: byUser, Target: forUser, Details: role.Name})
+	return nil
+}
+
+// RemoveRole takes the role from the user. Only for administrators, who can not take the admin permission from themselves
+func (s *PermissionServiceImpl) RemoveRole(ctx context.Context, byUser string, forUser string, roleName string) ports.APIError {
+	role, err := s.roleToManage(ctx, byUser, roleName)
+	if err != nil {
+		return err
+	}
+	if byUser == forUser && domain.HasPermission(role.Permissions, domain.PermissionAdmin) { // nobody could fix it without SQL
+		return ports.NewAPIError(http.StatusConflict, "Administrators can not take the admin permission from themselves")
+	}
+	if err := s.repo.RemoveUserRole(ctx, forUser, role.ID); err != nil {
+		return err
+	}
+	s.cache.Del(permissionsCacheKey(forUser))
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditRoleRevoked, ActorID: byUser, Target: forUser, Details: role.Name})
+	return nil
+}
+
+// roleToManage checks byUser can assign and remove roles and returns the role
+func (s *PermissionServiceImpl) roleToManage(ctx context.Context, byUser string, roleName string) (*domain.Role, ports.APIError) {
+	if _, err := s.hasSomePermission(ctx, byUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRoleByName(ctx, roleName)
+}
+
+// hasSomePermission checks byUser has any of the permissions. Operators, running commands on the server, have all of them
+func (s *PermissionServiceImpl) hasSomePermission(ctx context.Context, byUser string, neededPermissions []domain.Permission) (bool, ports.APIError) {
+	if domain.IsOperator(ctx) {
+		return true, nil
+	}
+	if byUser != "" {
+		granted, err := s.userPermissions(ctx, byUser)
+		if err != nil {
+			return false, err
+		}
+		if domain.HasSomePermission(granted, neededPermissions) {
+			return true, nil
+		}
+	}
 	return false, ports.NewAPIError(http.StatusForbidden, "The data is not accessible")
 }
+
+// userPermissions returns the permissions granted by all the roles of the user
+func (s *PermissionServiceImpl) userPermissions(ctx context.Context, idUser string) ([]domain.Permission, ports.APIError) {
+	roles, err := s.userRoles(ctx, idUser)
+	if err != nil {
+		return nil, err
+	}
+	var permissions []domain.Permission
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			if !domain.HasPermission(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// userRoles reads the roles from the cache, or from the database if not there
+func (s *PermissionServiceImpl) userRoles(ctx context.Context, idUser string) ([]*domain.Role, ports.APIError) {
+	cacheKey := permissionsCacheKey(idUser)
+	if cached, found := s.cache.Get(cacheKey); found {
+		if roles, ok := cached.([]*domain.Role); ok {
+			return roles, nil
+		}
+	}
+	roles, err := s.repo.GetUserRoles(ctx, idUser)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.SetWithTTL(cacheKey, roles, permissionsCacheDuration)
+	return roles, nil
+}
+
+func permissionsCacheKey(idUser string) string {
+	return "permissions.roles." + idUser
+}
+
+// ExportUserData returns the roles and groups of the user, and the grants on single resources
+func (s *PermissionServiceImpl) ExportUserData(ctx context.Context, user *domain.User) (interface{}, ports.APIError) {
+	roles, err := s.repo.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.repo.GetUserGroups(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := s.repo.GetUserGrants(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.PermissionsData{Roles: dtos.FromDomainRoles(roles), Groups: dtos.FromDomainGroups(groups), Grants: dtos.FromDomainGrants(grants)}, nil
+}
+
+// EraseUserData removes the user from its roles and groups and deletes the grants given to the user
+func (s *PermissionServiceImpl) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	if err := s.repo.DeleteUserPermissions(ctx, user.ID); err != nil {
+		return err
+	}
+	s.cache.Del(permissionsCacheKey(user.ID))
+	s.cache.Del(grantsCacheKey(user.ID))
+	return nil
+}
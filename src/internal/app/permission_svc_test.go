@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var (
+	adminRole  = &domain.Role{ID: "AdminRoleId", Name: domain.RoleAdmin, Permissions: domain.Roles.Admin}
+	editorRole = &domain.Role{ID: "EditorRoleId", Name: domain.RoleEditor, Permissions: domain.Roles.Editor}
+	userRole   = &domain.Role{ID: "UserRoleId", Name: domain.RoleUser, Permissions: domain.Roles.User}
+)
+
+func Test_Permissions_FromRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockPermissionRepository(ctrl)
+	svc := NewPermissionService(repo, mocks.NewMockAuditService(ctrl), newSyncCache(), logger.GetNopLogger())
+
+	ok, err := svc.IsSameUserOrHasSomePermission(ctx, "JohnId", "JohnId", []domain.Permission{domain.PermissionAdmin})
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	_, err = svc.IsSameUserOrHasSomePermission(ctx, "", "", []domain.Permission{domain.PermissionRead}) // anonymous
+	assert.NotNil(t, err)
+	ok, err = svc.IsSameUserOrHasSomePermission(domain.AsOperator(ctx), "", "JaneId", []domain.Permission{domain.PermissionAdmin})
+	assert.True(t, ok) // commands run on the server
+	assert.Nil(t, err)
+
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return([]*domain.Role{editorRole}, nil).Times(1) // then cached
+	ok, err = svc.IsSameUserOrHasSomePermission(ctx, "JohnId", "JaneId", []domain.Permission{domain.PermissionWrite})
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	ok, err = svc.IsSameUserOrHasSomePermission(ctx, "JohnId", "JaneId", []domain.Permission{domain.PermissionDelete, domain.PermissionAdmin})
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+
+	_, err = svc.GetUserGlobalPermissions(ctx, "JaneId", "JohnId") // only admins see the permissions of others
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	permissions, err := svc.GetUserGlobalPermissions(ctx, "JohnId", "JohnId")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, domain.Roles.Editor, permissions)
+}
+
+func Test_Permissions_AssignRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockPermissionRepository(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewPermissionService(repo, audit, newSyncCache(), logger.GetNopLogger())
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "GrannyId").Return([]*domain.Role{adminRole}, nil).AnyTimes()
+
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return(nil, nil)
+	err := svc.AssignRole(ctx, "JohnId", "JaneId", domain.RoleAdmin) // not an administrator
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	err = svc.AssignRole(ctx, "JohnId", "JohnId", domain.RoleAdmin) // no self promotion
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	err = svc.AssignRole(ctx, "GrannyId", "GrannyId", domain.RoleEditor) // not even for administrators
+	assert.Equal(t, http.StatusForbidden, err.Status())
+
+	repo.EXPECT().GetRoleByName(gomock.Eq(ctx), "wizard").Return(nil, ports.NewAPIError(http.StatusNotFound, "Role not found"))
+	err = svc.AssignRole(ctx, "GrannyId", "JohnId", "wizard")
+	assert.Equal(t, http.StatusNotFound, err.Status())
+
+	repo.EXPECT().GetRoleByName(gomock.Eq(ctx), domain.RoleEditor).Return(editorRole, nil).Times(2)
+	repo.EXPECT().AddUserRole(gomock.Eq(ctx), "JohnId", editorRole.ID).Return(nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), &domain.AuditEvent{Type: domain.AuditRoleGranted, ActorID: "GrannyId", Target: "JohnId", Details: domain.RoleEditor})
+	assert.Nil(t, svc.AssignRole(ctx, "GrannyId", "JohnId", domain.RoleEditor))
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return([]*domain.Role{editorRole}, nil) // the cache was cleared
+	ok, _ := svc.IsSameUserOrHasSomePermission(ctx, "JohnId", "JaneId", []domain.Permission{domain.PermissionWrite})
+	assert.True(t, ok)
+
+	repo.EXPECT().RemoveUserRole(gomock.Eq(ctx), "JohnId", editorRole.ID).Return(nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), &domain.AuditEvent{Type: domain.AuditRoleRevoked, ActorID: "GrannyId", Target: "JohnId", Details: domain.RoleEditor})
+	assert.Nil(t, svc.RemoveRole(ctx, "GrannyId", "JohnId", domain.RoleEditor))
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return(nil, nil)
+	ok, _ = svc.IsSameUserOrHasSomePermission(ctx, "JohnId", "JaneId", []domain.Permission{domain.PermissionWrite})
+	assert.False(t, ok)
+}
+
+func Test_Permissions_CustomRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockPermissionRepository(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewPermissionService(repo, audit, newSyncCache(), logger.GetNopLogger())
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "GrannyId").Return([]*domain.Role{adminRole}, nil).AnyTimes()
+	repo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return([]*domain.Role{editorRole}, nil).AnyTimes()
+
+	_, err := svc.CreateRole(ctx, "JohnId", dtos.RoleCreate{Name: "auditor", Permissions: []int16{1}})
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	_, err = svc.CreateRole(ctx, "GrannyId", dtos.RoleCreate{Name: "auditor", Permissions: []int16{1, 42}})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+
+	repo.EXPECT().GetRoleByName(gomock.Eq(ctx), domain.RoleEditor).Return(editorRole, nil)
+	_, err = svc.CreateRole(ctx, "GrannyId", dtos.RoleCreate{Name: domain.RoleEditor, Permissions: []int16{1}})
+	assert.Equal(t, http.StatusConflict, err.Status())
+
+	repo.EXPECT().GetRoleByName(gomock.Eq(ctx), "auditor").Return(nil, ports.NewAPIError(http.StatusNotFound, "Role not found"))
+	repo.EXPECT().CreateRole(gomock.Eq(ctx), &domain.Role{Name: "auditor", Permissions: []domain.Permission{domain.PermissionRead}}).Return("AuditorId", nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), gomock.Any())
+	role, err := svc.CreateRole(ctx, "GrannyId", dtos.RoleCreate{Name: "auditor", Permissions: []int16{1, 1}})
+	assert.Nil(t, err)
+	assert.Equal(t, "AuditorId", role.ID)
+
+	repo.EXPECT().GetRoleByName(gomock.Eq(ctx), domain.RoleAdmin).Return(adminRole, nil)
+	err = svc.RemoveRole(ctx, "GrannyId", "GrannyId", domain.RoleAdmin)
+	assert.Equal(t, http.StatusConflict, err.Status())
+}
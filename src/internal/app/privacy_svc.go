@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+type namedHook struct {
+	name string
+	hook ports.PersonalDataHook
+}
+
+// PrivacyServiceImpl serves the requests of the users about their personal data (GDPR): getting a copy of it and
+// having it erased. Each module that stores data about the users registers a hook, so it takes part in both
+type PrivacyServiceImpl struct {
+	userSvc ports.UserService
+	audit   ports.AuditService
+	si      *ServiceInfra
+	hooks   []namedHook
+}
+
+func NewPrivacyService(userSvc ports.UserService, audit ports.AuditService, serviceInfra *ServiceInfra) ports.PrivacyService {
+	return &PrivacyServiceImpl{userSvc: userSvc, audit: audit, si: serviceInfra}
+}
+
+// Register is meant to be called when the application starts, before serving any request
+func (s *PrivacyServiceImpl) Register(name string, hook ports.PersonalDataHook) {
+	s.hooks = append(s.hooks, namedHook{name: name, hook: hook})
+}
+
+// ExportUserData gathers the data of every module, in the order they were registered. Deleted users can be exported too
+func (s *PrivacyServiceImpl) ExportUserData(ctx context.Context, idUser string, byUser string) (*dtos.UserDataExport, ports.APIError) {
+	user, err := s.userToServe(ctx, idUser, byUser)
+	if err != nil {
+		return nil, err
+	}
+	export := &dtos.UserDataExport{UserID: user.ID, GeneratedAt: time.Now().UTC(), Sections: map[string]interface{}{}}
+	for _, registered := range s.hooks {
+		data, err := registered.hook.ExportUserData(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			export.Sections[registered.name] = data
+		}
+	}
+	return export, nil
+}
+
+// EraseUser deletes the data of every module in the reverse order they were registered, so the user itself, registered
+// first, goes last. It is all or nothing: if a module fails, what the others erased is rolled back too, and the erasure
+// can be requested again
+func (s *PrivacyServiceImpl) EraseUser(ctx context.Context, idUser string, byUser string) ports.APIError {
+	user, err := s.userToServe(ctx, idUser, byUser)
+	if err != nil {
+		return err
+	}
+	err = s.si.Tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		for i := len(s.hooks) - 1; i >= 0; i-- {
+			if err := s.hooks[i].hook.EraseUserData(ctx, user); err != nil {
+				s.si.Logger.Info(fmt.Sprintf("Error erasing the data of user %s in %s: %s", user.ID, s.hooks[i].name, err.Error()))
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	event := &domain.AuditEvent{Type: domain.AuditUserErased, Target: user.ID}
+	if byUser != user.ID { // the user is gone, it can not be the actor of the event
+		event.ActorID = byUser
+	}
+	s.audit.Record(ctx, event)
+	return nil
+}
+
+// userToServe checks the request is made by the user or an administrator and returns the user, even if deleted
+func (s *PrivacyServiceImpl) userToServe(ctx context.Context, idUser string, byUser string) (*domain.User, ports.APIError) {
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, idUser, []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	user, err := s.userSvc.GetUserByIdUnscoped(ctx, idUser)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return user, nil
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_Privacy_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	si := mockServiceInfra(ctrl)
+	perm := si.Permissions.(*mocks.MockPermissionService)
+	user := &domain.User{ID: "SampleID", Email: "j1@mail.com"}
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserByIdUnscoped(gomock.Eq(ctx), "SampleID").Return(user, nil)
+	userSvc.EXPECT().ExportUserData(gomock.Eq(ctx), user).Return("profile", nil)
+	silent := mocks.NewMockPersonalDataHook(ctrl)
+	silent.EXPECT().ExportUserData(gomock.Eq(ctx), user).Return(nil, nil)
+	svc := NewPrivacyService(userSvc, mocks.NewMockAuditService(ctrl), si)
+	svc.Register("user", userSvc)
+	svc.Register("silent", silent)
+
+	perm.EXPECT().
+		IsSameUserOrHasSomePermission(gomock.Eq(ctx), "SampleID", "SampleID", []domain.Permission{domain.PermissionAdmin}).
+		Return(true, nil)
+	export, err := svc.ExportUserData(ctx, "SampleID", "SampleID")
+	assert.Nil(t, err)
+	assert.Equal(t, "SampleID", export.UserID)
+	assert.Equal(t, map[string]interface{}{"user": "profile"}, export.Sections) // modules without data are left out
+
+	perm.EXPECT().
+		IsSameUserOrHasSomePermission(gomock.Eq(ctx), "OtherID", "SampleID", gomock.Any()).
+		Return(false, ports.NewAPIError(http.StatusForbidden, "The data is not accessible"))
+	_, err = svc.ExportUserData(ctx, "SampleID", "OtherID")
+	assert.Equal(t, http.StatusForbidden, err.Status())
+}
+
+func Test_Privacy_Erase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	si := mockServiceInfra(ctrl)
+	perm := si.Permissions.(*mocks.MockPermissionService)
+	perm.EXPECT().IsSameUserOrHasSomePermission(gomock.Eq(ctx), gomock.Any(), "SampleID", gomock.Any()).Return(true, nil).AnyTimes()
+	user := &domain.User{ID: "SampleID", Email: "j1@mail.com"}
+	userSvc := mocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().GetUserByIdUnscoped(gomock.Eq(ctx), "SampleID").Return(user, nil).Times(2)
+	other := mocks.NewMockPersonalDataHook(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewPrivacyService(userSvc, audit, si)
+	svc.Register("user", userSvc)
+	svc.Register("other", other)
+
+	gomock.InOrder( // the user goes last
+		other.EXPECT().EraseUserData(gomock.Eq(ctx), user).Return(nil),
+		userSvc.EXPECT().EraseUserData(gomock.Eq(ctx), user).Return(nil),
+		audit.EXPECT().Record(gomock.Eq(ctx), &domain.AuditEvent{Type: domain.AuditUserErased, ActorID: "AdminID", Target: "SampleID"}),
+	)
+	assert.Nil(t, svc.EraseUser(ctx, "SampleID", "AdminID"))
+
+	// if a module fails the user is kept, so the erasure can be requested again
+	other.EXPECT().EraseUserData(gomock.Eq(ctx), user).Return(ports.NewAPIError(http.StatusInternalServerError, "database down"))
+	err := svc.EraseUser(ctx, "SampleID", "SampleID")
+	assert.Equal(t, http.StatusInternalServerError, err.Status())
+}
@@ -0,0 +1,237 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/validator"
+)
+
+// Import and export of users in bulk, to load the users of a new customer or to take them elsewhere
+
+// Lines of the import file processed together: the existing users are looked up and the new ones created in one go
+const importBatchSize = 500
+
+// Users read from the database at a time when exporting
+const exportPageSize = 500
+
+// ImportUsers reads the file in batches, so files of any size can be imported. The emails already registered update
+// the names of the user and, if given, the password. Each line is reported, the wrong ones do not stop the import.
+// In a dry run the lines are checked the same way, but nothing is stored. Only for administrators
+func (s *UserServiceImpl) ImportUsers(ctx context.Context, byUser string, rows ports.UserRowReader, dryRun bool) (*dtos.UserImportReport, ports.APIError) {
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, "", []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	report := &dtos.UserImportReport{DryRun: dryRun, Rows: []*dtos.UserImportResult{}}
+	seen := map[string]int{} // line where each email was found, to refuse the repeated ones
+	batch := make([]*dtos.UserImportRow, 0, importBatchSize)
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ports.NewAPIError(http.StatusBadRequest, "Error reading the file: "+err.Error())
+		}
+		if batch = append(batch, row); len(batch) == importBatchSize {
+			if err := s.importBatch(ctx, byUser, batch, seen, dryRun, report); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := s.importBatch(ctx, byUser, batch, seen, dryRun, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *UserServiceImpl) importBatch(ctx context.Context, byUser string, batch []*dtos.UserImportRow, seen map[string]int, dryRun bool, report *dtos.UserImportReport) ports.APIError {
+	results := make([]*dtos.UserImportResult, len(batch))
+	var emails []string
+	for i, row := range batch {
+		results[i] = &dtos.UserImportResult{Line: row.Line, Email: row.Email}
+		if problem := s.checkImportRow(row); problem != "" {
+			importFailed(results[i], problem)
+			continue
+		}
+		email := strings.ToLower(row.Email)
+		if line, found := seen[email]; found {
+			importFailed(results[i], fmt.Sprintf("Repeated email, already in line %d", line))
+			continue
+		}
+		seen[email] = row.Line
+		emails = append(emails, row.Email)
+	}
+	existing := map[string]*domain.User{}
+	if len(emails) > 0 {
+		users, err := s.repo.GetUsersByEmails(ctx, emails)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			existing[strings.ToLower(user.Email)] = user
+		}
+	}
+
+	var newUsers []*dtos.InternalUserCreate
+	var newResults []*dtos.UserImportResult
+	for i, row := range batch {
+		result := results[i]
+		if result.Action == dtos.UserImportFailed {
+			continue
+		}
+		if user := existing[strings.ToLower(row.Email)]; user != nil {
+			result.ID = user.ID
+			s.importUpdate(ctx, byUser, user, row, dryRun, result)
+			continue
+		}
+		result.Action = dtos.UserImportCreated
+		if dryRun {
+			continue
+		}
+		newUser := &dtos.InternalUserCreate{FirstName: row.FirstName, FirstLastName: row.FirstLastName, SecondLastName: row.SecondLastName,
+			Email: row.Email, AuthMethod: domain.AuthMethPassword}
+		if row.Password != "" {
+			hashedPassword, err := HashPassword(row.Password)
+			if err != nil {
+				importFailed(result, err.Error())
+				continue
+			}
+			newUser.HashedPassword = hashedPassword
+		}
+		newUsers = append(newUsers, newUser)
+		newResults = append(newResults, result)
+	}
+	if len(newUsers) > 0 {
+		ids, errs := s.repo.CreateUsers(ctx, newUsers)
+		for i, result := range newResults {
+			if errs[i] != nil {
+				importFailed(result, errs[i].Error())
+			} else {
+				result.ID = ids[i]
+			}
+		}
+	}
+	for _, result := range results {
+		report.Add(result)
+	}
+	return nil
+}
+
+// checkImportRow returns what is wrong with the line, if anything
+func (s *UserServiceImpl) checkImportRow(row *dtos.UserImportRow) string {
+	if row.Problem != "" {
+		return row.Problem
+	}
+	if err := validator.ValidateStruct(row); err != nil {
+		return err.Error()
+	}
+	if row.Password != "" {
+		if err := s.passwordPolicy.Check(row.Password); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// importUpdate changes what differs between the user and the line. As with any other change of the password, it closes
+// every session of the user
+func (s *UserServiceImpl) importUpdate(ctx context.Context, byUser string, user *domain.User, row *dtos.UserImportRow, dryRun bool, result *dtos.UserImportResult) {
+	update := dtos.UserUpdate{}
+	if row.FirstName != user.FirstName {
+		update.FirstName = &row.FirstName
+	}
+	if row.FirstLastName != user.FirstLastName {
+		update.FirstLastName = &row.FirstLastName
+	}
+	if row.SecondLastName != user.SecondLastName {
+		update.SecondLastName = &row.SecondLastName
+	}
+	newPassword := false
+	if row.Password != "" {
+		if user.AuthMethod != domain.AuthMethPassword {
+			importFailed(result, "The credentials of the user are managed by an external provider")
+			return
+		}
+		newPassword = !CheckPassword(row.Password, user.HashedPassword)
+	}
+	if update.IsEmpty() && !newPassword {
+		result.Action = dtos.UserImportUnchanged
+		return
+	}
+	result.Action = dtos.UserImportUpdated
+	if dryRun {
+		return
+	}
+	var changes []string
+	if !update.IsEmpty() {
+		changes = append(changes, "names")
+	}
+	hashedPassword := ""
+	if newPassword {
+		var err error
+		if hashedPassword, err = HashPassword(row.Password); err != nil {
+			importFailed(result, err.Error())
+			return
+		}
+		changes = append(changes, "password")
+	}
+	errTx := s.si.Tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		if !update.IsEmpty() {
+			if err := s.repo.UpdateUser(ctx, user.ID, &update); err != nil {
+				return err
+			}
+		}
+		if !newPassword {
+			return nil
+		}
+		if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+			return err
+		}
+		return s.tokenRepo.RevokeUserRefreshTokens(ctx, user.ID)
+	})
+	if errTx != nil {
+		importFailed(result, errTx.Error())
+		return
+	}
+	s.audit.Record(ctx, &domain.AuditEvent{Type: domain.AuditUserImported, ActorID: byUser, Target: user.ID, Details: strings.Join(changes, ", ")})
+}
+
+func importFailed(result *dtos.UserImportResult, problem string) {
+	result.Action = dtos.UserImportFailed
+	result.Error = problem
+}
+
+// ExportUsers reads the users page by page, so they are never all in memory. Only for administrators
+func (s *UserServiceImpl) ExportUsers(ctx context.Context, byUser string, rows ports.UserRowWriter) ports.APIError {
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, "", []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return err
+	}
+	query := dtos.ListQuery{Limit: exportPageSize}
+	for {
+		users, result, err := s.repo.GetUsers(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := rows.Write(dtos.FromDomainUser(user)); err != nil {
+				return ports.NewAPIError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+	if err := rows.Flush(); err != nil {
+		return ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type sliceRows struct {
+	rows  []*dtos.UserImportRow
+	users []*dtos.User
+}
+
+func (s *sliceRows) Next() (*dtos.UserImportRow, error) {
+	if len(s.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+func (s *sliceRows) Write(user *dtos.User) error {
+	s.users = append(s.users, user)
+	return nil
+}
+
+func (s *sliceRows) Flush() error {
+	return nil
+}
+
+func importRows() *sliceRows {
+	return &sliceRows{rows: []*dtos.UserImportRow{
+		{Line: 2, Email: "new@mail.com", FirstName: "New", FirstLastName: "User", Password: "a long password"},
+		{Line: 3, Email: "Known@mail.com", FirstName: "Renamed", FirstLastName: "Doe"},
+		{Line: 4, Email: "same@mail.com", FirstName: "Same", FirstLastName: "Doe"},
+		{Line: 5, Email: "not an email", FirstName: "Wrong", FirstLastName: "Doe"},
+		{Line: 6, Email: "NEW@mail.com", FirstName: "Twice", FirstLastName: "User"},
+		{Line: 7, Problem: "wrong number of fields"},
+		{Line: 8, Email: "short@mail.com", FirstName: "Short", FirstLastName: "Password", Password: "short"},
+	}}
+}
+
+func existingUsers() []*domain.User {
+	return []*domain.User{
+		{ID: "KnownID", Email: "known@mail.com", FirstName: "John", FirstLastName: "Doe", AuthMethod: domain.AuthMethPassword},
+		{ID: "SameID", Email: "same@mail.com", FirstName: "Same", FirstLastName: "Doe", AuthMethod: domain.AuthMethPassword},
+	}
+}
+
+func actions(report *dtos.UserImportReport) []dtos.UserImportAction {
+	var all []dtos.UserImportAction
+	for _, row := range report.Rows {
+		all = append(all, row.Action)
+	}
+	return all
+}
+
+// adminServiceInfra lets AdminID, an administrator, import and export
+func adminServiceInfra(ctrl *gomock.Controller) *ServiceInfra {
+	serviceInfra := mockServiceInfra(ctrl)
+	serviceInfra.Permissions.(*mocks.MockPermissionService).EXPECT().
+		IsSameUserOrHasSomePermission(gomock.Any(), "AdminID", "", []domain.Permission{domain.PermissionAdmin}).
+		Return(true, nil).AnyTimes()
+	return serviceInfra
+}
+
+func TestImportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewUserService(repo, mocks.NewMockTokenRepository(ctrl), audit, adminServiceInfra(ctrl), DefaultPasswordPolicy)
+	repo.EXPECT().
+		GetUsersByEmails(gomock.Eq(ctx), []string{"new@mail.com", "Known@mail.com", "same@mail.com"}).
+		Return(existingUsers(), nil)
+	repo.EXPECT().
+		CreateUsers(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, users []*dtos.InternalUserCreate) ([]string, []ports.APIError) {
+			assert.Len(t, users, 1)
+			assert.Equal(t, "new@mail.com", users[0].Email)
+			assert.True(t, CheckPassword("a long password", users[0].HashedPassword))
+			return []string{"NewID"}, []ports.APIError{nil}
+		})
+	renamed := "Renamed"
+	repo.EXPECT().UpdateUser(gomock.Eq(ctx), "KnownID", &dtos.UserUpdate{FirstName: &renamed}).Return(nil)
+	audit.EXPECT().Record(gomock.Eq(ctx), &domain.AuditEvent{Type: domain.AuditUserImported, ActorID: "AdminID", Target: "KnownID", Details: "names"})
+
+	report, err := svc.ImportUsers(ctx, "AdminID", importRows(), false)
+	assert.Nil(t, err)
+	assert.Equal(t, []dtos.UserImportAction{dtos.UserImportCreated, dtos.UserImportUpdated, dtos.UserImportUnchanged,
+		dtos.UserImportFailed, dtos.UserImportFailed, dtos.UserImportFailed, dtos.UserImportFailed}, actions(report))
+	assert.Equal(t, "NewID", report.Rows[0].ID)
+	assert.Equal(t, "Repeated email, already in line 2", report.Rows[4].Error)
+	assert.Equal(t, "wrong number of fields", report.Rows[5].Error)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 4, report.Failed)
+}
+
+func TestImportUsers_DryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	svc := NewUserService(repo, nil, nil, adminServiceInfra(ctrl), DefaultPasswordPolicy)
+	repo.EXPECT().GetUsersByEmails(gomock.Eq(ctx), gomock.Any()).Return(existingUsers(), nil)
+	report, err := svc.ImportUsers(ctx, "AdminID", importRows(), true) // nothing is written
+	assert.Nil(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []dtos.UserImportAction{dtos.UserImportCreated, dtos.UserImportUpdated, dtos.UserImportUnchanged,
+		dtos.UserImportFailed, dtos.UserImportFailed, dtos.UserImportFailed, dtos.UserImportFailed}, actions(report))
+}
+
+func TestImportUsers_Conflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	svc := NewUserService(repo, nil, nil, adminServiceInfra(ctrl), DefaultPasswordPolicy)
+	repo.EXPECT().
+		GetUsersByEmails(gomock.Eq(ctx), gomock.Any()).
+		Return([]*domain.User{{ID: "GoogleID", Email: "google@mail.com", FirstName: "G", FirstLastName: "User", AuthMethod: domain.AuthMethGoogle}}, nil)
+	repo.EXPECT().
+		CreateUsers(gomock.Eq(ctx), gomock.Any()).
+		Return([]string{""}, []ports.APIError{ports.NewAPIError(http.StatusConflict, "Email already registered")}) // a deleted user
+	rows := &sliceRows{rows: []*dtos.UserImportRow{
+		{Line: 1, Email: "google@mail.com", FirstName: "G", FirstLastName: "User", Password: "a long password"},
+		{Line: 2, Email: "deleted@mail.com", FirstName: "D", FirstLastName: "User"},
+	}}
+	report, err := svc.ImportUsers(ctx, "AdminID", rows, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, report.Failed)
+	assert.Contains(t, report.Rows[0].Error, "external provider")
+	assert.Equal(t, "Email already registered", report.Rows[1].Error)
+}
+
+func TestImportUsers_Password(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	audit := mocks.NewMockAuditService(ctrl)
+	svc := NewUserService(repo, tokenRepo, audit, adminServiceInfra(ctrl), DefaultPasswordPolicy)
+	hashedPassword, _ := HashPassword("the old password")
+	repo.EXPECT().
+		GetUsersByEmails(gomock.Eq(ctx), gomock.Any()).
+		Return([]*domain.User{{ID: "KnownID", Email: "known@mail.com", FirstName: "John", FirstLastName: "Doe", AuthMethod: domain.AuthMethPassword, HashedPassword: hashedPassword}}, nil)
+	repo.EXPECT().UpdatePassword(gomock.Eq(ctx), "KnownID", gomock.Any()).Return(nil)
+	tokenRepo.EXPECT().RevokeUserRefreshTokens(gomock.Eq(ctx), "KnownID").Return(nil) // as any other change of the password
+	audit.EXPECT().Record(gomock.Eq(ctx), &domain.AuditEvent{Type: domain.AuditUserImported, ActorID: "AdminID", Target: "KnownID", Details: "password"})
+	rows := &sliceRows{rows: []*dtos.UserImportRow{
+		{Line: 1, Email: "known@mail.com", FirstName: "John", FirstLastName: "Doe", Password: "a new long password"},
+	}}
+	report, err := svc.ImportUsers(ctx, "AdminID", rows, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Updated)
+}
+
+func TestExportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	svc := NewUserService(repo, nil, nil, adminServiceInfra(ctrl), DefaultPasswordPolicy)
+	gomock.InOrder(
+		repo.EXPECT().
+			GetUsers(gomock.Eq(ctx), dtos.ListQuery{Limit: exportPageSize}).
+			Return([]*domain.User{{ID: "1"}, {ID: "2"}}, &dtos.ListResult{NextCursor: "next"}, nil),
+		repo.EXPECT().
+			GetUsers(gomock.Eq(ctx), dtos.ListQuery{Limit: exportPageSize, Cursor: "next"}).
+			Return([]*domain.User{{ID: "3"}}, &dtos.ListResult{}, nil),
+	)
+	rows := &sliceRows{}
+	assert.Nil(t, svc.ExportUsers(ctx, "AdminID", rows))
+	assert.Len(t, rows.users, 3)
+	assert.Equal(t, "3", rows.users[2].ID)
+}
+
+func TestImportExportUsers_OnlyAdmins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	serviceInfra := mockServiceInfra(ctrl)
+	serviceInfra.Permissions.(*mocks.MockPermissionService).EXPECT().
+		IsSameUserOrHasSomePermission(gomock.Eq(ctx), "EditorID", "", []domain.Permission{domain.PermissionAdmin}).
+		Return(false, ports.NewAPIError(http.StatusForbidden, "The data is not accessible")).Times(2)
+	svc := NewUserService(mocks.NewMockUserRepository(ctrl), nil, nil, serviceInfra, DefaultPasswordPolicy) // the repository is not used
+
+	_, err := svc.ImportUsers(ctx, "EditorID", importRows(), true)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	rows := &sliceRows{}
+	err = svc.ExportUsers(ctx, "EditorID", rows)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	assert.Empty(t, rows.users)
+}
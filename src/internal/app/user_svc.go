@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
@@ -11,12 +12,15 @@ import (
 )
 
 type UserServiceImpl struct {
-	repo ports.UserRepository
-	si   *ServiceInfra
+	repo           ports.UserRepository
+	tokenRepo      ports.TokenRepository // to close the sessions of the users whose password is imported
+	audit          ports.AuditService
+	si             *ServiceInfra
+	passwordPolicy PasswordPolicy // for the users created by administrators
 }
 
-func NewUserService(repo ports.UserRepository, serviceInfra *ServiceInfra) ports.UserService {
-	return &UserServiceImpl{repo: repo, si: serviceInfra}
+func NewUserService(repo ports.UserRepository, tokenRepo ports.TokenRepository, audit ports.AuditService, serviceInfra *ServiceInfra, passwordPolicy PasswordPolicy) ports.UserService {
+	return &UserServiceImpl{repo: repo, tokenRepo: tokenRepo, audit: audit, si: serviceInfra, passwordPolicy: passwordPolicy}
 }
 
 // CreateUser creates a new user in the platform. It is intended to be used only internally. REST calls shall be targeted to the auth_svc.
@@ -24,12 +28,17 @@ func (s *UserServiceImpl) CreateUser(ctx context.Context, creationData *dtos.Int
 	if err := validator.ValidateStruct(creationData); err != nil {
 		return "", ports.NewAPIError(http.StatusBadRequest, err.Error())
 	}
-	if creationData.AuthMethod == domain.AuthMethPassword {
+	switch creationData.AuthMethod {
+	case domain.AuthMethPassword:
 		if creationData.HashedPassword == "" {
 			return "", ports.NewAPIError(http.StatusBadRequest, "Password is required")
 		}
-	} else {
-		return "", ports.NewAPIError(http.StatusBadRequest, "Only password authentication is currently supported")
+	case domain.AuthMethGoogle:
+		if creationData.HashedPassword != "" {
+			return "", ports.NewAPIError(http.StatusBadRequest, "Users of external providers can not have a password")
+		}
+	default:
+		return "", ports.NewAPIError(http.StatusBadRequest, "Unsupported authentication method")
 	}
 
 	existingUser := s.repo.GetUserIdByEmail(ctx, creationData.Email)
@@ -45,7 +54,7 @@ func (s *UserServiceImpl) CreateUser(ctx context.Context, creationData *dtos.Int
 
 }
 
-// GetUserByEmail retrieves a domain.User by its email or nil if not found
+// GetUserByEmail retrieves a domain.User by its email or nil if not found. It is intended to be used only internally
 func (s *UserServiceImpl) GetUserByEmail(ctx context.Context, email string) (*domain.User, ports.APIError) {
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
@@ -54,21 +63,159 @@ func (s *UserServiceImpl) GetUserByEmail(ctx context.Context, email string) (*do
 	return user, nil
 }
 
-// GetUsers retrieves a all users
-func (s *UserServiceImpl) GetUsers(ctx context.Context, byUser string) ([]*domain.User, ports.APIError) {
-	// we should check if 'byUser' has permissions for this query
-	users, err := s.repo.GetUsers(ctx)
+// GetUsers retrieves a page of users. Only for users with the read permission
+func (s *UserServiceImpl) GetUsers(ctx context.Context, byUser string, query dtos.ListQuery) ([]*domain.User, *dtos.ListResult, ports.APIError) {
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, "", []domain.Permission{domain.PermissionRead}); err != nil {
+		return nil, nil, err
+	}
+	if err := validator.ValidateStruct(query); err != nil {
+		return nil, nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	users, result, err := s.repo.GetUsers(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return users, nil
+	return users, result, nil
 }
 
+// GetUserById retrieves the user. Users can read their own data, the data of others needs the read permission
 func (s *UserServiceImpl) GetUserById(ctx context.Context, idUser string, byUser string) (*domain.User, ports.APIError) {
-	// we should check if 'byUser' has permissions for this query
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, idUser, []domain.Permission{domain.PermissionRead}); err != nil {
+		return nil, err
+	}
 	user, err := s.repo.GetUserById(ctx, idUser)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
+
+// AddUser creates a user that signs in with a password. Only for administrators
+func (s *UserServiceImpl) AddUser(ctx context.Context, byUser string, request dtos.UserCreate) (*domain.User, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, "", []domain.Permission{domain.PermissionAdmin}); err != nil {
+		return nil, err
+	}
+	if err := s.passwordPolicy.Check(request.Secret); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	hashedPassword, err := HashPassword(request.Secret)
+	if err != nil {
+		return nil, ports.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	userId, errCreate := s.CreateUser(ctx, dtos.FromDtosUserCreate(&request, hashedPassword))
+	if errCreate != nil {
+		return nil, errCreate
+	}
+	return s.repo.GetUserById(ctx, userId)
+}
+
+// UpdateUser changes the profile of the user. Users can change their own, the profile of others needs the write permission
+func (s *UserServiceImpl) UpdateUser(ctx context.Context, idUser string, byUser string, request dtos.UserUpdate) (*domain.User, ports.APIError) {
+	if err := validator.ValidateStruct(request); err != nil {
+		return nil, ports.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if request.IsEmpty() {
+		return nil, ports.NewAPIError(http.StatusBadRequest, "Nothing to update")
+	}
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, idUser, []domain.Permission{domain.PermissionWrite}); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateUser(ctx, idUser, &request); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserById(ctx, idUser)
+}
+
+// DeleteUser hides the user, who can no longer sign in nor use its API keys, but can be restored.
+// Users can delete themselves, deleting others needs the delete permission
+func (s *UserServiceImpl) DeleteUser(ctx context.Context, idUser string, byUser string) ports.APIError {
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, idUser, []domain.Permission{domain.PermissionDelete}); err != nil {
+		return err
+	}
+	return s.repo.DeleteUser(ctx, idUser)
+}
+
+// RestoreUser brings back a deleted user. Only for users with the delete permission
+func (s *UserServiceImpl) RestoreUser(ctx context.Context, idUser string, byUser string) ports.APIError {
+	if _, err := s.si.Permissions.IsSameUserOrHasSomePermission(ctx, byUser, "", []domain.Permission{domain.PermissionDelete}); err != nil {
+		return err
+	}
+	return s.repo.RestoreUser(ctx, idUser)
+}
+
+// MarkEmailVerified records that the user proved the email is theirs. It is intended to be used only internally
+func (s *UserServiceImpl) MarkEmailVerified(ctx context.Context, idUser string) ports.APIError {
+	return s.repo.SetEmailVerified(ctx, idUser, time.Now())
+}
+
+// SetPassword replaces the password of the user. It is intended to be used only internally, the caller must have hashed it
+func (s *UserServiceImpl) SetPassword(ctx context.Context, idUser string, hashedPassword string) ports.APIError {
+	if hashedPassword == "" {
+		return ports.NewAPIError(http.StatusBadRequest, "Password is required")
+	}
+	return s.repo.UpdatePassword(ctx, idUser, hashedPassword)
+}
+
+// ChangeEmail replaces the email of the user, which is considered verified. It is intended to be used only internally,
+// the caller must have checked the user controls the address. Emails are unique regardless of the case
+func (s *UserServiceImpl) ChangeEmail(ctx context.Context, idUser string, email string) ports.APIError {
+	if email == "" {
+		return ports.NewAPIError(http.StatusBadRequest, "Email is required")
+	}
+	if owner := s.repo.GetUserIdByEmail(ctx, email); owner != "" && owner != idUser {
+		return ports.NewAPIError(http.StatusConflict, "Email already registered")
+	}
+	return s.repo.UpdateEmail(ctx, idUser, email, time.Now())
+}
+
+// GetUserByIdentity retrieves the user linked to the identity of an external provider
+func (s *UserServiceImpl) GetUserByIdentity(ctx context.Context, issuer string, subject string) (*domain.User, ports.APIError) {
+	userId := s.repo.GetUserIdByIdentity(ctx, issuer, subject)
+	if userId == "" {
+		return nil, ports.NewAPIError(http.StatusNotFound, "User not found")
+	}
+	return s.repo.GetUserById(ctx, userId)
+}
+
+// LinkIdentity lets the user sign in with the identity of an external provider. It is intended to be used only internally
+func (s *UserServiceImpl) LinkIdentity(ctx context.Context, idUser string, identity *domain.ExternalIdentity) ports.APIError {
+	if identity.Issuer == "" || identity.Subject == "" {
+		return ports.NewAPIError(http.StatusBadRequest, "The identity needs an issuer and a subject")
+	}
+	return s.repo.LinkIdentity(ctx, idUser, identity)
+}
+
+// UpdateMFA replaces the two-factor authentication state of the user. It is intended to be used only internally
+func (s *UserServiceImpl) UpdateMFA(ctx context.Context, idUser string, secret string, enabledAt *time.Time, recoveryCodes []string) ports.APIError {
+	if enabledAt != nil && secret == "" {
+		return ports.NewAPIError(http.StatusBadRequest, "MFA can not be enabled without a secret")
+	}
+	return s.repo.UpdateMFA(ctx, idUser, secret, enabledAt, recoveryCodes)
+}
+
+// UseRecoveryCode consumes one of the recovery codes of the user. It is intended to be used only internally
+func (s *UserServiceImpl) UseRecoveryCode(ctx context.Context, idUser string, codeHash string) (bool, ports.APIError) {
+	return s.repo.UseRecoveryCode(ctx, idUser, codeHash)
+}
+
+// GetUserByIdUnscoped finds the user even if it has been deleted. For internal use, it does not check permissions
+func (s *UserServiceImpl) GetUserByIdUnscoped(ctx context.Context, idUser string) (*domain.User, ports.APIError) {
+	return s.repo.GetUserByIdUnscoped(ctx, idUser)
+}
+
+// ExportUserData returns the profile of the user and the external accounts linked to it
+func (s *UserServiceImpl) ExportUserData(ctx context.Context, user *domain.User) (interface{}, ports.APIError) {
+	identities, err := s.repo.GetUserIdentities(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return dtos.FromDomainUserProfile(user, identities), nil
+}
+
+// EraseUserData deletes the user for good, not only flagging it as deleted
+func (s *UserServiceImpl) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	return s.repo.EraseUser(ctx, user.ID)
+}
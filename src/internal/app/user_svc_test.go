@@ -8,6 +8,7 @@ import (
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
 	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/cache"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
 	"github.com/stretchr/testify/assert"
@@ -28,7 +29,7 @@ func TestUserCreationWithValidData01(t *testing.T) {
 		Create(gomock.Eq(ctx), gomock.Any()).
 		Return("JohnId", nil)
 
-	svc := NewUserService(repo, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()})
+	svc := NewUserService(repo, nil, nil, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, DefaultPasswordPolicy)
 	creationParams := &dtos.InternalUserCreate{
 		FirstName:      "John",
 		FirstLastName:  "Doe",
@@ -56,7 +57,7 @@ func TestUserCreationWithValidData02(t *testing.T) {
 		Create(gomock.Eq(ctx), gomock.Any()).
 		Return("JohnId", nil)
 
-	svc := NewUserService(repo, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()})
+	svc := NewUserService(repo, nil, nil, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, DefaultPasswordPolicy)
 	creationParams := &dtos.InternalUserCreate{
 		FirstName:      "John",
 		FirstLastName:  "Doe",
@@ -81,7 +82,7 @@ func TestUserCreationWithDupEmail(t *testing.T) {
 		GetUserIdByEmail(gomock.Eq(ctx), gomock.Any()).
 		Return("JohnId")
 
-	svc := NewUserService(repo, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()})
+	svc := NewUserService(repo, nil, nil, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, DefaultPasswordPolicy)
 	creationParams := &dtos.InternalUserCreate{
 		FirstName:      "John",
 		FirstLastName:  "Doe",
@@ -112,7 +113,7 @@ func TestUserCreationWithInvalidData(t *testing.T) {
 	perm := mocks.NewMockPermissionService(ctrl)
 	repo := mocks.NewMockUserRepository(ctrl)
 
-	svc := NewUserService(repo, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()})
+	svc := NewUserService(repo, nil, nil, &ServiceInfra{Permissions: perm, Logger: logger.GetNopLogger(), Cache: cache.GetNopCache()}, DefaultPasswordPolicy)
 	for _, creationParams := range invalidUsersCreate {
 		newUser, err := svc.CreateUser(ctx, &creationParams)
 		assert.NotNil(t, err)
@@ -120,3 +121,141 @@ func TestUserCreationWithInvalidData(t *testing.T) {
 		assert.Equal(t, "", newUser)
 	}
 }
+
+func TestUserCreationWithExternalProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().
+		GetUserIdByEmail(gomock.Eq(ctx), gomock.Any()).
+		Return("")
+	repo.EXPECT().
+		Create(gomock.Eq(ctx), gomock.Any()).
+		Return("JohnId", nil)
+
+	svc := NewUserService(repo, nil, nil, mockServiceInfra(ctrl), DefaultPasswordPolicy)
+	identity := &domain.ExternalIdentity{Issuer: "https://accounts.google.com", Subject: "1234", Email: "john@gmail.com", Name: "John Ronald Doe"}
+	creationParams := dtos.FromExternalIdentity(identity)
+	assert.Equal(t, "John", creationParams.FirstName)
+	assert.Equal(t, "Ronald Doe", creationParams.FirstLastName)
+	newUser, err := svc.CreateUser(ctx, creationParams) // no password needed
+	assert.Nil(t, err)
+	assert.Equal(t, "JohnId", newUser)
+
+	repo.EXPECT().LinkIdentity(gomock.Eq(ctx), "JohnId", identity).Return(nil)
+	assert.Nil(t, svc.LinkIdentity(ctx, "JohnId", identity))
+	repo.EXPECT().GetUserIdByIdentity(gomock.Eq(ctx), identity.Issuer, identity.Subject).Return("JohnId")
+	repo.EXPECT().GetUserById(gomock.Eq(ctx), "JohnId").Return(&domain.User{ID: "JohnId"}, nil)
+	user, err := svc.GetUserByIdentity(ctx, identity.Issuer, identity.Subject)
+	assert.Nil(t, err)
+	assert.Equal(t, "JohnId", user.ID)
+}
+
+func TestUserReadingNeedsPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	permRepo := mocks.NewMockPermissionRepository(ctrl)
+	serviceInfra := &ServiceInfra{Logger: logger.GetNopLogger(), Cache: newSyncCache()}
+	serviceInfra.Permissions = NewPermissionService(permRepo, mocks.NewMockAuditService(ctrl), serviceInfra.Cache, serviceInfra.Logger)
+	svc := NewUserService(repo, nil, nil, serviceInfra, DefaultPasswordPolicy)
+
+	repo.EXPECT().GetUserById(gomock.Eq(ctx), "JohnId").Return(&domain.User{ID: "JohnId"}, nil)
+	_, err := svc.GetUserById(ctx, "JohnId", "JohnId") // own data
+	assert.Nil(t, err)
+
+	permRepo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return(nil, nil)
+	_, err = svc.GetUserById(ctx, "JaneId", "JohnId")
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	_, _, err = svc.GetUsers(ctx, "JohnId", dtos.ListQuery{})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+
+	permRepo.EXPECT().GetUserRoles(gomock.Eq(ctx), "GrannyId").Return([]*domain.Role{{Name: domain.RoleUser, Permissions: domain.Roles.User}}, nil)
+	repo.EXPECT().GetUserById(gomock.Eq(ctx), "JaneId").Return(&domain.User{ID: "JaneId"}, nil)
+	_, err = svc.GetUserById(ctx, "JaneId", "GrannyId")
+	assert.Nil(t, err)
+	query := dtos.ListQuery{Limit: 10, Filters: []dtos.ListFilter{{Field: "email", Op: dtos.ListOpPrefix, Value: "jane"}}}
+	repo.EXPECT().GetUsers(gomock.Eq(ctx), query).Return([]*domain.User{{ID: "JaneId"}}, &dtos.ListResult{}, nil)
+	_, _, err = svc.GetUsers(ctx, "GrannyId", query)
+	assert.Nil(t, err)
+	_, _, err = svc.GetUsers(ctx, "GrannyId", dtos.ListQuery{Limit: 1000})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+}
+
+func TestUserCrud(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	permRepo := mocks.NewMockPermissionRepository(ctrl)
+	serviceInfra := &ServiceInfra{Logger: logger.GetNopLogger(), Cache: newSyncCache()}
+	serviceInfra.Permissions = NewPermissionService(permRepo, mocks.NewMockAuditService(ctrl), serviceInfra.Cache, serviceInfra.Logger)
+	svc := NewUserService(repo, nil, nil, serviceInfra, DefaultPasswordPolicy)
+	permRepo.EXPECT().GetUserRoles(gomock.Eq(ctx), "GrannyId").Return([]*domain.Role{{Name: domain.RoleAdmin, Permissions: domain.Roles.Admin}}, nil).AnyTimes()
+	permRepo.EXPECT().GetUserRoles(gomock.Eq(ctx), "JohnId").Return(nil, nil).AnyTimes()
+
+	creation := dtos.UserCreate{FirstName: "Jane", FirstLastName: "Doe", Email: "jane@mail.com", Secret: "a long password"}
+	_, err := svc.AddUser(ctx, "JohnId", creation)
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	weak := creation
+	weak.Secret = "short"
+	_, err = svc.AddUser(ctx, "GrannyId", weak)
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	repo.EXPECT().GetUserIdByEmail(gomock.Eq(ctx), "jane@mail.com").Return("")
+	repo.EXPECT().
+		Create(gomock.Eq(ctx), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, creationData *dtos.InternalUserCreate) (string, ports.APIError) {
+			assert.True(t, CheckPassword("a long password", creationData.HashedPassword))
+			return "JaneId", nil
+		})
+	repo.EXPECT().GetUserById(gomock.Eq(ctx), "JaneId").Return(&domain.User{ID: "JaneId"}, nil)
+	user, err := svc.AddUser(ctx, "GrannyId", creation)
+	assert.Nil(t, err)
+	assert.Equal(t, "JaneId", user.ID)
+
+	name, empty := "Johnny", ""
+	_, err = svc.UpdateUser(ctx, "JaneId", "JohnId", dtos.UserUpdate{FirstName: &name})
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	_, err = svc.UpdateUser(ctx, "JohnId", "JohnId", dtos.UserUpdate{FirstName: &empty})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	_, err = svc.UpdateUser(ctx, "JohnId", "JohnId", dtos.UserUpdate{})
+	assert.Equal(t, http.StatusBadRequest, err.Status())
+	update := dtos.UserUpdate{FirstName: &name, SecondLastName: &empty}
+	repo.EXPECT().UpdateUser(gomock.Eq(ctx), "JohnId", &update).Return(nil)
+	repo.EXPECT().GetUserById(gomock.Eq(ctx), "JohnId").Return(&domain.User{ID: "JohnId", FirstName: name}, nil)
+	user, err = svc.UpdateUser(ctx, "JohnId", "JohnId", update)
+	assert.Nil(t, err)
+	assert.Equal(t, name, user.FirstName)
+
+	err = svc.DeleteUser(ctx, "JaneId", "JohnId")
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	repo.EXPECT().DeleteUser(gomock.Eq(ctx), "JaneId").Return(nil)
+	assert.Nil(t, svc.DeleteUser(ctx, "JaneId", "GrannyId"))
+	err = svc.RestoreUser(ctx, "JohnId", "JohnId") // not even your own account
+	assert.Equal(t, http.StatusForbidden, err.Status())
+	repo.EXPECT().RestoreUser(gomock.Eq(ctx), "JaneId").Return(nil)
+	assert.Nil(t, svc.RestoreUser(ctx, "JaneId", "GrannyId"))
+}
+
+func TestChangeEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	svc := NewUserService(repo, nil, nil, mockServiceInfra(ctrl), DefaultPasswordPolicy)
+	repo.EXPECT().GetUserIdByEmail(gomock.Eq(ctx), "taken@mail.com").Return("OtherId")
+	err := svc.ChangeEmail(ctx, "JohnId", "taken@mail.com")
+	assert.Equal(t, http.StatusConflict, err.Status())
+
+	repo.EXPECT().GetUserIdByEmail(gomock.Eq(ctx), "JOHN@mail.com").Return("JohnId") // a change of case
+	repo.EXPECT().UpdateEmail(gomock.Eq(ctx), "JohnId", "JOHN@mail.com", gomock.Any()).Return(nil)
+	assert.Nil(t, svc.ChangeEmail(ctx, "JohnId", "JOHN@mail.com"))
+}
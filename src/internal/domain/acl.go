@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+type SubjectType string
+
+const (
+	SubjectUser  SubjectType = "user"
+	SubjectGroup SubjectType = "group"
+)
+
+// Group of users that receive grants together
+type Group struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+// Grant gives a user, or every member of a group, a permission on a single resource, on top of the global permissions of its roles
+type Grant struct {
+	ID           string
+	SubjectType  SubjectType
+	SubjectID    string // Id of the user or of the group
+	ResourceType string // Whatever the module owning the resource calls it, e.g. "document"
+	ResourceID   string
+	Permission   Permission
+	CreatedAt    time.Time
+}
+
+// Allows tells whether the grant lets do the action. The admin permission on a resource allows anything on it
+func (g *Grant) Allows(action Permission) bool {
+	return g.Permission == action || g.Permission == PermissionAdmin
+}
+
+// ResourceFilter tells which resources of a type a user can access. List endpoints use it to return only those
+type ResourceFilter struct {
+	All bool     // Administrators can access every resource of the type
+	IDs []string // Otherwise, the resources allowed by grants
+}
+
+func (f *ResourceFilter) Allows(resourceID string) bool {
+	return f.All || slices.Contains(f.IDs, resourceID)
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// APIKey is a long lived credential for machine clients (CI jobs, integrations) that can not sign in interactively.
+// It acts on behalf of its user, limited by its scopes. The key is shown once when created, only its hash is stored
+type APIKey struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string // First characters of the key, to tell the keys apart
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+type AuditEventType string
+
+const (
+	AuditAccountLocked AuditEventType = "account_locked" // Too many failed sign ins for an email
+	AuditIPLocked      AuditEventType = "ip_locked"      // Too many failed sign ins from an IP address
+	AuditMFALocked     AuditEventType = "mfa_locked"     // Too many wrong two-factor codes for a user
+	AuditAPIKeyCreated AuditEventType = "api_key_created"
+	AuditAPIKeyRevoked AuditEventType = "api_key_revoked"
+	AuditRoleCreated   AuditEventType = "role_created"
+	AuditRoleGranted   AuditEventType = "role_granted"
+	AuditRoleRevoked   AuditEventType = "role_revoked"
+	AuditAccessGranted AuditEventType = "access_granted" // Permission on a single resource
+	AuditAccessRevoked AuditEventType = "access_revoked"
+	AuditGroupJoined   AuditEventType = "group_joined"
+	AuditGroupLeft     AuditEventType = "group_left"
+	AuditUserErased    AuditEventType = "user_erased"   // The data of the user was deleted on request (GDPR)
+	AuditUserImported  AuditEventType = "user_imported" // An import changed the names or the password of an existing user
+)
+
+// AuditEvent records a security relevant action, for the administrators to review
+type AuditEvent struct {
+	ID        string
+	Type      AuditEventType
+	ActorID   string // User that did it. Empty for anonymous actions or the system itself
+	Target    string // What it was done to: a user, an email, an IP address...
+	IP        string // Address the request came from, if any
+	Details   string
+	CreatedAt time.Time
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// ExternalIdentity is the user identity asserted by an external provider (OIDC) in a verified ID token
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string // Unique and stable id of the user in the issuer. Emails can change, this can't
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Name          string
+}
+
+// OIDCFlow is what we need to remember between the start of a sign in with the provider and the callback
+type OIDCFlow struct {
+	StateHash    string // Hash of the state sent to the provider. The state itself is only in the cookie of the browser
+	Nonce        string
+	CodeVerifier string // PKCE
+	ExpiresAt    time.Time
+}
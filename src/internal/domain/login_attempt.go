@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// LoginAttempts are the recent failed sign ins of an account or of an IP address, or the wrong two-factor codes of a user
+type LoginAttempts struct {
+	Key           string // "account:<email>", "ip:<address>" or "mfa:<user id>"
+	Failures      int    // Failures since the last success, lockout or quiet period
+	LastFailureAt time.Time
+	LockedUntil   *time.Time // Sign ins are refused until then. Nil if not locked
+}
+
+func (a *LoginAttempts) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Organization is a tenant: a customer of the platform whose data is kept apart from the others
+type Organization struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"  // Created the organization. Can not be removed
+	OrgRoleAdmin  OrgRole = "admin"  // Manages the members
+	OrgRoleMember OrgRole = "member" // Works with the data of the organization
+)
+
+// CanManageMembers tells whether the role allows adding and removing members
+func (r OrgRole) CanManageMembers() bool {
+	return r == OrgRoleOwner || r == OrgRoleAdmin
+}
+
+// Membership says a user belongs to an organization, and with which role
+type Membership struct {
+	OrganizationID string
+	UserID         string
+	Role           OrgRole
+	CreatedAt      time.Time
+}
+
+type tenantContextKey struct{} // to avoid collision with other context keys
+
+// WithTenant returns a copy of the context carrying the organization the request acts on behalf of
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the organization the request acts on behalf of, or false if there is none
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && tenant != ""
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type Permission int16
 
@@ -11,6 +14,9 @@ const (
 	PermissionAdmin  Permission = 300
 )
 
+// AllPermissions lists every permission, to validate the ones given to custom roles
+var AllPermissions = []Permission{PermissionRead, PermissionWrite, PermissionDelete, PermissionAdmin}
+
 type roles struct {
 	User   []Permission
 	Editor []Permission
@@ -23,6 +29,29 @@ var Roles = roles{
 	Admin:  []Permission{PermissionRead, PermissionWrite, PermissionDelete, PermissionAdmin},
 }
 
+// Names of the roles stored when the database is created
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Role is a named set of permissions that can be assigned to users
+type Role struct {
+	ID          string
+	Name        string
+	Permissions []Permission
+}
+
+// DefaultRoles returns the roles stored when the database is created, built from Roles
+func DefaultRoles() []*Role {
+	return []*Role{
+		{Name: RoleUser, Permissions: Roles.User},
+		{Name: RoleEditor, Permissions: Roles.Editor},
+		{Name: RoleAdmin, Permissions: Roles.Admin},
+	}
+}
+
 func HasPermission(role []Permission, permission Permission) bool {
 	for _, p := range role {
 		if p == permission {
@@ -32,6 +61,30 @@ func HasPermission(role []Permission, permission Permission) bool {
 	return false
 }
 
+type operatorContextKey struct{} // to avoid collision with other context keys
+
+// AsOperator returns a copy of the context of a task run by an operator of the server, from the command line. There is
+// no user behind it, and it has every global permission. Requests coming from the network never carry it
+func AsOperator(ctx context.Context) context.Context {
+	return context.WithValue(ctx, operatorContextKey{}, true)
+}
+
+// IsOperator tells whether the context belongs to a task run by an operator of the server
+func IsOperator(ctx context.Context) bool {
+	operator, _ := ctx.Value(operatorContextKey{}).(bool)
+	return operator
+}
+
+// HasSomePermission tells whether any of the needed permissions has been granted
+func HasSomePermission(granted []Permission, needed []Permission) bool {
+	for _, permission := range needed {
+		if HasPermission(granted, permission) {
+			return true
+		}
+	}
+	return false
+}
+
 func usageSample() {
 	fmt.Println("Can Editor write?", HasPermission(Roles.Editor, PermissionWrite))     // true
 	fmt.Println("Can User delete?", HasPermission(Roles.User, PermissionDelete))       // false
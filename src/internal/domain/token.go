@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// RefreshToken is a long lived credential used to obtain new access tokens.
+// Every refresh rotates the token: the used one is flagged and a new one of the same family is issued.
+// Presenting an already used token means it was stolen (or replayed) and the whole family gets revoked.
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string // All the tokens descending from the same sign in share the family
+	TenantID  string // Organization the session acts on behalf of. Empty if none
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+type ActionTokenPurpose string
+
+const (
+	ActionVerifyEmail   ActionTokenPurpose = "verify_email"
+	ActionResetPassword ActionTokenPurpose = "reset_password"
+	ActionChangeEmail   ActionTokenPurpose = "change_email"
+)
+
+// ActionToken is a single use credential sent by email to prove the user controls the address,
+// e.g. to verify it or to reset the password. Only its hash is stored
+type ActionToken struct {
+	ID        string
+	UserID    string
+	Purpose   ActionTokenPurpose
+	NewEmail  string // Address the email changes to, only for ActionChangeEmail
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// MFAChallenge is what is remembered between the password and the code of a user with two-factor authentication.
+// Only the hash of its token is stored
+type MFAChallenge struct {
+	TokenHash string
+	UserID    string
+	Attempts  int // Codes tried with this challenge
+	ExpiresAt time.Time
+}
@@ -1,18 +1,24 @@
 package domain
 
+import "time"
+
 type AuthMethod int
 
 const (
 	AuthMethPassword AuthMethod = 1
-	AuthMethGoogle   AuthMethod = 2
+	AuthMethGoogle   AuthMethod = 2 // Signs in through the OIDC provider, Google or any other
 )
 
 type User struct {
-	ID             string
-	FirstName      string
-	FirstLastName  string
-	SecondLastName string
-	Email          string
-	AuthMethod     AuthMethod
-	HashedPassword string
+	ID              string
+	FirstName       string
+	FirstLastName   string
+	SecondLastName  string
+	Email           string
+	AuthMethod      AuthMethod
+	HashedPassword  string
+	EmailVerifiedAt *time.Time // Nil until the user proves the email is theirs
+	MFASecret       string     // TOTP secret. Set when the enrollment starts, even if it has not been confirmed yet
+	MFAEnabledAt    *time.Time // Nil until the user confirms the enrollment with a valid code
+	RecoveryCodes   []string   // Hashes of the recovery codes not used yet
 }
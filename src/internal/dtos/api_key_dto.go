@@ -0,0 +1,54 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// @Name APIKeyCreate
+// @Description Data to create an API key
+type APIKeyCreate struct {
+	Name          string   `json:"name" validate:"required,max=64" example:"CI pipeline"`                 // To remember what the key is for
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=read write" example:"read"` // "read" allows only GET requests, "write" any request
+	ExpiresInDays int      `json:"expires_in_days" validate:"required,min=1,max=365" example:"90"`        // Days until the key stops working
+}
+
+// @Name APIKey
+// @Description API key of a machine client. The key itself is only returned when created
+type APIKey struct {
+	ID         string     `json:"id" example:"23GfxRTs"`
+	Name       string     `json:"name" example:"CI pipeline"`
+	Prefix     string     `json:"prefix" example:"gmk_Xb3kT9aQ"` // First characters of the key
+	Scopes     []string   `json:"scopes" example:"read"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// @Name CreatedAPIKey
+// @Description New API key. Keep the key safe, it can not be retrieved again
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key" example:"gmk_Xb3kT9aQ..."` // Send it as "Authorization: Bearer <key>" or "X-API-Key: <key>"
+}
+
+func FromDomainAPIKey(key *domain.APIKey) *APIKey {
+	return &APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func FromDomainAPIKeys(keys []*domain.APIKey) []*APIKey {
+	result := make([]*APIKey, len(keys))
+	for i, key := range keys {
+		result[i] = FromDomainAPIKey(key)
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// Number of events returned when the query does not say
+const DefaultAuditLimit = 100
+
+// @Name AuditEvent
+// @Description Security relevant action
+type AuditEvent struct {
+	ID        string    `json:"id" example:"23GfxRTs"`
+	Type      string    `json:"type" example:"account_locked"`                   // What happened
+	ActorID   string    `json:"actor_id,omitempty" example:"23GfxRTs"`           // User that did it, if any
+	Target    string    `json:"target,omitempty" example:"john.doe@example.com"` // What it was done to
+	IP        string    `json:"ip,omitempty" example:"203.0.113.7"`              // Address the request came from
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditQuery selects the events to return
+type AuditQuery struct {
+	Type   string    `validate:"omitempty,max=64"`  // Only events of this type
+	Target string    `validate:"omitempty,max=256"` // Only events on this target
+	Since  time.Time // Only events after this moment
+	Limit  int       `validate:"omitempty,min=1,max=1000"` // Maximum number of events, DefaultAuditLimit if zero
+}
+
+func FromDomainAuditEvents(events []*domain.AuditEvent) []*AuditEvent {
+	result := make([]*AuditEvent, len(events))
+	for i, event := range events {
+		result[i] = &AuditEvent{
+			ID:        event.ID,
+			Type:      string(event.Type),
+			ActorID:   event.ActorID,
+			Target:    event.Target,
+			IP:        event.IP,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt,
+		}
+	}
+	return result
+}
@@ -1,6 +1,6 @@
 package dtos
 
-import "github.com/Manolo-Esc/gommence/src/internal/domain"
+import "time"
 
 // @Name LoginCredentials
 // @Description Request to sign in the program
@@ -12,16 +12,121 @@ type LoginCredentials struct {
 // @Name LoggedUser
 // @Description Logged user information
 type LoggedUser struct {
-	AccessToken string `json:"access_token"` // Authenticaton token
+	AccessToken               string `json:"access_token"`                          // Authenticaton token
+	RefreshToken              string `json:"refresh_token"`                         // Single use token to get a new access token when it expires
+	ExpiresIn                 int    `json:"expires_in" example:"900"`              // Seconds until the access token expires
+	MFARequired               bool   `json:"mfa_required,omitempty"`                // The user has two-factor authentication. No token is issued until the code is sent with the MFA token
+	MFAToken                  string `json:"mfa_token,omitempty"`                   // Short lived token to send along with the code to /auth/mfa/verify
+	UserID                    string `json:"user_id,omitempty"`                     // Id of the new user, when signing up gives no tokens
+	EmailVerificationRequired bool   `json:"email_verification_required,omitempty"` // No token is issued until the email is verified
+}
+
+// @Name RefreshTokenRequest
+// @Description Request to get a new access token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"` // Refresh token received when signing in or in the last refresh
+}
+
+// @Name SignOutRequest
+// @Description Request to close the current session
+type SignOutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Optional. Refresh token of the session, it will be revoked along with the access token
 }
 
 // @Name UserSignUp
 // @Description Request to create a new user in the platform
 type UserSignUp struct {
-	FirstName      string            `json:"first_name" validate:"required" example:"John"`                  // First name of the new user
-	FirstLastName  string            `json:"last_name" validate:"required" example:"Doe"`                    // First last name of the new user
-	SecondLastName string            `json:"second_last_name" example:"Smith"`                               // Second last name of the new user
-	Email          string            `json:"email" validate:"required,email" example:"john.doe@example.com"` // Email of the new user
-	AuthMethod     domain.AuthMethod // Authentication method of the new user
-	Secret         string            `json:"secret" validate:"required" example:"password"` // Password or third party token of the user signing in
+	FirstName      string `json:"first_name" validate:"required" example:"John"`                  // First name of the new user
+	FirstLastName  string `json:"last_name" validate:"required" example:"Doe"`                    // First last name of the new user
+	SecondLastName string `json:"second_last_name" example:"Smith"`                               // Second last name of the new user
+	Email          string `json:"email" validate:"required,email" example:"john.doe@example.com"` // Email of the new user
+	Secret         string `json:"secret" validate:"required" example:"password"`                  // Password of the new user
+}
+
+// @Name PasswordResetRequest
+// @Description Request to receive an email with a link to reset the password
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john.doe@example.com"` // Email of the user who forgot the password
+}
+
+// @Name PasswordResetConfirm
+// @Description Request to set a new password using the token received by email
+type PasswordResetConfirm struct {
+	Token  string `json:"token" validate:"required"`                     // Token received by email
+	Secret string `json:"secret" validate:"required" example:"password"` // New password
+}
+
+// @Name EmailVerificationRequest
+// @Description Request to receive again the email with the link to verify the address
+type EmailVerificationRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john.doe@example.com"` // Email to verify
+}
+
+// @Name VerifyEmailRequest
+// @Description Request to verify an email address using the token received by email
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"` // Token received by email
+}
+
+// @Name PasswordChange
+// @Description Request of a signed in user to change the password
+type PasswordChange struct {
+	Secret    string `json:"secret" validate:"required" example:"password"`            // Current password
+	NewSecret string `json:"new_secret" validate:"required" example:"my new password"` // New password
+}
+
+// @Name EmailChangeRequest
+// @Description Request of a signed in user to change the email. It changes when the link sent to the new address is followed
+type EmailChangeRequest struct {
+	Email  string `json:"email" validate:"required,email" example:"john.doe@example.org"` // New email
+	Secret string `json:"secret" validate:"required" example:"password"`                  // Current password
+}
+
+// @Name EmailChangeConfirm
+// @Description Request to switch to the new email using the token received in it
+type EmailChangeConfirm struct {
+	Token string `json:"token" validate:"required"` // Token received by email
+}
+
+// @Name OIDCCallback
+// @Description Parameters the OIDC provider sends to the callback
+type OIDCCallback struct {
+	Code             string `validate:"required"` // Authorization code
+	State            string `validate:"required"` // State sent when the sign in started
+	Error            string // Set by the provider if the user did not sign in
+	ErrorDescription string
+	BrowserState     string // State kept in the cookie of the browser that started the sign in. Must be the same as State
+}
+
+// OIDCStart is where the browser has to be sent to sign in, and the state it has to keep until the callback
+type OIDCStart struct {
+	AuthURL   string
+	State     string
+	ExpiresAt time.Time
+}
+
+// @Name MFAVerifyRequest
+// @Description Second step of the sign in of users with two-factor authentication
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`             // Token received in the first step of the sign in
+	Code     string `json:"code" validate:"required" example:"123456"` // Code of the authenticator app or one of the recovery codes
+}
+
+// @Name MFACode
+// @Description Code proving the user has the authenticator app, or one of the recovery codes where allowed
+type MFACode struct {
+	Code string `json:"code" validate:"required" example:"123456"` // Code of the authenticator app
+}
+
+// @Name MFAEnrollment
+// @Description Secret to add to the authenticator app. The enrollment must be confirmed with a code before it takes effect
+type MFAEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`                                    // Base32 secret, for apps that can not scan the QR code
+	URI    string `json:"uri" example:"otpauth://totp/Gommence:john.doe%40example.com?secret=JBSWY3DPEHPK3PXP"` // Payload of the QR code to scan with the authenticator app
+}
+
+// @Name MFARecoveryCodes
+// @Description Single use codes to sign in when the authenticator app is not available. They are shown only once
+type MFARecoveryCodes struct {
+	Codes []string `json:"codes" example:"k3n4p-7qz2m"`
 }
@@ -0,0 +1,46 @@
+package dtos
+
+// Number of items returned when the query does not say, and the most that can be asked for
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListOp says how a ListFilter compares the field with the value
+type ListOp string
+
+const (
+	ListOpEq       ListOp = "eq"
+	ListOpPrefix   ListOp = "prefix"   // case insensitive
+	ListOpContains ListOp = "contains" // case insensitive
+	ListOpGte      ListOp = "gte"
+	ListOpLte      ListOp = "lte"
+)
+
+type ListSort struct {
+	Field string
+	Desc  bool
+}
+
+type ListFilter struct {
+	Field string
+	Op    ListOp
+	Value string
+}
+
+// ListQuery selects a page of a list. Pages are walked either with the cursor returned by the previous page,
+// the default, or by number. Cursors are cheaper for the database and do not skip or repeat items when the list changes
+type ListQuery struct {
+	Limit     int          `validate:"omitempty,min=1,max=100"` // Maximum number of items, DefaultListLimit if zero
+	Page      int          `validate:"omitempty,min=1"`         // Number of the page, starting at 1. Not compatible with Cursor
+	Cursor    string       `validate:"omitempty,max=1024"`      // NextCursor of the previous page
+	Sort      []ListSort   `validate:"max=4"`                   // The id of the items always breaks ties
+	Filters   []ListFilter `validate:"max=16"`                  // All of them must match
+	WithTotal bool         // Count the items matching the filters
+}
+
+// ListResult tells how to go on after a page
+type ListResult struct {
+	NextCursor string // Empty on the last page, and when paging by number
+	Total      *int64 // Only when asked for with WithTotal
+}
@@ -0,0 +1,62 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// @Name Organization
+// @Description Tenant whose data is kept apart from the others
+type Organization struct {
+	ID        string    `json:"id" example:"23GfxRTs"`
+	Name      string    `json:"name" example:"Ankh-Morpork City Watch"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// @Name OrganizationCreate
+// @Description Data to create an organization
+type OrganizationCreate struct {
+	Name string `json:"name" validate:"required,max=128" example:"Ankh-Morpork City Watch"`
+}
+
+// @Name Member
+// @Description User belonging to an organization
+type Member struct {
+	UserID    string    `json:"user_id" example:"23GfxRTs"`
+	Role      string    `json:"role" example:"member"` // owner, admin or member
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// @Name MemberAdd
+// @Description User to add to the current organization, or whose role to change
+type MemberAdd struct {
+	UserID string `json:"user_id" validate:"required" example:"23GfxRTs"`
+	Role   string `json:"role" validate:"required,oneof=admin member" example:"member"`
+}
+
+// @Name TenantSwitch
+// @Description Organization to act on behalf of
+type TenantSwitch struct {
+	Tenant string `json:"tenant" validate:"required" example:"23GfxRTs"` // Id of the organization
+}
+
+func FromDomainOrganizations(organizations []*domain.Organization) []*Organization {
+	result := make([]*Organization, len(organizations))
+	for i, organization := range organizations {
+		result[i] = FromDomainOrganization(organization)
+	}
+	return result
+}
+
+func FromDomainOrganization(organization *domain.Organization) *Organization {
+	return &Organization{ID: organization.ID, Name: organization.Name, CreatedAt: organization.CreatedAt}
+}
+
+func FromDomainMembers(memberships []*domain.Membership) []*Member {
+	result := make([]*Member, len(memberships))
+	for i, membership := range memberships {
+		result[i] = &Member{UserID: membership.UserID, Role: string(membership.Role), CreatedAt: membership.CreatedAt}
+	}
+	return result
+}
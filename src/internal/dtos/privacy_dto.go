@@ -0,0 +1,51 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// @Name UserDataExport
+// @Description Everything stored about a user, one section per module of the application
+type UserDataExport struct {
+	UserID      string                 `json:"user_id" example:"23GfxRTs"`
+	GeneratedAt time.Time              `json:"generated_at"`
+	Sections    map[string]interface{} `json:"sections"` // Data of each module, e.g. user, api_keys or audit
+}
+
+// @Name UserProfileData
+// @Description Section of the user module in the exports
+type UserProfileData struct {
+	Profile    *User               `json:"profile"`
+	AuthMethod string              `json:"auth_method" example:"password"` // password or oidc
+	Identities []*ExternalIdentity `json:"identities,omitempty"`           // Accounts of external providers linked to the user
+}
+
+// @Name ExternalIdentity
+// @Description Account of an external (OIDC) provider linked to a user
+type ExternalIdentity struct {
+	Issuer  string `json:"issuer" example:"https://accounts.google.com"`
+	Subject string `json:"subject" example:"110169484474386276334"` // Id of the user in the provider
+	Email   string `json:"email,omitempty" example:"john.doe@example.com"`
+}
+
+// @Name PermissionsData
+// @Description Section of the permissions module in the exports
+type PermissionsData struct {
+	Roles  []*Role  `json:"roles"`
+	Groups []*Group `json:"groups"`
+	Grants []*Grant `json:"grants"` // Permissions on single resources, also those given to the groups of the user
+}
+
+func FromDomainUserProfile(user *domain.User, identities []*domain.ExternalIdentity) *UserProfileData {
+	authMethod := "password"
+	if user.AuthMethod == domain.AuthMethGoogle {
+		authMethod = "oidc"
+	}
+	profile := &UserProfileData{Profile: FromDomainUser(user), AuthMethod: authMethod, Identities: make([]*ExternalIdentity, len(identities))}
+	for i, identity := range identities {
+		profile.Identities[i] = &ExternalIdentity{Issuer: identity.Issuer, Subject: identity.Subject, Email: identity.Email}
+	}
+	return profile
+}
@@ -0,0 +1,115 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// @Name Role
+// @Description Named set of permissions assigned to users
+type Role struct {
+	ID          string  `json:"id" example:"23GfxRTs"`
+	Name        string  `json:"name" example:"editor"`
+	Permissions []int16 `json:"permissions" example:"1,100"` // 1 read, 100 write, 200 delete, 300 admin
+}
+
+// @Name RoleCreate
+// @Description Data to create a custom role
+type RoleCreate struct {
+	Name        string  `json:"name" validate:"required,min=2,max=64" example:"auditor"`
+	Permissions []int16 `json:"permissions" validate:"required,min=1" example:"1"` // 1 read, 100 write, 200 delete, 300 admin
+}
+
+// @Name RoleGrant
+// @Description Role to give to a user
+type RoleGrant struct {
+	Role string `json:"role" validate:"required" example:"editor"` // Name of the role
+}
+
+func FromDomainRole(role *domain.Role) *Role {
+	permissions := make([]int16, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = int16(permission)
+	}
+	return &Role{ID: role.ID, Name: role.Name, Permissions: permissions}
+}
+
+func FromDomainRoles(roles []*domain.Role) []*Role {
+	result := make([]*Role, len(roles))
+	for i, role := range roles {
+		result[i] = FromDomainRole(role)
+	}
+	return result
+}
+
+// @Name Group
+// @Description Group of users that receive grants together
+type Group struct {
+	ID   string `json:"id" example:"23GfxRTs"`
+	Name string `json:"name" example:"night-watch"`
+}
+
+// @Name GroupCreate
+// @Description Data to create a group
+type GroupCreate struct {
+	Name string `json:"name" validate:"required,min=2,max=64" example:"night-watch"`
+}
+
+// @Name GroupMemberAdd
+// @Description User to add to a group
+type GroupMemberAdd struct {
+	UserID string `json:"user_id" validate:"required" example:"23GfxRTs"`
+}
+
+// @Name Grant
+// @Description Permission of a user, or of the members of a group, on a single resource
+type Grant struct {
+	ID           string    `json:"id" example:"23GfxRTs"`
+	SubjectType  string    `json:"subject_type" example:"user"` // user or group
+	SubjectID    string    `json:"subject_id" example:"23GfxRTs"`
+	ResourceType string    `json:"resource_type" example:"document"`
+	ResourceID   string    `json:"resource_id" example:"42"`
+	Permission   int16     `json:"permission" example:"100"` // 1 read, 100 write, 200 delete, 300 admin
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// @Name GrantCreate
+// @Description Permission to give on a resource
+type GrantCreate struct {
+	SubjectType string `json:"subject_type" validate:"required,oneof=user group" example:"user"`
+	Subject     string `json:"subject" validate:"required" example:"23GfxRTs"` // Id of the user or name of the group
+	Permission  int16  `json:"permission" validate:"required,oneof=1 100 200 300" example:"100"`
+}
+
+func FromDomainGroup(group *domain.Group) *Group {
+	return &Group{ID: group.ID, Name: group.Name}
+}
+
+func FromDomainGroups(groups []*domain.Group) []*Group {
+	result := make([]*Group, len(groups))
+	for i, group := range groups {
+		result[i] = FromDomainGroup(group)
+	}
+	return result
+}
+
+func FromDomainGrant(grant *domain.Grant) *Grant {
+	return &Grant{
+		ID:           grant.ID,
+		SubjectType:  string(grant.SubjectType),
+		SubjectID:    grant.SubjectID,
+		ResourceType: grant.ResourceType,
+		ResourceID:   grant.ResourceID,
+		Permission:   int16(grant.Permission),
+		CreatedAt:    grant.CreatedAt,
+	}
+}
+
+func FromDomainGrants(grants []*domain.Grant) []*Grant {
+	result := make([]*Grant, len(grants))
+	for i, grant := range grants {
+		result[i] = FromDomainGrant(grant)
+	}
+	return result
+}
@@ -1,6 +1,10 @@
 package dtos
 
-import "github.com/Manolo-Esc/gommence/src/internal/domain"
+import (
+	"strings"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
 
 // @Name User
 // @Description User data
@@ -10,6 +14,8 @@ type User struct {
 	FirstLastName  string `json:"last_name" example:"Doe"`              // First last name of the new user
 	SecondLastName string `json:"second_last_name" example:"Smith"`     // Second last name of the new user
 	Email          string `json:"email" example:"john.doe@example.com"` // Email of the new user
+	EmailVerified  bool   `json:"email_verified" example:"true"`        // Whether the user has proved the email is theirs
+	MFAEnabled     bool   `json:"mfa_enabled" example:"false"`          // Whether the user signs in with a second factor
 }
 
 func FromDomainUser(user *domain.User) *User {
@@ -19,6 +25,8 @@ func FromDomainUser(user *domain.User) *User {
 		FirstLastName:  user.FirstLastName,
 		SecondLastName: user.SecondLastName,
 		Email:          user.Email,
+		EmailVerified:  user.EmailVerifiedAt != nil,
+		MFAEnabled:     user.MFAEnabledAt != nil,
 	}
 }
 
@@ -30,6 +38,41 @@ func FromDomainUsers(users []*domain.User) []*User {
 	return result
 }
 
+// @Name UserCreate
+// @Description Data of a user created by an administrator, who signs in with the password
+type UserCreate struct {
+	FirstName      string `json:"first_name" validate:"required" example:"John"`                  // First name of the new user
+	FirstLastName  string `json:"last_name" validate:"required" example:"Doe"`                    // First last name of the new user
+	SecondLastName string `json:"second_last_name" example:"Smith"`                               // Second last name of the new user
+	Email          string `json:"email" validate:"required,email" example:"john.doe@example.com"` // Email of the new user
+	Secret         string `json:"secret" validate:"required" example:"password"`                  // Password of the new user
+}
+
+// @Name UserUpdate
+// @Description Changes to the profile of a user. Missing fields are left as they are
+type UserUpdate struct {
+	FirstName      *string `json:"first_name,omitempty" validate:"omitnil,min=1" example:"John"`
+	FirstLastName  *string `json:"last_name,omitempty" validate:"omitnil,min=1" example:"Doe"`
+	SecondLastName *string `json:"second_last_name,omitempty" example:"Smith"` // Empty to remove it
+}
+
+// IsEmpty tells whether the update changes nothing
+func (u *UserUpdate) IsEmpty() bool {
+	return u.FirstName == nil && u.FirstLastName == nil && u.SecondLastName == nil
+}
+
+// FromDtosUserCreate builds the creation data of a user created by an administrator. The secret must have been hashed by the caller
+func FromDtosUserCreate(creationData *UserCreate, hashedPassword string) *InternalUserCreate {
+	return &InternalUserCreate{
+		FirstName:      creationData.FirstName,
+		FirstLastName:  creationData.FirstLastName,
+		SecondLastName: creationData.SecondLastName,
+		AuthMethod:     domain.AuthMethPassword,
+		Email:          creationData.Email,
+		HashedPassword: hashedPassword,
+	}
+}
+
 // Intended for internal use only. External request should go through an authentication endpoint
 type InternalUserCreate struct {
 	FirstName      string            `json:"first_name" validate:"required" example:"John"`                  // First name of the new user
@@ -40,13 +83,50 @@ type InternalUserCreate struct {
 	HashedPassword string            `json:"hashed_password" example:"123GfxRTs"` // Hashed user password in case of AuthMethod is AuthMethPassword
 }
 
-func fromDtosUserSignUp(creationData *UserSignUp) *InternalUserCreate {
+// FromDtosUserSignUp builds the creation data of a self signed up user. The secret must have been hashed by the caller
+func FromDtosUserSignUp(creationData *UserSignUp, hashedPassword string) *InternalUserCreate {
 	return &InternalUserCreate{
 		FirstName:      creationData.FirstName,
 		FirstLastName:  creationData.FirstLastName,
 		SecondLastName: creationData.SecondLastName,
-		AuthMethod:     creationData.AuthMethod,
+		AuthMethod:     domain.AuthMethPassword,
 		Email:          creationData.Email,
-		HashedPassword: creationData.Secret,
+		HashedPassword: hashedPassword,
+	}
+}
+
+// FromExternalIdentity builds the creation data of a user that signs in with an external provider.
+// Not every provider splits the name, so it is taken from the full name or the email when needed
+func FromExternalIdentity(identity *domain.ExternalIdentity) *InternalUserCreate {
+	firstName, lastName := identity.GivenName, identity.FamilyName
+	if firstName == "" || lastName == "" {
+		parts := strings.Fields(identity.Name)
+		if len(parts) > 1 {
+			firstName, lastName = parts[0], strings.Join(parts[1:], " ")
+		}
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if lastName == "" {
+		lastName = "-"
 	}
+	return &InternalUserCreate{
+		FirstName:     firstName,
+		FirstLastName: lastName,
+		AuthMethod:    domain.AuthMethGoogle,
+		Email:         identity.Email,
+	}
+}
+
+// @Name UserList
+// @Description Page of users
+type UserList struct {
+	Items      []*User `json:"items"`
+	NextCursor string  `json:"next_cursor,omitempty" example:"WyIyMDI1LTAxLTAxVDAwOjAwOjAwWiIsIjIzR2Z4UlRzIl0"` // Pass it as cursor to get the next page
+	Total      *int64  `json:"total,omitempty" example:"42"`                                                    // Number of users matching the filters, when asked for
+}
+
+func FromDomainUserList(users []*domain.User, result *ListResult) *UserList {
+	return &UserList{Items: FromDomainUsers(users), NextCursor: result.NextCursor, Total: result.Total}
 }
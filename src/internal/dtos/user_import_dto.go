@@ -0,0 +1,57 @@
+package dtos
+
+// User read from an import file, a CSV line or a JSON line. The columns of the CSV files are the json names
+type UserImportRow struct {
+	Line           int    `json:"-"` // Line of the file, starting at 1
+	Email          string `json:"email" validate:"required,email"`
+	FirstName      string `json:"first_name" validate:"required"`
+	FirstLastName  string `json:"last_name" validate:"required"`
+	SecondLastName string `json:"second_last_name"`
+	Password       string `json:"password"` // Optional. The new users without it can not sign in until they reset the password
+	Problem        string `json:"-"`        // Why the line could not be read, if it could not
+}
+
+type UserImportAction string
+
+const (
+	UserImportCreated   UserImportAction = "created"
+	UserImportUpdated   UserImportAction = "updated"
+	UserImportUnchanged UserImportAction = "unchanged"
+	UserImportFailed    UserImportAction = "failed"
+)
+
+// @Name UserImportResult
+// @Description What was done with a line of the import file
+type UserImportResult struct {
+	Line   int              `json:"line" example:"2"`
+	Email  string           `json:"email,omitempty" example:"john.doe@example.com"`
+	Action UserImportAction `json:"action" example:"created"` // created, updated, unchanged or failed
+	ID     string           `json:"id,omitempty" example:"23GfxRTs"`
+	Error  string           `json:"error,omitempty" example:"Invalid email"`
+}
+
+// @Name UserImportReport
+// @Description Result of an import, line by line. In a dry run nothing is stored, the report tells what would be done
+type UserImportReport struct {
+	DryRun    bool                `json:"dry_run" example:"false"`
+	Created   int                 `json:"created" example:"40"`
+	Updated   int                 `json:"updated" example:"2"`
+	Unchanged int                 `json:"unchanged" example:"0"`
+	Failed    int                 `json:"failed" example:"1"`
+	Rows      []*UserImportResult `json:"rows"`
+}
+
+// Add counts the result and appends it to the report
+func (r *UserImportReport) Add(result *UserImportResult) {
+	switch result.Action {
+	case UserImportCreated:
+		r.Created++
+	case UserImportUpdated:
+		r.Updated++
+	case UserImportUnchanged:
+		r.Unchanged++
+	case UserImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
@@ -0,0 +1,286 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	repos "github.com/Manolo-Esc/gommence/src/internal/adapters/repos_db"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// Each migration declares the tables it creates or changes as they were in its version, never with the models of
+// repos_db: those follow the current code, and would make an old migration create what a later one is meant to add.
+// Tables are created whole; on tables that already exist only the new columns are declared
+
+// MigrationBaseModel is repos_db.BaseDBModel as it was when the migrations were written. Exported, gorm ignores the
+// embedded structs that are not
+type MigrationBaseModel struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func migrationV1_1_0() Migration {
+	type RefreshToken struct {
+		MigrationBaseModel
+		UserID    string `gorm:"index"`
+		FamilyID  string `gorm:"index"`
+		TokenHash string `gorm:"uniqueIndex"`
+		ExpiresAt time.Time
+		UsedAt    sql.NullTime
+		RevokedAt sql.NullTime
+	}
+	type RevokedAccessToken struct {
+		ID        string `gorm:"primaryKey"`
+		UserID    string
+		ExpiresAt time.Time `gorm:"index"`
+		CreatedAt time.Time
+	}
+	return Migration{
+		Version:     "1.1.0",
+		Description: "Refresh tokens and revoked access tokens",
+		Up:          autoMigrate(&RefreshToken{}, &RevokedAccessToken{}),
+		Down:        dropTables(&RefreshToken{}, &RevokedAccessToken{}),
+	}
+}
+
+func migrationV1_2_0() Migration {
+	type User struct {
+		EmailVerifiedAt sql.NullTime
+	}
+	type ActionToken struct {
+		MigrationBaseModel
+		UserID    string `gorm:"index"`
+		Purpose   string
+		TokenHash string `gorm:"uniqueIndex"`
+		ExpiresAt time.Time
+		UsedAt    sql.NullTime
+	}
+	return Migration{
+		Version:     "1.2.0",
+		Description: "Email verification date and single use tokens sent by email",
+		Up:          autoMigrate(&User{}, &ActionToken{}),
+		Down: func(ctx context.Context, tx *gorm.DB) error {
+			if err := dropTables(&ActionToken{})(ctx, tx); err != nil {
+				return err
+			}
+			return dropColumns(&User{}, "EmailVerifiedAt")(ctx, tx)
+		},
+	}
+}
+
+func migrationV1_3_0() Migration {
+	type UserIdentity struct {
+		MigrationBaseModel
+		UserID  string `gorm:"index"`
+		Issuer  string `gorm:"uniqueIndex:idx_user_identity"`
+		Subject string `gorm:"uniqueIndex:idx_user_identity"`
+		Email   string
+	}
+	return Migration{
+		Version:     "1.3.0",
+		Description: "Identities of external (OIDC) providers",
+		Up:          autoMigrate(&UserIdentity{}),
+		Down:        dropTables(&UserIdentity{}),
+	}
+}
+
+func migrationV1_4_0() Migration {
+	type User struct {
+		MFASecret     sql.NullString
+		MFAEnabledAt  sql.NullTime
+		RecoveryCodes sql.NullString
+	}
+	return Migration{
+		Version:     "1.4.0",
+		Description: "Two-factor authentication (TOTP secret and recovery codes)",
+		Up:          autoMigrate(&User{}),
+		Down:        dropColumns(&User{}, "MFASecret", "MFAEnabledAt", "RecoveryCodes"),
+	}
+}
+
+func migrationV1_5_0() Migration {
+	type LoginAttempt struct {
+		ID            string `gorm:"primaryKey"`
+		Failures      int
+		LastFailureAt time.Time
+		LockedUntil   sql.NullTime
+		UpdatedAt     time.Time
+	}
+	type AuditEvent struct {
+		MigrationBaseModel
+		Type    string `gorm:"index"`
+		ActorID string `gorm:"index"`
+		Target  string `gorm:"index"`
+		IP      string
+		Details string
+	}
+	return Migration{
+		Version:     "1.5.0",
+		Description: "Failed sign ins and audit log",
+		Up:          autoMigrate(&LoginAttempt{}, &AuditEvent{}),
+		Down:        dropTables(&LoginAttempt{}, &AuditEvent{}),
+	}
+}
+
+func migrationV1_6_0() Migration {
+	type APIKey struct {
+		MigrationBaseModel
+		UserID     string `gorm:"index"`
+		Name       string
+		Prefix     string
+		KeyHash    string `gorm:"uniqueIndex"`
+		Scopes     string
+		ExpiresAt  time.Time
+		LastUsedAt sql.NullTime
+		RevokedAt  sql.NullTime
+	}
+	return Migration{
+		Version:     "1.6.0",
+		Description: "API keys of machine clients",
+		Up:          autoMigrate(&APIKey{}),
+		Down:        dropTables(&APIKey{}),
+	}
+}
+
+func migrationV1_7_0() Migration {
+	type Role struct {
+		MigrationBaseModel
+		Name        string `gorm:"uniqueIndex"`
+		Permissions string
+	}
+	type UserRole struct {
+		UserID    string `gorm:"primaryKey"`
+		RoleID    string `gorm:"primaryKey;index"`
+		CreatedAt time.Time
+	}
+	return Migration{
+		Version:     "1.7.0",
+		Description: "Roles and the roles of each user",
+		Up: func(ctx context.Context, tx *gorm.DB) error {
+			if err := autoMigrate(&Role{}, &UserRole{})(ctx, tx); err != nil {
+				return err
+			}
+			_, err := createRoles(ctx, repos.NewPermissionRepository(&repos.DBReposInfra{Db: tx, Logger: logger.GetLogger()}))
+			return err
+		},
+		Down: dropTables(&UserRole{}, &Role{}),
+	}
+}
+
+func migrationV1_8_0() Migration {
+	type Organization struct {
+		MigrationBaseModel
+		Name string
+	}
+	type Membership struct {
+		TenantID  string `gorm:"primaryKey"`
+		UserID    string `gorm:"primaryKey;index"`
+		Role      string
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+	type RefreshToken struct {
+		TenantID string
+	}
+	return Migration{
+		Version:     "1.8.0",
+		Description: "Organizations, their members and the tenant of each session",
+		Up:          autoMigrate(&Organization{}, &Membership{}, &RefreshToken{}),
+		Down: func(ctx context.Context, tx *gorm.DB) error {
+			if err := dropTables(&Membership{}, &Organization{})(ctx, tx); err != nil {
+				return err
+			}
+			return dropColumns(&RefreshToken{}, "TenantID")(ctx, tx)
+		},
+	}
+}
+
+func migrationV1_9_0() Migration {
+	type Group struct {
+		MigrationBaseModel
+		Name string `gorm:"uniqueIndex"`
+	}
+	type GroupMember struct {
+		GroupID   string `gorm:"primaryKey"`
+		UserID    string `gorm:"primaryKey;index"`
+		CreatedAt time.Time
+	}
+	type Grant struct {
+		MigrationBaseModel
+		SubjectType  string `gorm:"uniqueIndex:idx_grant"`
+		SubjectID    string `gorm:"uniqueIndex:idx_grant;index"`
+		ResourceType string `gorm:"uniqueIndex:idx_grant;index:idx_grant_resource"`
+		ResourceID   string `gorm:"uniqueIndex:idx_grant;index:idx_grant_resource"`
+		Permission   int16  `gorm:"uniqueIndex:idx_grant"`
+	}
+	return Migration{
+		Version:     "1.9.0",
+		Description: "Groups and permissions on single resources",
+		Up:          autoMigrate(&Group{}, &GroupMember{}, &Grant{}),
+		Down:        dropTables(&GroupMember{}, &Grant{}, &Group{}),
+	}
+}
+
+func migrationV1_10_0() Migration {
+	type ActionToken struct {
+		NewEmail string
+	}
+	return Migration{
+		Version:     "1.10.0",
+		Description: "New address of the pending email changes",
+		Up:          autoMigrate(&ActionToken{}),
+		Down:        dropColumns(&ActionToken{}, "NewEmail"),
+	}
+}
+
+func migrationV1_13_0() Migration {
+	type MFAChallenge struct {
+		ID        string `gorm:"primaryKey"`
+		UserID    string `gorm:"index"`
+		Attempts  int
+		ExpiresAt time.Time `gorm:"index"`
+		CreatedAt time.Time
+	}
+	type UsedTOTPCode struct {
+		UserID    string    `gorm:"primaryKey"`
+		Step      int64     `gorm:"primaryKey;autoIncrement:false"`
+		ExpiresAt time.Time `gorm:"index"`
+	}
+	return Migration{
+		Version:     "1.13.0",
+		Description: "Challenges and used codes of the two-factor sign ins",
+		Up:          autoMigrate(&MFAChallenge{}, &UsedTOTPCode{}),
+		Down:        dropTables(&MFAChallenge{}, &UsedTOTPCode{}),
+	}
+}
+
+func migrationV1_14_0() Migration {
+	type OidcFlow struct { // not OIDCFlow, gorm would name the table o_id_c_flows
+		ID           string `gorm:"primaryKey"`
+		Nonce        string
+		CodeVerifier string
+		ExpiresAt    time.Time `gorm:"index"`
+		CreatedAt    time.Time
+	}
+	return Migration{
+		Version:     "1.14.0",
+		Description: "Sign ins with the OIDC provider in progress",
+		Up:          autoMigrate(&OidcFlow{}),
+		Down:        dropTables(&OidcFlow{}),
+	}
+}
+
+func migrationV1_15_0() Migration {
+	return Migration{
+		Version:     "1.15.0",
+		Description: "Remove the development roles given to the system users by 1.7.0",
+		Up:          removeSystemRoles,
+		Down: func(ctx context.Context, tx *gorm.DB) error {
+			return nil // the roles are not given back, seed -dev does it where wanted
+		},
+	}
+}
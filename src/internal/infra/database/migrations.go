@@ -3,16 +3,20 @@ package database
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/adapters/repos_db"
 	repos "github.com/Manolo-Esc/gommence/src/internal/adapters/repos_db"
 	"github.com/Manolo-Esc/gommence/src/internal/app"
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
 	"gorm.io/gorm"
 )
 
+// VersionDBEntity is the single row where older versions of the application kept the version of the database.
+// Migrate moves it to the schema_migrations table
 type VersionDBEntity struct {
 	Major int `gorm:"column:major"`
 	Minor int `gorm:"column:minor"`
@@ -28,76 +32,185 @@ var systemUsersCreate = []dtos.InternalUserCreate{
 	{FirstName: "Sam", FirstLastName: "Vimes", Email: "theduke@ankh.dw", AuthMethod: domain.AuthMethPassword},
 	{FirstName: "Guest", FirstLastName: "User", Email: "user@mail.com", AuthMethod: domain.AuthMethPassword},
 }
-var systemUsers []domain.User // The above systemUsersCreate information plus the ID
 
-func Migrate(ctx context.Context, db *gorm.DB) error {
-	if !db.Migrator().HasTable(&VersionDBEntity{}) {
-		fmt.Println("version_db table not found. Initializing database")
-		err := createDatabase(ctx, db)
-		if err != nil {
-			return err
-		}
-	} else {
-		var version VersionDBEntity
-		result := db.Limit(1).Find(&version)
-		if result.Error != nil {
-			return result.Error
-		}
-		runMigrations(ctx, db, version)
-	}
-	return nil
+// Role of each of the systemUsersCreate in development. They all have the same known password, so the roles are only
+// given on request, see SeedDevelopmentRoles
+var systemUserRoles = map[string]string{
+	"granny@lancre.dw": domain.RoleAdmin,
+	"theduke@ankh.dw":  domain.RoleEditor,
+	"user@mail.com":    domain.RoleUser,
+}
+
+// Version of the schema created from scratch by createDatabase. The migrations up to it are recorded as applied.
+// Those after it run on new databases too, so they must work on a database created from the current models
+const baselineVersion = "1.10.0"
+
+// goMigrations are the changes of the schema written in Go, see go_migrations.go. Add the new ones at the end, the SQL
+// ones are in the folder sql
+var goMigrations = []Migration{
+	migrationV1_1_0(),
+	migrationV1_2_0(),
+	migrationV1_3_0(),
+	migrationV1_4_0(),
+	migrationV1_5_0(),
+	migrationV1_6_0(),
+	migrationV1_7_0(),
+	migrationV1_8_0(),
+	migrationV1_9_0(),
+	migrationV1_10_0(),
+	migrationV1_13_0(),
+	migrationV1_14_0(),
+	migrationV1_15_0(),
 }
 
 func createDatabase(ctx context.Context, db *gorm.DB) error {
 	models := []interface{}{
-		&VersionDBEntity{},
 		&repos.User{},
+		&repos.RefreshToken{},
+		&repos.RevokedAccessToken{},
+		&repos.ActionToken{},
+		&repos.UserIdentity{},
+		&repos.LoginAttempt{},
+		&repos.AuditEvent{},
+		&repos.APIKey{},
+		&repos.Role{},
+		&repos.UserRole{},
+		&repos.Organization{},
+		&repos.Membership{},
+		&repos.Group{},
+		&repos.GroupMember{},
+		&repos.Grant{},
+		&repos.MFAChallenge{},
+		&repos.UsedTOTPCode{},
+		&repos.OIDCFlow{},
 	}
-	err := db.WithContext(ctx).AutoMigrate(models...) // Create tables
-	if err != nil {
+	if err := db.WithContext(ctx).AutoMigrate(models...); err != nil { // Create tables
 		return err
 	}
-	err = populateDatabase(ctx, db)
-	if err == nil {
-		err = populateDevelopmentDatabase(ctx, db, systemUsers) // Populate with development data
-	}
-	return err
+	return Seed(ctx, db)
 }
 
-func runMigrations(ctx context.Context, db *gorm.DB, version VersionDBEntity) {
-	fmt.Printf("Current database version: %d.%d.%d\n", version.Major, version.Minor, version.Patch)
+func autoMigrate(models ...interface{}) func(ctx context.Context, tx *gorm.DB) error {
+	return func(ctx context.Context, tx *gorm.DB) error {
+		return tx.WithContext(ctx).AutoMigrate(models...)
+	}
+}
 
-	if version.Major == 1 && version.Minor == 0 && version.Patch == 0 {
-		/*
-			fmt.Println("Migrating database to version 1.1.0...")
-			// Add here the code to migrate from 1.0.0 to 1.1.0
-		*/
+func dropTables(models ...interface{}) func(ctx context.Context, tx *gorm.DB) error {
+	return func(ctx context.Context, tx *gorm.DB) error {
+		return tx.WithContext(ctx).Migrator().DropTable(models...)
 	}
 }
 
-func populateDatabase(ctx context.Context, db *gorm.DB) error {
-	if err := db.WithContext(ctx).Create(&VersionDBEntity{Major: 1, Minor: 0, Patch: 0}); err.Error != nil {
-		return err.Error
+// dropColumns removes the columns, given by the name of their fields, that the table still has
+func dropColumns(model interface{}, fields ...string) func(ctx context.Context, tx *gorm.DB) error {
+	return func(ctx context.Context, tx *gorm.DB) error {
+		migrator := tx.WithContext(ctx).Migrator()
+		for _, field := range fields {
+			if !migrator.HasColumn(model, field) {
+				continue
+			}
+			if err := migrator.DropColumn(model, field); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-	if err := createUsers(ctx, db); err != nil {
+}
+
+// Seed stores the data every database starts with: the default roles, the system users and the development data. What
+// already exists is kept, so it can be run again to restore what is missing
+func Seed(ctx context.Context, db *gorm.DB) error {
+	dbInfra := &repos_db.DBReposInfra{Db: db, Logger: logger.GetLogger()}
+	users, err := SeedRepositories(ctx, repos.NewUserRepository(dbInfra), repos.NewPermissionRepository(dbInfra))
+	if err != nil {
 		return err
 	}
 	// create more entities here
-	return nil
+	return populateDevelopmentDatabase(ctx, db, users) // Populate with development data
 }
 
-func createUsers(ctx context.Context, db *gorm.DB) error {
-	var repo = repos.NewUserRepository(&repos_db.DBReposInfra{Db: db, Logger: logger.GetLogger()})
+// SeedRepositories stores the default roles and the system users through the repositories, so it works on any storage,
+// and returns the system users. Seed calls it for the database; it alone prepares the repositories kept in memory
+func SeedRepositories(ctx context.Context, userRepo ports.UserRepository, permissionRepo ports.PermissionRepository) ([]domain.User, error) {
+	if _, err := createRoles(ctx, permissionRepo); err != nil {
+		return nil, err
+	}
+	return createUsers(ctx, userRepo)
+}
 
+// createUsers stores the system users missing and returns all of them. They get no role, see SeedDevelopmentRoles
+func createUsers(ctx context.Context, repo ports.UserRepository) ([]domain.User, error) {
+	var users []domain.User
 	for _, user := range systemUsersCreate {
+		if userId := repo.GetUserIdByEmail(ctx, user.Email); userId != "" {
+			users = append(users, domain.User{ID: userId, Email: user.Email})
+			continue
+		}
 		fmt.Printf("Creating user %s\n", user.Email)
 		user.HashedPassword, _ = app.HashPassword("password")
-		if userId, err := repo.Create(ctx, &user); err != nil {
+		userId, err := repo.Create(ctx, &user)
+		if err != nil {
 			fmt.Printf("Error creating user %s: %s\n", user.Email, err.Error())
+			return nil, err
+		}
+		if err := repo.SetEmailVerified(ctx, userId, time.Now()); err != nil {
+			return nil, err
+		}
+		users = append(users, domain.User{ID: userId, Email: user.Email})
+	}
+	return users, nil
+}
+
+// createRoles stores the default roles missing and returns the ids of all of them by name
+func createRoles(ctx context.Context, permissionRepo ports.PermissionRepository) (map[string]string, error) {
+	roleIds := map[string]string{}
+	for _, role := range domain.DefaultRoles() {
+		if existing, err := permissionRepo.GetRoleByName(ctx, role.Name); err == nil {
+			roleIds[role.Name] = existing.ID
+			continue
+		}
+		fmt.Printf("Creating role %s\n", role.Name)
+		roleId, err := permissionRepo.CreateRole(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		roleIds[role.Name] = roleId
+	}
+	return roleIds, nil
+}
+
+// SeedDevelopmentRoles gives the system users that exist their development role: granny@lancre.dw is an admin,
+// theduke@ankh.dw an editor and user@mail.com a plain user. Never on a database reachable by others, their password is known
+func SeedDevelopmentRoles(ctx context.Context, userRepo ports.UserRepository, permissionRepo ports.PermissionRepository) error {
+	roleIds, err := createRoles(ctx, permissionRepo)
+	if err != nil {
+		return err
+	}
+	for email, roleName := range systemUserRoles {
+		if userId := userRepo.GetUserIdByEmail(ctx, email); userId != "" {
+			if err := permissionRepo.AddUserRole(ctx, userId, roleIds[roleName]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeSystemRoles takes from the system users the roles that 1.7.0 gave them to every database
+func removeSystemRoles(ctx context.Context, tx *gorm.DB) error {
+	dbInfra := &repos_db.DBReposInfra{Db: tx, Logger: logger.GetLogger()}
+	permissionRepo := repos.NewPermissionRepository(dbInfra)
+	userRepo := repos.NewUserRepository(dbInfra)
+
+	for email, roleName := range systemUserRoles {
+		userId := userRepo.GetUserIdByEmail(ctx, email)
+		role, err := permissionRepo.GetRoleByName(ctx, roleName)
+		if userId == "" || err != nil {
+			continue
+		}
+		if err := permissionRepo.RemoveUserRole(ctx, userId, role.ID); err != nil {
 			return err
-		} else {
-			domainUser := domain.User{ID: userId, Email: user.Email}
-			systemUsers = append(systemUsers, domainUser)
 		}
 	}
 	return nil
@@ -0,0 +1,318 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration takes the database from the previous version to this one. Each migration runs in its own transaction
+type Migration struct {
+	Version     string // major.minor.patch. The migrations run in the order of their versions
+	Description string
+	Up          func(ctx context.Context, tx *gorm.DB) error
+	Down        func(ctx context.Context, tx *gorm.DB) error // Nil if it can not be undone
+}
+
+// SchemaMigration records a migration applied to the database
+type SchemaMigration struct {
+	Version     string `gorm:"primaryKey"`
+	Description string
+	AppliedAt   time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationState tells whether a migration has been applied to the database
+type MigrationState struct {
+	Version     string
+	Description string
+	AppliedAt   *time.Time // Nil if pending
+	Reversible  bool
+	Unknown     bool // Applied, but not known by this version of the application
+}
+
+// Key of the advisory lock held while migrating, so the replicas starting at once do not migrate at the same time
+const migrationsLockKey = 7_273_001
+
+// Migrations written in SQL, in files named VERSION_description.up.sql and, if they can be undone, VERSION_description.down.sql
+//
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+// Migrate brings the database up to date: it creates it if empty, or applies the migrations still pending
+func Migrate(ctx context.Context, db *gorm.DB) error {
+	migrations, err := allMigrations()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := prepareMigrationsTable(ctx, db, migrations); err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		if _, found := applied[migration.Version]; found {
+			continue
+		}
+		fmt.Printf("Migrating database to version %s: %s\n", migration.Version, migration.Description)
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(ctx, tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("error migrating to version %s: %w", migration.Version, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown undoes the last steps migrations applied, newest first. It stops at the first that can not be undone
+func MigrateDown(ctx context.Context, db *gorm.DB, steps int) error {
+	migrations, err := allMigrations()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return fmt.Errorf("the database has no migrations applied")
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := migrations[i]
+		if _, found := applied[migration.Version]; !found {
+			continue
+		}
+		if migration.Down == nil {
+			return fmt.Errorf("version %s can not be undone", migration.Version)
+		}
+		fmt.Printf("Undoing version %s of the database: %s\n", migration.Version, migration.Description)
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(ctx, tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{Version: migration.Version}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("error undoing version %s: %w", migration.Version, err)
+		}
+		steps--
+	}
+	return nil
+}
+
+// MigrationStatus lists the migrations known by the application and those found in the database, oldest first
+func MigrationStatus(ctx context.Context, db *gorm.DB) ([]MigrationState, error) {
+	migrations, err := allMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied := map[string]SchemaMigration{}
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		if applied, err = appliedMigrations(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+	states := make([]MigrationState, 0, len(migrations))
+	for _, migration := range migrations {
+		state := MigrationState{Version: migration.Version, Description: migration.Description, Reversible: migration.Down != nil}
+		if record, found := applied[migration.Version]; found {
+			state.AppliedAt = &record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		states = append(states, state)
+	}
+	for _, record := range applied {
+		states = append(states, MigrationState{Version: record.Version, Description: record.Description, AppliedAt: &record.AppliedAt, Unknown: true})
+	}
+	slices.SortStableFunc(states, func(a, b MigrationState) int { return compareVersions(a.Version, b.Version) })
+	return states, nil
+}
+
+// prepareMigrationsTable creates the table of the applied migrations. If the database is empty it is created from the
+// models, at the baseline version. Databases of older versions of the application, with a version_db row, are converted
+func prepareMigrationsTable(ctx context.Context, db *gorm.DB, migrations []Migration) error {
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&SchemaMigration{}); err != nil {
+			return err
+		}
+		version := baselineVersion
+		if tx.Migrator().HasTable(&VersionDBEntity{}) {
+			var old VersionDBEntity
+			if err := tx.Limit(1).Find(&old).Error; err != nil {
+				return err
+			}
+			version = fmt.Sprintf("%d.%d.%d", old.Major, old.Minor, old.Patch)
+			fmt.Printf("Moving the database version %s to the %s table\n", version, SchemaMigration{}.TableName())
+			if err := tx.Migrator().DropTable(&VersionDBEntity{}); err != nil {
+				return err
+			}
+		} else {
+			fmt.Println("Empty database. Initializing database")
+			if err := createDatabase(ctx, tx); err != nil {
+				return err
+			}
+		}
+		for _, migration := range migrations { // already in the database
+			if compareVersions(migration.Version, version) > 0 {
+				break
+			}
+			record := SchemaMigration{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+			if err := tx.Create(&record).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func appliedMigrations(ctx context.Context, db *gorm.DB) (map[string]SchemaMigration, error) {
+	var records []SchemaMigration
+	if err := db.WithContext(ctx).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// lockMigrations waits until no one else is migrating the database. Only Postgres needs it: other databases are not
+// shared by several replicas
+func lockMigrations(ctx context.Context, db *gorm.DB) (func(), error) {
+	if db.Dialector.Name() != "postgres" {
+		return func() {}, nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx) // the lock belongs to the session, it must be released from the same connection
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationsLockKey); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error locking the database to migrate it: %w", err)
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockKey); err != nil {
+			fmt.Printf("Error unlocking the database after migrating it: %s\n", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+// allMigrations joins the migrations written in Go and in SQL, in the order they must run
+func allMigrations() ([]Migration, error) {
+	sqlMigrations, err := loadSQLMigrations(sqlFiles)
+	if err != nil {
+		return nil, err
+	}
+	return sortMigrations(append(slices.Clone(goMigrations), sqlMigrations...))
+}
+
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	for _, migration := range migrations {
+		if _, err := parseVersion(migration.Version); err != nil {
+			return nil, err
+		}
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %s has nothing to do", migration.Version)
+		}
+	}
+	slices.SortStableFunc(migrations, func(a, b Migration) int { return compareVersions(a.Version, b.Version) })
+	for i := 1; i < len(migrations); i++ {
+		if compareVersions(migrations[i-1].Version, migrations[i].Version) == 0 {
+			return nil, fmt.Errorf("there are two migrations to version %s", migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// loadSQLMigrations reads the .sql files of the folder sql. The down file is optional
+func loadSQLMigrations(files fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(files, "sql/*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	for _, name := range names {
+		base := strings.TrimSuffix(path.Base(name), ".up.sql")
+		version, description, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("the name of %s must be VERSION_description.up.sql", name)
+		}
+		up, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		migration := Migration{Version: version, Description: strings.ReplaceAll(description, "_", " "), Up: execSQL(string(up))}
+		if down, err := fs.ReadFile(files, path.Join(path.Dir(name), base+".down.sql")); err == nil {
+			migration.Down = execSQL(string(down))
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+func execSQL(statements string) func(ctx context.Context, tx *gorm.DB) error {
+	return func(ctx context.Context, tx *gorm.DB) error {
+		return tx.Exec(statements).Error
+	}
+}
+
+func parseVersion(version string) ([3]int, error) {
+	var numbers [3]int
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return numbers, fmt.Errorf("invalid version %s, it must be major.minor.patch", version)
+	}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return numbers, fmt.Errorf("invalid version %s, it must be major.minor.patch", version)
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+// compareVersions compares them number by number, so 1.10.0 goes after 1.9.0. Invalid versions go first
+func compareVersions(a string, b string) int {
+	numbersA, _ := parseVersion(a)
+	numbersB, _ := parseVersion(b)
+	return slices.Compare(numbersA[:], numbersB[:])
+}
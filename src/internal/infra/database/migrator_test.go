@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func nothing(ctx context.Context, tx *gorm.DB) error {
+	return nil
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 1, compareVersions("1.10.0", "1.9.0"))
+	assert.Equal(t, -1, compareVersions("1.2.3", "2.0.0"))
+	assert.Equal(t, 0, compareVersions("1.2.3", "1.2.3"))
+	for _, version := range []string{"1.2", "1.2.x", "1.-2.0", ""} {
+		_, err := parseVersion(version)
+		assert.NotNil(t, err, version)
+	}
+}
+
+func TestSortMigrations(t *testing.T) {
+	migrations, err := sortMigrations([]Migration{{Version: "1.10.0", Up: nothing}, {Version: "1.9.0", Up: nothing}, {Version: "0.1.0", Up: nothing}})
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1.0", migrations[0].Version)
+	assert.Equal(t, "1.10.0", migrations[2].Version)
+
+	_, err = sortMigrations([]Migration{{Version: "1.1.0", Up: nothing}, {Version: "1.1.0", Up: nothing}})
+	assert.NotNil(t, err)
+	_, err = sortMigrations([]Migration{{Version: "1.1", Up: nothing}})
+	assert.NotNil(t, err)
+	_, err = sortMigrations([]Migration{{Version: "1.1.0"}})
+	assert.NotNil(t, err)
+}
+
+func TestLoadSQLMigrations(t *testing.T) {
+	files := fstest.MapFS{
+		"sql/2.0.0_add_index.up.sql":   {Data: []byte("CREATE INDEX ...")},
+		"sql/2.0.0_add_index.down.sql": {Data: []byte("DROP INDEX ...")},
+		"sql/2.1.0_fill_column.up.sql": {Data: []byte("UPDATE ...")},
+		"sql/notes.txt":                {Data: []byte("ignored")},
+	}
+	migrations, err := loadSQLMigrations(files)
+	assert.Nil(t, err)
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, "2.0.0", migrations[0].Version)
+	assert.Equal(t, "add index", migrations[0].Description)
+	assert.NotNil(t, migrations[0].Down)
+	assert.Nil(t, migrations[1].Down) // can not be undone
+
+	_, err = loadSQLMigrations(fstest.MapFS{"sql/2.0.0.up.sql": {Data: []byte("...")}})
+	assert.NotNil(t, err) // without description
+}
+
+func TestAllMigrations(t *testing.T) {
+	migrations, err := allMigrations()
+	assert.Nil(t, err)
+	assert.Equal(t, "1.1.0", migrations[0].Version)
+	versions := map[string]bool{}
+	for _, migration := range migrations {
+		versions[migration.Version] = true
+	}
+	assert.True(t, versions[baselineVersion])
+	assert.True(t, versions["1.11.0"]) // the embedded SQL files
+}
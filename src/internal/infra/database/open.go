@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+
+	repos "github.com/Manolo-Esc/gommence/src/internal/adapters/repos_db"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Open connects to a database of the driver. For Postgres the dsn is "host=... user=... dbname=...", for SQLite the
+// path of the file, or ":memory:" for a database that is lost when closed. The data owned by organizations is filtered by
+// the tenant of the context, see repos_db.TenantPlugin
+func Open(driver string, dsn string, config *gorm.Config) (*gorm.DB, error) {
+	var db *gorm.DB
+	var err error
+	switch driver {
+	case DriverPostgres:
+		db, err = gorm.Open(postgres.Open(dsn), config)
+	case DriverSQLite:
+		db, err = openSQLite(dsn, config)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(repos.TenantPlugin{}); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// SQLite is given a single connection: it writes one at a time anyway, and every connection to ":memory:" would open
+// a database of its own
+func openSQLite(path string, config *gorm.Config) (*gorm.DB, error) {
+	sqlDB, err := sql.Open(sqlite.DriverName, path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	db, err := gorm.Open(sqlite.Dialector{Conn: &utcConnPool{db: sqlDB}}, config)
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// utcConnPool passes every time to SQLite in UTC, whoever made it. SQLite keeps the times as text, so times with
+// different offsets would be compared as strings and give wrong results
+type utcConnPool struct {
+	db *sql.DB
+}
+
+func (p *utcConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.db.PrepareContext(ctx, query)
+}
+
+func (p *utcConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.db.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.db.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.db.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	tx, err := p.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &utcTx{Tx: tx}, nil
+}
+
+// GetDBConn lets gorm's DB() return the pool, to set it up or close it
+func (p *utcConnPool) GetDBConn() (*sql.DB, error) {
+	return p.db, nil
+}
+
+// utcTx is the transaction of a utcConnPool. Commit and Rollback are those of the sql.Tx
+type utcTx struct {
+	*sql.Tx
+}
+
+func (t *utcTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (t *utcTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (t *utcTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+// utcArgs returns a copy of the arguments with the times, also those inside sql.NullTime or gorm.DeletedAt, in UTC
+func utcArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		converted[i] = arg
+		switch value := arg.(type) {
+		case time.Time:
+			converted[i] = value.UTC()
+		case *time.Time:
+			if value != nil {
+				converted[i] = value.UTC()
+			}
+		case driver.Valuer:
+			if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+				continue
+			}
+			if inner, err := value.Value(); err == nil {
+				if t, isTime := inner.(time.Time); isTime {
+					converted[i] = t.UTC()
+				}
+			}
+		}
+	}
+	return converted
+}
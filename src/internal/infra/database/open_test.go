@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type timedRow struct {
+	ID        uint
+	CreatedAt time.Time
+	UsedAt    sql.NullTime
+}
+
+func TestSQLiteTimesInUTC(t *testing.T) {
+	db, err := Open(DriverSQLite, ":memory:", &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&timedRow{}))
+
+	east := time.FixedZone("east", 5*3600)
+	west := time.FixedZone("west", -5*3600)
+	now := time.Now().Round(time.Second)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&timedRow{CreatedAt: now.In(west), UsedAt: sql.NullTime{Time: now.Add(-time.Hour).In(east), Valid: true}}).Error
+	})
+	assert.Nil(t, err)
+
+	var rows []timedRow
+	// as strings, with their offsets, the times of the west would come before those of the east
+	assert.Nil(t, db.Where("created_at > ?", now.Add(-time.Minute).In(east)).Find(&rows).Error)
+	assert.Len(t, rows, 1)
+	assert.True(t, now.Equal(rows[0].CreatedAt))
+	assert.Nil(t, db.Where("used_at < ?", now.Add(-30*time.Minute).In(west)).Find(&rows).Error)
+	assert.Len(t, rows, 1)
+
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	assert.Nil(t, sqlDB.PingContext(context.Background()))
+}
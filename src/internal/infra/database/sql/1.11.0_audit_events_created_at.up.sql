@@ -0,0 +1,2 @@
+-- Listing the audit log is sorted by date
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
@@ -0,0 +1,2 @@
+-- Emails are unique whatever their case, as they are looked up with LOWER(email)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email));
@@ -0,0 +1,67 @@
+package jwt
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims of the tokens issued by Gommence. The user is the subject ("sub")
+type Claims struct {
+	jwt.RegisteredClaims
+	Scopes []string `json:"scopes,omitempty"` // What the token allows to do. Empty for full access tokens
+	Roles  []string `json:"roles,omitempty"`
+	Tenant string   `json:"tenant,omitempty"` // Organization the user is acting on behalf of
+}
+
+// Scopes understood by the middleware. Tokens without scopes have full access
+const (
+	ScopeRead  = "read"  // Safe requests only: GET, HEAD and OPTIONS
+	ScopeWrite = "write" // Any request
+)
+
+// UserClaims returns the claims of a plain access token for the user
+func UserClaims(user string) Claims {
+	return Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: user}}
+}
+
+// APIKeyClaims returns the claims of a request authenticated with an API key. They are never signed, so they carry no "jti"
+func APIKeyClaims(user string, scopes []string, expiresAt time.Time) Claims {
+	claims := UserClaims(user)
+	claims.Scopes = scopes
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	return claims
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// Settings of the tokens issued and accepted by this server
+type Settings struct {
+	Issuer   string   // Stamped as "iss" on new tokens and required when validating. Not checked if empty
+	Audience []string // Stamped as "aud" on new tokens. Validation requires any of them. Not checked if empty
+}
+
+var (
+	settings     Settings
+	settingsLock sync.RWMutex
+)
+
+func SetSettings(newSettings Settings) {
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	settings = newSettings
+}
+
+func GetSettings() Settings {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return settings
+}
@@ -2,67 +2,95 @@ package jwt
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
-	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/opo_uid"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Secret key for signing the JWT tokens
-// "what_makes_a_good_secret": "secret should 64 chars or more with lower case, upper case, numbers and special characters. crypto.randomBytes(64).toString('hex') is a good way to generate a secret",
-var secretKey = []byte("mlcfZIl97A930yvsVuoR171aMS3tXBbWqbE1IscEWzvn2w2AzSNF9RnA1Pcnc46DPimhwEGRLC2UaQY6hBow7u")
+// Access tokens are short lived. Clients are expected to get new ones with their refresh token
+const AccessTokenDuration = 15 * time.Minute
 
-func createToken(user string, exp int64) (string, error) {
-	claims := jwt.MapClaims{
-		"user": user,
-		"exp":  exp,
+// IssuedToken is a signed token plus the data needed to revoke it before it expires
+type IssuedToken struct {
+	Token     string
+	ID        string // "jti" claim
+	ExpiresAt time.Time
+}
+
+// IssueToken signs the claims. Issuer, audience, id and times are filled here, any value set by the caller is overwritten
+func IssueToken(claims Claims, duration time.Duration) (*IssuedToken, error) {
+	now := time.Now()
+	current := GetSettings()
+	claims.Issuer = current.Issuer
+	claims.Audience = current.Audience
+	claims.ID = opo_uid.New()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(duration))
+	token, err := signClaims(&claims)
+	if err != nil {
+		return nil, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(secretKey)
+	return &IssuedToken{Token: token, ID: claims.ID, ExpiresAt: claims.ExpiresAt.Time}, nil
+}
+
+func signClaims(claims jwt.Claims) (string, error) {
+	key := GetKeyRing().SigningKey()
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.Kid
+	tokenString, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
 
+// CreateToken returns an access token for the user
 func CreateToken(user string) (string, error) {
-	expiration := time.Now().Add(time.Hour * 24).Unix() // 24 hours
-	return createToken(user, expiration)
+	issued, err := IssueToken(UserClaims(user), AccessTokenDuration)
+	if err != nil {
+		return "", err
+	}
+	return issued.Token, nil
 }
 
-func ValidateToken(tokenString string) (map[string]string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+// ValidateToken checks the signature, the times and, if configured, the issuer and the audience of the token
+func ValidateToken(tokenString string) (*Claims, error) {
+	ring := GetKeyRing()
+	current := GetSettings()
+	options := []jwt.ParserOption{jwt.WithExpirationRequired(), jwt.WithIssuedAt()}
+	if current.Issuer != "" {
+		options = append(options, jwt.WithIssuer(current.Issuer))
+	}
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		key := ring.SigningKey() // tokens without "kid" are checked against the signing key
+		if kid, hasKid := token.Header["kid"].(string); hasKid {
+			var found bool
+			if key, found = ring.Key(kid); !found {
+				return nil, fmt.Errorf("Unknown key id: %s", kid)
+			}
+		}
+		if token.Method.Alg() != key.Algorithm { // never let the token choose the algorithm
 			return nil, fmt.Errorf("Unexpected signature method: %v", token.Header["alg"]) // the text is used in tests!
 		}
-		return secretKey, nil
-	})
+		return key.verifyKey, nil
+	}, options...)
 	if err != nil {
 		return nil, err
 	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Check expiration
-		exp, ok := claims["exp"].(float64)
-		if !ok || time.Now().Unix() > int64(exp) {
-			logger.Assert(false) // jwt.Parse() already checks the expiration (if there is a "exp" claim) so we should not have reached this point
-			return nil, fmt.Errorf("expiration not valid")
-		}
-		claimsMap := make(map[string]string)
-		// Convert claims to string map
-		for key, value := range claims {
-			switch v := value.(type) {
-			case string:
-				claimsMap[key] = v
-			case float64:
-				claimsMap[key] = fmt.Sprintf("%.0f", v)
-			default:
-				claimsMap[key] = fmt.Sprintf("%v", v)
-			}
-		}
-
-		return claimsMap, nil
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
 	}
-
-	return nil, fmt.Errorf("invalid token")
+	if len(current.Audience) > 0 && !slices.ContainsFunc(claims.Audience, func(audience string) bool {
+		return slices.Contains(current.Audience, audience)
+	}) {
+		return nil, fmt.Errorf("token has invalid audience")
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+	return claims, nil
 }
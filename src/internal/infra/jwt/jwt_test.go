@@ -14,22 +14,83 @@ func TestHappyPath(t *testing.T) {
 	assert.Nil(t, err)
 	claims, err := ValidateToken(token)
 	assert.Nil(t, err)
-	assert.Equal(t, testUserName, claims["user"])
+	assert.Equal(t, testUserName, claims.Subject)
 }
 
-func TestTokenExpired(t *testing.T) {
-	expirated := time.Now().Add(-time.Second * 5).Unix()
-	token, err := createToken(testUserName, expirated)
+func TestIssuedTokensHaveId(t *testing.T) {
+	issued1, err := IssueToken(UserClaims(testUserName), time.Minute)
+	assert.Nil(t, err)
+	issued2, err := IssueToken(UserClaims(testUserName), time.Minute)
+	assert.Nil(t, err)
+	assert.NotEqual(t, issued1.ID, issued2.ID)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), issued1.ExpiresAt, time.Second)
+	claims, err := ValidateToken(issued1.Token)
+	assert.Nil(t, err)
+	assert.Equal(t, issued1.ID, claims.ID)
+	assert.Equal(t, issued1.ExpiresAt.Unix(), claims.ExpiresAt.Unix())
+}
+
+func TestTypedClaims(t *testing.T) {
+	claims := UserClaims(testUserName)
+	claims.Scopes = []string{"read", "write"}
+	claims.Roles = []string{"admin"}
+	claims.Tenant = "tenant1"
+	claims.ID = "overwritten"
+	issued, err := IssueToken(claims, time.Minute)
+	assert.Nil(t, err)
+	validated, err := ValidateToken(issued.Token)
+	assert.Nil(t, err)
+	assert.Equal(t, testUserName, validated.Subject)
+	assert.Equal(t, []string{"read", "write"}, validated.Scopes)
+	assert.True(t, validated.HasScope("write"))
+	assert.False(t, validated.HasScope("delete"))
+	assert.True(t, validated.HasRole("admin"))
+	assert.Equal(t, "tenant1", validated.Tenant)
+	assert.Equal(t, issued.ID, validated.ID)
+	assert.NotNil(t, validated.IssuedAt)
+	assert.NotNil(t, validated.NotBefore)
+}
+
+func TestIssuerAndAudience(t *testing.T) {
+	defer SetSettings(Settings{})
+	SetSettings(Settings{Issuer: "gommence", Audience: []string{"api", "partners"}})
+	token, err := CreateToken(testUserName)
+	assert.Nil(t, err)
+	claims, err := ValidateToken(token)
 	assert.Nil(t, err)
+	assert.Equal(t, "gommence", claims.Issuer)
+	assert.Equal(t, []string{"api", "partners"}, []string(claims.Audience))
+
+	SetSettings(Settings{Issuer: "someone-else"})
+	_, err = ValidateToken(token)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "token has invalid issuer")
+
+	SetSettings(Settings{Issuer: "gommence", Audience: []string{"partners", "mobile"}}) // any audience is enough
+	_, err = ValidateToken(token)
+	assert.Nil(t, err)
+
+	SetSettings(Settings{Issuer: "gommence", Audience: []string{"mobile"}})
 	_, err = ValidateToken(token)
 	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "token has invalid audience")
+}
+
+func TestTokenExpired(t *testing.T) {
+	issued, err := IssueToken(UserClaims(testUserName), -time.Second*5)
+	assert.Nil(t, err)
+	_, err = ValidateToken(issued.Token)
+	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "token is expired")
 }
 
 func TestBadEncryptionKey(t *testing.T) {
 	token, err := CreateToken(testUserName)
 	assert.Nil(t, err)
-	secretKey = []byte("bad_key")
+	badKey, _ := NewHMACKey(GetKeyRing().SigningKey().Kid, []byte("bad_key_but_long_enough_to_be_accepted"))
+	badRing, _ := NewKeyRing([]*Key{badKey}, badKey.Kid)
+	SetKeyRing(badRing)
+	defer SetKeyRing(nil)
 	_, err = ValidateToken(token)
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "signature is invalid")
@@ -0,0 +1,317 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	minHMACSecretLength = 32 // bytes. Shorter secrets can be brute forced from a single token
+)
+
+// Key is one of the keys of a KeyRing. Keys built from a public key can only verify tokens
+type Key struct {
+	Kid       string
+	Algorithm string
+	signKey   interface{} // []byte, *rsa.PrivateKey or ed25519.PrivateKey. Nil for verification only keys
+	verifyKey interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+func NewHMACKey(kid string, secret []byte) (*Key, error) {
+	if len(secret) < minHMACSecretLength {
+		return nil, fmt.Errorf("secret of key %s is too short, it must have at least %d bytes", kid, minHMACSecretLength)
+	}
+	return &Key{Kid: kid, Algorithm: AlgHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+func NewRSAKey(kid string, privateKey *rsa.PrivateKey) (*Key, error) {
+	if privateKey.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("rsa key %s is too short, it must have at least 2048 bits", kid)
+	}
+	return &Key{Kid: kid, Algorithm: AlgRS256, signKey: privateKey, verifyKey: &privateKey.PublicKey}, nil
+}
+
+func NewEd25519Key(kid string, privateKey ed25519.PrivateKey) (*Key, error) {
+	return &Key{Kid: kid, Algorithm: AlgEdDSA, signKey: privateKey, verifyKey: privateKey.Public()}, nil
+}
+
+// NewVerificationKey creates a key that only validates tokens, e.g. a retired key whose private part has been destroyed
+func NewVerificationKey(kid string, publicKey crypto.PublicKey) (*Key, error) {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return &Key{Kid: kid, Algorithm: AlgRS256, verifyKey: pub}, nil
+	case ed25519.PublicKey:
+		return &Key{Kid: kid, Algorithm: AlgEdDSA, verifyKey: pub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T for key %s", publicKey, kid)
+	}
+}
+
+// ParsePEMKey reads a RSA or Ed25519 key in PEM format. Private keys (PKCS#8 or PKCS#1) can sign, public keys (PKIX) only verify
+func ParsePEMKey(kid string, pemData []byte) (*Key, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found for key %s", kid)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		switch private := parsed.(type) {
+		case *rsa.PrivateKey:
+			return NewRSAKey(kid, private)
+		case ed25519.PrivateKey:
+			return NewEd25519Key(kid, private)
+		default:
+			return nil, fmt.Errorf("unsupported private key type %T for key %s", parsed, kid)
+		}
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		return NewRSAKey(kid, private)
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		return NewVerificationKey(kid, parsed)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %s for key %s", block.Type, kid)
+	}
+}
+
+// KeyRing holds the keys used to validate tokens and the one used to sign the new ones.
+// Rotating means adding a new key, making it the signing key and, once the tokens signed with
+// the old one have expired, removing the old key
+type KeyRing struct {
+	keys    map[string]*Key
+	signing *Key
+}
+
+func NewKeyRing(keys []*Key, signingKid string) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]*Key)}
+	for _, key := range keys {
+		if key.Kid == "" {
+			return nil, fmt.Errorf("keys must have an id")
+		}
+		if _, exists := ring.keys[key.Kid]; exists {
+			return nil, fmt.Errorf("duplicated key id %s", key.Kid)
+		}
+		ring.keys[key.Kid] = key
+	}
+	signing, found := ring.keys[signingKid]
+	if !found {
+		return nil, fmt.Errorf("signing key %s not found", signingKid)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("key %s can not be used to sign, it has no private part", signingKid)
+	}
+	ring.signing = signing
+	return ring, nil
+}
+
+// NewEphemeralKeyRing creates a ring with a random secret. Tokens won't survive a restart nor be valid in other instances
+func NewEphemeralKeyRing() *KeyRing {
+	secret := make([]byte, 64)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	key, _ := NewHMACKey("ephemeral", secret)
+	ring, _ := NewKeyRing([]*Key{key}, key.Kid)
+	return ring
+}
+
+func (r *KeyRing) SigningKey() *Key {
+	return r.signing
+}
+
+func (r *KeyRing) Key(kid string) (*Key, bool) {
+	key, found := r.keys[kid]
+	return key, found
+}
+
+// Sorted by kid so the output is stable
+func (r *KeyRing) Keys() []*Key {
+	keys := make([]*Key, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+	return keys
+}
+
+// KeyRingConfig tells where the keys of the ring are
+type KeyRingConfig struct {
+	KeysDir    string // Folder with one file per key. "<kid>.pem" for RSA/Ed25519 keys (private or public only) and "<kid>.secret" for HS256 secrets
+	Secret     string // A HS256 secret. Handy for simple deployments
+	SecretKid  string // Id of Secret, "default" if empty
+	SigningKid string // Id of the key used to sign. If empty, the last signing capable key sorted by id, so naming the keys by date (e.g. "2025-06") makes the newest one the signing key
+}
+
+// LoadKeyRing builds the key ring. With no keys configured an ephemeral key ring is returned. That is fine for development only
+func LoadKeyRing(config KeyRingConfig) (*KeyRing, error) {
+	var keys []*Key
+	if config.KeysDir != "" {
+		dirKeys, err := loadKeysDir(config.KeysDir)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, dirKeys...)
+	}
+	if config.Secret != "" {
+		kid := config.SecretKid
+		if kid == "" {
+			kid = "default"
+		}
+		key, err := NewHMACKey(kid, []byte(config.Secret))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		log.Println("No JWT keys configured (jwt.keys_dir, jwt.secret). Using an ephemeral key: tokens will not survive a restart")
+		return NewEphemeralKeyRing(), nil
+	}
+
+	signingKid := config.SigningKid
+	if signingKid == "" {
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+		for _, key := range keys {
+			if key.CanSign() {
+				signingKid = key.Kid
+			}
+		}
+	}
+	return NewKeyRing(keys, signingKid)
+}
+
+func loadKeysDir(dir string) ([]*Key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWT keys folder: %w", err)
+	}
+	var keys []*Key
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		kid := strings.TrimSuffix(name, ext)
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var key *Key
+		switch ext {
+		case ".pem":
+			key, err = ParsePEMKey(kid, data)
+		case ".secret":
+			key, err = NewHMACKey(kid, []byte(strings.TrimSpace(string(data))))
+		default:
+			continue // README files and the like
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// JSONWebKey is the public part of a key in RFC 7517 format
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // Ed25519 curve
+	X   string `json:"x,omitempty"`   // Ed25519 public key
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of the ring so other services can validate our tokens. HMAC secrets are never exposed
+func (r *KeyRing) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range r.Keys() {
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "RSA", Kid: key.Kid, Use: "sig", Alg: key.Algorithm,
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "OKP", Kid: key.Kid, Use: "sig", Alg: key.Algorithm,
+				Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
+
+var (
+	keyRing     *KeyRing
+	keyRingLock sync.RWMutex
+)
+
+// SetKeyRing sets the key ring used by CreateToken and ValidateToken
+func SetKeyRing(ring *KeyRing) {
+	keyRingLock.Lock()
+	defer keyRingLock.Unlock()
+	keyRing = ring
+}
+
+// GetKeyRing returns the key ring in use. If none was set an ephemeral one is created
+func GetKeyRing() *KeyRing {
+	keyRingLock.RLock()
+	ring := keyRing
+	keyRingLock.RUnlock()
+	if ring != nil {
+		return ring
+	}
+	keyRingLock.Lock()
+	defer keyRingLock.Unlock()
+	if keyRing == nil {
+		keyRing = NewEphemeralKeyRing()
+	}
+	return keyRing
+}
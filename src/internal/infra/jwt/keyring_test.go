@@ -0,0 +1,160 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKeys(t *testing.T) (*Key, *Key, *Key) {
+	hmacKey, err := NewHMACKey("hmac", []byte(strings.Repeat("s", 32)))
+	assert.Nil(t, err)
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	rsaKey, err := NewRSAKey("rsa", rsaPrivate)
+	assert.Nil(t, err)
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	edKey, err := NewEd25519Key("ed", edPrivate)
+	assert.Nil(t, err)
+	return hmacKey, rsaKey, edKey
+}
+
+func tokenHeader(t *testing.T, token string) map[string]interface{} {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	assert.Nil(t, err)
+	return parsed.Header
+}
+
+func TestAllAlgorithms(t *testing.T) {
+	defer SetKeyRing(nil)
+	hmacKey, rsaKey, edKey := newTestKeys(t)
+	for _, key := range []*Key{hmacKey, rsaKey, edKey} {
+		ring, err := NewKeyRing([]*Key{hmacKey, rsaKey, edKey}, key.Kid)
+		assert.Nil(t, err)
+		SetKeyRing(ring)
+		token, err := CreateToken(testUserName)
+		assert.Nil(t, err)
+		header := tokenHeader(t, token)
+		assert.Equal(t, key.Kid, header["kid"])
+		assert.Equal(t, key.Algorithm, header["alg"])
+		claims, err := ValidateToken(token)
+		assert.Nil(t, err)
+		assert.Equal(t, testUserName, claims.Subject)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	defer SetKeyRing(nil)
+	hmacKey, rsaKey, edKey := newTestKeys(t)
+	oldRing, _ := NewKeyRing([]*Key{rsaKey}, rsaKey.Kid)
+	SetKeyRing(oldRing)
+	oldToken, err := CreateToken(testUserName)
+	assert.Nil(t, err)
+
+	newRing, _ := NewKeyRing([]*Key{rsaKey, edKey}, edKey.Kid) // new signing key, the old one still validates
+	SetKeyRing(newRing)
+	_, err = ValidateToken(oldToken)
+	assert.Nil(t, err)
+
+	retiredRing, _ := NewKeyRing([]*Key{hmacKey, edKey}, edKey.Kid) // old key removed
+	SetKeyRing(retiredRing)
+	_, err = ValidateToken(oldToken)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Unknown key id")
+}
+
+func TestAlgorithmMustMatchKey(t *testing.T) {
+	defer SetKeyRing(nil)
+	hmacKey, rsaKey, _ := newTestKeys(t)
+	ring, _ := NewKeyRing([]*Key{hmacKey, rsaKey}, rsaKey.Kid)
+	SetKeyRing(ring)
+	// HS256 token signed with the public RSA key as secret, pointing to the RSA key: the classic algorithm confusion attack
+	publicBytes := x509.MarshalPKCS1PublicKey(rsaKey.verifyKey.(*rsa.PublicKey))
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": testUserName})
+	forged.Header["kid"] = rsaKey.Kid
+	forgedString, err := forged.SignedString(publicBytes)
+	assert.Nil(t, err)
+	_, err = ValidateToken(forgedString)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Unexpected signature method")
+}
+
+func TestKeyRingErrors(t *testing.T) {
+	hmacKey, rsaKey, _ := newTestKeys(t)
+	_, err := NewHMACKey("short", []byte("short"))
+	assert.NotNil(t, err)
+	_, err = NewKeyRing([]*Key{hmacKey, hmacKey}, hmacKey.Kid)
+	assert.NotNil(t, err)
+	_, err = NewKeyRing([]*Key{hmacKey}, "missing")
+	assert.NotNil(t, err)
+	verifyOnly, err := NewVerificationKey("public", rsaKey.verifyKey)
+	assert.Nil(t, err)
+	assert.False(t, verifyOnly.CanSign())
+	_, err = NewKeyRing([]*Key{verifyOnly}, verifyOnly.Kid)
+	assert.NotNil(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	hmacKey, rsaKey, edKey := newTestKeys(t)
+	ring, _ := NewKeyRing([]*Key{hmacKey, rsaKey, edKey}, hmacKey.Kid)
+	jwks := ring.JWKS()
+	assert.Equal(t, 2, len(jwks.Keys)) // no secrets published
+	assert.Equal(t, "ed", jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.NotEmpty(t, jwks.Keys[0].X)
+	assert.Equal(t, "rsa", jwks.Keys[1].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	assert.NotEmpty(t, jwks.Keys[1].N)
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	assert.Nil(t, err)
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edPrivate)
+	writePEM(t, filepath.Join(dir, "2025-01.pem"), "PRIVATE KEY", der)
+	rsaPrivate, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writePEM(t, filepath.Join(dir, "2025-02.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPrivate))
+	publicDer, _ := x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+	writePEM(t, filepath.Join(dir, "2025-03.pem"), "PUBLIC KEY", publicDer) // verification only, can't be the signing key
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0600)
+
+	config := KeyRingConfig{KeysDir: dir}
+	ring, err := LoadKeyRing(config)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(ring.Keys()))
+	assert.Equal(t, "2025-02", ring.SigningKey().Kid)
+
+	config.Secret = strings.Repeat("x", 40)
+	config.SigningKid = "2025-01"
+	ring, err = LoadKeyRing(config)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(ring.Keys()))
+	assert.Equal(t, "2025-01", ring.SigningKey().Kid)
+	_, found := ring.Key("default")
+	assert.True(t, found)
+
+	ring, err = LoadKeyRing(KeyRingConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, "ephemeral", ring.SigningKey().Kid)
+
+	config.Secret = "too short"
+	_, err = LoadKeyRing(config)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,34 @@
+package opaque_token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Opaque tokens are random strings handed to the clients (refresh tokens, reset links, api keys...).
+// Only their hash is stored so a leaked table can not be used to impersonate anyone.
+
+const tokenBytes = 32 // 256 bits of entropy, 43 characters once encoded
+
+// New returns a new random url-safe token
+func New() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Hash returns the value to be stored for a token. A fast hash is enough because the tokens have full entropy
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches compares a token with a stored hash in constant time
+func Matches(token string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(token)), []byte(hash)) == 1
+}
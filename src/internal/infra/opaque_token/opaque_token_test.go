@@ -0,0 +1,30 @@
+package opaque_token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokensAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		token, err := New()
+		assert.Nil(t, err)
+		assert.Equal(t, 43, len(token))
+		assert.False(t, seen[token])
+		seen[token] = true
+	}
+}
+
+func TestHashes(t *testing.T) {
+	token, err := New()
+	assert.Nil(t, err)
+	hash := Hash(token)
+	assert.Equal(t, 64, len(hash))
+	assert.Equal(t, hash, Hash(token))
+	assert.NotEqual(t, token, hash)
+	assert.True(t, Matches(token, hash))
+	assert.False(t, Matches(token+"x", hash))
+	assert.False(t, Matches("", hash))
+}
@@ -0,0 +1,60 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors of RFC 6238 for SHA1, truncated to 6 digits
+func TestRFCVectors(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range vectors {
+		code, err := Code(secret, Step(time.Unix(unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := NewSecret()
+	assert.Nil(t, err)
+	assert.Equal(t, 32, len(secret))
+	now := time.Now()
+	code, _ := Code(secret, Step(now))
+	step, valid := Validate(secret, code, now)
+	assert.True(t, valid)
+	assert.Equal(t, Step(now), step)
+
+	_, valid = Validate(secret, code, now.Add(Period)) // clock skew
+	assert.True(t, valid)
+	_, valid = Validate(secret, code, now.Add(3*Period))
+	assert.False(t, valid)
+	_, valid = Validate(secret, "12345", now)
+	assert.False(t, valid)
+	_, valid = Validate("not base32!", "123456", now)
+	assert.False(t, valid)
+	emptyCode, _ := Code(encoding.EncodeToString([]byte{0}), Step(now))
+	_, valid = Validate("", emptyCode, now)
+	assert.False(t, valid)
+}
+
+func TestKeyURI(t *testing.T) {
+	uri, err := url.Parse(KeyURI("Gommence", "john@mail.com", "JBSWY3DPEHPK3PXP"))
+	assert.Nil(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Gommence:john@mail.com", uri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal(t, "Gommence", uri.Query().Get("issuer"))
+}
@@ -0,0 +1,183 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: audit_ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source=audit_ports.go -destination=../mocks/audit_mocks.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Manolo-Esc/gommence/src/internal/domain"
+	dtos "github.com/Manolo-Esc/gommence/src/internal/dtos"
+	ports "github.com/Manolo-Esc/gommence/src/internal/ports"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AnonymizeUserAuditEvents mocks base method.
+func (m *MockAuditRepository) AnonymizeUserAuditEvents(ctx context.Context, idUser, email string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUserAuditEvents", ctx, idUser, email)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// AnonymizeUserAuditEvents indicates an expected call of AnonymizeUserAuditEvents.
+func (mr *MockAuditRepositoryMockRecorder) AnonymizeUserAuditEvents(ctx, idUser, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUserAuditEvents", reflect.TypeOf((*MockAuditRepository)(nil).AnonymizeUserAuditEvents), ctx, idUser, email)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockAuditRepository) CreateAuditEvent(ctx context.Context, event *domain.AuditEvent) (string, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, event)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockAuditRepositoryMockRecorder) CreateAuditEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockAuditRepository)(nil).CreateAuditEvent), ctx, event)
+}
+
+// GetAuditEvents mocks base method.
+func (m *MockAuditRepository) GetAuditEvents(ctx context.Context, query dtos.AuditQuery) ([]*domain.AuditEvent, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", ctx, query)
+	ret0, _ := ret[0].([]*domain.AuditEvent)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents.
+func (mr *MockAuditRepositoryMockRecorder) GetAuditEvents(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockAuditRepository)(nil).GetAuditEvents), ctx, query)
+}
+
+// GetUserAuditEvents mocks base method.
+func (m *MockAuditRepository) GetUserAuditEvents(ctx context.Context, idUser, email string) ([]*domain.AuditEvent, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAuditEvents", ctx, idUser, email)
+	ret0, _ := ret[0].([]*domain.AuditEvent)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserAuditEvents indicates an expected call of GetUserAuditEvents.
+func (mr *MockAuditRepositoryMockRecorder) GetUserAuditEvents(ctx, idUser, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAuditEvents", reflect.TypeOf((*MockAuditRepository)(nil).GetUserAuditEvents), ctx, idUser, email)
+}
+
+// MockAuditService is a mock of AuditService interface.
+type MockAuditService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditServiceMockRecorder is the mock recorder for MockAuditService.
+type MockAuditServiceMockRecorder struct {
+	mock *MockAuditService
+}
+
+// NewMockAuditService creates a new mock instance.
+func NewMockAuditService(ctrl *gomock.Controller) *MockAuditService {
+	mock := &MockAuditService{ctrl: ctrl}
+	mock.recorder = &MockAuditServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditService) EXPECT() *MockAuditServiceMockRecorder {
+	return m.recorder
+}
+
+// EraseUserData mocks base method.
+func (m *MockAuditService) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUserData", ctx, user)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// EraseUserData indicates an expected call of EraseUserData.
+func (mr *MockAuditServiceMockRecorder) EraseUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUserData", reflect.TypeOf((*MockAuditService)(nil).EraseUserData), ctx, user)
+}
+
+// ExportUserData mocks base method.
+func (m *MockAuditService) ExportUserData(ctx context.Context, user *domain.User) (any, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, user)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockAuditServiceMockRecorder) ExportUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockAuditService)(nil).ExportUserData), ctx, user)
+}
+
+// GetEvents mocks base method.
+func (m *MockAuditService) GetEvents(ctx context.Context, byUser string, query dtos.AuditQuery) ([]*domain.AuditEvent, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", ctx, byUser, query)
+	ret0, _ := ret[0].([]*domain.AuditEvent)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockAuditServiceMockRecorder) GetEvents(ctx, byUser, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockAuditService)(nil).GetEvents), ctx, byUser, query)
+}
+
+// Record mocks base method.
+func (m *MockAuditService) Record(ctx context.Context, event *domain.AuditEvent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", ctx, event)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditServiceMockRecorder) Record(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditService)(nil).Record), ctx, event)
+}
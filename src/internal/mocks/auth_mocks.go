@@ -12,12 +12,390 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	domain "github.com/Manolo-Esc/gommence/src/internal/domain"
 	dtos "github.com/Manolo-Esc/gommence/src/internal/dtos"
 	ports "github.com/Manolo-Esc/gommence/src/internal/ports"
 	gomock "go.uber.org/mock/gomock"
 )
 
+// MockTokenRepository is a mock of TokenRepository interface.
+type MockTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockTokenRepositoryMockRecorder is the mock recorder for MockTokenRepository.
+type MockTokenRepositoryMockRecorder struct {
+	mock *MockTokenRepository
+}
+
+// NewMockTokenRepository creates a new mock instance.
+func NewMockTokenRepository(ctrl *gomock.Controller) *MockTokenRepository {
+	mock := &MockTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenRepository) EXPECT() *MockTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockTokenRepository) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) (string, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", ctx, token)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockTokenRepositoryMockRecorder) CreateRefreshToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockTokenRepository)(nil).CreateRefreshToken), ctx, token)
+}
+
+// DeleteExpiredTokens mocks base method.
+func (m *MockTokenRepository) DeleteExpiredTokens(ctx context.Context, before time.Time) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredTokens", ctx, before)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteExpiredTokens indicates an expected call of DeleteExpiredTokens.
+func (mr *MockTokenRepositoryMockRecorder) DeleteExpiredTokens(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredTokens", reflect.TypeOf((*MockTokenRepository)(nil).DeleteExpiredTokens), ctx, before)
+}
+
+// DeleteUserRefreshTokens mocks base method.
+func (m *MockTokenRepository) DeleteUserRefreshTokens(ctx context.Context, userId string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRefreshTokens", ctx, userId)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteUserRefreshTokens indicates an expected call of DeleteUserRefreshTokens.
+func (mr *MockTokenRepositoryMockRecorder) DeleteUserRefreshTokens(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRefreshTokens", reflect.TypeOf((*MockTokenRepository)(nil).DeleteUserRefreshTokens), ctx, userId)
+}
+
+// GetRefreshTokenByHash mocks base method.
+func (m *MockTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshTokenByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*domain.RefreshToken)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetRefreshTokenByHash indicates an expected call of GetRefreshTokenByHash.
+func (mr *MockTokenRepositoryMockRecorder) GetRefreshTokenByHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshTokenByHash", reflect.TypeOf((*MockTokenRepository)(nil).GetRefreshTokenByHash), ctx, tokenHash)
+}
+
+// IsAccessTokenRevoked mocks base method.
+func (m *MockTokenRepository) IsAccessTokenRevoked(ctx context.Context, tokenId string) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAccessTokenRevoked", ctx, tokenId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// IsAccessTokenRevoked indicates an expected call of IsAccessTokenRevoked.
+func (mr *MockTokenRepositoryMockRecorder) IsAccessTokenRevoked(ctx, tokenId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAccessTokenRevoked", reflect.TypeOf((*MockTokenRepository)(nil).IsAccessTokenRevoked), ctx, tokenId)
+}
+
+// MarkRefreshTokenUsed mocks base method.
+func (m *MockTokenRepository) MarkRefreshTokenUsed(ctx context.Context, idToken string) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRefreshTokenUsed", ctx, idToken)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// MarkRefreshTokenUsed indicates an expected call of MarkRefreshTokenUsed.
+func (mr *MockTokenRepositoryMockRecorder) MarkRefreshTokenUsed(ctx, idToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRefreshTokenUsed", reflect.TypeOf((*MockTokenRepository)(nil).MarkRefreshTokenUsed), ctx, idToken)
+}
+
+// RevokeAccessToken mocks base method.
+func (m *MockTokenRepository) RevokeAccessToken(ctx context.Context, tokenId, userId string, expiresAt time.Time) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccessToken", ctx, tokenId, userId, expiresAt)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RevokeAccessToken indicates an expected call of RevokeAccessToken.
+func (mr *MockTokenRepositoryMockRecorder) RevokeAccessToken(ctx, tokenId, userId, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessToken", reflect.TypeOf((*MockTokenRepository)(nil).RevokeAccessToken), ctx, tokenId, userId, expiresAt)
+}
+
+// RevokeRefreshTokenFamily mocks base method.
+func (m *MockTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyId string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", ctx, familyId)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily.
+func (mr *MockTokenRepositoryMockRecorder) RevokeRefreshTokenFamily(ctx, familyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockTokenRepository)(nil).RevokeRefreshTokenFamily), ctx, familyId)
+}
+
+// RevokeUserRefreshTokens mocks base method.
+func (m *MockTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userId string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserRefreshTokens", ctx, userId)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RevokeUserRefreshTokens indicates an expected call of RevokeUserRefreshTokens.
+func (mr *MockTokenRepositoryMockRecorder) RevokeUserRefreshTokens(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserRefreshTokens", reflect.TypeOf((*MockTokenRepository)(nil).RevokeUserRefreshTokens), ctx, userId)
+}
+
+// MockActionTokenRepository is a mock of ActionTokenRepository interface.
+type MockActionTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockActionTokenRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockActionTokenRepositoryMockRecorder is the mock recorder for MockActionTokenRepository.
+type MockActionTokenRepositoryMockRecorder struct {
+	mock *MockActionTokenRepository
+}
+
+// NewMockActionTokenRepository creates a new mock instance.
+func NewMockActionTokenRepository(ctrl *gomock.Controller) *MockActionTokenRepository {
+	mock := &MockActionTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockActionTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockActionTokenRepository) EXPECT() *MockActionTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateActionToken mocks base method.
+func (m *MockActionTokenRepository) CreateActionToken(ctx context.Context, token *domain.ActionToken) (string, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateActionToken", ctx, token)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateActionToken indicates an expected call of CreateActionToken.
+func (mr *MockActionTokenRepositoryMockRecorder) CreateActionToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateActionToken", reflect.TypeOf((*MockActionTokenRepository)(nil).CreateActionToken), ctx, token)
+}
+
+// DeleteExpiredActionTokens mocks base method.
+func (m *MockActionTokenRepository) DeleteExpiredActionTokens(ctx context.Context, before time.Time) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredActionTokens", ctx, before)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteExpiredActionTokens indicates an expected call of DeleteExpiredActionTokens.
+func (mr *MockActionTokenRepositoryMockRecorder) DeleteExpiredActionTokens(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredActionTokens", reflect.TypeOf((*MockActionTokenRepository)(nil).DeleteExpiredActionTokens), ctx, before)
+}
+
+// DeleteUserActionTokens mocks base method.
+func (m *MockActionTokenRepository) DeleteUserActionTokens(ctx context.Context, userId string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserActionTokens", ctx, userId)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteUserActionTokens indicates an expected call of DeleteUserActionTokens.
+func (mr *MockActionTokenRepositoryMockRecorder) DeleteUserActionTokens(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserActionTokens", reflect.TypeOf((*MockActionTokenRepository)(nil).DeleteUserActionTokens), ctx, userId)
+}
+
+// GetActionTokenByHash mocks base method.
+func (m *MockActionTokenRepository) GetActionTokenByHash(ctx context.Context, purpose domain.ActionTokenPurpose, tokenHash string) (*domain.ActionToken, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActionTokenByHash", ctx, purpose, tokenHash)
+	ret0, _ := ret[0].(*domain.ActionToken)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetActionTokenByHash indicates an expected call of GetActionTokenByHash.
+func (mr *MockActionTokenRepositoryMockRecorder) GetActionTokenByHash(ctx, purpose, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActionTokenByHash", reflect.TypeOf((*MockActionTokenRepository)(nil).GetActionTokenByHash), ctx, purpose, tokenHash)
+}
+
+// InvalidateActionTokens mocks base method.
+func (m *MockActionTokenRepository) InvalidateActionTokens(ctx context.Context, userId string, purpose domain.ActionTokenPurpose) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateActionTokens", ctx, userId, purpose)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// InvalidateActionTokens indicates an expected call of InvalidateActionTokens.
+func (mr *MockActionTokenRepositoryMockRecorder) InvalidateActionTokens(ctx, userId, purpose any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateActionTokens", reflect.TypeOf((*MockActionTokenRepository)(nil).InvalidateActionTokens), ctx, userId, purpose)
+}
+
+// MarkActionTokenUsed mocks base method.
+func (m *MockActionTokenRepository) MarkActionTokenUsed(ctx context.Context, idToken string) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkActionTokenUsed", ctx, idToken)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// MarkActionTokenUsed indicates an expected call of MarkActionTokenUsed.
+func (mr *MockActionTokenRepositoryMockRecorder) MarkActionTokenUsed(ctx, idToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkActionTokenUsed", reflect.TypeOf((*MockActionTokenRepository)(nil).MarkActionTokenUsed), ctx, idToken)
+}
+
+// MockMFARepository is a mock of MFARepository interface.
+type MockMFARepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFARepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockMFARepositoryMockRecorder is the mock recorder for MockMFARepository.
+type MockMFARepositoryMockRecorder struct {
+	mock *MockMFARepository
+}
+
+// NewMockMFARepository creates a new mock instance.
+func NewMockMFARepository(ctrl *gomock.Controller) *MockMFARepository {
+	mock := &MockMFARepository{ctrl: ctrl}
+	mock.recorder = &MockMFARepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFARepository) EXPECT() *MockMFARepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddMFAChallengeAttempt mocks base method.
+func (m *MockMFARepository) AddMFAChallengeAttempt(ctx context.Context, tokenHash string, now time.Time) (*domain.MFAChallenge, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMFAChallengeAttempt", ctx, tokenHash, now)
+	ret0, _ := ret[0].(*domain.MFAChallenge)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// AddMFAChallengeAttempt indicates an expected call of AddMFAChallengeAttempt.
+func (mr *MockMFARepositoryMockRecorder) AddMFAChallengeAttempt(ctx, tokenHash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMFAChallengeAttempt", reflect.TypeOf((*MockMFARepository)(nil).AddMFAChallengeAttempt), ctx, tokenHash, now)
+}
+
+// CreateMFAChallenge mocks base method.
+func (m *MockMFARepository) CreateMFAChallenge(ctx context.Context, challenge *domain.MFAChallenge) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAChallenge", ctx, challenge)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// CreateMFAChallenge indicates an expected call of CreateMFAChallenge.
+func (mr *MockMFARepositoryMockRecorder) CreateMFAChallenge(ctx, challenge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAChallenge", reflect.TypeOf((*MockMFARepository)(nil).CreateMFAChallenge), ctx, challenge)
+}
+
+// DeleteExpiredMFA mocks base method.
+func (m *MockMFARepository) DeleteExpiredMFA(ctx context.Context, before time.Time) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredMFA", ctx, before)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteExpiredMFA indicates an expected call of DeleteExpiredMFA.
+func (mr *MockMFARepositoryMockRecorder) DeleteExpiredMFA(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredMFA", reflect.TypeOf((*MockMFARepository)(nil).DeleteExpiredMFA), ctx, before)
+}
+
+// DeleteMFAChallenge mocks base method.
+func (m *MockMFARepository) DeleteMFAChallenge(ctx context.Context, tokenHash string) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMFAChallenge", ctx, tokenHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// DeleteMFAChallenge indicates an expected call of DeleteMFAChallenge.
+func (mr *MockMFARepositoryMockRecorder) DeleteMFAChallenge(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMFAChallenge", reflect.TypeOf((*MockMFARepository)(nil).DeleteMFAChallenge), ctx, tokenHash)
+}
+
+// DeleteUserMFA mocks base method.
+func (m *MockMFARepository) DeleteUserMFA(ctx context.Context, userId string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserMFA", ctx, userId)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteUserMFA indicates an expected call of DeleteUserMFA.
+func (mr *MockMFARepositoryMockRecorder) DeleteUserMFA(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMFA", reflect.TypeOf((*MockMFARepository)(nil).DeleteUserMFA), ctx, userId)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockMFARepository) UseTOTPStep(ctx context.Context, userId string, step int64, expiresAt time.Time) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, userId, step, expiresAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockMFARepositoryMockRecorder) UseTOTPStep(ctx, userId, step, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockMFARepository)(nil).UseTOTPStep), ctx, userId, step, expiresAt)
+}
+
 // MockAuthService is a mock of AuthService interface.
 type MockAuthService struct {
 	ctrl     *gomock.Controller
@@ -42,17 +420,350 @@ func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockAuthService) ChangePassword(ctx context.Context, byUser string, request dtos.PasswordChange, clientIP string) (*dtos.LoggedUser, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, byUser, request, clientIP)
+	ret0, _ := ret[0].(*dtos.LoggedUser)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthServiceMockRecorder) ChangePassword(ctx, byUser, request, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthService)(nil).ChangePassword), ctx, byUser, request, clientIP)
+}
+
+// CompleteOIDCLogin mocks base method.
+func (m *MockAuthService) CompleteOIDCLogin(ctx context.Context, callback dtos.OIDCCallback) (*dtos.LoggedUser, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteOIDCLogin", ctx, callback)
+	ret0, _ := ret[0].(*dtos.LoggedUser)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CompleteOIDCLogin indicates an expected call of CompleteOIDCLogin.
+func (mr *MockAuthServiceMockRecorder) CompleteOIDCLogin(ctx, callback any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteOIDCLogin", reflect.TypeOf((*MockAuthService)(nil).CompleteOIDCLogin), ctx, callback)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockAuthService) ConfirmEmailChange(ctx context.Context, request dtos.EmailChangeConfirm) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, request)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockAuthServiceMockRecorder) ConfirmEmailChange(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockAuthService)(nil).ConfirmEmailChange), ctx, request)
+}
+
+// ConfirmMFAEnrollment mocks base method.
+func (m *MockAuthService) ConfirmMFAEnrollment(ctx context.Context, byUser string, request dtos.MFACode) (*dtos.MFARecoveryCodes, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmMFAEnrollment", ctx, byUser, request)
+	ret0, _ := ret[0].(*dtos.MFARecoveryCodes)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ConfirmMFAEnrollment indicates an expected call of ConfirmMFAEnrollment.
+func (mr *MockAuthServiceMockRecorder) ConfirmMFAEnrollment(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmMFAEnrollment", reflect.TypeOf((*MockAuthService)(nil).ConfirmMFAEnrollment), ctx, byUser, request)
+}
+
+// ConfirmPasswordReset mocks base method.
+func (m *MockAuthService) ConfirmPasswordReset(ctx context.Context, request dtos.PasswordResetConfirm) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmPasswordReset", ctx, request)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// ConfirmPasswordReset indicates an expected call of ConfirmPasswordReset.
+func (mr *MockAuthServiceMockRecorder) ConfirmPasswordReset(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmPasswordReset", reflect.TypeOf((*MockAuthService)(nil).ConfirmPasswordReset), ctx, request)
+}
+
+// DisableMFA mocks base method.
+func (m *MockAuthService) DisableMFA(ctx context.Context, byUser string, request dtos.MFACode) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableMFA", ctx, byUser, request)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DisableMFA indicates an expected call of DisableMFA.
+func (mr *MockAuthServiceMockRecorder) DisableMFA(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableMFA", reflect.TypeOf((*MockAuthService)(nil).DisableMFA), ctx, byUser, request)
+}
+
+// EraseUserData mocks base method.
+func (m *MockAuthService) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUserData", ctx, user)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// EraseUserData indicates an expected call of EraseUserData.
+func (mr *MockAuthServiceMockRecorder) EraseUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUserData", reflect.TypeOf((*MockAuthService)(nil).EraseUserData), ctx, user)
+}
+
+// ExportUserData mocks base method.
+func (m *MockAuthService) ExportUserData(ctx context.Context, user *domain.User) (any, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, user)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockAuthServiceMockRecorder) ExportUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockAuthService)(nil).ExportUserData), ctx, user)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockAuthService) IsTokenRevoked(ctx context.Context, tokenId string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, tokenId)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockAuthServiceMockRecorder) IsTokenRevoked(ctx, tokenId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockAuthService)(nil).IsTokenRevoked), ctx, tokenId)
+}
+
 // Login mocks base method.
-func (m *MockAuthService) Login(ctx context.Context, credentials dtos.LoginCredentials) (*dtos.LoggedUser, ports.APIError) {
+func (m *MockAuthService) Login(ctx context.Context, credentials dtos.LoginCredentials, clientIP string) (*dtos.LoggedUser, ports.APIError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", ctx, credentials)
+	ret := m.ctrl.Call(m, "Login", ctx, credentials, clientIP)
 	ret0, _ := ret[0].(*dtos.LoggedUser)
 	ret1, _ := ret[1].(ports.APIError)
 	return ret0, ret1
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockAuthServiceMockRecorder) Login(ctx, credentials any) *gomock.Call {
+func (mr *MockAuthServiceMockRecorder) Login(ctx, credentials, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthService)(nil).Login), ctx, credentials, clientIP)
+}
+
+// PurgeExpired mocks base method.
+func (m *MockAuthService) PurgeExpired(ctx context.Context) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpired", ctx)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// PurgeExpired indicates an expected call of PurgeExpired.
+func (mr *MockAuthServiceMockRecorder) PurgeExpired(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpired", reflect.TypeOf((*MockAuthService)(nil).PurgeExpired), ctx)
+}
+
+// Refresh mocks base method.
+func (m *MockAuthService) Refresh(ctx context.Context, request dtos.RefreshTokenRequest) (*dtos.LoggedUser, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, request)
+	ret0, _ := ret[0].(*dtos.LoggedUser)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockAuthServiceMockRecorder) Refresh(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuthService)(nil).Refresh), ctx, request)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockAuthService) RegenerateRecoveryCodes(ctx context.Context, byUser string, request dtos.MFACode) (*dtos.MFARecoveryCodes, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", ctx, byUser, request)
+	ret0, _ := ret[0].(*dtos.MFARecoveryCodes)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockAuthServiceMockRecorder) RegenerateRecoveryCodes(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockAuthService)(nil).RegenerateRecoveryCodes), ctx, byUser, request)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockAuthService) RequestEmailChange(ctx context.Context, byUser string, request dtos.EmailChangeRequest, clientIP string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", ctx, byUser, request, clientIP)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockAuthServiceMockRecorder) RequestEmailChange(ctx, byUser, request, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockAuthService)(nil).RequestEmailChange), ctx, byUser, request, clientIP)
+}
+
+// RequestEmailVerification mocks base method.
+func (m *MockAuthService) RequestEmailVerification(ctx context.Context, request dtos.EmailVerificationRequest) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailVerification", ctx, request)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RequestEmailVerification indicates an expected call of RequestEmailVerification.
+func (mr *MockAuthServiceMockRecorder) RequestEmailVerification(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailVerification", reflect.TypeOf((*MockAuthService)(nil).RequestEmailVerification), ctx, request)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockAuthService) RequestPasswordReset(ctx context.Context, request dtos.PasswordResetRequest) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, request)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockAuthServiceMockRecorder) RequestPasswordReset(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockAuthService)(nil).RequestPasswordReset), ctx, request)
+}
+
+// SetUserPassword mocks base method.
+func (m *MockAuthService) SetUserPassword(ctx context.Context, byUser, forUser, password string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPassword", ctx, byUser, forUser, password)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// SetUserPassword indicates an expected call of SetUserPassword.
+func (mr *MockAuthServiceMockRecorder) SetUserPassword(ctx, byUser, forUser, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPassword", reflect.TypeOf((*MockAuthService)(nil).SetUserPassword), ctx, byUser, forUser, password)
+}
+
+// SignOut mocks base method.
+func (m *MockAuthService) SignOut(ctx context.Context, byUser, tokenId string, tokenExpiration time.Time, request dtos.SignOutRequest) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignOut", ctx, byUser, tokenId, tokenExpiration, request)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// SignOut indicates an expected call of SignOut.
+func (mr *MockAuthServiceMockRecorder) SignOut(ctx, byUser, tokenId, tokenExpiration, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignOut", reflect.TypeOf((*MockAuthService)(nil).SignOut), ctx, byUser, tokenId, tokenExpiration, request)
+}
+
+// SignUp mocks base method.
+func (m *MockAuthService) SignUp(ctx context.Context, signUp dtos.UserSignUp) (*dtos.LoggedUser, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignUp", ctx, signUp)
+	ret0, _ := ret[0].(*dtos.LoggedUser)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// SignUp indicates an expected call of SignUp.
+func (mr *MockAuthServiceMockRecorder) SignUp(ctx, signUp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockAuthService)(nil).SignUp), ctx, signUp)
+}
+
+// StartMFAEnrollment mocks base method.
+func (m *MockAuthService) StartMFAEnrollment(ctx context.Context, byUser string) (*dtos.MFAEnrollment, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartMFAEnrollment", ctx, byUser)
+	ret0, _ := ret[0].(*dtos.MFAEnrollment)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// StartMFAEnrollment indicates an expected call of StartMFAEnrollment.
+func (mr *MockAuthServiceMockRecorder) StartMFAEnrollment(ctx, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartMFAEnrollment", reflect.TypeOf((*MockAuthService)(nil).StartMFAEnrollment), ctx, byUser)
+}
+
+// StartOIDCLogin mocks base method.
+func (m *MockAuthService) StartOIDCLogin(ctx context.Context) (*dtos.OIDCStart, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartOIDCLogin", ctx)
+	ret0, _ := ret[0].(*dtos.OIDCStart)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// StartOIDCLogin indicates an expected call of StartOIDCLogin.
+func (mr *MockAuthServiceMockRecorder) StartOIDCLogin(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartOIDCLogin", reflect.TypeOf((*MockAuthService)(nil).StartOIDCLogin), ctx)
+}
+
+// SwitchTenant mocks base method.
+func (m *MockAuthService) SwitchTenant(ctx context.Context, byUser string, request dtos.TenantSwitch) (*dtos.LoggedUser, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SwitchTenant", ctx, byUser, request)
+	ret0, _ := ret[0].(*dtos.LoggedUser)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// SwitchTenant indicates an expected call of SwitchTenant.
+func (mr *MockAuthServiceMockRecorder) SwitchTenant(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwitchTenant", reflect.TypeOf((*MockAuthService)(nil).SwitchTenant), ctx, byUser, request)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAuthService) VerifyEmail(ctx context.Context, request dtos.VerifyEmailRequest) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, request)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthServiceMockRecorder) VerifyEmail(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthService)(nil).VerifyEmail), ctx, request)
+}
+
+// VerifyMFA mocks base method.
+func (m *MockAuthService) VerifyMFA(ctx context.Context, request dtos.MFAVerifyRequest, clientIP string) (*dtos.LoggedUser, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFA", ctx, request, clientIP)
+	ret0, _ := ret[0].(*dtos.LoggedUser)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockAuthServiceMockRecorder) VerifyMFA(ctx, request, clientIP any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthService)(nil).Login), ctx, credentials)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockAuthService)(nil).VerifyMFA), ctx, request, clientIP)
 }
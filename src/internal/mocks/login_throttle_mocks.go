@@ -0,0 +1,231 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: login_throttle_ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source=login_throttle_ports.go -destination=../mocks/login_throttle_mocks.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/Manolo-Esc/gommence/src/internal/domain"
+	ports "github.com/Manolo-Esc/gommence/src/internal/ports"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginAttemptRepository is a mock of LoginAttemptRepository interface.
+type MockLoginAttemptRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockLoginAttemptRepositoryMockRecorder is the mock recorder for MockLoginAttemptRepository.
+type MockLoginAttemptRepositoryMockRecorder struct {
+	mock *MockLoginAttemptRepository
+}
+
+// NewMockLoginAttemptRepository creates a new mock instance.
+func NewMockLoginAttemptRepository(ctrl *gomock.Controller) *MockLoginAttemptRepository {
+	mock := &MockLoginAttemptRepository{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttemptRepository) EXPECT() *MockLoginAttemptRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddLoginFailure mocks base method.
+func (m *MockLoginAttemptRepository) AddLoginFailure(ctx context.Context, key string, at, windowStart time.Time) (*domain.LoginAttempts, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", ctx, key, at, windowStart)
+	ret0, _ := ret[0].(*domain.LoginAttempts)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure.
+func (mr *MockLoginAttemptRepositoryMockRecorder) AddLoginFailure(ctx, key, at, windowStart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockLoginAttemptRepository)(nil).AddLoginFailure), ctx, key, at, windowStart)
+}
+
+// DeleteLoginAttempts mocks base method.
+func (m *MockLoginAttemptRepository) DeleteLoginAttempts(ctx context.Context, key string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginAttempts", ctx, key)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteLoginAttempts indicates an expected call of DeleteLoginAttempts.
+func (mr *MockLoginAttemptRepositoryMockRecorder) DeleteLoginAttempts(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginAttempts", reflect.TypeOf((*MockLoginAttemptRepository)(nil).DeleteLoginAttempts), ctx, key)
+}
+
+// DeleteLoginAttemptsBefore mocks base method.
+func (m *MockLoginAttemptRepository) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginAttemptsBefore", ctx, before)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteLoginAttemptsBefore indicates an expected call of DeleteLoginAttemptsBefore.
+func (mr *MockLoginAttemptRepositoryMockRecorder) DeleteLoginAttemptsBefore(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginAttemptsBefore", reflect.TypeOf((*MockLoginAttemptRepository)(nil).DeleteLoginAttemptsBefore), ctx, before)
+}
+
+// GetLoginAttempts mocks base method.
+func (m *MockLoginAttemptRepository) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginAttempts", ctx, key)
+	ret0, _ := ret[0].(*domain.LoginAttempts)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetLoginAttempts indicates an expected call of GetLoginAttempts.
+func (mr *MockLoginAttemptRepositoryMockRecorder) GetLoginAttempts(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginAttempts", reflect.TypeOf((*MockLoginAttemptRepository)(nil).GetLoginAttempts), ctx, key)
+}
+
+// LockLoginAttempts mocks base method.
+func (m *MockLoginAttemptRepository) LockLoginAttempts(ctx context.Context, key string, minFailures int, lockedUntil time.Time) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLoginAttempts", ctx, key, minFailures, lockedUntil)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// LockLoginAttempts indicates an expected call of LockLoginAttempts.
+func (mr *MockLoginAttemptRepositoryMockRecorder) LockLoginAttempts(ctx, key, minFailures, lockedUntil any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLoginAttempts", reflect.TypeOf((*MockLoginAttemptRepository)(nil).LockLoginAttempts), ctx, key, minFailures, lockedUntil)
+}
+
+// MockLoginThrottler is a mock of LoginThrottler interface.
+type MockLoginThrottler struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginThrottlerMockRecorder
+	isgomock struct{}
+}
+
+// MockLoginThrottlerMockRecorder is the mock recorder for MockLoginThrottler.
+type MockLoginThrottlerMockRecorder struct {
+	mock *MockLoginThrottler
+}
+
+// NewMockLoginThrottler creates a new mock instance.
+func NewMockLoginThrottler(ctrl *gomock.Controller) *MockLoginThrottler {
+	mock := &MockLoginThrottler{ctrl: ctrl}
+	mock.recorder = &MockLoginThrottlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginThrottler) EXPECT() *MockLoginThrottlerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockLoginThrottler) Check(ctx context.Context, email, clientIP string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, email, clientIP)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLoginThrottlerMockRecorder) Check(ctx, email, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLoginThrottler)(nil).Check), ctx, email, clientIP)
+}
+
+// CheckMFA mocks base method.
+func (m *MockLoginThrottler) CheckMFA(ctx context.Context, userId string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckMFA", ctx, userId)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// CheckMFA indicates an expected call of CheckMFA.
+func (mr *MockLoginThrottlerMockRecorder) CheckMFA(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckMFA", reflect.TypeOf((*MockLoginThrottler)(nil).CheckMFA), ctx, userId)
+}
+
+// Failed mocks base method.
+func (m *MockLoginThrottler) Failed(ctx context.Context, email, clientIP string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Failed", ctx, email, clientIP)
+}
+
+// Failed indicates an expected call of Failed.
+func (mr *MockLoginThrottlerMockRecorder) Failed(ctx, email, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Failed", reflect.TypeOf((*MockLoginThrottler)(nil).Failed), ctx, email, clientIP)
+}
+
+// FailedMFA mocks base method.
+func (m *MockLoginThrottler) FailedMFA(ctx context.Context, userId, clientIP string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "FailedMFA", ctx, userId, clientIP)
+}
+
+// FailedMFA indicates an expected call of FailedMFA.
+func (mr *MockLoginThrottlerMockRecorder) FailedMFA(ctx, userId, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailedMFA", reflect.TypeOf((*MockLoginThrottler)(nil).FailedMFA), ctx, userId, clientIP)
+}
+
+// Purge mocks base method.
+func (m *MockLoginThrottler) Purge(ctx context.Context) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockLoginThrottlerMockRecorder) Purge(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockLoginThrottler)(nil).Purge), ctx)
+}
+
+// Succeeded mocks base method.
+func (m *MockLoginThrottler) Succeeded(ctx context.Context, email, clientIP string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Succeeded", ctx, email, clientIP)
+}
+
+// Succeeded indicates an expected call of Succeeded.
+func (mr *MockLoginThrottlerMockRecorder) Succeeded(ctx, email, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Succeeded", reflect.TypeOf((*MockLoginThrottler)(nil).Succeeded), ctx, email, clientIP)
+}
+
+// SucceededMFA mocks base method.
+func (m *MockLoginThrottler) SucceededMFA(ctx context.Context, userId string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SucceededMFA", ctx, userId)
+}
+
+// SucceededMFA indicates an expected call of SucceededMFA.
+func (mr *MockLoginThrottlerMockRecorder) SucceededMFA(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SucceededMFA", reflect.TypeOf((*MockLoginThrottler)(nil).SucceededMFA), ctx, userId)
+}
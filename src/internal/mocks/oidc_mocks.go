@@ -0,0 +1,141 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: oidc_ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source=oidc_ports.go -destination=../mocks/oidc_mocks.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/Manolo-Esc/gommence/src/internal/domain"
+	ports "github.com/Manolo-Esc/gommence/src/internal/ports"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIdentityProvider is a mock of IdentityProvider interface.
+type MockIdentityProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockIdentityProviderMockRecorder is the mock recorder for MockIdentityProvider.
+type MockIdentityProviderMockRecorder struct {
+	mock *MockIdentityProvider
+}
+
+// NewMockIdentityProvider creates a new mock instance.
+func NewMockIdentityProvider(ctrl *gomock.Controller) *MockIdentityProvider {
+	mock := &MockIdentityProvider{ctrl: ctrl}
+	mock.recorder = &MockIdentityProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityProvider) EXPECT() *MockIdentityProviderMockRecorder {
+	return m.recorder
+}
+
+// AuthCodeURL mocks base method.
+func (m *MockIdentityProvider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthCodeURL", ctx, state, nonce, codeVerifier)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthCodeURL indicates an expected call of AuthCodeURL.
+func (mr *MockIdentityProviderMockRecorder) AuthCodeURL(ctx, state, nonce, codeVerifier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthCodeURL", reflect.TypeOf((*MockIdentityProvider)(nil).AuthCodeURL), ctx, state, nonce, codeVerifier)
+}
+
+// Exchange mocks base method.
+func (m *MockIdentityProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*domain.ExternalIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", ctx, code, codeVerifier, nonce)
+	ret0, _ := ret[0].(*domain.ExternalIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MockIdentityProviderMockRecorder) Exchange(ctx, code, codeVerifier, nonce any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MockIdentityProvider)(nil).Exchange), ctx, code, codeVerifier, nonce)
+}
+
+// MockOIDCFlowRepository is a mock of OIDCFlowRepository interface.
+type MockOIDCFlowRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCFlowRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOIDCFlowRepositoryMockRecorder is the mock recorder for MockOIDCFlowRepository.
+type MockOIDCFlowRepositoryMockRecorder struct {
+	mock *MockOIDCFlowRepository
+}
+
+// NewMockOIDCFlowRepository creates a new mock instance.
+func NewMockOIDCFlowRepository(ctrl *gomock.Controller) *MockOIDCFlowRepository {
+	mock := &MockOIDCFlowRepository{ctrl: ctrl}
+	mock.recorder = &MockOIDCFlowRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCFlowRepository) EXPECT() *MockOIDCFlowRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateOIDCFlow mocks base method.
+func (m *MockOIDCFlowRepository) CreateOIDCFlow(ctx context.Context, flow *domain.OIDCFlow) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCFlow", ctx, flow)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// CreateOIDCFlow indicates an expected call of CreateOIDCFlow.
+func (mr *MockOIDCFlowRepositoryMockRecorder) CreateOIDCFlow(ctx, flow any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCFlow", reflect.TypeOf((*MockOIDCFlowRepository)(nil).CreateOIDCFlow), ctx, flow)
+}
+
+// DeleteExpiredOIDCFlows mocks base method.
+func (m *MockOIDCFlowRepository) DeleteExpiredOIDCFlows(ctx context.Context, before time.Time) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredOIDCFlows", ctx, before)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteExpiredOIDCFlows indicates an expected call of DeleteExpiredOIDCFlows.
+func (mr *MockOIDCFlowRepositoryMockRecorder) DeleteExpiredOIDCFlows(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredOIDCFlows", reflect.TypeOf((*MockOIDCFlowRepository)(nil).DeleteExpiredOIDCFlows), ctx, before)
+}
+
+// TakeOIDCFlow mocks base method.
+func (m *MockOIDCFlowRepository) TakeOIDCFlow(ctx context.Context, stateHash string, now time.Time) (*domain.OIDCFlow, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeOIDCFlow", ctx, stateHash, now)
+	ret0, _ := ret[0].(*domain.OIDCFlow)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// TakeOIDCFlow indicates an expected call of TakeOIDCFlow.
+func (mr *MockOIDCFlowRepositoryMockRecorder) TakeOIDCFlow(ctx, stateHash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeOIDCFlow", reflect.TypeOf((*MockOIDCFlowRepository)(nil).TakeOIDCFlow), ctx, stateHash, now)
+}
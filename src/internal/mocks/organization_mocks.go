@@ -0,0 +1,287 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: organization_ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source=organization_ports.go -destination=../mocks/organization_mocks.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Manolo-Esc/gommence/src/internal/domain"
+	dtos "github.com/Manolo-Esc/gommence/src/internal/dtos"
+	ports "github.com/Manolo-Esc/gommence/src/internal/ports"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrganizationRepository is a mock of OrganizationRepository interface.
+type MockOrganizationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOrganizationRepositoryMockRecorder is the mock recorder for MockOrganizationRepository.
+type MockOrganizationRepositoryMockRecorder struct {
+	mock *MockOrganizationRepository
+}
+
+// NewMockOrganizationRepository creates a new mock instance.
+func NewMockOrganizationRepository(ctrl *gomock.Controller) *MockOrganizationRepository {
+	mock := &MockOrganizationRepository{ctrl: ctrl}
+	mock.recorder = &MockOrganizationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizationRepository) EXPECT() *MockOrganizationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateOrganization mocks base method.
+func (m *MockOrganizationRepository) CreateOrganization(ctx context.Context, organization *domain.Organization) (string, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, organization)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockOrganizationRepositoryMockRecorder) CreateOrganization(ctx, organization any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockOrganizationRepository)(nil).CreateOrganization), ctx, organization)
+}
+
+// DeleteMembership mocks base method.
+func (m *MockOrganizationRepository) DeleteMembership(ctx context.Context, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMembership", ctx, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteMembership indicates an expected call of DeleteMembership.
+func (mr *MockOrganizationRepositoryMockRecorder) DeleteMembership(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMembership", reflect.TypeOf((*MockOrganizationRepository)(nil).DeleteMembership), ctx, idUser)
+}
+
+// DeleteUserMemberships mocks base method.
+func (m *MockOrganizationRepository) DeleteUserMemberships(ctx context.Context, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserMemberships", ctx, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteUserMemberships indicates an expected call of DeleteUserMemberships.
+func (mr *MockOrganizationRepositoryMockRecorder) DeleteUserMemberships(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMemberships", reflect.TypeOf((*MockOrganizationRepository)(nil).DeleteUserMemberships), ctx, idUser)
+}
+
+// GetMembers mocks base method.
+func (m *MockOrganizationRepository) GetMembers(ctx context.Context) ([]*domain.Membership, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembers", ctx)
+	ret0, _ := ret[0].([]*domain.Membership)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetMembers indicates an expected call of GetMembers.
+func (mr *MockOrganizationRepositoryMockRecorder) GetMembers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembers", reflect.TypeOf((*MockOrganizationRepository)(nil).GetMembers), ctx)
+}
+
+// GetMembership mocks base method.
+func (m *MockOrganizationRepository) GetMembership(ctx context.Context, idOrganization, idUser string) (*domain.Membership, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembership", ctx, idOrganization, idUser)
+	ret0, _ := ret[0].(*domain.Membership)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetMembership indicates an expected call of GetMembership.
+func (mr *MockOrganizationRepositoryMockRecorder) GetMembership(ctx, idOrganization, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembership", reflect.TypeOf((*MockOrganizationRepository)(nil).GetMembership), ctx, idOrganization, idUser)
+}
+
+// GetUserOrganizations mocks base method.
+func (m *MockOrganizationRepository) GetUserOrganizations(ctx context.Context, idUser string) ([]*domain.Organization, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrganizations", ctx, idUser)
+	ret0, _ := ret[0].([]*domain.Organization)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserOrganizations indicates an expected call of GetUserOrganizations.
+func (mr *MockOrganizationRepositoryMockRecorder) GetUserOrganizations(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrganizations", reflect.TypeOf((*MockOrganizationRepository)(nil).GetUserOrganizations), ctx, idUser)
+}
+
+// SaveMembership mocks base method.
+func (m *MockOrganizationRepository) SaveMembership(ctx context.Context, membership *domain.Membership) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMembership", ctx, membership)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// SaveMembership indicates an expected call of SaveMembership.
+func (mr *MockOrganizationRepositoryMockRecorder) SaveMembership(ctx, membership any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMembership", reflect.TypeOf((*MockOrganizationRepository)(nil).SaveMembership), ctx, membership)
+}
+
+// MockOrganizationService is a mock of OrganizationService interface.
+type MockOrganizationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockOrganizationServiceMockRecorder is the mock recorder for MockOrganizationService.
+type MockOrganizationServiceMockRecorder struct {
+	mock *MockOrganizationService
+}
+
+// NewMockOrganizationService creates a new mock instance.
+func NewMockOrganizationService(ctrl *gomock.Controller) *MockOrganizationService {
+	mock := &MockOrganizationService{ctrl: ctrl}
+	mock.recorder = &MockOrganizationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizationService) EXPECT() *MockOrganizationServiceMockRecorder {
+	return m.recorder
+}
+
+// AddMember mocks base method.
+func (m *MockOrganizationService) AddMember(ctx context.Context, byUser string, request dtos.MemberAdd) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, byUser, request)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockOrganizationServiceMockRecorder) AddMember(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockOrganizationService)(nil).AddMember), ctx, byUser, request)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockOrganizationService) CreateOrganization(ctx context.Context, byUser string, request dtos.OrganizationCreate) (*domain.Organization, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, byUser, request)
+	ret0, _ := ret[0].(*domain.Organization)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockOrganizationServiceMockRecorder) CreateOrganization(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockOrganizationService)(nil).CreateOrganization), ctx, byUser, request)
+}
+
+// EraseUserData mocks base method.
+func (m *MockOrganizationService) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUserData", ctx, user)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// EraseUserData indicates an expected call of EraseUserData.
+func (mr *MockOrganizationServiceMockRecorder) EraseUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUserData", reflect.TypeOf((*MockOrganizationService)(nil).EraseUserData), ctx, user)
+}
+
+// ExportUserData mocks base method.
+func (m *MockOrganizationService) ExportUserData(ctx context.Context, user *domain.User) (any, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, user)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockOrganizationServiceMockRecorder) ExportUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockOrganizationService)(nil).ExportUserData), ctx, user)
+}
+
+// GetMembers mocks base method.
+func (m *MockOrganizationService) GetMembers(ctx context.Context, byUser string) ([]*domain.Membership, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembers", ctx, byUser)
+	ret0, _ := ret[0].([]*domain.Membership)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetMembers indicates an expected call of GetMembers.
+func (mr *MockOrganizationServiceMockRecorder) GetMembers(ctx, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembers", reflect.TypeOf((*MockOrganizationService)(nil).GetMembers), ctx, byUser)
+}
+
+// GetMembership mocks base method.
+func (m *MockOrganizationService) GetMembership(ctx context.Context, idOrganization, idUser string) (*domain.Membership, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembership", ctx, idOrganization, idUser)
+	ret0, _ := ret[0].(*domain.Membership)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetMembership indicates an expected call of GetMembership.
+func (mr *MockOrganizationServiceMockRecorder) GetMembership(ctx, idOrganization, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembership", reflect.TypeOf((*MockOrganizationService)(nil).GetMembership), ctx, idOrganization, idUser)
+}
+
+// GetUserOrganizations mocks base method.
+func (m *MockOrganizationService) GetUserOrganizations(ctx context.Context, forUser, byUser string) ([]*domain.Organization, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrganizations", ctx, forUser, byUser)
+	ret0, _ := ret[0].([]*domain.Organization)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserOrganizations indicates an expected call of GetUserOrganizations.
+func (mr *MockOrganizationServiceMockRecorder) GetUserOrganizations(ctx, forUser, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrganizations", reflect.TypeOf((*MockOrganizationService)(nil).GetUserOrganizations), ctx, forUser, byUser)
+}
+
+// RemoveMember mocks base method.
+func (m *MockOrganizationService) RemoveMember(ctx context.Context, byUser, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, byUser, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockOrganizationServiceMockRecorder) RemoveMember(ctx, byUser, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockOrganizationService)(nil).RemoveMember), ctx, byUser, idUser)
+}
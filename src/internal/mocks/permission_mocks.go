@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	domain "github.com/Manolo-Esc/gommence/src/internal/domain"
+	dtos "github.com/Manolo-Esc/gommence/src/internal/dtos"
 	ports "github.com/Manolo-Esc/gommence/src/internal/ports"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -42,6 +43,285 @@ func (m *MockPermissionRepository) EXPECT() *MockPermissionRepositoryMockRecorde
 	return m.recorder
 }
 
+// AddGroupMember mocks base method.
+func (m *MockPermissionRepository) AddGroupMember(ctx context.Context, idGroup, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupMember", ctx, idGroup, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// AddGroupMember indicates an expected call of AddGroupMember.
+func (mr *MockPermissionRepositoryMockRecorder) AddGroupMember(ctx, idGroup, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupMember", reflect.TypeOf((*MockPermissionRepository)(nil).AddGroupMember), ctx, idGroup, idUser)
+}
+
+// AddUserRole mocks base method.
+func (m *MockPermissionRepository) AddUserRole(ctx context.Context, idUser, idRole string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUserRole", ctx, idUser, idRole)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// AddUserRole indicates an expected call of AddUserRole.
+func (mr *MockPermissionRepositoryMockRecorder) AddUserRole(ctx, idUser, idRole any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUserRole", reflect.TypeOf((*MockPermissionRepository)(nil).AddUserRole), ctx, idUser, idRole)
+}
+
+// CreateGrant mocks base method.
+func (m *MockPermissionRepository) CreateGrant(ctx context.Context, grant *domain.Grant) (string, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGrant", ctx, grant)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateGrant indicates an expected call of CreateGrant.
+func (mr *MockPermissionRepositoryMockRecorder) CreateGrant(ctx, grant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGrant", reflect.TypeOf((*MockPermissionRepository)(nil).CreateGrant), ctx, grant)
+}
+
+// CreateGroup mocks base method.
+func (m *MockPermissionRepository) CreateGroup(ctx context.Context, group *domain.Group) (string, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, group)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockPermissionRepositoryMockRecorder) CreateGroup(ctx, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockPermissionRepository)(nil).CreateGroup), ctx, group)
+}
+
+// CreateRole mocks base method.
+func (m *MockPermissionRepository) CreateRole(ctx context.Context, role *domain.Role) (string, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, role)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockPermissionRepositoryMockRecorder) CreateRole(ctx, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockPermissionRepository)(nil).CreateRole), ctx, role)
+}
+
+// DeleteGrant mocks base method.
+func (m *MockPermissionRepository) DeleteGrant(ctx context.Context, idGrant string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGrant", ctx, idGrant)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteGrant indicates an expected call of DeleteGrant.
+func (mr *MockPermissionRepositoryMockRecorder) DeleteGrant(ctx, idGrant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGrant", reflect.TypeOf((*MockPermissionRepository)(nil).DeleteGrant), ctx, idGrant)
+}
+
+// DeleteUserPermissions mocks base method.
+func (m *MockPermissionRepository) DeleteUserPermissions(ctx context.Context, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserPermissions", ctx, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteUserPermissions indicates an expected call of DeleteUserPermissions.
+func (mr *MockPermissionRepositoryMockRecorder) DeleteUserPermissions(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserPermissions", reflect.TypeOf((*MockPermissionRepository)(nil).DeleteUserPermissions), ctx, idUser)
+}
+
+// GetGrant mocks base method.
+func (m *MockPermissionRepository) GetGrant(ctx context.Context, idGrant string) (*domain.Grant, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrant", ctx, idGrant)
+	ret0, _ := ret[0].(*domain.Grant)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetGrant indicates an expected call of GetGrant.
+func (mr *MockPermissionRepositoryMockRecorder) GetGrant(ctx, idGrant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrant", reflect.TypeOf((*MockPermissionRepository)(nil).GetGrant), ctx, idGrant)
+}
+
+// GetGroupByName mocks base method.
+func (m *MockPermissionRepository) GetGroupByName(ctx context.Context, name string) (*domain.Group, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupByName", ctx, name)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetGroupByName indicates an expected call of GetGroupByName.
+func (mr *MockPermissionRepositoryMockRecorder) GetGroupByName(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupByName", reflect.TypeOf((*MockPermissionRepository)(nil).GetGroupByName), ctx, name)
+}
+
+// GetGroupMembers mocks base method.
+func (m *MockPermissionRepository) GetGroupMembers(ctx context.Context, idGroup string) ([]string, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupMembers", ctx, idGroup)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetGroupMembers indicates an expected call of GetGroupMembers.
+func (mr *MockPermissionRepositoryMockRecorder) GetGroupMembers(ctx, idGroup any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupMembers", reflect.TypeOf((*MockPermissionRepository)(nil).GetGroupMembers), ctx, idGroup)
+}
+
+// GetGroups mocks base method.
+func (m *MockPermissionRepository) GetGroups(ctx context.Context) ([]*domain.Group, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroups", ctx)
+	ret0, _ := ret[0].([]*domain.Group)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetGroups indicates an expected call of GetGroups.
+func (mr *MockPermissionRepositoryMockRecorder) GetGroups(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockPermissionRepository)(nil).GetGroups), ctx)
+}
+
+// GetResourceGrants mocks base method.
+func (m *MockPermissionRepository) GetResourceGrants(ctx context.Context, resourceType, resourceID string) ([]*domain.Grant, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResourceGrants", ctx, resourceType, resourceID)
+	ret0, _ := ret[0].([]*domain.Grant)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetResourceGrants indicates an expected call of GetResourceGrants.
+func (mr *MockPermissionRepositoryMockRecorder) GetResourceGrants(ctx, resourceType, resourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResourceGrants", reflect.TypeOf((*MockPermissionRepository)(nil).GetResourceGrants), ctx, resourceType, resourceID)
+}
+
+// GetRoleByName mocks base method.
+func (m *MockPermissionRepository) GetRoleByName(ctx context.Context, name string) (*domain.Role, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleByName", ctx, name)
+	ret0, _ := ret[0].(*domain.Role)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetRoleByName indicates an expected call of GetRoleByName.
+func (mr *MockPermissionRepositoryMockRecorder) GetRoleByName(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleByName", reflect.TypeOf((*MockPermissionRepository)(nil).GetRoleByName), ctx, name)
+}
+
+// GetRoles mocks base method.
+func (m *MockPermissionRepository) GetRoles(ctx context.Context) ([]*domain.Role, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", ctx)
+	ret0, _ := ret[0].([]*domain.Role)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockPermissionRepositoryMockRecorder) GetRoles(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockPermissionRepository)(nil).GetRoles), ctx)
+}
+
+// GetUserGrants mocks base method.
+func (m *MockPermissionRepository) GetUserGrants(ctx context.Context, idUser string) ([]*domain.Grant, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserGrants", ctx, idUser)
+	ret0, _ := ret[0].([]*domain.Grant)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserGrants indicates an expected call of GetUserGrants.
+func (mr *MockPermissionRepositoryMockRecorder) GetUserGrants(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserGrants", reflect.TypeOf((*MockPermissionRepository)(nil).GetUserGrants), ctx, idUser)
+}
+
+// GetUserGroups mocks base method.
+func (m *MockPermissionRepository) GetUserGroups(ctx context.Context, idUser string) ([]*domain.Group, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserGroups", ctx, idUser)
+	ret0, _ := ret[0].([]*domain.Group)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserGroups indicates an expected call of GetUserGroups.
+func (mr *MockPermissionRepositoryMockRecorder) GetUserGroups(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserGroups", reflect.TypeOf((*MockPermissionRepository)(nil).GetUserGroups), ctx, idUser)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockPermissionRepository) GetUserRoles(ctx context.Context, idUser string) ([]*domain.Role, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, idUser)
+	ret0, _ := ret[0].([]*domain.Role)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockPermissionRepositoryMockRecorder) GetUserRoles(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockPermissionRepository)(nil).GetUserRoles), ctx, idUser)
+}
+
+// RemoveGroupMember mocks base method.
+func (m *MockPermissionRepository) RemoveGroupMember(ctx context.Context, idGroup, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveGroupMember", ctx, idGroup, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RemoveGroupMember indicates an expected call of RemoveGroupMember.
+func (mr *MockPermissionRepositoryMockRecorder) RemoveGroupMember(ctx, idGroup, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGroupMember", reflect.TypeOf((*MockPermissionRepository)(nil).RemoveGroupMember), ctx, idGroup, idUser)
+}
+
+// RemoveUserRole mocks base method.
+func (m *MockPermissionRepository) RemoveUserRole(ctx context.Context, idUser, idRole string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveUserRole", ctx, idUser, idRole)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RemoveUserRole indicates an expected call of RemoveUserRole.
+func (mr *MockPermissionRepositoryMockRecorder) RemoveUserRole(ctx, idUser, idRole any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUserRole", reflect.TypeOf((*MockPermissionRepository)(nil).RemoveUserRole), ctx, idUser, idRole)
+}
+
 // MockPermissionService is a mock of PermissionService interface.
 type MockPermissionService struct {
 	ctrl     *gomock.Controller
@@ -66,6 +346,168 @@ func (m *MockPermissionService) EXPECT() *MockPermissionServiceMockRecorder {
 	return m.recorder
 }
 
+// AddGroupMember mocks base method.
+func (m *MockPermissionService) AddGroupMember(ctx context.Context, byUser, groupName, forUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupMember", ctx, byUser, groupName, forUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// AddGroupMember indicates an expected call of AddGroupMember.
+func (mr *MockPermissionServiceMockRecorder) AddGroupMember(ctx, byUser, groupName, forUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupMember", reflect.TypeOf((*MockPermissionService)(nil).AddGroupMember), ctx, byUser, groupName, forUser)
+}
+
+// AllowedResources mocks base method.
+func (m *MockPermissionService) AllowedResources(ctx context.Context, subject string, action domain.Permission, resourceType string) (*domain.ResourceFilter, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllowedResources", ctx, subject, action, resourceType)
+	ret0, _ := ret[0].(*domain.ResourceFilter)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// AllowedResources indicates an expected call of AllowedResources.
+func (mr *MockPermissionServiceMockRecorder) AllowedResources(ctx, subject, action, resourceType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowedResources", reflect.TypeOf((*MockPermissionService)(nil).AllowedResources), ctx, subject, action, resourceType)
+}
+
+// AssignRole mocks base method.
+func (m *MockPermissionService) AssignRole(ctx context.Context, byUser, forUser, roleName string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", ctx, byUser, forUser, roleName)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockPermissionServiceMockRecorder) AssignRole(ctx, byUser, forUser, roleName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockPermissionService)(nil).AssignRole), ctx, byUser, forUser, roleName)
+}
+
+// Can mocks base method.
+func (m *MockPermissionService) Can(ctx context.Context, subject string, action domain.Permission, resourceType, resourceID string) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Can", ctx, subject, action, resourceType, resourceID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// Can indicates an expected call of Can.
+func (mr *MockPermissionServiceMockRecorder) Can(ctx, subject, action, resourceType, resourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Can", reflect.TypeOf((*MockPermissionService)(nil).Can), ctx, subject, action, resourceType, resourceID)
+}
+
+// CreateGroup mocks base method.
+func (m *MockPermissionService) CreateGroup(ctx context.Context, byUser string, request dtos.GroupCreate) (*domain.Group, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, byUser, request)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockPermissionServiceMockRecorder) CreateGroup(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockPermissionService)(nil).CreateGroup), ctx, byUser, request)
+}
+
+// CreateRole mocks base method.
+func (m *MockPermissionService) CreateRole(ctx context.Context, byUser string, request dtos.RoleCreate) (*domain.Role, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, byUser, request)
+	ret0, _ := ret[0].(*domain.Role)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockPermissionServiceMockRecorder) CreateRole(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockPermissionService)(nil).CreateRole), ctx, byUser, request)
+}
+
+// EraseUserData mocks base method.
+func (m *MockPermissionService) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUserData", ctx, user)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// EraseUserData indicates an expected call of EraseUserData.
+func (mr *MockPermissionServiceMockRecorder) EraseUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUserData", reflect.TypeOf((*MockPermissionService)(nil).EraseUserData), ctx, user)
+}
+
+// ExportUserData mocks base method.
+func (m *MockPermissionService) ExportUserData(ctx context.Context, user *domain.User) (any, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, user)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockPermissionServiceMockRecorder) ExportUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockPermissionService)(nil).ExportUserData), ctx, user)
+}
+
+// GetGroups mocks base method.
+func (m *MockPermissionService) GetGroups(ctx context.Context, byUser string) ([]*domain.Group, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroups", ctx, byUser)
+	ret0, _ := ret[0].([]*domain.Group)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetGroups indicates an expected call of GetGroups.
+func (mr *MockPermissionServiceMockRecorder) GetGroups(ctx, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockPermissionService)(nil).GetGroups), ctx, byUser)
+}
+
+// GetResourceGrants mocks base method.
+func (m *MockPermissionService) GetResourceGrants(ctx context.Context, byUser, resourceType, resourceID string) ([]*domain.Grant, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResourceGrants", ctx, byUser, resourceType, resourceID)
+	ret0, _ := ret[0].([]*domain.Grant)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetResourceGrants indicates an expected call of GetResourceGrants.
+func (mr *MockPermissionServiceMockRecorder) GetResourceGrants(ctx, byUser, resourceType, resourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResourceGrants", reflect.TypeOf((*MockPermissionService)(nil).GetResourceGrants), ctx, byUser, resourceType, resourceID)
+}
+
+// GetRoles mocks base method.
+func (m *MockPermissionService) GetRoles(ctx context.Context, byUser string) ([]*domain.Role, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", ctx, byUser)
+	ret0, _ := ret[0].([]*domain.Role)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockPermissionServiceMockRecorder) GetRoles(ctx, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockPermissionService)(nil).GetRoles), ctx, byUser)
+}
+
 // GetUserGlobalPermissions mocks base method.
 func (m *MockPermissionService) GetUserGlobalPermissions(ctx context.Context, forUser, byUser string) ([]domain.Permission, ports.APIError) {
 	m.ctrl.T.Helper()
@@ -81,17 +523,89 @@ func (mr *MockPermissionServiceMockRecorder) GetUserGlobalPermissions(ctx, forUs
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserGlobalPermissions", reflect.TypeOf((*MockPermissionService)(nil).GetUserGlobalPermissions), ctx, forUser, byUser)
 }
 
+// GetUserRoles mocks base method.
+func (m *MockPermissionService) GetUserRoles(ctx context.Context, forUser, byUser string) ([]*domain.Role, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, forUser, byUser)
+	ret0, _ := ret[0].([]*domain.Role)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockPermissionServiceMockRecorder) GetUserRoles(ctx, forUser, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockPermissionService)(nil).GetUserRoles), ctx, forUser, byUser)
+}
+
+// GrantAccess mocks base method.
+func (m *MockPermissionService) GrantAccess(ctx context.Context, byUser, resourceType, resourceID string, request dtos.GrantCreate) (*domain.Grant, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantAccess", ctx, byUser, resourceType, resourceID, request)
+	ret0, _ := ret[0].(*domain.Grant)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GrantAccess indicates an expected call of GrantAccess.
+func (mr *MockPermissionServiceMockRecorder) GrantAccess(ctx, byUser, resourceType, resourceID, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantAccess", reflect.TypeOf((*MockPermissionService)(nil).GrantAccess), ctx, byUser, resourceType, resourceID, request)
+}
+
 // IsSameUserOrHasSomePermission mocks base method.
-func (m *MockPermissionService) IsSameUserOrHasSomePermission(byUser, forUser string, permissions []domain.Permission) (bool, ports.APIError) {
+func (m *MockPermissionService) IsSameUserOrHasSomePermission(ctx context.Context, byUser, forUser string, permissions []domain.Permission) (bool, ports.APIError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsSameUserOrHasSomePermission", byUser, forUser, permissions)
+	ret := m.ctrl.Call(m, "IsSameUserOrHasSomePermission", ctx, byUser, forUser, permissions)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(ports.APIError)
 	return ret0, ret1
 }
 
-// IsSameUserOrHasSomePermission indicates an expected call of IsSameUserOrHasAnyPermission.
-func (mr *MockPermissionServiceMockRecorder) IsSameUserOrHasSomePermission(byUser, forUser, permissions any) *gomock.Call {
+// IsSameUserOrHasSomePermission indicates an expected call of IsSameUserOrHasSomePermission.
+func (mr *MockPermissionServiceMockRecorder) IsSameUserOrHasSomePermission(ctx, byUser, forUser, permissions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSameUserOrHasSomePermission", reflect.TypeOf((*MockPermissionService)(nil).IsSameUserOrHasSomePermission), ctx, byUser, forUser, permissions)
+}
+
+// RemoveGroupMember mocks base method.
+func (m *MockPermissionService) RemoveGroupMember(ctx context.Context, byUser, groupName, forUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveGroupMember", ctx, byUser, groupName, forUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RemoveGroupMember indicates an expected call of RemoveGroupMember.
+func (mr *MockPermissionServiceMockRecorder) RemoveGroupMember(ctx, byUser, groupName, forUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGroupMember", reflect.TypeOf((*MockPermissionService)(nil).RemoveGroupMember), ctx, byUser, groupName, forUser)
+}
+
+// RemoveRole mocks base method.
+func (m *MockPermissionService) RemoveRole(ctx context.Context, byUser, forUser, roleName string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRole", ctx, byUser, forUser, roleName)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RemoveRole indicates an expected call of RemoveRole.
+func (mr *MockPermissionServiceMockRecorder) RemoveRole(ctx, byUser, forUser, roleName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRole", reflect.TypeOf((*MockPermissionService)(nil).RemoveRole), ctx, byUser, forUser, roleName)
+}
+
+// RevokeAccess mocks base method.
+func (m *MockPermissionService) RevokeAccess(ctx context.Context, byUser, idGrant string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccess", ctx, byUser, idGrant)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RevokeAccess indicates an expected call of RevokeAccess.
+func (mr *MockPermissionServiceMockRecorder) RevokeAccess(ctx, byUser, idGrant any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSameUserOrHasSomePermission", reflect.TypeOf((*MockPermissionService)(nil).IsSameUserOrHasSomePermission), byUser, forUser, permissions)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccess", reflect.TypeOf((*MockPermissionService)(nil).RevokeAccess), ctx, byUser, idGrant)
 }
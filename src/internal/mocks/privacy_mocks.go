@@ -0,0 +1,138 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: privacy_ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source=privacy_ports.go -destination=../mocks/privacy_mocks.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Manolo-Esc/gommence/src/internal/domain"
+	dtos "github.com/Manolo-Esc/gommence/src/internal/dtos"
+	ports "github.com/Manolo-Esc/gommence/src/internal/ports"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPersonalDataHook is a mock of PersonalDataHook interface.
+type MockPersonalDataHook struct {
+	ctrl     *gomock.Controller
+	recorder *MockPersonalDataHookMockRecorder
+	isgomock struct{}
+}
+
+// MockPersonalDataHookMockRecorder is the mock recorder for MockPersonalDataHook.
+type MockPersonalDataHookMockRecorder struct {
+	mock *MockPersonalDataHook
+}
+
+// NewMockPersonalDataHook creates a new mock instance.
+func NewMockPersonalDataHook(ctrl *gomock.Controller) *MockPersonalDataHook {
+	mock := &MockPersonalDataHook{ctrl: ctrl}
+	mock.recorder = &MockPersonalDataHookMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPersonalDataHook) EXPECT() *MockPersonalDataHookMockRecorder {
+	return m.recorder
+}
+
+// EraseUserData mocks base method.
+func (m *MockPersonalDataHook) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUserData", ctx, user)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// EraseUserData indicates an expected call of EraseUserData.
+func (mr *MockPersonalDataHookMockRecorder) EraseUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUserData", reflect.TypeOf((*MockPersonalDataHook)(nil).EraseUserData), ctx, user)
+}
+
+// ExportUserData mocks base method.
+func (m *MockPersonalDataHook) ExportUserData(ctx context.Context, user *domain.User) (any, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, user)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockPersonalDataHookMockRecorder) ExportUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockPersonalDataHook)(nil).ExportUserData), ctx, user)
+}
+
+// MockPrivacyService is a mock of PrivacyService interface.
+type MockPrivacyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivacyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPrivacyServiceMockRecorder is the mock recorder for MockPrivacyService.
+type MockPrivacyServiceMockRecorder struct {
+	mock *MockPrivacyService
+}
+
+// NewMockPrivacyService creates a new mock instance.
+func NewMockPrivacyService(ctrl *gomock.Controller) *MockPrivacyService {
+	mock := &MockPrivacyService{ctrl: ctrl}
+	mock.recorder = &MockPrivacyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivacyService) EXPECT() *MockPrivacyServiceMockRecorder {
+	return m.recorder
+}
+
+// EraseUser mocks base method.
+func (m *MockPrivacyService) EraseUser(ctx context.Context, idUser, byUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, idUser, byUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockPrivacyServiceMockRecorder) EraseUser(ctx, idUser, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockPrivacyService)(nil).EraseUser), ctx, idUser, byUser)
+}
+
+// ExportUserData mocks base method.
+func (m *MockPrivacyService) ExportUserData(ctx context.Context, idUser, byUser string) (*dtos.UserDataExport, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, idUser, byUser)
+	ret0, _ := ret[0].(*dtos.UserDataExport)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockPrivacyServiceMockRecorder) ExportUserData(ctx, idUser, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockPrivacyService)(nil).ExportUserData), ctx, idUser, byUser)
+}
+
+// Register mocks base method.
+func (m *MockPrivacyService) Register(name string, hook ports.PersonalDataHook) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Register", name, hook)
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockPrivacyServiceMockRecorder) Register(name, hook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockPrivacyService)(nil).Register), name, hook)
+}
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/Manolo-Esc/gommence/src/internal/domain"
 	dtos "github.com/Manolo-Esc/gommence/src/internal/dtos"
@@ -58,6 +59,49 @@ func (mr *MockUserRepositoryMockRecorder) Create(ctx, creationData any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, creationData)
 }
 
+// CreateUsers mocks base method.
+func (m *MockUserRepository) CreateUsers(ctx context.Context, creationData []*dtos.InternalUserCreate) ([]string, []ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUsers", ctx, creationData)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].([]ports.APIError)
+	return ret0, ret1
+}
+
+// CreateUsers indicates an expected call of CreateUsers.
+func (mr *MockUserRepositoryMockRecorder) CreateUsers(ctx, creationData any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUsers", reflect.TypeOf((*MockUserRepository)(nil).CreateUsers), ctx, creationData)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUserRepository) DeleteUser(ctx context.Context, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserRepositoryMockRecorder) DeleteUser(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserRepository)(nil).DeleteUser), ctx, idUser)
+}
+
+// EraseUser mocks base method.
+func (m *MockUserRepository) EraseUser(ctx context.Context, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockUserRepositoryMockRecorder) EraseUser(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockUserRepository)(nil).EraseUser), ctx, idUser)
+}
+
 // GetUserByEmail mocks base method.
 func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, ports.APIError) {
 	m.ctrl.T.Helper()
@@ -88,6 +132,21 @@ func (mr *MockUserRepositoryMockRecorder) GetUserById(ctx, idUser any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserRepository)(nil).GetUserById), ctx, idUser)
 }
 
+// GetUserByIdUnscoped mocks base method.
+func (m *MockUserRepository) GetUserByIdUnscoped(ctx context.Context, idUser string) (*domain.User, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdUnscoped", ctx, idUser)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserByIdUnscoped indicates an expected call of GetUserByIdUnscoped.
+func (mr *MockUserRepositoryMockRecorder) GetUserByIdUnscoped(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdUnscoped", reflect.TypeOf((*MockUserRepository)(nil).GetUserByIdUnscoped), ctx, idUser)
+}
+
 // GetUserIdByEmail mocks base method.
 func (m *MockUserRepository) GetUserIdByEmail(ctx context.Context, email string) string {
 	m.ctrl.T.Helper()
@@ -102,19 +161,268 @@ func (mr *MockUserRepositoryMockRecorder) GetUserIdByEmail(ctx, email any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserIdByEmail), ctx, email)
 }
 
+// GetUserIdByIdentity mocks base method.
+func (m *MockUserRepository) GetUserIdByIdentity(ctx context.Context, issuer, subject string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdByIdentity", ctx, issuer, subject)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetUserIdByIdentity indicates an expected call of GetUserIdByIdentity.
+func (mr *MockUserRepositoryMockRecorder) GetUserIdByIdentity(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdByIdentity", reflect.TypeOf((*MockUserRepository)(nil).GetUserIdByIdentity), ctx, issuer, subject)
+}
+
+// GetUserIdentities mocks base method.
+func (m *MockUserRepository) GetUserIdentities(ctx context.Context, idUser string) ([]*domain.ExternalIdentity, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdentities", ctx, idUser)
+	ret0, _ := ret[0].([]*domain.ExternalIdentity)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserIdentities indicates an expected call of GetUserIdentities.
+func (mr *MockUserRepositoryMockRecorder) GetUserIdentities(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdentities", reflect.TypeOf((*MockUserRepository)(nil).GetUserIdentities), ctx, idUser)
+}
+
 // GetUsers mocks base method.
-func (m *MockUserRepository) GetUsers(ctx context.Context) ([]*domain.User, ports.APIError) {
+func (m *MockUserRepository) GetUsers(ctx context.Context, query dtos.ListQuery) ([]*domain.User, *dtos.ListResult, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", ctx, query)
+	ret0, _ := ret[0].([]*domain.User)
+	ret1, _ := ret[1].(*dtos.ListResult)
+	ret2, _ := ret[2].(ports.APIError)
+	return ret0, ret1, ret2
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockUserRepositoryMockRecorder) GetUsers(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserRepository)(nil).GetUsers), ctx, query)
+}
+
+// GetUsersByEmails mocks base method.
+func (m *MockUserRepository) GetUsersByEmails(ctx context.Context, emails []string) ([]*domain.User, ports.APIError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", ctx)
+	ret := m.ctrl.Call(m, "GetUsersByEmails", ctx, emails)
 	ret0, _ := ret[0].([]*domain.User)
 	ret1, _ := ret[1].(ports.APIError)
 	return ret0, ret1
 }
 
-// GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserRepositoryMockRecorder) GetUsers(ctx any) *gomock.Call {
+// GetUsersByEmails indicates an expected call of GetUsersByEmails.
+func (mr *MockUserRepositoryMockRecorder) GetUsersByEmails(ctx, emails any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByEmails", reflect.TypeOf((*MockUserRepository)(nil).GetUsersByEmails), ctx, emails)
+}
+
+// LinkIdentity mocks base method.
+func (m *MockUserRepository) LinkIdentity(ctx context.Context, idUser string, identity *domain.ExternalIdentity) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkIdentity", ctx, idUser, identity)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// LinkIdentity indicates an expected call of LinkIdentity.
+func (mr *MockUserRepositoryMockRecorder) LinkIdentity(ctx, idUser, identity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkIdentity", reflect.TypeOf((*MockUserRepository)(nil).LinkIdentity), ctx, idUser, identity)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserRepository) RestoreUser(ctx context.Context, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", ctx, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserRepositoryMockRecorder) RestoreUser(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserRepository)(nil).RestoreUser), ctx, idUser)
+}
+
+// SetEmailVerified mocks base method.
+func (m *MockUserRepository) SetEmailVerified(ctx context.Context, idUser string, verifiedAt time.Time) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmailVerified", ctx, idUser, verifiedAt)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// SetEmailVerified indicates an expected call of SetEmailVerified.
+func (mr *MockUserRepositoryMockRecorder) SetEmailVerified(ctx, idUser, verifiedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailVerified", reflect.TypeOf((*MockUserRepository)(nil).SetEmailVerified), ctx, idUser, verifiedAt)
+}
+
+// UpdateEmail mocks base method.
+func (m *MockUserRepository) UpdateEmail(ctx context.Context, idUser, email string, verifiedAt time.Time) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", ctx, idUser, email, verifiedAt)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockUserRepositoryMockRecorder) UpdateEmail(ctx, idUser, email, verifiedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockUserRepository)(nil).UpdateEmail), ctx, idUser, email, verifiedAt)
+}
+
+// UpdateMFA mocks base method.
+func (m *MockUserRepository) UpdateMFA(ctx context.Context, idUser, secret string, enabledAt *time.Time, recoveryCodes []string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMFA", ctx, idUser, secret, enabledAt, recoveryCodes)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// UpdateMFA indicates an expected call of UpdateMFA.
+func (mr *MockUserRepositoryMockRecorder) UpdateMFA(ctx, idUser, secret, enabledAt, recoveryCodes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMFA", reflect.TypeOf((*MockUserRepository)(nil).UpdateMFA), ctx, idUser, secret, enabledAt, recoveryCodes)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, idUser, hashedPassword string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, idUser, hashedPassword)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserRepositoryMockRecorder) UpdatePassword(ctx, idUser, hashedPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepository)(nil).UpdatePassword), ctx, idUser, hashedPassword)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUserRepository) UpdateUser(ctx context.Context, idUser string, update *dtos.UserUpdate) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, idUser, update)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserRepositoryMockRecorder) UpdateUser(ctx, idUser, update any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), ctx, idUser, update)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockUserRepository) UseRecoveryCode(ctx context.Context, idUser, codeHash string) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, idUser, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockUserRepositoryMockRecorder) UseRecoveryCode(ctx, idUser, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockUserRepository)(nil).UseRecoveryCode), ctx, idUser, codeHash)
+}
+
+// MockUserRowReader is a mock of UserRowReader interface.
+type MockUserRowReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserRowReaderMockRecorder
+	isgomock struct{}
+}
+
+// MockUserRowReaderMockRecorder is the mock recorder for MockUserRowReader.
+type MockUserRowReaderMockRecorder struct {
+	mock *MockUserRowReader
+}
+
+// NewMockUserRowReader creates a new mock instance.
+func NewMockUserRowReader(ctrl *gomock.Controller) *MockUserRowReader {
+	mock := &MockUserRowReader{ctrl: ctrl}
+	mock.recorder = &MockUserRowReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserRowReader) EXPECT() *MockUserRowReaderMockRecorder {
+	return m.recorder
+}
+
+// Next mocks base method.
+func (m *MockUserRowReader) Next() (*dtos.UserImportRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Next")
+	ret0, _ := ret[0].(*dtos.UserImportRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Next indicates an expected call of Next.
+func (mr *MockUserRowReaderMockRecorder) Next() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockUserRowReader)(nil).Next))
+}
+
+// MockUserRowWriter is a mock of UserRowWriter interface.
+type MockUserRowWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserRowWriterMockRecorder
+	isgomock struct{}
+}
+
+// MockUserRowWriterMockRecorder is the mock recorder for MockUserRowWriter.
+type MockUserRowWriterMockRecorder struct {
+	mock *MockUserRowWriter
+}
+
+// NewMockUserRowWriter creates a new mock instance.
+func NewMockUserRowWriter(ctrl *gomock.Controller) *MockUserRowWriter {
+	mock := &MockUserRowWriter{ctrl: ctrl}
+	mock.recorder = &MockUserRowWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserRowWriter) EXPECT() *MockUserRowWriterMockRecorder {
+	return m.recorder
+}
+
+// Flush mocks base method.
+func (m *MockUserRowWriter) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockUserRowWriterMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockUserRowWriter)(nil).Flush))
+}
+
+// Write mocks base method.
+func (m *MockUserRowWriter) Write(user *dtos.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockUserRowWriterMockRecorder) Write(user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserRepository)(nil).GetUsers), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockUserRowWriter)(nil).Write), user)
 }
 
 // MockUserService is a mock of UserService interface.
@@ -141,6 +449,35 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// AddUser mocks base method.
+func (m *MockUserService) AddUser(ctx context.Context, byUser string, request dtos.UserCreate) (*domain.User, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", ctx, byUser, request)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockUserServiceMockRecorder) AddUser(ctx, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockUserService)(nil).AddUser), ctx, byUser, request)
+}
+
+// ChangeEmail mocks base method.
+func (m *MockUserService) ChangeEmail(ctx context.Context, idUser, email string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeEmail", ctx, idUser, email)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// ChangeEmail indicates an expected call of ChangeEmail.
+func (mr *MockUserServiceMockRecorder) ChangeEmail(ctx, idUser, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeEmail", reflect.TypeOf((*MockUserService)(nil).ChangeEmail), ctx, idUser, email)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserService) CreateUser(ctx context.Context, creationData *dtos.InternalUserCreate) (string, ports.APIError) {
 	m.ctrl.T.Helper()
@@ -156,6 +493,63 @@ func (mr *MockUserServiceMockRecorder) CreateUser(ctx, creationData any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), ctx, creationData)
 }
 
+// DeleteUser mocks base method.
+func (m *MockUserService) DeleteUser(ctx context.Context, idUser, byUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, idUser, byUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserServiceMockRecorder) DeleteUser(ctx, idUser, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), ctx, idUser, byUser)
+}
+
+// EraseUserData mocks base method.
+func (m *MockUserService) EraseUserData(ctx context.Context, user *domain.User) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUserData", ctx, user)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// EraseUserData indicates an expected call of EraseUserData.
+func (mr *MockUserServiceMockRecorder) EraseUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUserData", reflect.TypeOf((*MockUserService)(nil).EraseUserData), ctx, user)
+}
+
+// ExportUserData mocks base method.
+func (m *MockUserService) ExportUserData(ctx context.Context, user *domain.User) (any, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, user)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockUserServiceMockRecorder) ExportUserData(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockUserService)(nil).ExportUserData), ctx, user)
+}
+
+// ExportUsers mocks base method.
+func (m *MockUserService) ExportUsers(ctx context.Context, byUser string, rows ports.UserRowWriter) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", ctx, byUser, rows)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockUserServiceMockRecorder) ExportUsers(ctx, byUser, rows any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockUserService)(nil).ExportUsers), ctx, byUser, rows)
+}
+
 // GetUserByEmail mocks base method.
 func (m *MockUserService) GetUserByEmail(ctx context.Context, email string) (*domain.User, ports.APIError) {
 	m.ctrl.T.Helper()
@@ -186,17 +580,163 @@ func (mr *MockUserServiceMockRecorder) GetUserById(ctx, idUser, byUser any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserService)(nil).GetUserById), ctx, idUser, byUser)
 }
 
+// GetUserByIdUnscoped mocks base method.
+func (m *MockUserService) GetUserByIdUnscoped(ctx context.Context, idUser string) (*domain.User, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdUnscoped", ctx, idUser)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserByIdUnscoped indicates an expected call of GetUserByIdUnscoped.
+func (mr *MockUserServiceMockRecorder) GetUserByIdUnscoped(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdUnscoped", reflect.TypeOf((*MockUserService)(nil).GetUserByIdUnscoped), ctx, idUser)
+}
+
+// GetUserByIdentity mocks base method.
+func (m *MockUserService) GetUserByIdentity(ctx context.Context, issuer, subject string) (*domain.User, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdentity", ctx, issuer, subject)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// GetUserByIdentity indicates an expected call of GetUserByIdentity.
+func (mr *MockUserServiceMockRecorder) GetUserByIdentity(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdentity", reflect.TypeOf((*MockUserService)(nil).GetUserByIdentity), ctx, issuer, subject)
+}
+
 // GetUsers mocks base method.
-func (m *MockUserService) GetUsers(ctx context.Context, byUser string) ([]*domain.User, ports.APIError) {
+func (m *MockUserService) GetUsers(ctx context.Context, byUser string, query dtos.ListQuery) ([]*domain.User, *dtos.ListResult, ports.APIError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", ctx, byUser)
+	ret := m.ctrl.Call(m, "GetUsers", ctx, byUser, query)
 	ret0, _ := ret[0].([]*domain.User)
+	ret1, _ := ret[1].(*dtos.ListResult)
+	ret2, _ := ret[2].(ports.APIError)
+	return ret0, ret1, ret2
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockUserServiceMockRecorder) GetUsers(ctx, byUser, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserService)(nil).GetUsers), ctx, byUser, query)
+}
+
+// ImportUsers mocks base method.
+func (m *MockUserService) ImportUsers(ctx context.Context, byUser string, rows ports.UserRowReader, dryRun bool) (*dtos.UserImportReport, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportUsers", ctx, byUser, rows, dryRun)
+	ret0, _ := ret[0].(*dtos.UserImportReport)
 	ret1, _ := ret[1].(ports.APIError)
 	return ret0, ret1
 }
 
-// GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserServiceMockRecorder) GetUsers(ctx, byUser any) *gomock.Call {
+// ImportUsers indicates an expected call of ImportUsers.
+func (mr *MockUserServiceMockRecorder) ImportUsers(ctx, byUser, rows, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportUsers", reflect.TypeOf((*MockUserService)(nil).ImportUsers), ctx, byUser, rows, dryRun)
+}
+
+// LinkIdentity mocks base method.
+func (m *MockUserService) LinkIdentity(ctx context.Context, idUser string, identity *domain.ExternalIdentity) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkIdentity", ctx, idUser, identity)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// LinkIdentity indicates an expected call of LinkIdentity.
+func (mr *MockUserServiceMockRecorder) LinkIdentity(ctx, idUser, identity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkIdentity", reflect.TypeOf((*MockUserService)(nil).LinkIdentity), ctx, idUser, identity)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUserService) MarkEmailVerified(ctx context.Context, idUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, idUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserServiceMockRecorder) MarkEmailVerified(ctx, idUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserService)(nil).MarkEmailVerified), ctx, idUser)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserService) RestoreUser(ctx context.Context, idUser, byUser string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", ctx, idUser, byUser)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserServiceMockRecorder) RestoreUser(ctx, idUser, byUser any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserService)(nil).RestoreUser), ctx, idUser, byUser)
+}
+
+// SetPassword mocks base method.
+func (m *MockUserService) SetPassword(ctx context.Context, idUser, hashedPassword string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPassword", ctx, idUser, hashedPassword)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// SetPassword indicates an expected call of SetPassword.
+func (mr *MockUserServiceMockRecorder) SetPassword(ctx, idUser, hashedPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPassword", reflect.TypeOf((*MockUserService)(nil).SetPassword), ctx, idUser, hashedPassword)
+}
+
+// UpdateMFA mocks base method.
+func (m *MockUserService) UpdateMFA(ctx context.Context, idUser, secret string, enabledAt *time.Time, recoveryCodes []string) ports.APIError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMFA", ctx, idUser, secret, enabledAt, recoveryCodes)
+	ret0, _ := ret[0].(ports.APIError)
+	return ret0
+}
+
+// UpdateMFA indicates an expected call of UpdateMFA.
+func (mr *MockUserServiceMockRecorder) UpdateMFA(ctx, idUser, secret, enabledAt, recoveryCodes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMFA", reflect.TypeOf((*MockUserService)(nil).UpdateMFA), ctx, idUser, secret, enabledAt, recoveryCodes)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUserService) UpdateUser(ctx context.Context, idUser, byUser string, request dtos.UserUpdate) (*domain.User, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, idUser, byUser, request)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserServiceMockRecorder) UpdateUser(ctx, idUser, byUser, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserService)(nil).UpdateUser), ctx, idUser, byUser, request)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockUserService) UseRecoveryCode(ctx context.Context, idUser, codeHash string) (bool, ports.APIError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, idUser, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(ports.APIError)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockUserServiceMockRecorder) UseRecoveryCode(ctx, idUser, codeHash any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserService)(nil).GetUsers), ctx, byUser)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockUserService)(nil).UseRecoveryCode), ctx, idUser, codeHash)
 }
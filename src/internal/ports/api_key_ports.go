@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) (string, APIError)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, APIError)
+	// GetUserAPIKeys returns the keys of the user not revoked yet, expired ones included
+	GetUserAPIKeys(ctx context.Context, idUser string) ([]*domain.APIKey, APIError)
+	RevokeAPIKey(ctx context.Context, idKey string) APIError
+	TouchAPIKey(ctx context.Context, idKey string, usedAt time.Time) APIError
+	// DeleteUserAPIKeys removes every key of the user, the revoked ones too
+	DeleteUserAPIKeys(ctx context.Context, idUser string) APIError
+}
+
+type APIKeyService interface {
+	PersonalDataHook
+	CreateAPIKey(ctx context.Context, byUser string, request dtos.APIKeyCreate) (*dtos.CreatedAPIKey, APIError)
+	GetAPIKeys(ctx context.Context, byUser string) ([]*domain.APIKey, APIError)
+	RevokeAPIKey(ctx context.Context, byUser string, idKey string) APIError
+	// AuthenticateAPIKey returns the claims of the key owner, or false if the key is unknown, expired or revoked
+	AuthenticateAPIKey(ctx context.Context, key string) (*jwt.Claims, bool)
+}
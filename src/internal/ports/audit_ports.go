@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+)
+
+type AuditRepository interface {
+	CreateAuditEvent(ctx context.Context, event *domain.AuditEvent) (string, APIError)
+	// GetAuditEvents returns the newest events first
+	GetAuditEvents(ctx context.Context, query dtos.AuditQuery) ([]*domain.AuditEvent, APIError)
+	// GetUserAuditEvents returns the events done by the user or to the user, known by the id or by the email
+	GetUserAuditEvents(ctx context.Context, idUser string, email string) ([]*domain.AuditEvent, APIError)
+	// AnonymizeUserAuditEvents removes the user from the events, which are kept
+	AnonymizeUserAuditEvents(ctx context.Context, idUser string, email string) APIError
+}
+
+type AuditService interface {
+	PersonalDataHook
+	// Record stores the event. Failures are logged, they never stop the audited action
+	Record(ctx context.Context, event *domain.AuditEvent)
+	GetEvents(ctx context.Context, byUser string, query dtos.AuditQuery) ([]*domain.AuditEvent, APIError)
+}
@@ -2,10 +2,77 @@ package ports
 
 import (
 	"context"
+	"time"
 
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
 )
 
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) (string, APIError)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, APIError)
+	// MarkRefreshTokenUsed returns false if the token was already used or revoked
+	MarkRefreshTokenUsed(ctx context.Context, idToken string) (bool, APIError)
+	RevokeRefreshTokenFamily(ctx context.Context, familyId string) APIError
+	RevokeUserRefreshTokens(ctx context.Context, userId string) APIError
+	DeleteUserRefreshTokens(ctx context.Context, userId string) APIError
+	RevokeAccessToken(ctx context.Context, tokenId string, userId string, expiresAt time.Time) APIError
+	IsAccessTokenRevoked(ctx context.Context, tokenId string) (bool, APIError)
+	// DeleteExpiredTokens removes the refresh tokens expired or revoked, and the revoked access tokens expired, before the time
+	DeleteExpiredTokens(ctx context.Context, before time.Time) APIError
+}
+
+type ActionTokenRepository interface {
+	CreateActionToken(ctx context.Context, token *domain.ActionToken) (string, APIError)
+	GetActionTokenByHash(ctx context.Context, purpose domain.ActionTokenPurpose, tokenHash string) (*domain.ActionToken, APIError)
+	// MarkActionTokenUsed returns false if the token was already used
+	MarkActionTokenUsed(ctx context.Context, idToken string) (bool, APIError)
+	// InvalidateActionTokens marks as used the pending tokens of the user for the purpose, so only the newest one works
+	InvalidateActionTokens(ctx context.Context, userId string, purpose domain.ActionTokenPurpose) APIError
+	DeleteUserActionTokens(ctx context.Context, userId string) APIError
+	// DeleteExpiredActionTokens removes the tokens used, or expired before the time
+	DeleteExpiredActionTokens(ctx context.Context, before time.Time) APIError
+}
+
+// MFARepository keeps the state of the two-factor sign ins, so every server shares it and it survives restarts
+type MFARepository interface {
+	CreateMFAChallenge(ctx context.Context, challenge *domain.MFAChallenge) APIError
+	// AddMFAChallengeAttempt counts an attempt in a single statement and returns the challenge with it. Not found if it
+	// does not exist or has expired
+	AddMFAChallengeAttempt(ctx context.Context, tokenHash string, now time.Time) (*domain.MFAChallenge, APIError)
+	// DeleteMFAChallenge returns false if the challenge was already gone, e.g. used by a concurrent request
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) (bool, APIError)
+	// UseTOTPStep records that the user used the code of the time step. False if it was already used
+	UseTOTPStep(ctx context.Context, userId string, step int64, expiresAt time.Time) (bool, APIError)
+	DeleteUserMFA(ctx context.Context, userId string) APIError
+	// DeleteExpiredMFA removes the challenges and the used codes expired before the time
+	DeleteExpiredMFA(ctx context.Context, before time.Time) APIError
+}
+
 type AuthService interface {
-	Login(ctx context.Context, credentials dtos.LoginCredentials) (*dtos.LoggedUser, APIError)
+	PersonalDataHook
+	Login(ctx context.Context, credentials dtos.LoginCredentials, clientIP string) (*dtos.LoggedUser, APIError)
+	SignUp(ctx context.Context, signUp dtos.UserSignUp) (*dtos.LoggedUser, APIError)
+	Refresh(ctx context.Context, request dtos.RefreshTokenRequest) (*dtos.LoggedUser, APIError)
+	SwitchTenant(ctx context.Context, byUser string, request dtos.TenantSwitch) (*dtos.LoggedUser, APIError)
+	SignOut(ctx context.Context, byUser string, tokenId string, tokenExpiration time.Time, request dtos.SignOutRequest) APIError
+	IsTokenRevoked(ctx context.Context, tokenId string) bool
+	RequestPasswordReset(ctx context.Context, request dtos.PasswordResetRequest) APIError
+	ConfirmPasswordReset(ctx context.Context, request dtos.PasswordResetConfirm) APIError
+	RequestEmailVerification(ctx context.Context, request dtos.EmailVerificationRequest) APIError
+	VerifyEmail(ctx context.Context, request dtos.VerifyEmailRequest) APIError
+	ChangePassword(ctx context.Context, byUser string, request dtos.PasswordChange, clientIP string) (*dtos.LoggedUser, APIError)
+	RequestEmailChange(ctx context.Context, byUser string, request dtos.EmailChangeRequest, clientIP string) APIError
+	// SetUserPassword does not ask for the current password, the caller must be an administrator
+	SetUserPassword(ctx context.Context, byUser string, forUser string, password string) APIError
+	ConfirmEmailChange(ctx context.Context, request dtos.EmailChangeConfirm) APIError
+	StartOIDCLogin(ctx context.Context) (*dtos.OIDCStart, APIError)
+	CompleteOIDCLogin(ctx context.Context, callback dtos.OIDCCallback) (*dtos.LoggedUser, APIError)
+	VerifyMFA(ctx context.Context, request dtos.MFAVerifyRequest, clientIP string) (*dtos.LoggedUser, APIError)
+	StartMFAEnrollment(ctx context.Context, byUser string) (*dtos.MFAEnrollment, APIError)
+	ConfirmMFAEnrollment(ctx context.Context, byUser string, request dtos.MFACode) (*dtos.MFARecoveryCodes, APIError)
+	RegenerateRecoveryCodes(ctx context.Context, byUser string, request dtos.MFACode) (*dtos.MFARecoveryCodes, APIError)
+	DisableMFA(ctx context.Context, byUser string, request dtos.MFACode) APIError
+	// PurgeExpired deletes the tokens and the failed sign ins that are no longer needed. It is meant to run from time to time
+	PurgeExpired(ctx context.Context) APIError
 }
@@ -0,0 +1,36 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+type LoginAttemptRepository interface {
+	GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, APIError)
+	// AddLoginFailure counts a failure of the key in a single statement and returns the attempts with it. The failures
+	// before windowStart are forgotten first
+	AddLoginFailure(ctx context.Context, key string, at time.Time, windowStart time.Time) (*domain.LoginAttempts, APIError)
+	// LockLoginAttempts locks the key until the time and forgets its failures, if it still has minFailures.
+	// False if it does not, e.g. a concurrent failure locked it first
+	LockLoginAttempts(ctx context.Context, key string, minFailures int, lockedUntil time.Time) (bool, APIError)
+	DeleteLoginAttempts(ctx context.Context, key string) APIError
+	// DeleteLoginAttemptsBefore removes the attempts whose last failure and lockout are older than the time
+	DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) APIError
+}
+
+// LoginThrottler slows down and eventually locks the sign ins of accounts and IP addresses with too many failures
+type LoginThrottler interface {
+	// Check returns an error if the sign in must be refused before looking at the credentials
+	Check(ctx context.Context, email string, clientIP string) APIError
+	Failed(ctx context.Context, email string, clientIP string)
+	Succeeded(ctx context.Context, email string, clientIP string)
+	// CheckMFA, FailedMFA and SucceededMFA do the same with the codes of the second factor of the user. They are counted
+	// apart, whatever the challenges, so signing in again with the password does not give more attempts
+	CheckMFA(ctx context.Context, userId string) APIError
+	FailedMFA(ctx context.Context, userId string, clientIP string)
+	SucceededMFA(ctx context.Context, userId string)
+	// Purge forgets the failures too old to count
+	Purge(ctx context.Context) APIError
+}
@@ -0,0 +1,14 @@
+package ports
+
+import "context"
+
+// MailMessage is a plain text email
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message MailMessage) error
+}
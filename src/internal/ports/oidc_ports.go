@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+)
+
+// IdentityProvider is an external OIDC provider where users sign in with the authorization code flow and PKCE
+type IdentityProvider interface {
+	// AuthCodeURL returns the provider URL where the user must be sent to sign in. The PKCE challenge is derived from codeVerifier
+	AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error)
+	// Exchange trades the authorization code for the tokens and returns the identity asserted in the verified ID token
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*domain.ExternalIdentity, error)
+}
+
+// OIDCFlowRepository keeps the sign ins with the provider in progress, so the callback can reach any instance of the server
+type OIDCFlowRepository interface {
+	CreateOIDCFlow(ctx context.Context, flow *domain.OIDCFlow) APIError
+	// TakeOIDCFlow deletes the flow and returns it, so it is used once. Not found if it does not exist or has expired
+	TakeOIDCFlow(ctx context.Context, stateHash string, now time.Time) (*domain.OIDCFlow, APIError)
+	DeleteExpiredOIDCFlows(ctx context.Context, before time.Time) APIError
+}
@@ -0,0 +1,34 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+)
+
+type OrganizationRepository interface {
+	CreateOrganization(ctx context.Context, organization *domain.Organization) (string, APIError)
+	// GetUserOrganizations returns the organizations of the user, the one joined first goes first
+	GetUserOrganizations(ctx context.Context, idUser string) ([]*domain.Organization, APIError)
+	GetMembership(ctx context.Context, idOrganization string, idUser string) (*domain.Membership, APIError)
+	// SaveMembership adds the user to the organization, or changes the role if already a member
+	SaveMembership(ctx context.Context, membership *domain.Membership) APIError
+	// GetMembers returns the members of the tenant in the context
+	GetMembers(ctx context.Context) ([]*domain.Membership, APIError)
+	// DeleteMembership removes the user from the tenant in the context
+	DeleteMembership(ctx context.Context, idUser string) APIError
+	// DeleteUserMemberships removes the user from every organization
+	DeleteUserMemberships(ctx context.Context, idUser string) APIError
+}
+
+type OrganizationService interface {
+	PersonalDataHook
+	CreateOrganization(ctx context.Context, byUser string, request dtos.OrganizationCreate) (*domain.Organization, APIError)
+	GetUserOrganizations(ctx context.Context, forUser string, byUser string) ([]*domain.Organization, APIError)
+	GetMembership(ctx context.Context, idOrganization string, idUser string) (*domain.Membership, APIError)
+	// The following act on the tenant in the context
+	GetMembers(ctx context.Context, byUser string) ([]*domain.Membership, APIError)
+	AddMember(ctx context.Context, byUser string, request dtos.MemberAdd) APIError
+	RemoveMember(ctx context.Context, byUser string, idUser string) APIError
+}
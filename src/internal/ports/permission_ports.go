@@ -4,13 +4,54 @@ import (
 	"context"
 
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
 )
 
 type PermissionRepository interface {
-	//CreateCourse(ctx context.Context, creationParams *dtos.CourseCreation) (*dtos.Course, error)
+	GetRoles(ctx context.Context) ([]*domain.Role, APIError)
+	GetRoleByName(ctx context.Context, name string) (*domain.Role, APIError)
+	CreateRole(ctx context.Context, role *domain.Role) (string, APIError)
+	GetUserRoles(ctx context.Context, idUser string) ([]*domain.Role, APIError)
+	// AddUserRole does nothing if the user already has the role. Unknown users are not found
+	AddUserRole(ctx context.Context, idUser string, idRole string) APIError
+	RemoveUserRole(ctx context.Context, idUser string, idRole string) APIError
+	GetGroups(ctx context.Context) ([]*domain.Group, APIError)
+	GetGroupByName(ctx context.Context, name string) (*domain.Group, APIError)
+	CreateGroup(ctx context.Context, group *domain.Group) (string, APIError)
+	GetGroupMembers(ctx context.Context, idGroup string) ([]string, APIError)
+	GetUserGroups(ctx context.Context, idUser string) ([]*domain.Group, APIError)
+	// AddGroupMember does nothing if the user is already a member. Unknown users are not found
+	AddGroupMember(ctx context.Context, idGroup string, idUser string) APIError
+	RemoveGroupMember(ctx context.Context, idGroup string, idUser string) APIError
+	CreateGrant(ctx context.Context, grant *domain.Grant) (string, APIError)
+	GetGrant(ctx context.Context, idGrant string) (*domain.Grant, APIError)
+	DeleteGrant(ctx context.Context, idGrant string) APIError
+	GetResourceGrants(ctx context.Context, resourceType string, resourceID string) ([]*domain.Grant, APIError)
+	// GetUserGrants returns the grants given to the user and to the groups of the user
+	GetUserGrants(ctx context.Context, idUser string) ([]*domain.Grant, APIError)
+	// DeleteUserPermissions removes the roles of the user, the memberships of groups and the grants given to the user
+	DeleteUserPermissions(ctx context.Context, idUser string) APIError
 }
 
 type PermissionService interface {
-	IsSameUserOrHasSomePermission(byUser string, forUser string, permissions []domain.Permission) (bool, APIError)
+	PersonalDataHook
+	IsSameUserOrHasSomePermission(ctx context.Context, byUser string, forUser string, permissions []domain.Permission) (bool, APIError)
 	GetUserGlobalPermissions(ctx context.Context, forUser string, byUser string) ([]domain.Permission, APIError)
+	GetUserRoles(ctx context.Context, forUser string, byUser string) ([]*domain.Role, APIError)
+	GetRoles(ctx context.Context, byUser string) ([]*domain.Role, APIError)
+	CreateRole(ctx context.Context, byUser string, request dtos.RoleCreate) (*domain.Role, APIError)
+	AssignRole(ctx context.Context, byUser string, forUser string, roleName string) APIError
+	RemoveRole(ctx context.Context, byUser string, forUser string, roleName string) APIError
+	// Can tells whether the subject can do the action on the resource, either by being an administrator or by a grant.
+	// Being denied is not an error
+	Can(ctx context.Context, subject string, action domain.Permission, resourceType string, resourceID string) (bool, APIError)
+	// AllowedResources tells which resources of the type the subject can do the action on
+	AllowedResources(ctx context.Context, subject string, action domain.Permission, resourceType string) (*domain.ResourceFilter, APIError)
+	GetResourceGrants(ctx context.Context, byUser string, resourceType string, resourceID string) ([]*domain.Grant, APIError)
+	GrantAccess(ctx context.Context, byUser string, resourceType string, resourceID string, request dtos.GrantCreate) (*domain.Grant, APIError)
+	RevokeAccess(ctx context.Context, byUser string, idGrant string) APIError
+	GetGroups(ctx context.Context, byUser string) ([]*domain.Group, APIError)
+	CreateGroup(ctx context.Context, byUser string, request dtos.GroupCreate) (*domain.Group, APIError)
+	AddGroupMember(ctx context.Context, byUser string, groupName string, forUser string) APIError
+	RemoveGroupMember(ctx context.Context, byUser string, groupName string, forUser string) APIError
 }
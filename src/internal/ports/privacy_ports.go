@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+)
+
+// PersonalDataHook is implemented by the modules that store data about the users, so the privacy requests (GDPR) cover
+// that data too. The modules are registered in the PrivacyService when the application starts
+type PersonalDataHook interface {
+	// ExportUserData returns what the module stores about the user, as something that encodes to JSON. Nil if nothing.
+	// Secrets, like password hashes, are left out
+	ExportUserData(ctx context.Context, user *domain.User) (interface{}, APIError)
+	// EraseUserData deletes what the module stores about the user, or anonymizes what must be kept. Running it twice is harmless
+	EraseUserData(ctx context.Context, user *domain.User) APIError
+}
+
+type PrivacyService interface {
+	// Register adds the hook of a module. The name is the section of the module in the exports
+	Register(name string, hook PersonalDataHook)
+	ExportUserData(ctx context.Context, idUser string, byUser string) (*dtos.UserDataExport, APIError)
+	EraseUser(ctx context.Context, idUser string, byUser string) APIError
+}
@@ -0,0 +1,11 @@
+package ports
+
+import "context"
+
+// TxManager runs several repository calls atomically. The repositories pick up the transaction from the context
+// given to fn, so it must be passed down to every call that belongs to the transaction
+type TxManager interface {
+	// WithinTx commits what fn does if it returns nil, and rolls it back otherwise. A WithinTx inside another one uses
+	// a savepoint: its failure only undoes its own work, and the outer transaction carries on if fn handles the error
+	WithinTx(ctx context.Context, fn func(ctx context.Context) APIError) APIError
+}
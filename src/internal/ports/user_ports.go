@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
@@ -10,15 +11,67 @@ import (
 type UserRepository interface {
 	//GetByID(ctx context.Context, id string) (*domain.User, APIError)
 	Create(ctx context.Context, creationData *dtos.InternalUserCreate) (string, APIError)
+	// CreateUsers inserts the users in one go. It returns the id of each user, or why it could not be created
+	CreateUsers(ctx context.Context, creationData []*dtos.InternalUserCreate) ([]string, []APIError)
 	GetUserById(ctx context.Context, idUser string) (*domain.User, APIError)
+	// GetUserByIdUnscoped also finds the deleted users
+	GetUserByIdUnscoped(ctx context.Context, idUser string) (*domain.User, APIError)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, APIError)
 	GetUserIdByEmail(ctx context.Context, email string) string
-	GetUsers(ctx context.Context) ([]*domain.User, APIError)
+	// GetUsersByEmails returns the users with any of the emails, ignoring the case
+	GetUsersByEmails(ctx context.Context, emails []string) ([]*domain.User, APIError)
+	GetUsers(ctx context.Context, query dtos.ListQuery) ([]*domain.User, *dtos.ListResult, APIError)
+	UpdateUser(ctx context.Context, idUser string, update *dtos.UserUpdate) APIError
+	// DeleteUser is a soft delete: the user is kept, hidden, and can be restored
+	DeleteUser(ctx context.Context, idUser string) APIError
+	RestoreUser(ctx context.Context, idUser string) APIError
+	SetEmailVerified(ctx context.Context, idUser string, verifiedAt time.Time) APIError
+	UpdatePassword(ctx context.Context, idUser string, hashedPassword string) APIError
+	// UpdateEmail fails with a 409 if another user has the email
+	UpdateEmail(ctx context.Context, idUser string, email string, verifiedAt time.Time) APIError
+	GetUserIdByIdentity(ctx context.Context, issuer string, subject string) string
+	LinkIdentity(ctx context.Context, idUser string, identity *domain.ExternalIdentity) APIError
+	GetUserIdentities(ctx context.Context, idUser string) ([]*domain.ExternalIdentity, APIError)
+	// EraseUser removes the user for good, deleted or not, along with the identities linked to it
+	EraseUser(ctx context.Context, idUser string) APIError
+	UpdateMFA(ctx context.Context, idUser string, secret string, enabledAt *time.Time, recoveryCodes []string) APIError
+	// UseRecoveryCode returns false if the user does not have the code (any more)
+	UseRecoveryCode(ctx context.Context, idUser string, codeHash string) (bool, APIError)
+}
+
+// UserRowReader reads the users of an import file, one at a time. Next returns io.EOF after the last one.
+// A line that can not be read is returned as a row with the Problem set, so the import goes on
+type UserRowReader interface {
+	Next() (*dtos.UserImportRow, error)
+}
+
+// UserRowWriter writes the users of an export file. Flush must be called after the last one
+type UserRowWriter interface {
+	Write(user *dtos.User) error
+	Flush() error
 }
 
 type UserService interface {
+	PersonalDataHook
 	CreateUser(ctx context.Context, creationData *dtos.InternalUserCreate) (string, APIError)
 	GetUserById(ctx context.Context, idUser string, byUser string) (*domain.User, APIError)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, APIError)
-	GetUsers(ctx context.Context, byUser string) ([]*domain.User, APIError)
+	GetUserByIdUnscoped(ctx context.Context, idUser string) (*domain.User, APIError)
+	GetUsers(ctx context.Context, byUser string, query dtos.ListQuery) ([]*domain.User, *dtos.ListResult, APIError)
+	AddUser(ctx context.Context, byUser string, request dtos.UserCreate) (*domain.User, APIError)
+	UpdateUser(ctx context.Context, idUser string, byUser string, request dtos.UserUpdate) (*domain.User, APIError)
+	DeleteUser(ctx context.Context, idUser string, byUser string) APIError
+	RestoreUser(ctx context.Context, idUser string, byUser string) APIError
+	MarkEmailVerified(ctx context.Context, idUser string) APIError
+	SetPassword(ctx context.Context, idUser string, hashedPassword string) APIError
+	ChangeEmail(ctx context.Context, idUser string, email string) APIError
+	GetUserByIdentity(ctx context.Context, issuer string, subject string) (*domain.User, APIError)
+	LinkIdentity(ctx context.Context, idUser string, identity *domain.ExternalIdentity) APIError
+	UpdateMFA(ctx context.Context, idUser string, secret string, enabledAt *time.Time, recoveryCodes []string) APIError
+	UseRecoveryCode(ctx context.Context, idUser string, codeHash string) (bool, APIError)
+	// ImportUsers creates the users of the file, or updates them if the email is already registered. Only for
+	// administrators, or operators from the command line. byUser is recorded in the audit log
+	ImportUsers(ctx context.Context, byUser string, rows UserRowReader, dryRun bool) (*dtos.UserImportReport, APIError)
+	// ExportUsers writes every user, oldest first. Only for administrators, or operators from the command line
+	ExportUsers(ctx context.Context, byUser string, rows UserRowWriter) APIError
 }
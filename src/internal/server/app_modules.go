@@ -2,6 +2,7 @@ package server
 
 import (
 	"github.com/Manolo-Esc/gommence/src/internal/adapters/repos_db"
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/repos_mem"
 	"github.com/Manolo-Esc/gommence/src/internal/app"
 	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	"github.com/Manolo-Esc/gommence/src/pkg/cache"
@@ -10,27 +11,98 @@ import (
 )
 
 type AppModules struct {
-	auth       *ports.AuthService
-	permission *ports.PermissionService
-	user       *ports.UserService
+	apiKey       *ports.APIKeyService
+	audit        *ports.AuditService
+	auth         *ports.AuthService
+	organization *ports.OrganizationService
+	permission   *ports.PermissionService
+	privacy      *ports.PrivacyService
+	user         *ports.UserService
 }
 
-func ProductionAppModulesFactory(logger logger.LoggerService, db *gorm.DB, cache cache.CacheService) *AppModules {
-	dbInfra := repos_db.DBReposInfra{
+// Repositories are the storage of the modules: the database, or memory to run without one
+type Repositories struct {
+	APIKey       ports.APIKeyRepository
+	ActionToken  ports.ActionTokenRepository
+	Audit        ports.AuditRepository
+	LoginAttempt ports.LoginAttemptRepository
+	MFA          ports.MFARepository
+	OIDCFlow     ports.OIDCFlowRepository
+	Organization ports.OrganizationRepository
+	Permission   ports.PermissionRepository
+	Token        ports.TokenRepository
+	User         ports.UserRepository
+	Tx           ports.TxManager
+}
+
+func DBRepositories(logger logger.LoggerService, db *gorm.DB) Repositories {
+	dbInfra := &repos_db.DBReposInfra{
 		Db:     db,
 		Logger: logger,
 	}
-	permission := app.NewPermissionService(repos_db.NewPermissionRepository(&dbInfra), cache, logger)
+	return Repositories{
+		APIKey:       repos_db.NewAPIKeyRepository(dbInfra),
+		ActionToken:  repos_db.NewActionTokenRepository(dbInfra),
+		Audit:        repos_db.NewAuditRepository(dbInfra),
+		LoginAttempt: repos_db.NewLoginAttemptRepository(dbInfra),
+		MFA:          repos_db.NewMFARepository(dbInfra),
+		OIDCFlow:     repos_db.NewOIDCFlowRepository(dbInfra),
+		Organization: repos_db.NewOrganizationRepository(dbInfra),
+		Permission:   repos_db.NewPermissionRepository(dbInfra),
+		Token:        repos_db.NewTokenRepository(dbInfra),
+		User:         repos_db.NewUserRepository(dbInfra),
+		Tx:           repos_db.NewTxManager(dbInfra),
+	}
+}
+
+// MemoryRepositories keep the data in memory, empty at the start. Everything is lost when the server stops
+func MemoryRepositories() Repositories {
+	memInfra := repos_mem.NewMemReposInfra()
+	return Repositories{
+		APIKey:       repos_mem.NewAPIKeyRepository(memInfra),
+		ActionToken:  repos_mem.NewActionTokenRepository(memInfra),
+		Audit:        repos_mem.NewAuditRepository(memInfra),
+		LoginAttempt: repos_mem.NewLoginAttemptRepository(memInfra),
+		MFA:          repos_mem.NewMFARepository(memInfra),
+		OIDCFlow:     repos_mem.NewOIDCFlowRepository(memInfra),
+		Organization: repos_mem.NewOrganizationRepository(memInfra),
+		Permission:   repos_mem.NewPermissionRepository(memInfra),
+		Token:        repos_mem.NewTokenRepository(memInfra),
+		User:         repos_mem.NewUserRepository(memInfra),
+		Tx:           repos_mem.NewTxManager(memInfra),
+	}
+}
+
+func ProductionAppModulesFactory(logger logger.LoggerService, repos Repositories, cache cache.CacheService, mailer ports.Mailer,
+	identityProvider ports.IdentityProvider, authConfig app.AuthConfig) *AppModules {
 	serviceInfra := app.ServiceInfra{
-		Logger:      logger,
-		Cache:       cache,
-		Permissions: permission,
+		Logger: logger,
+		Cache:  cache,
+		Tx:     repos.Tx,
 	}
-	user := app.NewUserService(repos_db.NewUserRepository(&dbInfra), &serviceInfra)
-	auth := app.NewAuthService(&serviceInfra, user)
+	audit := app.NewAuditService(repos.Audit, &serviceInfra) // it reads the permissions once set below
+	permission := app.NewPermissionService(repos.Permission, audit, cache, logger)
+	serviceInfra.Permissions = permission
+	organization := app.NewOrganizationService(repos.Organization, &serviceInfra)
+	serviceInfra.Organizations = organization
+	apiKey := app.NewAPIKeyService(repos.APIKey, audit, &serviceInfra)
+	user := app.NewUserService(repos.User, repos.Token, audit, &serviceInfra, authConfig.PasswordPolicy)
+	throttler := app.NewLoginThrottle(&serviceInfra, repos.LoginAttempt, audit, authConfig.Lockout)
+	auth := app.NewAuthService(&serviceInfra, user, repos.Token, repos.ActionToken, repos.MFA, repos.OIDCFlow, throttler, mailer, identityProvider, authConfig)
+	privacy := app.NewPrivacyService(user, audit, &serviceInfra)
+	privacy.Register("user", user) // first, so it is erased last
+	privacy.Register("auth", auth)
+	privacy.Register("api_keys", apiKey)
+	privacy.Register("permissions", permission)
+	privacy.Register("organizations", organization)
+	privacy.Register("audit", audit)
 	return &AppModules{
-		auth:       &auth,
-		permission: &permission,
-		user:       &user,
+		apiKey:       &apiKey,
+		audit:        &audit,
+		auth:         &auth,
+		organization: &organization,
+		permission:   &permission,
+		privacy:      &privacy,
+		user:         &user,
 	}
 }
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/user_file"
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/database"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const commandsUsage = `usage:
+  serve                                              start the web server, the default when no command is given
+  config print                                       print the configuration, with the secrets hidden
+  migrate up                                         apply the pending migrations of the database
+  migrate down [N]                                   undo the last N migrations applied, 1 by default
+  migrate status                                     list the migrations and whether they are applied
+  seed [-dev]                                        store the default roles and system users that are missing. -dev also
+                                                     gives the system users their development roles, never in production
+  user create -email EMAIL -first-name NAME -last-name NAME [-second-last-name NAME] [-role ROLE]
+                                                     create a user, with the password read from the standard input
+  user set-password EMAIL                            replace the password of the user with the one read from the standard input
+  user grant EMAIL ROLE                              give the role to the user
+  user import [-dry-run] [-format csv|jsonl] FILE    import the users of the file, - reads the standard input
+  user export [-format csv|jsonl] [FILE]             write every user to the file, or to the standard output
+  token mint [-ttl DURATION] [-tenant ORG_ID] [-scopes read,write] EMAIL
+                                                     print an access token of the user, to debug the API`
+
+// runCommand runs the command given in the command line instead of the server. The commands act as an operator of
+// the server, with every permission
+func runCommand(ctx context.Context, args []string, appModules *AppModules, db *gorm.DB, stdin io.Reader, stdout io.Writer) error {
+	ctx = domain.AsOperator(ctx)
+	switch {
+	case args[0] == "seed":
+		return seedCommand(ctx, args[1:], db)
+	case (args[0] == "user" || args[0] == "users") && len(args) > 1: // users, as it was first named
+		return userCommand(ctx, args[1:], appModules, stdin, stdout)
+	case args[0] == "token" && len(args) > 1 && args[1] == "mint":
+		return mintTokenCommand(ctx, args[2:], appModules, stdout)
+	default:
+		return fmt.Errorf("unknown command '%s'\n%s", strings.Join(args, " "), commandsUsage)
+	}
+}
+
+// seedCommand gives the development roles only when asked: the system users have a known password
+func seedCommand(ctx context.Context, args []string, db *gorm.DB) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	dev := flags.Bool("dev", false, "give the system users their development roles")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments '%s'\n%s", strings.Join(flags.Args(), " "), commandsUsage)
+	}
+	if err := database.Seed(ctx, db); err != nil {
+		return err
+	}
+	if *dev {
+		repos := DBRepositories(logger.GetLogger(), db)
+		return database.SeedDevelopmentRoles(ctx, repos.User, repos.Permission)
+	}
+	return nil
+}
+
+func userCommand(ctx context.Context, args []string, appModules *AppModules, stdin io.Reader, stdout io.Writer) error {
+	switch args[0] {
+	case "create":
+		return createUserCommand(ctx, args[1:], appModules, stdin, stdout)
+	case "set-password":
+		return setPasswordCommand(ctx, args[1:], appModules, stdin, stdout)
+	case "grant":
+		return grantRoleCommand(ctx, args[1:], appModules, stdout)
+	case "import":
+		return importUsersCommand(ctx, args[1:], appModules, stdin, stdout)
+	case "export":
+		return exportUsersCommand(ctx, args[1:], appModules, stdout)
+	default:
+		return fmt.Errorf("unknown command 'user %s'\n%s", args[0], commandsUsage)
+	}
+}
+
+// createUserCommand prints the id of the new user. The email is considered verified, the operator vouches for it
+func createUserCommand(ctx context.Context, args []string, appModules *AppModules, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	var request dtos.UserCreate
+	flags.StringVar(&request.Email, "email", "", "email of the user")
+	flags.StringVar(&request.FirstName, "first-name", "", "first name of the user")
+	flags.StringVar(&request.FirstLastName, "last-name", "", "last name of the user")
+	flags.StringVar(&request.SecondLastName, "second-last-name", "", "second last name of the user")
+	role := flags.String("role", "", "role given to the user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments '%s'\n%s", strings.Join(flags.Args(), " "), commandsUsage)
+	}
+	var err error
+	if request.Secret, err = readPassword(stdin); err != nil {
+		return err
+	}
+	user, errAdd := (*appModules.user).AddUser(ctx, "", request)
+	if errAdd != nil {
+		return errAdd
+	}
+	if err := (*appModules.user).MarkEmailVerified(ctx, user.ID); err != nil {
+		return err
+	}
+	if *role != "" {
+		if err := (*appModules.permission).AssignRole(ctx, "", user.ID, *role); err != nil {
+			return fmt.Errorf("user %s created, but the role could not be given: %w", user.ID, err)
+		}
+	}
+	fmt.Fprintln(stdout, user.ID)
+	return nil
+}
+
+// setPasswordCommand closes every session of the user, and unlocks the account if it was locked by failed sign ins
+func setPasswordCommand(ctx context.Context, args []string, appModules *AppModules, stdin io.Reader, stdout io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("the email of the user is missing\n%s", commandsUsage)
+	}
+	user, err := (*appModules.user).GetUserByEmail(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	password, errRead := readPassword(stdin)
+	if errRead != nil {
+		return errRead
+	}
+	if err := (*appModules.auth).SetUserPassword(ctx, "", user.ID, password); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "password of %s changed\n", user.Email)
+	return nil
+}
+
+func grantRoleCommand(ctx context.Context, args []string, appModules *AppModules, stdout io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("the email of the user or the role are missing\n%s", commandsUsage)
+	}
+	user, err := (*appModules.user).GetUserByEmail(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if err := (*appModules.permission).AssignRole(ctx, "", user.ID, args[1]); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "role %s given to %s\n", args[1], user.Email)
+	return nil
+}
+
+// readPassword reads the first line of the input, so the password is not left in the history of the shell
+func readPassword(stdin io.Reader) (string, error) {
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("the password is read from the standard input, and it is empty")
+	}
+	return password, nil
+}
+
+// importUsersCommand prints the lines that failed and the totals. It fails if any line did
+func importUsersCommand(ctx context.Context, args []string, appModules *AppModules, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("user import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "check the file without storing anything")
+	formatName := flags.String("format", "", "csv or jsonl, by default taken from the extension of the file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("the file to import is missing\n%s", commandsUsage)
+	}
+	path := flags.Arg(0)
+	format, err := commandFormat(*formatName, path)
+	if err != nil {
+		return err
+	}
+	input := stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+	rows, err := user_file.NewReader(input, format)
+	if err != nil {
+		return err
+	}
+	report, errImport := (*appModules.user).ImportUsers(ctx, "", rows, *dryRun)
+	if errImport != nil {
+		return errImport
+	}
+	for _, row := range report.Rows {
+		if row.Action == dtos.UserImportFailed {
+			fmt.Fprintf(stdout, "line %d %s: %s\n", row.Line, row.Email, row.Error)
+		}
+	}
+	dryRunNote := ""
+	if report.DryRun {
+		dryRunNote = " (dry run, nothing stored)"
+	}
+	fmt.Fprintf(stdout, "created %d, updated %d, unchanged %d, failed %d%s\n", report.Created, report.Updated, report.Unchanged, report.Failed, dryRunNote)
+	if report.Failed > 0 {
+		return fmt.Errorf("%d lines failed", report.Failed)
+	}
+	return nil
+}
+
+func exportUsersCommand(ctx context.Context, args []string, appModules *AppModules, stdout io.Writer) error {
+	flags := flag.NewFlagSet("user export", flag.ContinueOnError)
+	formatName := flags.String("format", "", "csv or jsonl, by default taken from the extension of the file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	path := flags.Arg(0)
+	format, err := commandFormat(*formatName, path)
+	if err != nil {
+		return err
+	}
+	if path == "" || path == "-" {
+		if err := (*appModules.user).ExportUsers(ctx, "", user_file.NewWriter(stdout, format)); err != nil {
+			return err
+		}
+		return nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := (*appModules.user).ExportUsers(ctx, "", user_file.NewWriter(file, format)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// migrateCommand changes the version of the database. Unlike the rest, it runs before the database is migrated
+func migrateCommand(ctx context.Context, args []string, db *gorm.DB, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("the migrate command is missing\n%s", commandsUsage)
+	}
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		return database.Migrate(ctx, db)
+	case args[0] == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations '%s'", args[1])
+			}
+		}
+		return database.MigrateDown(ctx, db, steps)
+	case args[0] == "status" && len(args) == 1:
+		states, err := database.MigrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = state.AppliedAt.Format(time.DateTime)
+			}
+			notes := ""
+			if state.Unknown {
+				notes = " (unknown to this version of the application)"
+			} else if !state.Reversible {
+				notes = " (can not be undone)"
+			}
+			fmt.Fprintf(stdout, "%-8s %-19s %s%s\n", state.Version, applied, state.Description, notes)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown command 'migrate %s'\n%s", strings.Join(args, " "), commandsUsage)
+	}
+}
+
+// mintTokenCommand prints a signed access token of the user. The server must have the same JWT keys to accept it
+func mintTokenCommand(ctx context.Context, args []string, appModules *AppModules, stdout io.Writer) error {
+	flags := flag.NewFlagSet("token mint", flag.ContinueOnError)
+	ttl := flags.Duration("ttl", jwt.AccessTokenDuration, "time until the token expires")
+	tenant := flags.String("tenant", "", "id of the organization the token acts on behalf of")
+	scopes := flags.String("scopes", "", "comma separated scopes of the token, full access if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("the email of the user is missing\n%s", commandsUsage)
+	}
+	if jwt.GetKeyRing().SigningKey().Kid == "ephemeral" {
+		return fmt.Errorf("no JWT key is configured, the server would not accept the token")
+	}
+	user, err := (*appModules.user).GetUserByEmail(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	claims := jwt.UserClaims(user.ID)
+	claims.Tenant = *tenant
+	if *scopes != "" {
+		claims.Scopes = strings.Split(*scopes, ",")
+	}
+	issued, errIssue := jwt.IssueToken(claims, *ttl)
+	if errIssue != nil {
+		return errIssue
+	}
+	fmt.Fprintln(stdout, issued.Token)
+	return nil
+}
+
+func commandFormat(formatName string, path string) (user_file.Format, error) {
+	if formatName != "" {
+		return user_file.ParseFormat(formatName)
+	}
+	return user_file.FormatOfFile(path), nil
+}
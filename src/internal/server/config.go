@@ -0,0 +1,171 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/mailer"
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/oidc"
+	"github.com/Manolo-Esc/gommence/src/internal/app"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/database"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+)
+
+// Config holds every setting of the server. It is loaded by config.Load, see the package for the tags and the sources
+type Config struct {
+	Server   ServerConfig   `key:"server"`
+	Database DatabaseConfig `key:"database"`
+	Log      LogConfig      `key:"log"`
+	JWT      JWTConfig      `key:"jwt"`
+	Mail     MailConfig     `key:"mail"`
+	OIDC     OIDCConfig     `key:"oidc"`
+	Auth     AuthConfig     `key:"auth"`
+}
+
+type ServerConfig struct {
+	Host string `key:"host" env:"SERVER_HOST" help:"address the server listens on"`
+	Port int    `key:"port" env:"SERVER_PORT" validate:"min=1,max=65535" help:"port the server listens on"`
+	// The client IP is taken from X-Forwarded-For only on the requests of these proxies
+	TrustedProxies []string `key:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" validate:"dive,cidr|ip" help:"IPs or CIDRs of the proxies in front of the server, whose X-Forwarded-For header gives the client IP"`
+}
+
+type DatabaseConfig struct {
+	Driver   string `key:"driver" env:"DB_DRIVER" validate:"oneof=postgres sqlite memory" help:"postgres, sqlite, or memory to run without a database. In memory everything is lost when the server stops"`
+	Host     string `key:"host" env:"DB_HOST" validate:"required" help:"host of the Postgres database"`
+	Port     int    `key:"port" env:"DB_PORT" validate:"min=1,max=65535" help:"port of the database"`
+	User     string `key:"user" env:"DB_USER" validate:"required" help:"user of the database"`
+	Password string `key:"password" env:"DB_PASSWORD" secret:"true" help:"password of the user"`
+	Name     string `key:"name" env:"DB_NAME" validate:"required" help:"name of the database"`
+	SSLMode  string `key:"sslmode" env:"DB_SSLMODE" validate:"oneof=disable allow prefer require verify-ca verify-full" help:"sslmode of the connection"`
+	Path     string `key:"path" env:"DB_PATH" validate:"required" help:"file of the SQLite database, :memory: to lose it when the server stops"`
+}
+
+type LogConfig struct {
+	Console bool   `key:"console" env:"LOG_CONSOLE" help:"also write the logs to the console"`
+	File    string `key:"file" env:"LOG_FILE" help:"file of the logs, rotated when it grows. Empty to not write them to a file"`
+}
+
+type JWTConfig struct {
+	KeysDir    string   `key:"keys_dir" env:"JWT_KEYS_DIR" help:"folder with one file per key: <kid>.pem for RSA/Ed25519 keys and <kid>.secret for HS256 secrets"`
+	Secret     string   `key:"secret" env:"JWT_SECRET" secret:"true" validate:"omitempty,min=32" help:"a HS256 secret, handy for simple deployments"`
+	SecretKid  string   `key:"secret_kid" env:"JWT_SECRET_KID" help:"id of the secret (\"default\" if empty)"`
+	SigningKid string   `key:"signing_kid" env:"JWT_SIGNING_KID" help:"id of the key used to sign. If empty, the last signing capable key sorted by id"`
+	Issuer     string   `key:"issuer" env:"JWT_ISSUER" help:"stamped as iss on new tokens and required when validating"`
+	Audience   []string `key:"audience" env:"JWT_AUDIENCE" help:"stamped as aud on new tokens, validation requires any of them"`
+}
+
+type MailConfig struct {
+	Mailer       string `key:"mailer" env:"MAILER" validate:"omitempty,oneof=smtp file memory" help:"smtp, file or memory. If empty, smtp when the SMTP host is set and file otherwise"`
+	SMTPHost     string `key:"smtp_host" env:"SMTP_HOST" help:"host of the SMTP server"`
+	SMTPPort     int    `key:"smtp_port" env:"SMTP_PORT" validate:"min=1,max=65535" help:"port of the SMTP server"`
+	SMTPUsername string `key:"smtp_username" env:"SMTP_USERNAME" help:"user of the SMTP server, no authentication if empty"`
+	SMTPPassword string `key:"smtp_password" env:"SMTP_PASSWORD" secret:"true" help:"password of the SMTP user"`
+	From         string `key:"from" env:"MAIL_FROM" help:"sender of the emails"`
+	OutboxDir    string `key:"outbox_dir" env:"MAIL_OUTBOX_DIR" help:"folder where the file mailer writes the emails"`
+}
+
+type OIDCConfig struct {
+	Issuer       string   `key:"issuer" env:"OIDC_ISSUER" validate:"omitempty,url" help:"OIDC provider, e.g. https://accounts.google.com. OIDC sign in is disabled if empty"`
+	ClientID     string   `key:"client_id" env:"OIDC_CLIENT_ID" validate:"required_with=Issuer" help:"client registered in the provider"`
+	ClientSecret string   `key:"client_secret" env:"OIDC_CLIENT_SECRET" secret:"true" help:"secret of the client, empty for public clients"`
+	RedirectURL  string   `key:"redirect_url" env:"OIDC_REDIRECT_URL" validate:"required_with=Issuer,omitempty,url" help:"our callback, e.g. https://example.com/api/v1/auth/oidc/callback"`
+	Scopes       []string `key:"scopes" env:"OIDC_SCOPES" help:"scopes requested, openid email profile if empty"`
+}
+
+type AuthConfig struct {
+	PasswordMinLength     int           `key:"password_min_length" env:"PASSWORD_MIN_LENGTH" validate:"min=1" help:"minimum length of new passwords"`
+	PasswordRequireUpper  bool          `key:"password_require_upper" env:"PASSWORD_REQUIRE_UPPER" help:"new passwords need an uppercase letter"`
+	PasswordRequireLower  bool          `key:"password_require_lower" env:"PASSWORD_REQUIRE_LOWER" help:"new passwords need a lowercase letter"`
+	PasswordRequireDigit  bool          `key:"password_require_digit" env:"PASSWORD_REQUIRE_DIGIT" help:"new passwords need a digit"`
+	PasswordRequireSymbol bool          `key:"password_require_symbol" env:"PASSWORD_REQUIRE_SYMBOL" help:"new passwords need a symbol"`
+	RequireVerifiedEmail  bool          `key:"require_verified_email" env:"AUTH_REQUIRE_VERIFIED_EMAIL" help:"refuse the login of users that have not verified their email"`
+	PublicURL             string        `key:"public_url" env:"PUBLIC_URL" validate:"required,url" help:"base URL of the links sent by email"`
+	MFAIssuer             string        `key:"mfa_issuer" env:"MFA_ISSUER" help:"name of the service shown by the authenticator apps"`
+	LockoutThreshold      int           `key:"lockout_threshold" env:"AUTH_LOCKOUT_THRESHOLD" validate:"min=1" help:"failed sign ins that lock an account"`
+	IPLockoutThreshold    int           `key:"ip_lockout_threshold" env:"AUTH_IP_LOCKOUT_THRESHOLD" validate:"min=1" help:"failed sign ins that lock an IP address"`
+	MFALockoutThreshold   int           `key:"mfa_lockout_threshold" env:"AUTH_MFA_LOCKOUT_THRESHOLD" validate:"min=1" help:"wrong two-factor codes that lock the second factor of a user"`
+	LockoutDuration       time.Duration `key:"lockout_duration" env:"AUTH_LOCKOUT_DURATION" validate:"min=1" help:"how long they stay locked, e.g. 15m"`
+}
+
+// DefaultConfig returns the settings used when no source gives them, fine for development
+func DefaultConfig() Config {
+	auth := app.DefaultAuthConfig
+	return Config{
+		Server:   ServerConfig{Host: "0.0.0.0", Port: 5080},
+		Database: DatabaseConfig{Driver: "postgres", Host: "localhost", Port: 5432, User: "postgres", Password: "password", Name: "sample_db", SSLMode: "disable", Path: "sample_db.sqlite"},
+		Log:      LogConfig{File: "logs/app.log"},
+		Mail:     MailConfig{SMTPPort: 587, OutboxDir: "mail_outbox"},
+		Auth: AuthConfig{
+			PasswordMinLength:     auth.PasswordPolicy.MinLength,
+			PasswordRequireUpper:  auth.PasswordPolicy.RequireUpper,
+			PasswordRequireLower:  auth.PasswordPolicy.RequireLower,
+			PasswordRequireDigit:  auth.PasswordPolicy.RequireDigit,
+			PasswordRequireSymbol: auth.PasswordPolicy.RequireSymbol,
+			RequireVerifiedEmail:  auth.RequireVerifiedEmail,
+			PublicURL:             auth.PublicURL,
+			MFAIssuer:             auth.MFAIssuer,
+			LockoutThreshold:      auth.Lockout.AccountThreshold,
+			IPLockoutThreshold:    auth.Lockout.IPThreshold,
+			MFALockoutThreshold:   auth.Lockout.MFAThreshold,
+			LockoutDuration:       auth.Lockout.LockoutDuration,
+		},
+	}
+}
+
+// DSN is the connection string of the driver: the path of the file for SQLite
+func (c DatabaseConfig) DSN() string {
+	if c.Driver == database.DriverSQLite {
+		return c.Path
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=UTC",
+		dsnValue(c.Host), dsnValue(c.User), dsnValue(c.Password), dsnValue(c.Name), c.Port, dsnValue(c.SSLMode))
+}
+
+// dsnValue quotes a value of a Postgres connection string, so spaces, quotes and backslashes are kept as they are
+func dsnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+func (c LogConfig) LoggerConfig() logger.LoggerConfig {
+	return logger.LoggerConfig{UseConsole: c.Console, UseFile: c.File != "", FilePath: c.File}
+}
+
+func (c JWTConfig) KeyRingConfig() jwt.KeyRingConfig {
+	return jwt.KeyRingConfig{KeysDir: c.KeysDir, Secret: c.Secret, SecretKid: c.SecretKid, SigningKid: c.SigningKid}
+}
+
+func (c JWTConfig) Settings() jwt.Settings {
+	return jwt.Settings{Issuer: c.Issuer, Audience: c.Audience}
+}
+
+func (c MailConfig) MailerConfig() mailer.Config {
+	return mailer.Config{
+		Kind:      c.Mailer,
+		SMTP:      mailer.SMTPConfig{Host: c.SMTPHost, Port: c.SMTPPort, Username: c.SMTPUsername, Password: c.SMTPPassword, From: c.From},
+		OutboxDir: c.OutboxDir,
+	}
+}
+
+func (c OIDCConfig) ProviderConfig() oidc.Config {
+	return oidc.Config{Issuer: c.Issuer, ClientID: c.ClientID, ClientSecret: c.ClientSecret, RedirectURL: c.RedirectURL, Scopes: c.Scopes}
+}
+
+// AuthConfig keeps the defaults of the settings that are not configurable
+func (c AuthConfig) AuthConfig() app.AuthConfig {
+	auth := app.DefaultAuthConfig
+	auth.PasswordPolicy.MinLength = c.PasswordMinLength
+	auth.PasswordPolicy.RequireUpper = c.PasswordRequireUpper
+	auth.PasswordPolicy.RequireLower = c.PasswordRequireLower
+	auth.PasswordPolicy.RequireDigit = c.PasswordRequireDigit
+	auth.PasswordPolicy.RequireSymbol = c.PasswordRequireSymbol
+	auth.RequireVerifiedEmail = c.RequireVerifiedEmail
+	auth.PublicURL = strings.TrimSuffix(c.PublicURL, "/")
+	auth.MFAIssuer = c.MFAIssuer
+	auth.Lockout.AccountThreshold = c.LockoutThreshold
+	auth.Lockout.IPThreshold = c.IPLockoutThreshold
+	auth.Lockout.MFAThreshold = c.MFALockoutThreshold
+	auth.Lockout.LockoutDuration = c.LockoutDuration
+	return auth
+}
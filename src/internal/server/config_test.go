@@ -0,0 +1,22 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseConfigDSN(t *testing.T) {
+	config := DatabaseConfig{Driver: "postgres", Host: "localhost", Port: 5433, User: "the user", Password: `it's a \\ secret=1`, Name: "my_db", SSLMode: "disable"}
+	parsed, err := pgconn.ParseConfig(config.DSN())
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", parsed.Host)
+	assert.Equal(t, uint16(5433), parsed.Port)
+	assert.Equal(t, "the user", parsed.User)
+	assert.Equal(t, `it's a \\ secret=1`, parsed.Password)
+	assert.Equal(t, "my_db", parsed.Database)
+
+	config.Driver, config.Path = "sqlite", "/data/my db.sqlite"
+	assert.Equal(t, "/data/my db.sqlite", config.DSN())
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+)
+
+// How often the expired tokens and the old failed sign ins are deleted
+const purgeInterval = 1 * time.Hour
+
+// runPurges deletes what has expired, at the start and then every purgeInterval, until the context is done.
+// Several servers can share the database and all run it, deleting twice is harmless
+func runPurges(ctx context.Context, auth ports.AuthService, logger logger.LoggerService) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	ctx = domain.AsOperator(ctx)
+	for {
+		if err := auth.PurgeExpired(ctx); err != nil {
+			logger.Info(fmt.Sprintf("Error purging expired tokens: %s", err.Error()))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	_ "github.com/Manolo-Esc/gommence/src/docs"
 	"github.com/Manolo-Esc/gommence/src/internal/adapters/rest"
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
 	"github.com/Manolo-Esc/gommence/src/pkg/netw"
 	"github.com/go-chi/chi/v5"
@@ -18,6 +20,10 @@ import (
 // @description     Go Web Server starter kit
 // @host           localhost:8080
 // @BasePath       /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and the access token
 
 // @Summary Health checking URL
 // @Tags Misc
@@ -30,16 +36,60 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"mensaje": "service is online"}`))
 }
 
+// @Summary Public keys to validate the tokens issued by this service
+// @Description JSON Web Key Set (RFC 7517) with the public part of the asymmetric signing keys. HMAC secrets are never published
+// @Tags Misc
+// @Produce  json
+// @Success 200 {object} jwt.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300") // overrides NoCacheMiddleware: verifiers may keep the keys for a while
+	if err := netw.Encode(w, r, http.StatusOK, jwt.GetKeyRing().JWKS()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func addRoutes(appModules *AppModules, r *chi.Mux, logger logger.LoggerService, db *gorm.DB) {
 	authHandler := rest.NewAuthHandler(*appModules.auth, logger)
 	userHandler := rest.NewUserHandler(*appModules.user, logger)
+	auditHandler := rest.NewAuditHandler(*appModules.audit, logger)
+	privacyHandler := rest.NewPrivacyHandler(*appModules.privacy, logger)
+	apiKeyHandler := rest.NewAPIKeyHandler(*appModules.apiKey, logger)
+	adminHandler := rest.NewAdminHandler(*appModules.permission, logger)
+	organizationHandler := rest.NewOrganizationHandler(*appModules.organization, logger)
+	accessHandler := rest.NewAccessHandler(*appModules.permission, logger)
+	jwtMiddleware := netw.JwtMiddleware(logger, *appModules.auth)
+	authMiddleware := netw.AuthMiddleware(logger, *appModules.auth, *appModules.apiKey) // also accepts API keys
+	permissions := *appModules.permission
 
-	r.Get("/health", healthHandler) // GET /health
+	r.Get("/health", healthHandler)              // GET /health
+	r.Get("/.well-known/jwks.json", jwksHandler) // GET /.well-known/jwks.json
 	r.Route("/api/v1", func(r chi.Router) {
 		// URLs unauthenticated
 		r.Get("/health", healthHandler) // GET /api/v1/health
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/signin", authHandler.Login) // POST /api/v1/auth/signin
+			r.Post("/signin", authHandler.Login)                                                   // POST /api/v1/auth/signin
+			r.Post("/signup", authHandler.SignUp)                                                  // POST /api/v1/auth/signup
+			r.Post("/refresh", authHandler.Refresh)                                                // POST /api/v1/auth/refresh
+			r.Post("/request-reset", authHandler.RequestPasswordReset)                             // POST /api/v1/auth/request-reset
+			r.Post("/confirm-reset", authHandler.ConfirmPasswordReset)                             // POST /api/v1/auth/confirm-reset
+			r.Post("/resend-verification", authHandler.RequestEmailVerification)                   // POST /api/v1/auth/resend-verification
+			r.Post("/verify-email", authHandler.VerifyEmail)                                       // POST /api/v1/auth/verify-email
+			r.Post("/confirm-email", authHandler.ConfirmEmailChange)                               // POST /api/v1/auth/confirm-email
+			r.Get("/oidc/start", authHandler.OIDCStart)                                            // GET /api/v1/auth/oidc/start
+			r.Get("/oidc/callback", authHandler.OIDCCallback)                                      // GET /api/v1/auth/oidc/callback
+			r.Post("/mfa/verify", authHandler.VerifyMFA)                                           // POST /api/v1/auth/mfa/verify
+			r.With(jwtMiddleware).Post("/signout", authHandler.SignOut)                            // POST /api/v1/auth/signout
+			r.With(jwtMiddleware).Post("/password", authHandler.ChangePassword)                    // POST /api/v1/auth/password
+			r.With(jwtMiddleware).Post("/email", authHandler.RequestEmailChange)                   // POST /api/v1/auth/email
+			r.With(jwtMiddleware).Post("/mfa/enroll", authHandler.StartMFAEnrollment)              // POST /api/v1/auth/mfa/enroll
+			r.With(jwtMiddleware).Post("/mfa/confirm", authHandler.ConfirmMFAEnrollment)           // POST /api/v1/auth/mfa/confirm
+			r.With(jwtMiddleware).Post("/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes) // POST /api/v1/auth/mfa/recovery-codes
+			r.With(jwtMiddleware).Post("/mfa/disable", authHandler.DisableMFA)                     // POST /api/v1/auth/mfa/disable
+			r.With(jwtMiddleware).Post("/api-keys", apiKeyHandler.CreateAPIKey)                    // POST /api/v1/auth/api-keys
+			r.With(jwtMiddleware).Get("/api-keys", apiKeyHandler.GetAPIKeys)                       // GET /api/v1/auth/api-keys
+			r.With(jwtMiddleware).Delete("/api-keys/{keyId}", apiKeyHandler.RevokeAPIKey)          // DELETE /api/v1/auth/api-keys/{keyId}
+			r.With(jwtMiddleware).Post("/tenant", authHandler.SwitchTenant)                        // POST /api/v1/auth/tenant
 		})
 		// swagger: http://localhost:5080/api/v1/doc/index.html
 		r.Get("/doc/doc.json", func(w http.ResponseWriter, r *http.Request) {
@@ -49,10 +99,44 @@ func addRoutes(appModules *AppModules, r *chi.Mux, logger logger.LoggerService,
 			httpSwagger.URL("doc.json"),
 		))
 
-		// URLs authenticated via jwt bearer token
-		r.With(netw.JwtMiddleware(logger)).Route("/user", func(r chi.Router) {
-			r.Get("/{userId}", userHandler.GetUserById) // GET /api/v1/user/u/{userId}
-			r.Get("/", userHandler.GetUsers)            // GET /api/v1/user
+		// URLs authenticated via jwt bearer token or API key
+		r.With(authMiddleware).Route("/user", func(r chi.Router) {
+			r.Get("/me", userHandler.GetMe)                                                                                                         // GET /api/v1/user/me
+			r.Get("/me/export", privacyHandler.ExportMe)                                                                                            // GET /api/v1/user/me/export
+			r.With(netw.RequireOwnerOrPermission(permissions, "userId", domain.PermissionRead)).Get("/{userId}", userHandler.GetUserById)           // GET /api/v1/user/u/{userId}
+			r.With(netw.RequirePermission(permissions, domain.PermissionRead)).Get("/", userHandler.GetUsers)                                       // GET /api/v1/user
+			r.With(netw.RequirePermission(permissions, domain.PermissionAdmin)).Post("/", userHandler.CreateUser)                                   // POST /api/v1/user
+			r.With(netw.RequireOwnerOrPermission(permissions, "userId", domain.PermissionWrite)).Patch("/{userId}", userHandler.UpdateUser)         // PATCH /api/v1/user/{userId}
+			r.With(netw.RequireOwnerOrPermission(permissions, "userId", domain.PermissionDelete)).Delete("/{userId}", userHandler.DeleteUser)       // DELETE /api/v1/user/{userId}
+			r.With(netw.RequirePermission(permissions, domain.PermissionDelete)).Post("/{userId}/restore", userHandler.RestoreUser)                 // POST /api/v1/user/{userId}/restore
+			r.With(netw.RequireOwnerOrPermission(permissions, "userId", domain.PermissionAdmin)).Get("/{userId}/export", privacyHandler.ExportUser) // GET /api/v1/user/{userId}/export
+			r.With(netw.RequireOwnerOrPermission(permissions, "userId", domain.PermissionAdmin)).Post("/{userId}/erase", privacyHandler.EraseUser)  // POST /api/v1/user/{userId}/erase
+		})
+		r.With(authMiddleware, netw.RequirePermission(permissions, domain.PermissionAdmin)).Get("/audit", auditHandler.GetEvents) // GET /api/v1/audit
+		r.With(authMiddleware, netw.RequirePermission(permissions, domain.PermissionAdmin)).Route("/admin", func(r chi.Router) {
+			r.Get("/roles", adminHandler.GetRoles)                                           // GET /api/v1/admin/roles
+			r.Post("/roles", adminHandler.CreateRole)                                        // POST /api/v1/admin/roles
+			r.Get("/users/{userId}/roles", adminHandler.GetUserRoles)                        // GET /api/v1/admin/users/{userId}/roles
+			r.Post("/users/{userId}/roles", adminHandler.GrantRole)                          // POST /api/v1/admin/users/{userId}/roles
+			r.Delete("/users/{userId}/roles/{roleName}", adminHandler.RevokeRole)            // DELETE /api/v1/admin/users/{userId}/roles/{roleName}
+			r.Post("/users/import", userHandler.ImportUsers)                                 // POST /api/v1/admin/users/import
+			r.Get("/users/export", userHandler.ExportUsers)                                  // GET /api/v1/admin/users/export
+			r.Get("/groups", adminHandler.GetGroups)                                         // GET /api/v1/admin/groups
+			r.Post("/groups", adminHandler.CreateGroup)                                      // POST /api/v1/admin/groups
+			r.Post("/groups/{groupName}/members", adminHandler.AddGroupMember)               // POST /api/v1/admin/groups/{groupName}/members
+			r.Delete("/groups/{groupName}/members/{userId}", adminHandler.RemoveGroupMember) // DELETE /api/v1/admin/groups/{groupName}/members/{userId}
+		})
+		r.With(authMiddleware).Route("/access", func(r chi.Router) { // each call checks the admin permission on the resource
+			r.Get("/resources/{resourceType}/{resourceId}", accessHandler.GetResourceGrants) // GET /api/v1/access/resources/{resourceType}/{resourceId}
+			r.Post("/resources/{resourceType}/{resourceId}", accessHandler.GrantAccess)      // POST /api/v1/access/resources/{resourceType}/{resourceId}
+			r.Delete("/grants/{grantId}", accessHandler.RevokeAccess)                        // DELETE /api/v1/access/grants/{grantId}
+		})
+		r.With(authMiddleware).Route("/orgs", func(r chi.Router) {
+			r.Post("/", organizationHandler.CreateOrganization)                     // POST /api/v1/orgs
+			r.Get("/", organizationHandler.GetMyOrganizations)                      // GET /api/v1/orgs
+			r.Get("/current/members", organizationHandler.GetMembers)               // GET /api/v1/orgs/current/members
+			r.Post("/current/members", organizationHandler.AddMember)               // POST /api/v1/orgs/current/members
+			r.Delete("/current/members/{userId}", organizationHandler.RemoveMember) // DELETE /api/v1/orgs/current/members/{userId}
 		})
 	})
 }
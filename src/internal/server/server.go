@@ -7,14 +7,20 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/mailer"
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/oidc"
 	"github.com/Manolo-Esc/gommence/src/internal/infra/database"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
 	"github.com/Manolo-Esc/gommence/src/pkg/cache"
+	"github.com/Manolo-Esc/gommence/src/pkg/config"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
 	"github.com/Manolo-Esc/gommence/src/pkg/netw"
 	"github.com/go-chi/chi/v5"
@@ -24,8 +30,6 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
-	"github.com/joho/godotenv"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -34,10 +38,10 @@ func teste(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "userId: %s", id)
 }
 
-func WebServiceFactory(appModules *AppModules, logger logger.LoggerService, db *gorm.DB) http.Handler {
+func WebServiceFactory(appModules *AppModules, logger logger.LoggerService, db *gorm.DB, trustedProxies []netip.Prefix) http.Handler {
 	r := chi.NewRouter()
 	// Global Middlewares
-	//r.Use(middleware.RealIP)
+	r.Use(netw.RealIPMiddleware(trustedProxies)) // first, the rest sees the client IP
 	r.Use(middleware.Recoverer)
 	// See samples in https://github.com/riandyrn/otelchi/metric to record metrics about the received calls
 	r.Use(netw.LogMiddleware(logger))
@@ -47,11 +51,6 @@ func WebServiceFactory(appModules *AppModules, logger logger.LoggerService, db *
 	return handler
 }
 
-type Config struct {
-	Host string
-	Port string
-}
-
 func initTracerProvider() (*trace.TracerProvider, error) {
 	// Binary OTLP exporter (can be configured for Jaeger, Prometheus, etc.)
 	// ctx := context.Background()
@@ -80,8 +79,8 @@ func initTracerProvider() (*trace.TracerProvider, error) {
 }
 
 
-func tryOpenDatabase(dsn string) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func tryOpenDatabase(driver string, dsn string) (*gorm.DB, error) {
+	db, err := database.Open(driver, dsn, &gorm.Config{})
 	if err != nil { // give some time in case the database in the docker compose is also starting up
 		timeout := time.After(5 * time.Second)
 		ticker := time.NewTicker(1 * time.Second)
@@ -92,7 +91,7 @@ func tryOpenDatabase(dsn string) (*gorm.DB, error) {
 			case <-timeout:
 				return nil, err
 			case <-ticker.C:
-				db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+				db, err := database.Open(driver, dsn, &gorm.Config{})
 				if err == nil {
 					return db, nil
 				}
@@ -102,29 +101,24 @@ func tryOpenDatabase(dsn string) (*gorm.DB, error) {
 	return db, nil
 }
 
-func getEnvOrDefault(key, defaultVal string, getenv func(string) string) string {
-	val := getenv(key)
-	if val == "" {
-		log.Printf("Environment variable %s not set, using default value: %s\n", key, defaultVal)
-		return defaultVal
+func openDatabase(dbConfig DatabaseConfig) (*gorm.DB, error) {
+	db, err := tryOpenDatabase(dbConfig.Driver, dbConfig.DSN())
+	if err != nil {
+		return nil, err
+	}
+	if dbConfig.Driver == database.DriverSQLite {
+		log.Printf("Connected to SQLite database %s\n", dbConfig.Path)
+		return db, nil
 	}
-	log.Printf("Using environment variable %s: %s\n", key, val)
-	return val
+	log.Printf("Connected to database %s on %s:%d\n", dbConfig.Name, dbConfig.Host, dbConfig.Port)
+	return db, nil
 }
 
-
-func initDatabase(ctx context.Context, getenv func(string) string/*, dsn string*/) (*gorm.DB, error) {
-	dbHost := getEnvOrDefault("DB_HOST", "localhost", getenv)
-	dbUser := getEnvOrDefault("DB_USER", "postgres", getenv)
-	dbPass := getEnvOrDefault("DB_PASSWORD", "password", getenv)
-	dbName := getEnvOrDefault("DB_NAME", "sample_db", getenv)
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable TimeZone=UTC", dbHost, dbUser, dbPass, dbName)
-
-	db, err := tryOpenDatabase(dsn)
+func initDatabase(ctx context.Context, dbConfig DatabaseConfig) (*gorm.DB, error) {
+	db, err := openDatabase(dbConfig)
 	if err != nil {
 		return nil, err
 	}
-	log.Println("Connected to database")
 	err = database.Migrate(ctx, db)
 	if err != nil {
 		log.Fatal("Error migrating or cheking database version: ", err)
@@ -134,38 +128,98 @@ func initDatabase(ctx context.Context, getenv func(string) string/*, dsn string*
 }
 
 func Run(ctx context.Context, args []string, getenv func(string) string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) > 1 && (args[1] == "help" || args[1] == "-h" || args[1] == "--help") {
+		defaults := DefaultConfig()
+		fmt.Fprintln(stdout, commandsUsage)
+		fmt.Fprintln(stdout, config.Usage(&defaults))
+		return nil
+	}
+
+	cfg := DefaultConfig()
+	args, err := config.Load(&cfg, args[1:], getenv) // from here on, args are the command and its arguments
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 && args[0] == "config" && args[1] == "print" {
+		config.Print(stdout, &cfg)
+		return nil
+	}
+
 	// Create a context that can be cancelled with SIGINT, SIGTERM o SIGHUP
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP) // We must not capture SIGKILL or SIGSTOP
 	defer cancel()
 
-	err := godotenv.Load()
+	keyRing, err := jwt.LoadKeyRing(cfg.JWT.KeyRingConfig())
 	if err != nil {
-		log.Println("No .env file found. Carrying on...")
+		return fmt.Errorf("error loading JWT keys: %w", err)
 	}
+	jwt.SetKeyRing(keyRing)
+	jwt.SetSettings(cfg.JWT.Settings())
 
-	tp, err := initTracerProvider()
-	if err != nil {
-		fmt.Println("Error initializing OpenTelemetry:", err)
-		return err
-	}
+	logger.Configure(cfg.Log.LoggerConfig())
 	logger := logger.GetLogger()
 	defer logger.Sync()
 
-	//db, err := initDatabase(ctx, "host=localhost user=postgres password=secret dbname=my_db port=5432 sslmode=disable TimeZone=UTC") // args or getenv should be used here
-	db, err := initDatabase(ctx, getenv) //"host=db user=postgres password=secret dbname=my_db port=5432 sslmode=disable TimeZone=UTC") // args or getenv should be used here
+	var db *gorm.DB
+	var repos Repositories
+	if cfg.Database.Driver == "memory" {
+		if len(args) > 0 && args[0] != "serve" {
+			return fmt.Errorf("the %s command needs a database, nothing is kept in memory between runs", args[0])
+		}
+		repos = MemoryRepositories()
+		if _, err := database.SeedRepositories(ctx, repos.User, repos.Permission); err != nil {
+			return fmt.Errorf("error seeding the memory: %w", err)
+		}
+		if err := database.SeedDevelopmentRoles(ctx, repos.User, repos.Permission); err != nil { // only to try the API
+			return fmt.Errorf("error seeding the memory: %w", err)
+		}
+		log.Println("Running without a database, the data is lost when the server stops")
+	} else {
+		if len(args) > 0 && args[0] == "migrate" { // before initDatabase, that would apply every pending migration
+			db, err := openDatabase(cfg.Database)
+			if err != nil {
+				return err
+			}
+			return migrateCommand(ctx, args[1:], db, stdout)
+		}
+
+		db, err = initDatabase(ctx, cfg.Database)
+		if err != nil {
+			return err
+		}
+		repos = DBRepositories(logger, db)
+	}
+
+	mailSender, err := mailer.LoadMailer(cfg.Mail.MailerConfig())
 	if err != nil {
-		return err
+		return fmt.Errorf("error configuring the mailer: %w", err)
 	}
 
-	appModules := ProductionAppModulesFactory(logger, db, cache.GetCache())
+	identityProvider, err := oidc.LoadProvider(cfg.OIDC.ProviderConfig())
+	if err != nil {
+		return fmt.Errorf("error configuring the OIDC provider: %w", err)
+	}
 
-	//config := Config{Host: "127.0.0.1", Port: "5080"} // args or getenv should be used here
-	config := Config{Host: "0.0.0.0", Port: "5080"} // args or getenv should be used here
+	appModules := ProductionAppModulesFactory(logger, repos, cache.GetCache(), mailSender, identityProvider, cfg.Auth.AuthConfig())
 
-	srv := WebServiceFactory(appModules, logger, db)
+	if len(args) > 0 && args[0] != "serve" { // a command instead of the server. Without traces, they would be mixed with what it writes
+		return runCommand(ctx, args, appModules, db, stdin, stdout)
+	}
+
+	tp, err := initTracerProvider()
+	if err != nil {
+		fmt.Println("Error initializing OpenTelemetry:", err)
+		return err
+	}
+
+	trustedProxies, err := netw.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("error reading the trusted proxies: %w", err)
+	}
+	srv := WebServiceFactory(appModules, logger, db, trustedProxies)
 
 	httpServer := &http.Server{
-		Addr:    net.JoinHostPort(config.Host, config.Port),
+		Addr:    net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
 		Handler: srv,
 	}
 	// launch server in a goroutine to avoid blocking this one
@@ -176,6 +230,8 @@ func Run(ctx context.Context, args []string, getenv func(string) string, stdin i
 		}
 	}()
 
+	go runPurges(ctx, *appModules.auth, logger)
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() { // cleaning goroutine
@@ -2,24 +2,33 @@ package cache
 
 import (
 	"sync"
+	"time"
 
 	"github.com/dgraph-io/ristretto/v2"
 )
 
 type CacheService interface {
 	Set(key string, value interface{}) bool
+	SetWithTTL(key string, value interface{}, ttl time.Duration) bool
 	Get(key string) (interface{}, bool)
 	Del(key string)
+	Wait()
 }
 
 type cacheServiceImpl struct {
 	provider *ristretto.Cache[string, interface{}]
 }
 
+// Set returns false if the value was dropped. Writes are buffered, so they may not be read right away even if true
 func (c *cacheServiceImpl) Set(key string, value interface{}) bool {
 	cost := int64(1)
-	c.provider.Set(key, value, cost)
-	return true
+	return c.provider.Set(key, value, cost)
+}
+
+// SetWithTTL stores a value that will be evicted after ttl has elapsed
+func (c *cacheServiceImpl) SetWithTTL(key string, value interface{}, ttl time.Duration) bool {
+	cost := int64(1)
+	return c.provider.SetWithTTL(key, value, cost, ttl)
 }
 
 func (c *cacheServiceImpl) Get(key string) (interface{}, bool) {
@@ -31,6 +40,11 @@ func (c *cacheServiceImpl) Del(key string) {
 	c.provider.Del(key)
 }
 
+// Wait blocks until the buffered writes are applied. Only for callers that have to read their own writes
+func (c *cacheServiceImpl) Wait() {
+	c.provider.Wait()
+}
+
 var (
 	cache      *cacheServiceImpl
 	createOnce sync.Once
@@ -79,6 +93,10 @@ func (c *cacheServiceNopImpl) Set(key string, value interface{}) bool {
 	return true
 }
 
+func (c *cacheServiceNopImpl) SetWithTTL(key string, value interface{}, ttl time.Duration) bool {
+	return true
+}
+
 func (c *cacheServiceNopImpl) Get(key string) (interface{}, bool) {
 	return nil, false
 }
@@ -86,6 +104,9 @@ func (c *cacheServiceNopImpl) Get(key string) (interface{}, bool) {
 func (c *cacheServiceNopImpl) Del(key string) {
 }
 
+func (c *cacheServiceNopImpl) Wait() {
+}
+
 func GetNopCache() CacheService {
 	return &cacheServiceNopImpl{}
 }
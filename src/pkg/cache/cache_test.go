@@ -91,3 +91,18 @@ func TestPointers2(t *testing.T) {
 	_, found = cache.Get("type2.t31")
 	assert.True(t, !found)
 }
+
+func TestTTL(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("ttl.short", "short", 50*time.Millisecond)
+	cache.SetWithTTL("ttl.long", "long", time.Hour)
+	waitConsolidation(cache)
+	_, found := cache.Get("ttl.short")
+	assert.True(t, found)
+	time.Sleep(100 * time.Millisecond)
+	_, found = cache.Get("ttl.short")
+	assert.False(t, found)
+	value, found := cache.Get("ttl.long")
+	assert.True(t, found)
+	assert.Equal(t, "long", value)
+}
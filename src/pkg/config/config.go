@@ -0,0 +1,352 @@
+// Package config loads a typed configuration: a struct, made of sections that are structs too, whose fields are tagged
+// with their names in the sources:
+//   - key: name in the config file, under the key of its section. The flag has the whole path, e.g. -server.port
+//   - env: environment variable, also looked up in the .env file
+//   - secret: "true" to hide the value when printed
+//   - validate: rules of github.com/go-playground/validator, checked once everything is loaded
+//   - help: what the setting is for
+//
+// Each source overrides the previous ones: the values already in the struct (the defaults), the YAML config file, the
+// .env file, the environment variables and the command line flags. Fields can be strings, ints, bools, durations
+// ("15m") and lists of strings (comma or space separated, or a YAML list)
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Default paths of the files, used if they exist. The flags -config and -env-file, or the variable CONFIG_FILE, give others
+const (
+	DefaultFile    = "config.yaml"
+	DefaultEnvFile = ".env"
+)
+
+const redacted = "********"
+
+type setting struct {
+	key    string // path in the config file, e.g. server.port
+	env    string
+	secret bool
+	help   string
+	field  string // path of the field in the struct, to explain the errors of the validator
+	value  reflect.Value
+}
+
+// Load fills the config from the sources and validates it. args are the command line arguments after the name of the
+// program: the flags are read until the first argument that is not one, and the rest are returned
+func Load(config any, args []string, getenv func(string) string) ([]string, error) {
+	settings := collectSettings(config)
+	byKey := map[string]*setting{}
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+
+	flags := flag.NewFlagSet("gommence", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFile := flags.String("config", "", "YAML config file ("+DefaultFile+" by default, if it exists)")
+	envFile := flags.String("env-file", "", "file with environment variables ("+DefaultEnvFile+" by default, if it exists)")
+	flagValues := map[string]string{}
+	var flagOrder []string
+	for _, s := range settings {
+		flags.Func(s.key, s.help, func(value string) error {
+			flagValues[s.key] = value
+			flagOrder = append(flagOrder, s.key)
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, Usage(config))
+	}
+
+	var problems []string
+	if *configFile == "" {
+		*configFile = getenv("CONFIG_FILE")
+	}
+	fileValues, err := readFile(*configFile, DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range sortedKeys(fileValues) {
+		s, found := byKey[key]
+		if !found {
+			problems = append(problems, fmt.Sprintf("unknown setting %s in the config file", key))
+			continue
+		}
+		problems = appendProblem(problems, s, fileValues[key], "in the config file")
+	}
+
+	envValues, err := readEnvFile(*envFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range settings {
+		if value, found := envValues[s.env]; found && s.env != "" {
+			problems = appendProblem(problems, s, value, "in the variable "+s.env+" of the .env file")
+		}
+	}
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" && s.env != "" {
+			problems = appendProblem(problems, s, value, "in the environment variable "+s.env)
+		}
+	}
+	for _, key := range flagOrder {
+		problems = appendProblem(problems, byKey[key], flagValues[key], "in the flag -"+key)
+	}
+	if len(problems) == 0 {
+		problems = validate(config, settings)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return flags.Args(), nil
+}
+
+// Print writes the config in the format of the config file, with the secrets hidden
+func Print(w io.Writer, config any) {
+	printSection(w, reflect.ValueOf(config).Elem(), "")
+}
+
+func printSection(w io.Writer, section reflect.Value, indent string) {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Type().Field(i)
+		key := field.Tag.Get("key")
+		if key == "" {
+			continue
+		}
+		value := section.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			fmt.Fprintf(w, "%s%s:\n", indent, key)
+			printSection(w, value, indent+"  ")
+			continue
+		}
+		text := formatValue(value)
+		if field.Tag.Get("secret") == "true" && !value.IsZero() {
+			text = strconv.Quote(redacted)
+		}
+		comment := ""
+		if env := field.Tag.Get("env"); env != "" {
+			comment = " # " + env
+		}
+		fmt.Fprintf(w, "%s%s: %s%s\n", indent, key, text, comment)
+	}
+}
+
+// Usage describes the flags, the environment variables and the default values of the settings
+func Usage(config any) string {
+	var usage strings.Builder
+	usage.WriteString("settings, given as flags before the command:\n")
+	usage.WriteString("  -config FILE     YAML config file (" + DefaultFile + " by default, if it exists. Also CONFIG_FILE)\n")
+	usage.WriteString("  -env-file FILE   file with environment variables (" + DefaultEnvFile + " by default, if it exists)\n")
+	for _, s := range collectSettings(config) {
+		fmt.Fprintf(&usage, "  -%s (%s) %s", s.key, s.env, s.help)
+		if !s.value.IsZero() && !s.secret {
+			fmt.Fprintf(&usage, " (default %s)", formatValue(s.value))
+		}
+		usage.WriteString("\n")
+	}
+	return strings.TrimSuffix(usage.String(), "\n")
+}
+
+func collectSettings(config any) []*setting {
+	var settings []*setting
+	var collect func(section reflect.Value, keyPrefix string, fieldPrefix string)
+	collect = func(section reflect.Value, keyPrefix string, fieldPrefix string) {
+		for i := 0; i < section.NumField(); i++ {
+			field := section.Type().Field(i)
+			key := field.Tag.Get("key")
+			if key == "" {
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct {
+				collect(section.Field(i), keyPrefix+key+".", fieldPrefix+field.Name+".")
+				continue
+			}
+			settings = append(settings, &setting{
+				key:    keyPrefix + key,
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret") == "true",
+				help:   field.Tag.Get("help"),
+				field:  fieldPrefix + field.Name,
+				value:  section.Field(i),
+			})
+		}
+	}
+	collect(reflect.ValueOf(config).Elem(), "", "")
+	return settings
+}
+
+func appendProblem(problems []string, s *setting, text string, source string) []string {
+	if err := setValue(s.value, text); err != nil {
+		shown := text
+		if s.secret {
+			shown = redacted
+		}
+		return append(problems, fmt.Sprintf("invalid %s '%s' %s: %s", s.key, shown, source, err))
+	}
+	return problems
+}
+
+func setValue(value reflect.Value, text string) error {
+	text = strings.TrimSpace(text)
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		duration, err := time.ParseDuration(text)
+		if err != nil {
+			return errors.New("it must be a duration, e.g. 90s, 15m or 24h")
+		}
+		value.SetInt(int64(duration))
+	case value.Kind() == reflect.String:
+		value.SetString(text)
+	case value.Kind() == reflect.Int:
+		number, err := strconv.Atoi(text)
+		if err != nil {
+			return errors.New("it must be a whole number")
+		}
+		value.SetInt(int64(number))
+	case value.Kind() == reflect.Bool:
+		flag, err := strconv.ParseBool(text)
+		if err != nil {
+			return errors.New("it must be true or false")
+		}
+		value.SetBool(flag)
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+		items := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("settings of type %s are not supported", value.Type())
+	}
+	return nil
+}
+
+func formatValue(value reflect.Value) string {
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		return strconv.Quote(time.Duration(value.Int()).String())
+	case value.Kind() == reflect.String:
+		return strconv.Quote(value.String())
+	case value.Kind() == reflect.Slice:
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = strconv.Quote(value.Index(i).String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// readFile returns the values of the YAML file by their path, e.g. server.port. The default file is optional
+func readFile(path string, defaultPath string) (map[string]string, error) {
+	if path == "" {
+		if _, err := os.Stat(defaultPath); err != nil {
+			return nil, nil
+		}
+		path = defaultPath
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the config file: %w", err)
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("reading the config file %s: %w", path, err)
+	}
+	values := map[string]string{}
+	flattenTree(tree, "", values)
+	return values, nil
+}
+
+func flattenTree(tree map[string]any, prefix string, values map[string]string) {
+	for key, node := range tree {
+		switch node := node.(type) {
+		case map[string]any:
+			flattenTree(node, prefix+key+".", values)
+		case []any:
+			items := make([]string, len(node))
+			for i, item := range node {
+				items[i] = fmt.Sprint(item)
+			}
+			values[prefix+key] = strings.Join(items, ",")
+		case nil:
+			values[prefix+key] = ""
+		default:
+			values[prefix+key] = fmt.Sprint(node)
+		}
+	}
+}
+
+// readEnvFile returns the variables of the file, without setting them in the environment. The default file is optional
+func readEnvFile(path string) (map[string]string, error) {
+	if path == "" {
+		if _, err := os.Stat(DefaultEnvFile); err != nil {
+			return nil, nil
+		}
+		path = DefaultEnvFile
+	}
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the environment file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func validate(config any, settings []*setting) []string {
+	err := validator.New().Struct(config)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+	byField := map[string]*setting{}
+	for _, s := range settings {
+		byField[s.field] = s
+	}
+	describe := func(field string) string {
+		s, found := byField[field]
+		if !found {
+			return field
+		}
+		if s.env != "" {
+			return s.key + " (" + s.env + ")"
+		}
+		return s.key
+	}
+	var problems []string
+	for _, fieldError := range validationErrors {
+		field := fieldError.StructNamespace()
+		field = field[strings.Index(field, ".")+1:] // without the name of the config type
+		name := describe(field)
+		switch {
+		case fieldError.Tag() == "required":
+			problems = append(problems, fmt.Sprintf("%s is required", name))
+		case strings.HasPrefix(fieldError.Tag(), "required_with"): // the param is a field of the same section
+			other := field[:strings.LastIndex(field, ".")+1] + fieldError.Param()
+			problems = append(problems, fmt.Sprintf("%s is required when %s is set", name, describe(other)))
+		default:
+			problems = append(problems, fmt.Sprintf("%s should be %s %s", name, fieldError.Tag(), fieldError.Param()))
+		}
+	}
+	return problems
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
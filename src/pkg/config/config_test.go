@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Server struct {
+		Host    string        `key:"host" env:"TEST_HOST"`
+		Port    int           `key:"port" env:"TEST_PORT" validate:"min=1,max=65535"`
+		Timeout time.Duration `key:"timeout" env:"TEST_TIMEOUT"`
+	} `key:"server"`
+	Auth struct {
+		Secret   string   `key:"secret" env:"TEST_SECRET" secret:"true" validate:"omitempty,min=8"`
+		Audience []string `key:"audience" env:"TEST_AUDIENCE"`
+		Strict   bool     `key:"strict" env:"TEST_STRICT"`
+		Issuer   string   `key:"issuer" env:"TEST_ISSUER"`
+		Client   string   `key:"client" validate:"required_with=Issuer"`
+	} `key:"auth"`
+}
+
+func defaultConfig() *testConfig {
+	config := &testConfig{}
+	config.Server.Host = "localhost"
+	config.Server.Port = 5080
+	config.Server.Timeout = time.Minute
+	return config
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", "server:\n  host: file\n  port: 1000\n  timeout: 30s\nauth:\n  audience: [api, partners]\n")
+	envFile := writeFile(t, ".env", "TEST_PORT=2000\nTEST_STRICT=true\n")
+	env := map[string]string{"TEST_PORT": "3000", "TEST_SECRET": "a long secret"}
+
+	config := defaultConfig()
+	rest, err := Load(config, []string{"-config", file, "-env-file", envFile, "-server.port", "4000", "serve", "-x"}, func(key string) string { return env[key] })
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"serve", "-x"}, rest) // the command and its own flags
+	assert.Equal(t, "file", config.Server.Host)
+	assert.Equal(t, 4000, config.Server.Port) // the flag wins over the environment, .env and the file
+	assert.Equal(t, 30*time.Second, config.Server.Timeout)
+	assert.Equal(t, []string{"api", "partners"}, config.Auth.Audience)
+	assert.True(t, config.Auth.Strict)
+	assert.Equal(t, "a long secret", config.Auth.Secret)
+
+	config = defaultConfig()
+	_, err = Load(config, []string{"-env-file", envFile}, func(key string) string { return env[key] })
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", config.Server.Host) // the default
+	assert.Equal(t, 3000, config.Server.Port)        // the environment wins over .env
+}
+
+func TestLoad_Errors(t *testing.T) {
+	noEnv := func(key string) string { return "" }
+	file := writeFile(t, "config.yaml", "server:\n  prot: 1000\n")
+	_, err := Load(defaultConfig(), []string{"-config", file}, noEnv)
+	assert.Contains(t, err.Error(), "unknown setting server.prot in the config file")
+
+	env := map[string]string{"TEST_PORT": "many", "TEST_SECRET": "short", "TEST_TIMEOUT": "10"}
+	_, err = Load(defaultConfig(), nil, func(key string) string { return env[key] })
+	assert.Contains(t, err.Error(), "invalid server.port 'many' in the environment variable TEST_PORT")
+	assert.Contains(t, err.Error(), "invalid server.timeout '10'")
+
+	delete(env, "TEST_PORT")
+	delete(env, "TEST_TIMEOUT")
+	_, err = Load(defaultConfig(), []string{"-server.port", "70000", "-auth.issuer", "me"}, func(key string) string { return env[key] })
+	assert.Contains(t, err.Error(), "server.port (TEST_PORT) should be max 65535")
+	assert.Contains(t, err.Error(), "auth.client is required when auth.issuer (TEST_ISSUER) is set")
+	assert.Contains(t, err.Error(), "auth.secret (TEST_SECRET) should be min 8")
+	assert.NotContains(t, err.Error(), "short") // secrets are never shown
+
+	_, err = Load(defaultConfig(), []string{"-config", "missing.yaml"}, noEnv)
+	assert.NotNil(t, err) // unlike the default file, a given file must exist
+	_, err = Load(defaultConfig(), []string{"-unknown", "1"}, noEnv)
+	assert.NotNil(t, err)
+}
+
+func TestPrint(t *testing.T) {
+	config := defaultConfig()
+	config.Auth.Secret = "a long secret"
+	config.Auth.Audience = []string{"api"}
+	var out bytes.Buffer
+	Print(&out, config)
+	assert.Equal(t, `server:
+  host: "localhost" # TEST_HOST
+  port: 5080 # TEST_PORT
+  timeout: "1m0s" # TEST_TIMEOUT
+auth:
+  secret: "********" # TEST_SECRET
+  audience: ["api"] # TEST_AUDIENCE
+  strict: false # TEST_STRICT
+  issuer: "" # TEST_ISSUER
+  client: ""
+`, out.String())
+
+	printed := defaultConfig() // what is printed can be loaded back
+	_, err := Load(printed, []string{"-config", writeFile(t, "config.yaml", out.String())}, func(key string) string { return "" })
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api"}, printed.Auth.Audience)
+	assert.Equal(t, time.Minute, printed.Server.Timeout)
+}
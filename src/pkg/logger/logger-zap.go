@@ -44,14 +44,20 @@ func (l *loggerServiceImpl) Sync() error {
 type LoggerConfig struct {
 	UseConsole bool
 	UseFile    bool
+	FilePath   string // Rotated when it grows
 }
 
 var (
 	theLogger  *loggerServiceImpl
 	createOnce sync.Once
-	config     LoggerConfig = LoggerConfig{UseConsole: false, UseFile: true}
+	config     LoggerConfig = LoggerConfig{UseConsole: false, UseFile: true, FilePath: "logs/app.log"}
 )
 
+// Configure sets where the logs go. It must be called before the first GetLogger, later changes have no effect
+func Configure(newConfig LoggerConfig) {
+	config = newConfig
+}
+
 func GetLogger() LoggerService {
 	createOnce.Do(func() {
 		theLogger = &loggerServiceImpl{}
@@ -88,11 +94,11 @@ func newLogger() *zap.Logger {
 	if config.UseFile {
 		// Configuración de lumberjack para rotación de archivos
 		fileWriter := zapcore.AddSync(&lumberjack.Logger{
-			Filename:   "logs/app.log", // Archivo donde se escribirán los logs
-			MaxSize:    10,             // Tamaño máximo del archivo en MB
-			MaxBackups: 5,              // Número máximo de archivos de backup
-			MaxAge:     30,             // Días máximos para mantener los archivos antiguos
-			Compress:   true,           // Habilita la compresión de archivos rotados
+			Filename:   config.FilePath, // Archivo donde se escribirán los logs
+			MaxSize:    10,              // Tamaño máximo del archivo en MB
+			MaxBackups: 5,               // Número máximo de archivos de backup
+			MaxAge:     30,              // Días máximos para mantener los archivos antiguos
+			Compress:   true,            // Habilita la compresión de archivos rotados
 		})
 		fileEncoder := zapcore.NewJSONEncoder(encoderConfig)
 		fileLevel := zapcore.InfoLevel // Logs de info y superiores a archivo
@@ -0,0 +1,16 @@
+package netw
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns the address the request comes from. Behind a proxy this is the proxy, unless it is one of the
+// server.trusted_proxies and RealIPMiddleware has taken the client address from X-Forwarded-For
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
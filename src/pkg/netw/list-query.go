@@ -0,0 +1,82 @@
+package netw
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+)
+
+/*
+Query string of list endpoints:
+
+	GET /api/v1/user?limit=50&sort=-created_at,email&email[prefix]=john&created_at[gte]=2025-01-01T00:00:00Z&total=true
+
+  - limit: items per page
+  - cursor: the next_cursor of the previous page, or page: number of the page
+  - sort: fields separated by commas, a leading - sorts descending
+  - total=true: count the items matching the filters
+  - field[op]=value: filter, with op one of eq, prefix, contains, gte or lte. field=value is the same as field[eq]=value
+*/
+
+// ListSpec says what can be sorted and filtered in a list endpoint
+type ListSpec struct {
+	Sort    []string                 // Fields that can be sorted by
+	Filters map[string][]dtos.ListOp // Fields that can be filtered and how
+}
+
+// ParseListQuery reads the list parameters of the request. Filters not in the spec are refused, other parameters are ignored
+func ParseListQuery(r *http.Request, spec ListSpec) (dtos.ListQuery, ports.APIError) {
+	var query dtos.ListQuery
+	var err error
+	values := r.URL.Query()
+	if limit := values.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return query, ports.NewAPIError(http.StatusBadRequest, "Invalid limit: "+err.Error())
+		}
+	}
+	if page := values.Get("page"); page != "" {
+		if query.Page, err = strconv.Atoi(page); err != nil {
+			return query, ports.NewAPIError(http.StatusBadRequest, "Invalid page: "+err.Error())
+		}
+	}
+	query.Cursor = values.Get("cursor")
+	if query.Cursor != "" && query.Page != 0 {
+		return query, ports.NewAPIError(http.StatusBadRequest, "Use either cursor or page")
+	}
+	if total := values.Get("total"); total != "" {
+		if query.WithTotal, err = strconv.ParseBool(total); err != nil {
+			return query, ports.NewAPIError(http.StatusBadRequest, "Invalid total: "+err.Error())
+		}
+	}
+	if sort := values.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field, desc := strings.CutPrefix(strings.TrimSpace(field), "-")
+			if !slices.Contains(spec.Sort, field) {
+				return query, ports.NewAPIError(http.StatusBadRequest, "Can not sort by "+field)
+			}
+			query.Sort = append(query.Sort, dtos.ListSort{Field: field, Desc: desc})
+		}
+	}
+	for param, paramValues := range values {
+		field, op := param, dtos.ListOpEq
+		if name, rest, found := strings.Cut(param, "["); found && strings.HasSuffix(rest, "]") {
+			field, op = name, dtos.ListOp(strings.TrimSuffix(rest, "]"))
+		} else if _, known := spec.Filters[field]; !known {
+			continue // not a filter
+		}
+		if !slices.Contains(spec.Filters[field], op) {
+			return query, ports.NewAPIError(http.StatusBadRequest, "Can not filter "+field+" by "+string(op))
+		}
+		for _, value := range paramValues {
+			query.Filters = append(query.Filters, dtos.ListFilter{Field: field, Op: op, Value: value})
+		}
+	}
+	slices.SortFunc(query.Filters, func(a, b dtos.ListFilter) int { // map order is random
+		return strings.Compare(a.Field+string(a.Op), b.Field+string(b.Op))
+	})
+	return query, nil
+}
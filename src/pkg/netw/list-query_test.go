@@ -0,0 +1,59 @@
+package netw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSpec = ListSpec{
+	Sort: []string{"created_at", "email"},
+	Filters: map[string][]dtos.ListOp{
+		"email":      {dtos.ListOpEq, dtos.ListOpPrefix},
+		"created_at": {dtos.ListOpGte, dtos.ListOpLte},
+	},
+}
+
+func parse(url string) (dtos.ListQuery, int) {
+	query, err := ParseListQuery(httptest.NewRequest("GET", url, nil), testSpec)
+	if err != nil {
+		return query, err.Status()
+	}
+	return query, http.StatusOK
+}
+
+func TestParseListQuery(t *testing.T) {
+	query, status := parse("/user?limit=5&sort=-created_at,email&total=true&email[prefix]=jo&created_at[lte]=2025-01-01&created_at[gte]=2024-01-01&other=1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, dtos.ListQuery{
+		Limit:     5,
+		WithTotal: true,
+		Sort:      []dtos.ListSort{{Field: "created_at", Desc: true}, {Field: "email"}},
+		Filters: []dtos.ListFilter{
+			{Field: "created_at", Op: dtos.ListOpGte, Value: "2024-01-01"},
+			{Field: "created_at", Op: dtos.ListOpLte, Value: "2025-01-01"},
+			{Field: "email", Op: dtos.ListOpPrefix, Value: "jo"},
+		},
+	}, query)
+
+	query, status = parse("/user?email=john@mail.com&cursor=abc")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []dtos.ListFilter{{Field: "email", Op: dtos.ListOpEq, Value: "john@mail.com"}}, query.Filters)
+	assert.Equal(t, "abc", query.Cursor)
+
+	for _, url := range []string{
+		"/user?limit=many",
+		"/user?page=2&cursor=abc",
+		"/user?sort=secret",
+		"/user?email[contains]=jo",
+		"/user?secret[eq]=x",
+		"/user?created_at=2024-01-01", // only ranges
+		"/user?total=perhaps",
+	} {
+		_, status = parse(url)
+		assert.Equal(t, http.StatusBadRequest, status, url)
+	}
+}
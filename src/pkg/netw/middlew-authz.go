@@ -0,0 +1,90 @@
+package netw
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Authorization middlewares. They go after JwtMiddleware (or AuthMiddleware), which puts the user in the context:
+
+	r.With(jwtMiddleware, netw.RequirePermission(permissions, domain.PermissionAdmin)).Get("/audit", ...)
+	r.With(netw.RequireOwnerOrPermission(permissions, "userId", domain.PermissionRead)).Get("/{userId}", ...)
+	r.With(netw.RequireResourcePermission(permissions, "document", "docId", domain.PermissionWrite)).Put("/{docId}", ...)
+*/
+
+// RequirePermission lets through the users having any of the permissions
+func RequirePermission(permissions ports.PermissionService, needed ...domain.Permission) func(http.Handler) http.Handler {
+	return authorize(func(ctx context.Context, r *http.Request, byUser string) ports.APIError {
+		_, err := permissions.IsSameUserOrHasSomePermission(ctx, byUser, "", needed)
+		return err
+	})
+}
+
+// RequireOwnerOrPermission lets through the user whose id is in the URL param, e.g. "userId" for "/user/{userId}",
+// and the users having any of the permissions
+func RequireOwnerOrPermission(permissions ports.PermissionService, userParam string, needed ...domain.Permission) func(http.Handler) http.Handler {
+	return authorize(func(ctx context.Context, r *http.Request, byUser string) ports.APIError {
+		_, err := permissions.IsSameUserOrHasSomePermission(ctx, byUser, chi.URLParam(r, userParam), needed)
+		return err
+	})
+}
+
+// RequireResourcePermission lets through the users that can do the action on the resource whose id is in the URL param,
+// either by being an administrator or by a grant on that resource
+func RequireResourcePermission(permissions ports.PermissionService, resourceType string, idParam string, action domain.Permission) func(http.Handler) http.Handler {
+	return authorize(func(ctx context.Context, r *http.Request, byUser string) ports.APIError {
+		allowed, err := permissions.Can(ctx, byUser, action, resourceType, chi.URLParam(r, idParam))
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ports.NewAPIError(http.StatusForbidden, forbiddenMessage)
+		}
+		return nil
+	})
+}
+
+// RequireRole lets through the users having any of the roles
+func RequireRole(permissions ports.PermissionService, roles ...string) func(http.Handler) http.Handler {
+	return authorize(func(ctx context.Context, r *http.Request, byUser string) ports.APIError {
+		userRoles, err := permissions.GetUserRoles(ctx, byUser, byUser)
+		if err != nil {
+			return err
+		}
+		for _, role := range userRoles {
+			if slices.Contains(roles, role.Name) {
+				return nil
+			}
+		}
+		return ports.NewAPIError(http.StatusForbidden, forbiddenMessage)
+	})
+}
+
+const forbiddenMessage = "The data is not accessible" // the text is used in tests!
+
+func authorize(check func(ctx context.Context, r *http.Request, byUser string) ports.APIError) func(http.Handler) http.Handler {
+	return func(nextHandler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			byUser := JwtGetUserInToken(r.Context())
+			if byUser == "" {
+				http.Error(w, "Authentication required", http.StatusUnauthorized) // the text is used in tests!
+				return
+			}
+			if err := check(r.Context(), r, byUser); err != nil {
+				if err.Status() == http.StatusForbidden { // the same answer whatever the reason
+					http.Error(w, forbiddenMessage, http.StatusForbidden)
+				} else {
+					http.Error(w, err.Error(), err.Status())
+				}
+				return
+			}
+			nextHandler.ServeHTTP(w, r)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
 )
@@ -16,45 +17,106 @@ const userInfoKey contextKey = "gommence_tk_userInfo"
 /*
 How to use downstream:
 
-	if userInfo, ok := netw.JwtTokenClaims(ctx); ok {
-		fmt.Println("User:", userInfo["user"])
-		fmt.Println("Expiration:", userInfo["exp"])
+	if claims, ok := netw.JwtGetClaims(ctx); ok {
+		fmt.Println("User:", claims.Subject)
+		fmt.Println("Expiration:", claims.ExpiresAt)
 	}
 */
-func JwtGetTokenClaims(ctx context.Context) (map[string]string, bool) {
-	userInfo, ok := ctx.Value(userInfoKey).(map[string]string)
-	return userInfo, ok
+func JwtGetClaims(ctx context.Context) (*jwt.Claims, bool) {
+	claims, ok := ctx.Value(userInfoKey).(*jwt.Claims)
+	return claims, ok && claims != nil
 }
 
 func JwtGetUserInToken(ctx context.Context) string {
-	claims, ok := JwtGetTokenClaims(ctx)
-	if ok && claims != nil {
-		return claims["user"]
+	if claims, ok := JwtGetClaims(ctx); ok {
+		return claims.Subject
 	}
 	return ""
 }
 
-func JwtMiddleware(logger logger.LoggerService) func(http.Handler) http.Handler {
+// JwtWithClaims returns a copy of the context carrying the claims and their tenant, as the middleware does. Useful in tests
+func JwtWithClaims(ctx context.Context, claims *jwt.Claims) context.Context {
+	if claims != nil && claims.Tenant != "" {
+		ctx = domain.WithTenant(ctx, claims.Tenant) // read by the repositories of tenant scoped data
+	}
+	return context.WithValue(ctx, userInfoKey, claims)
+}
+
+// RevocationChecker tells whether a token, valid by signature and expiration, has been revoked (e.g. on sign out)
+type RevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, tokenId string) bool
+}
+
+// APIKeyAuthenticator finds the user of an API key. False if the key is unknown, expired or revoked
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*jwt.Claims, bool)
+}
+
+// Header where clients can send their API key instead of using the Authorization header
+const APIKeyHeader = "X-API-Key"
+
+// JwtMiddleware validates the bearer token. If revocations is not nil, revoked tokens and tokens without "jti" are rejected
+func JwtMiddleware(logger logger.LoggerService, revocations RevocationChecker) func(http.Handler) http.Handler {
+	return AuthMiddleware(logger, revocations, nil)
+}
+
+// AuthMiddleware works as JwtMiddleware but, if apiKeys is not nil, also accepts API keys either as bearer token or in
+// the X-API-Key header. Both ways put the claims of the user in the context. Claims with scopes but without the
+// "write" one only allow safe requests
+func AuthMiddleware(logger logger.LoggerService, revocations RevocationChecker, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
 	return func(nextHandler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
+			apiKey := ""
+			if apiKeys != nil {
+				apiKey = r.Header.Get(APIKeyHeader)
+			}
+			if authHeader == "" && apiKey == "" {
 				http.Error(w, "Authorization header missing", http.StatusUnauthorized) // the text is used in tests!
 				return
 			}
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized) // the text is used in tests!
-				return
+			var claims *jwt.Claims
+			if apiKey == "" {
+				parts := strings.Split(authHeader, " ")
+				if len(parts) != 2 || parts[0] != "Bearer" {
+					http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized) // the text is used in tests!
+					return
+				}
+				token := parts[1]
+				if apiKeys != nil && strings.Count(token, ".") != 2 { // a JWT always has three parts
+					apiKey = token
+				} else {
+					var err error
+					claims, err = jwt.ValidateToken(token)
+					if err != nil {
+						http.Error(w, fmt.Sprintf("error in token: %s", err.Error()), http.StatusUnauthorized) // the text is used in tests!
+						return
+					}
+					if revocations != nil {
+						if claims.ID == "" || revocations.IsTokenRevoked(r.Context(), claims.ID) {
+							http.Error(w, "error in token: token has been revoked", http.StatusUnauthorized) // the text is used in tests!
+							return
+						}
+					}
+				}
 			}
-			token := parts[1]
-			tokenPayload, err := jwt.ValidateToken(token)
-			if err != nil {
-				http.Error(w, fmt.Sprintf("error in token: %s", err.Error()), http.StatusUnauthorized) // the text is used in tests!
+			if apiKey != "" {
+				var found bool
+				if claims, found = apiKeys.AuthenticateAPIKey(r.Context(), apiKey); !found {
+					http.Error(w, "Invalid API key", http.StatusUnauthorized) // the text is used in tests!
+					return
+				}
+			}
+			if len(claims.Scopes) > 0 && !claims.HasScope(jwt.ScopeWrite) && !isSafeMethod(r.Method) {
+				http.Error(w, "The token does not allow this request", http.StatusForbidden) // the text is used in tests!
 				return
 			}
-			ctx := context.WithValue(r.Context(), userInfoKey, tokenPayload)
+			ctx := JwtWithClaims(r.Context(), claims)
 			nextHandler.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
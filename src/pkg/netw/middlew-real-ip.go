@@ -0,0 +1,69 @@
+package netw
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies reads the addresses of the proxies in front of the server, as CIDRs (10.0.0.0/8) or single IPs
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// RealIPMiddleware takes the address of the client from X-Forwarded-For when the request comes from a trusted proxy, so
+// ClientIP returns the client and not the proxy. The header is read from the right, skipping the trusted proxies: what
+// is left of the first address that is not one of them may have been written by the client. Without trusted proxies
+// the header is ignored, anyone could forge it
+func RealIPMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if client := forwardedClient(r, trustedProxies); client != "" {
+				r.RemoteAddr = client
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedClient(r *http.Request, trustedProxies []netip.Prefix) string {
+	if !isTrustedProxy(ClientIP(r), trustedProxies) {
+		return ""
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			return "" // garbage, better the proxy than an address made up
+		}
+		if !isTrustedProxy(hop, trustedProxies) || i == 0 {
+			return hop
+		}
+	}
+	return ""
+}
+
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package netw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	assert.Nil(t, err)
+	_, err = ParseTrustedProxies([]string{"not an address"})
+	assert.NotNil(t, err)
+
+	clientIP := func(remoteAddr string, forwardedFor ...string) string {
+		var seen string
+		handler := RealIPMiddleware(trustedProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = ClientIP(r)
+		}))
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = remoteAddr
+		for _, header := range forwardedFor {
+			request.Header.Add("X-Forwarded-For", header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		return seen
+	}
+
+	assert.Equal(t, "203.0.113.7", clientIP("10.1.2.3:4567", "203.0.113.7"))
+	assert.Equal(t, "203.0.113.7", clientIP("10.1.2.3:4567", "1.1.1.1, 203.0.113.7, 192.168.1.1")) // the client may forge what is on the left
+	assert.Equal(t, "203.0.113.7", clientIP("10.1.2.3:4567", "1.1.1.1", "203.0.113.7"))
+	assert.Equal(t, "10.9.9.9", clientIP("10.1.2.3:4567", "10.9.9.9")) // only proxies, the first one
+	assert.Equal(t, "10.1.2.3", clientIP("10.1.2.3:4567", "not an address"))
+	assert.Equal(t, "10.1.2.3", clientIP("10.1.2.3:4567"))
+	assert.Equal(t, "198.51.100.1", clientIP("198.51.100.1:4567", "203.0.113.7")) // not a proxy of ours, the header is forged
+}
@@ -0,0 +1,141 @@
+package test_authz
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/app"
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/cache"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/pkg/netw"
+	"github.com/Manolo-Esc/gommence/src/tests/libtest"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const port = ":5093"
+
+var userRoles = map[string][]*domain.Role{
+	"granny": {{Name: domain.RoleAdmin, Permissions: domain.Roles.Admin}},
+	"duke":   {{Name: domain.RoleEditor, Permissions: domain.Roles.Editor}},
+	"john":   {},
+}
+
+func makeRestCall(t *testing.T, url string, user string) (int, string) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	if user != "" {
+		token, err := jwt.CreateToken(user)
+		if err != nil {
+			t.Fatalf("Error generating token: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error making call: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(body))
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+func permissionRequired(t *testing.T, baseURL string) {
+	code, _ := makeRestCall(t, baseURL+"admin", "granny")
+	assert.Equal(t, http.StatusOK, code)
+	code, msg := makeRestCall(t, baseURL+"admin", "duke")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "The data is not accessible", msg)
+}
+
+func roleRequired(t *testing.T, baseURL string) {
+	code, _ := makeRestCall(t, baseURL+"editor", "duke")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = makeRestCall(t, baseURL+"editor", "granny") // admins are not editors
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = makeRestCall(t, baseURL+"editor", "john")
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func ownerOrPermission(t *testing.T, baseURL string) {
+	code, _ := makeRestCall(t, baseURL+"user/john", "john")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = makeRestCall(t, baseURL+"user/duke", "john")
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = makeRestCall(t, baseURL+"user/john", "duke")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func resourcePermission(t *testing.T, baseURL string) {
+	code, _ := makeRestCall(t, baseURL+"document/42", "john") // granted on this document
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = makeRestCall(t, baseURL+"document/43", "john")
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = makeRestCall(t, baseURL+"document/43", "duke") // editors need a grant too
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = makeRestCall(t, baseURL+"document/43", "granny") // administrators write everything
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func noUser(t *testing.T, baseURL string) {
+	code, msg := makeRestCall(t, baseURL+"public/admin", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "Authentication required", msg)
+}
+
+func TestMiddlewareAuthorization(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping TestMiddlewareAuthorization in short mode")
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockPermissionRepository(ctrl)
+	repo.EXPECT().GetUserRoles(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, idUser string) ([]*domain.Role, ports.APIError) {
+			return userRoles[idUser], nil
+		}).
+		AnyTimes()
+	repo.EXPECT().GetUserGrants(gomock.Any(), "john").
+		Return([]*domain.Grant{{SubjectType: domain.SubjectUser, SubjectID: "john", ResourceType: "document", ResourceID: "42", Permission: domain.PermissionWrite}}, nil).
+		AnyTimes()
+	repo.EXPECT().GetUserGrants(gomock.Any(), "duke").Return(nil, nil).AnyTimes()
+	permissions := app.NewPermissionService(repo, mocks.NewMockAuditService(ctrl), cache.NewCache(), logger.GetNopLogger())
+
+	r := chi.NewRouter()
+	r.With(netw.RequirePermission(permissions, domain.PermissionAdmin)).Get("/public/admin", okHandler) // no authentication
+	r.Group(func(r chi.Router) {
+		r.Use(netw.JwtMiddleware(logger.GetNopLogger(), nil))
+		r.With(netw.RequirePermission(permissions, domain.PermissionAdmin)).Get("/admin", okHandler)
+		r.With(netw.RequireRole(permissions, domain.RoleEditor)).Get("/editor", okHandler)
+		r.With(netw.RequireOwnerOrPermission(permissions, "userId", domain.PermissionRead)).Get("/user/{userId}", okHandler)
+		r.With(netw.RequireResourcePermission(permissions, "document", "docId", domain.PermissionWrite)).Get("/document/{docId}", okHandler)
+	})
+
+	handlersFuncs := []libtest.HttpTestHandlerFunc{} // No handlers functions
+	handlers := []libtest.HttpTestHandler{{Path: "/", F: r}}
+
+	testFunctions := []func(t *testing.T, baseURL string){
+		permissionRequired,
+		roleRequired,
+		ownerOrPermission,
+		resourcePermission,
+		noUser,
+	}
+
+	libtest.RunSimpleServerEx(t, testFunctions, port, handlersFuncs, handlers)
+}
@@ -1,11 +1,13 @@
 package test_jwt
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	jwt "github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
 	"github.com/Manolo-Esc/gommence/src/pkg/logger"
@@ -17,12 +19,18 @@ import (
 const testUserName = "testUser"
 
 func makeRestCall(t *testing.T, baseURL string, authHeader string) (int, string) {
-	req, err := http.NewRequest("POST", baseURL+"checkToken", nil)
+	return makeRestCallEx(t, "POST", baseURL, map[string]string{"Authorization": authHeader})
+}
+
+func makeRestCallEx(t *testing.T, method string, baseURL string, headers map[string]string) (int, string) {
+	req, err := http.NewRequest(method, baseURL+"checkToken", nil)
 	if err != nil {
 		t.Fatalf("Error creating request: %v", err)
 	}
-	if authHeader != "" {
-		req.Header.Set("Authorization", authHeader)
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
 	}
 
 	client := &http.Client{}
@@ -38,12 +46,12 @@ func makeRestCall(t *testing.T, baseURL string, authHeader string) (int, string)
 }
 
 func checkTokenHandler(w http.ResponseWriter, r *http.Request) {
-	claims, ok := netw.JwtGetTokenClaims(r.Context())
+	claims, ok := netw.JwtGetClaims(r.Context())
 	if !ok {
 		http.Error(w, "Error getting claims", http.StatusBadRequest)
 		return
 	}
-	userName := claims["user"]
+	userName := claims.Subject
 	if userName != testUserName {
 		http.Error(w, "Unexpected user name "+userName, http.StatusInternalServerError)
 		return
@@ -109,7 +117,7 @@ func TestMiddlewareJWT(t *testing.T) {
 
 	handlersFuncs := []libtest.HttpTestHandlerFunc{} // No handlers functions
 	handlers := []libtest.HttpTestHandler{
-		{Path: "/checkToken", F: netw.JwtMiddleware(logger.GetNopLogger())(http.HandlerFunc(checkTokenHandler))},
+		{Path: "/checkToken", F: netw.JwtMiddleware(logger.GetNopLogger(), nil)(http.HandlerFunc(checkTokenHandler))},
 	}
 
 	testFunctions := []func(t *testing.T, baseURL string){
@@ -123,3 +131,109 @@ func TestMiddlewareJWT(t *testing.T) {
 
 	libtest.RunSimpleServer(t, testFunctions, handlersFuncs, handlers)
 }
+
+type revocationList map[string]bool
+
+func (l revocationList) IsTokenRevoked(ctx context.Context, tokenId string) bool {
+	return l[tokenId]
+}
+
+var revokedTokens = revocationList{}
+
+func revokedToken(t *testing.T, baseURL string) {
+	issued, err := jwt.IssueToken(jwt.UserClaims(testUserName), time.Minute)
+	if err != nil {
+		t.Fatalf("Error generating token: %v", err)
+	}
+	code, _ := makeRestCall(t, baseURL, "Bearer "+issued.Token)
+	assert.Equal(t, http.StatusOK, code)
+
+	revokedTokens[issued.ID] = true
+	code, msg := makeRestCall(t, baseURL, "Bearer "+issued.Token)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "error in token: token has been revoked", msg)
+}
+
+func TestMiddlewareJWTRevocation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping TestMiddlewareJWTRevocation in short mode")
+	}
+
+	handlersFuncs := []libtest.HttpTestHandlerFunc{} // No handlers functions
+	handlers := []libtest.HttpTestHandler{
+		{Path: "/checkToken", F: netw.JwtMiddleware(logger.GetNopLogger(), revokedTokens)(http.HandlerFunc(checkTokenHandler))},
+	}
+
+	testFunctions := []func(t *testing.T, baseURL string){
+		noToken,
+		tokenOk,
+		revokedToken,
+	}
+
+	libtest.RunSimpleServer(t, testFunctions, handlersFuncs, handlers)
+}
+
+// apiKeys plays the API key service: the key is the name of the user
+type apiKeys map[string][]string
+
+func (k apiKeys) AuthenticateAPIKey(ctx context.Context, key string) (*jwt.Claims, bool) {
+	scopes, found := k[key]
+	if !found {
+		return nil, false
+	}
+	claims := jwt.APIKeyClaims(testUserName, scopes, time.Now().Add(time.Hour))
+	return &claims, true
+}
+
+var testAPIKeys = apiKeys{"writeKey": {jwt.ScopeRead, jwt.ScopeWrite}, "readKey": {jwt.ScopeRead}}
+
+func apiKeyOk(t *testing.T, baseURL string) {
+	code, msg := makeRestCall(t, baseURL, "Bearer writeKey")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "You just reached the checkToken handler", msg)
+
+	code, _ = makeRestCallEx(t, "POST", baseURL, map[string]string{netw.APIKeyHeader: "writeKey"})
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func apiKeyUnknown(t *testing.T, baseURL string) {
+	code, msg := makeRestCall(t, baseURL, "Bearer stolenKey")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "Invalid API key", msg)
+
+	code, msg = makeRestCallEx(t, "POST", baseURL, map[string]string{netw.APIKeyHeader: "stolenKey"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "Invalid API key", msg)
+}
+
+func apiKeyReadOnly(t *testing.T, baseURL string) {
+	code, _ := makeRestCallEx(t, "GET", baseURL, map[string]string{netw.APIKeyHeader: "readKey"})
+	assert.Equal(t, http.StatusOK, code)
+
+	code, msg := makeRestCallEx(t, "POST", baseURL, map[string]string{netw.APIKeyHeader: "readKey"})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "The token does not allow this request", msg)
+}
+
+func TestMiddlewareAPIKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping TestMiddlewareAPIKey in short mode")
+	}
+
+	handlersFuncs := []libtest.HttpTestHandlerFunc{} // No handlers functions
+	handlers := []libtest.HttpTestHandler{
+		{Path: "/checkToken", F: netw.AuthMiddleware(logger.GetNopLogger(), revokedTokens, testAPIKeys)(http.HandlerFunc(checkTokenHandler))},
+	}
+
+	testFunctions := []func(t *testing.T, baseURL string){
+		noToken,
+		noBearer,
+		tokenOk,
+		revokedToken,
+		apiKeyOk,
+		apiKeyUnknown,
+		apiKeyReadOnly,
+	}
+
+	libtest.RunSimpleServer(t, testFunctions, handlersFuncs, handlers)
+}
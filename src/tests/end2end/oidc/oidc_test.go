@@ -0,0 +1,140 @@
+package test_oidc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/oidc"
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/repos_mem"
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/rest"
+	"github.com/Manolo-Esc/gommence/src/internal/app"
+	"github.com/Manolo-Esc/gommence/src/internal/domain"
+	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/jwt"
+	"github.com/Manolo-Esc/gommence/src/internal/mocks"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
+	"github.com/Manolo-Esc/gommence/src/pkg/cache"
+	"github.com/Manolo-Esc/gommence/src/pkg/logger"
+	"github.com/Manolo-Esc/gommence/src/tests/libtest"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	port     = ":5092"
+	clientID = "gommence-e2e"
+)
+
+var fakeUser = libtest.FakeOIDCUser{Subject: "fake-subject", Email: "jane@mail.com", EmailVerified: true, GivenName: "Jane", FamilyName: "Doe"}
+
+// signIn goes through the whole flow as a browser would: our start endpoint, the provider and back to our callback.
+// The state cookie is sent to the callback only if keepCookie, as if the callback was opened in another browser otherwise
+func signIn(t *testing.T, baseURL string, keepCookie bool) (int, []byte) {
+	var stateCookie *http.Cookie
+	client := &http.Client{ // no jar, it does not send Secure cookies over http as browsers do for localhost
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			for _, cookie := range req.Response.Cookies() {
+				if cookie.Name == "oidc_state" {
+					assert.True(t, cookie.Secure && cookie.HttpOnly)
+					stateCookie = cookie
+				}
+			}
+			if keepCookie && stateCookie != nil && strings.HasPrefix(req.URL.Path, stateCookie.Path) {
+				req.AddCookie(stateCookie)
+			}
+			return nil
+		},
+	}
+	response, err := client.Get(baseURL + "oidc/start")
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	return response.StatusCode, body
+}
+
+func newUserFirstSignIn(userSvc *mocks.MockUserService, tokenRepo *mocks.MockTokenRepository) func(t *testing.T, baseURL string) {
+	return func(t *testing.T, baseURL string) {
+		userSvc.EXPECT().GetUserByIdentity(gomock.Any(), gomock.Any(), fakeUser.Subject).Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found"))
+		userSvc.EXPECT().GetUserByEmail(gomock.Any(), fakeUser.Email).Return(nil, ports.NewAPIError(http.StatusNotFound, "User not found"))
+		userSvc.EXPECT().
+			CreateUser(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, creationData *dtos.InternalUserCreate) (string, ports.APIError) {
+				assert.Equal(t, fakeUser.Email, creationData.Email)
+				assert.Equal(t, domain.AuthMethGoogle, creationData.AuthMethod)
+				return "JaneID", nil
+			})
+		userSvc.EXPECT().
+			LinkIdentity(gomock.Any(), "JaneID", gomock.Any()).
+			DoAndReturn(func(ctx context.Context, idUser string, identity *domain.ExternalIdentity) ports.APIError {
+				assert.Equal(t, fakeUser.Subject, identity.Subject)
+				return nil
+			})
+		userSvc.EXPECT().MarkEmailVerified(gomock.Any(), "JaneID").Return(nil)
+		tokenRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return("RefreshID", nil)
+
+		status, body := signIn(t, baseURL, true)
+		assert.Equal(t, http.StatusOK, status, string(body))
+		var loggedUser dtos.LoggedUser
+		assert.Nil(t, json.Unmarshal(body, &loggedUser))
+		claims, err := jwt.ValidateToken(loggedUser.AccessToken)
+		assert.Nil(t, err)
+		assert.Equal(t, "JaneID", claims.Subject)
+	}
+}
+
+func linkedUserSignIn(userSvc *mocks.MockUserService, tokenRepo *mocks.MockTokenRepository) func(t *testing.T, baseURL string) {
+	return func(t *testing.T, baseURL string) {
+		userSvc.EXPECT().GetUserByIdentity(gomock.Any(), gomock.Any(), fakeUser.Subject).Return(&domain.User{ID: "JaneID"}, nil)
+		tokenRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return("RefreshID", nil)
+
+		status, body := signIn(t, baseURL, true)
+		assert.Equal(t, http.StatusOK, status, string(body))
+	}
+}
+
+func forgedCallback(t *testing.T, baseURL string) {
+	response, err := http.Get(baseURL + "oidc/callback?code=stolen&state=made-up")
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func otherBrowserCallback(t *testing.T, baseURL string) {
+	status, body := signIn(t, baseURL, false)
+	assert.Equal(t, http.StatusBadRequest, status, string(body))
+}
+
+func TestOIDCSignIn(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping TestOIDCSignIn in short mode")
+	}
+	issuer := libtest.NewFakeOIDCIssuer(clientID, fakeUser)
+	defer issuer.Close()
+	provider, err := oidc.NewProvider(oidc.Config{Issuer: issuer.URL(), ClientID: clientID, RedirectURL: "http://localhost" + port + "/oidc/callback"}, nil)
+	assert.Nil(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	userSvc := mocks.NewMockUserService(ctrl)
+	tokenRepo := mocks.NewMockTokenRepository(ctrl)
+	serviceInfra := &app.ServiceInfra{Logger: logger.GetNopLogger(), Cache: cache.NewCache(), Permissions: mocks.NewMockPermissionService(ctrl),
+		Tx: repos_mem.NewTxManager()}
+	authSvc := app.NewAuthService(serviceInfra, userSvc, tokenRepo, mocks.NewMockActionTokenRepository(ctrl), mocks.NewMockMFARepository(ctrl), repos_mem.NewOIDCFlowRepository(repos_mem.NewMemReposInfra()), mocks.NewMockLoginThrottler(ctrl), mocks.NewMockMailer(ctrl), provider, app.DefaultAuthConfig)
+	authHandler := rest.NewAuthHandler(authSvc, logger.GetNopLogger())
+
+	handlersFuncs := []libtest.HttpTestHandlerFunc{
+		{Path: "/oidc/start", F: authHandler.OIDCStart},
+		{Path: "/oidc/callback", F: authHandler.OIDCCallback},
+	}
+	testFunctions := []func(t *testing.T, baseURL string){
+		newUserFirstSignIn(userSvc, tokenRepo),
+		linkedUserSignIn(userSvc, tokenRepo),
+		forgedCallback,
+		otherBrowserCallback,
+	}
+	libtest.RunSimpleServerEx(t, testFunctions, port, handlersFuncs, nil)
+}
@@ -6,15 +6,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/Manolo-Esc/gommence/src/internal/adapters/repos_db"
 	"github.com/Manolo-Esc/gommence/src/internal/domain"
 	"github.com/Manolo-Esc/gommence/src/internal/dtos"
+	"github.com/Manolo-Esc/gommence/src/internal/infra/database"
+	"github.com/Manolo-Esc/gommence/src/internal/ports"
 	mylogger "github.com/Manolo-Esc/gommence/src/pkg/logger"
 	"github.com/stretchr/testify/suite"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -24,9 +26,16 @@ type databaseIntegrationSuite struct {
 	db *gorm.DB
 }
 
+// TEST_DB_DRIVER=sqlite runs the suite on a SQLite database in memory, without the Postgres of the docker compose
 func (s *databaseIntegrationSuite) SetupSuite() { // SetupSuite runs once, before all tests
+	driver := os.Getenv("TEST_DB_DRIVER")
 	dsn := "host=localhost user=postgres password=secret dbname=integration_tests port=5432 sslmode=disable TimeZone=UTC"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if driver == "" {
+		driver = database.DriverPostgres
+	} else if driver == database.DriverSQLite {
+		dsn = ":memory:"
+	}
+	db, err := database.Open(driver, dsn, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
 	if err != nil {
 		s.T().Fatalf("Error connecting to database: %v", err)
 	}
@@ -92,6 +101,422 @@ func (s *databaseIntegrationSuite) Test_FindUsersByEmail() {
 	s.Equal("", userID)
 }
 
+func (s *databaseIntegrationSuite) Test_TenantFilter() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	tenantOne := domain.WithTenant(ctx, fmt.Sprintf("one%d", time.Now().Nanosecond()))
+	tenantTwo := domain.WithTenant(ctx, fmt.Sprintf("two%d", time.Now().Nanosecond()))
+
+	s.NotNil(repos_db.CreateEntityWithPID(ctx, s.db, &tenantNote{Text: "Nobody's"})) // the tenant is required
+	s.Nil(repos_db.CreateEntityWithPID(tenantOne, s.db, &tenantNote{Text: "One's"}))
+	twos := tenantNote{Text: "Two's"}
+	s.Nil(repos_db.CreateEntityWithPID(tenantTwo, s.db, &twos))
+
+	var notes []tenantNote
+	s.Nil(s.db.WithContext(tenantOne).Find(&notes).Error) // without asking for it
+	s.Len(notes, 1)
+	s.Equal("One's", notes[0].Text)
+	s.ErrorIs(s.db.WithContext(ctx).Find(&notes).Error, repos_db.ErrNoTenant) // never everything
+	var count int64
+	s.Nil(s.db.WithContext(tenantOne).Model(&tenantNote{}).Where("id = ?", twos.ID).Count(&count).Error)
+	s.Equal(int64(0), count)
+
+	result := s.db.WithContext(tenantOne).Model(&twos).Update("text", "Taken by one")
+	s.Nil(result.Error)
+	s.Equal(int64(0), result.RowsAffected)
+	result = s.db.WithContext(tenantOne).Delete(&twos)
+	s.Nil(result.Error)
+	s.Equal(int64(0), result.RowsAffected)
+	s.ErrorIs(s.db.WithContext(ctx).Delete(&twos).Error, repos_db.ErrNoTenant)
+	s.ErrorIs(s.db.WithContext(tenantOne).Delete(&tenantNote{}).Error, gorm.ErrMissingWhereClause) // not the whole tenant
+
+	var stillTwos tenantNote
+	s.Nil(s.db.WithContext(tenantTwo).First(&stillTwos, "id = ?", twos.ID).Error)
+	s.Equal("Two's", stillTwos.Text)
+	s.Nil(s.db.WithContext(tenantTwo).Delete(&stillTwos).Error)
+}
+
+func (s *databaseIntegrationSuite) Test_GetUsersPages() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	repo := repos_db.NewUserRepository(&repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()})
+	domainName := fmt.Sprintf("@pages%d.com", time.Now().Nanosecond())
+	for _, name := range []string{"e", "a", "d", "b", "c"} {
+		_, err := repo.Create(ctx, &dtos.InternalUserCreate{FirstName: "John", FirstLastName: "Page", Email: name + domainName, AuthMethod: domain.AuthMethPassword, HashedPassword: "hashedPassword"})
+		s.Nil(err)
+	}
+	query := dtos.ListQuery{
+		Limit:     2,
+		Sort:      []dtos.ListSort{{Field: "email"}},
+		Filters:   []dtos.ListFilter{{Field: "email", Op: dtos.ListOpContains, Value: domainName}},
+		WithTotal: true,
+	}
+	var emails []string
+	for page := 0; page < 5; page++ {
+		users, result, err := repo.GetUsers(ctx, query)
+		s.Nil(err)
+		s.Equal(int64(5), *result.Total)
+		for _, user := range users {
+			emails = append(emails, user.Email[:1])
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+	s.Equal([]string{"a", "b", "c", "d", "e"}, emails)
+
+	query = dtos.ListQuery{Limit: 2, Page: 2, Sort: []dtos.ListSort{{Field: "email", Desc: true}}, Filters: query.Filters}
+	users, result, err := repo.GetUsers(ctx, query)
+	s.Nil(err)
+	s.Nil(result.Total)
+	s.Empty(result.NextCursor)
+	s.Equal("c"+domainName, users[0].Email)
+	s.Equal("b"+domainName, users[1].Email)
+
+	query = dtos.ListQuery{Sort: []dtos.ListSort{{Field: "created_at", Desc: true}}, Filters: append(query.Filters,
+		dtos.ListFilter{Field: "created_at", Op: dtos.ListOpGte, Value: time.Now().Add(-time.Minute).Format(time.RFC3339)})}
+	users, _, err = repo.GetUsers(ctx, query)
+	s.Nil(err)
+	s.Len(users, 5)
+	s.Equal("c"+domainName, users[0].Email) // the last created
+
+	_, _, err = repo.GetUsers(ctx, dtos.ListQuery{Cursor: "not a cursor"})
+	s.Equal(http.StatusBadRequest, err.Status())
+	_, _, err = repo.GetUsers(ctx, dtos.ListQuery{Sort: []dtos.ListSort{{Field: "name"}}}) // several columns
+	s.Equal(http.StatusBadRequest, err.Status())
+}
+
+func (s *databaseIntegrationSuite) Test_EraseUser() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	infra := &repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()}
+	repo := repos_db.NewUserRepository(infra)
+	audit := repos_db.NewAuditRepository(infra)
+	email := fmt.Sprintf("erase%d@mail.com", time.Now().Nanosecond())
+	idUser, err := repo.Create(ctx, &dtos.InternalUserCreate{FirstName: "John", FirstLastName: "Erased", Email: email, AuthMethod: domain.AuthMethPassword, HashedPassword: "hashedPassword"})
+	s.Nil(err)
+	s.Nil(repo.LinkIdentity(ctx, idUser, &domain.ExternalIdentity{Issuer: "https://issuer", Subject: idUser}))
+	_, err = audit.CreateAuditEvent(ctx, &domain.AuditEvent{Type: domain.AuditRoleGranted, ActorID: idUser, Target: "someone"})
+	s.Nil(err)
+	_, err = audit.CreateAuditEvent(ctx, &domain.AuditEvent{Type: domain.AuditAccountLocked, Target: email})
+	s.Nil(err)
+	events, err := audit.GetUserAuditEvents(ctx, idUser, email)
+	s.Nil(err)
+	s.Len(events, 2)
+
+	s.Nil(repo.DeleteUser(ctx, idUser))
+	s.Nil(audit.AnonymizeUserAuditEvents(ctx, idUser, email))
+	s.Nil(repo.EraseUser(ctx, idUser)) // also the deleted users
+	_, err = repo.GetUserByIdUnscoped(ctx, idUser)
+	s.Equal(http.StatusNotFound, err.Status())
+	identities, err := repo.GetUserIdentities(ctx, idUser)
+	s.Nil(err)
+	s.Empty(identities)
+	events, err = audit.GetUserAuditEvents(ctx, idUser, email)
+	s.Nil(err)
+	s.Empty(events)
+	s.Nil(repo.EraseUser(ctx, idUser)) // twice is harmless
+}
+
+func (s *databaseIntegrationSuite) Test_CreateUsers() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	repo := repos_db.NewUserRepository(&repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()})
+	suffix := fmt.Sprintf("%d@batch.com", time.Now().Nanosecond())
+	user := func(name string) *dtos.InternalUserCreate {
+		return &dtos.InternalUserCreate{FirstName: "John", FirstLastName: "Batch", Email: name + suffix, AuthMethod: domain.AuthMethPassword}
+	}
+	ids, errs := repo.CreateUsers(ctx, []*dtos.InternalUserCreate{user("a"), user("b")})
+	s.Equal([]ports.APIError{nil, nil}, errs)
+	s.NotEmpty(ids[0])
+	s.NotEmpty(ids[1])
+
+	// a taken email fails the statement, then the users are created one by one
+	ids, errs = repo.CreateUsers(ctx, []*dtos.InternalUserCreate{user("c"), user("a"), user("d")})
+	s.Nil(errs[0])
+	s.Equal(http.StatusConflict, errs[1].Status())
+	s.Nil(errs[2])
+	s.Empty(ids[1])
+	users, err := repo.GetUsersByEmails(ctx, []string{"A" + suffix, "c" + suffix, "d" + suffix})
+	s.Nil(err)
+	s.Len(users, 3)
+
+	_, errCreate := repo.Create(ctx, user("B")) // the case does not make it another email
+	s.Equal(http.StatusConflict, errCreate.Status())
+	s.Equal("Email already registered", errCreate.Error())
+	_, errs = repo.CreateUsers(ctx, []*dtos.InternalUserCreate{user("e"), user("E")})
+	s.Nil(errs[0])
+	s.Equal(http.StatusConflict, errs[1].Status())
+}
+
+func (s *databaseIntegrationSuite) Test_Transactions() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	infra := &repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()}
+	repo := repos_db.NewUserRepository(infra)
+	tx := repos_db.NewTxManager(infra)
+	suffix := fmt.Sprintf("%d@tx.com", time.Now().Nanosecond())
+	user := func(name string) *dtos.InternalUserCreate {
+		return &dtos.InternalUserCreate{FirstName: "John", FirstLastName: "Tx", Email: name + suffix, AuthMethod: domain.AuthMethPassword}
+	}
+	failure := ports.NewAPIError(http.StatusConflict, "failed")
+
+	err := tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		if _, err := repo.Create(ctx, user("rolledback")); err != nil {
+			return err
+		}
+		return failure
+	})
+	s.Equal(failure, err)
+	_, err = repo.GetUserByEmail(ctx, "rolledback"+suffix)
+	s.Equal(http.StatusNotFound, err.Status())
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) ports.APIError {
+		if _, err := repo.Create(ctx, user("outer")); err != nil {
+			return err
+		}
+		s.Equal(failure, tx.WithinTx(ctx, func(ctx context.Context) ports.APIError { // a savepoint
+			if _, err := repo.Create(ctx, user("inner")); err != nil {
+				return err
+			}
+			return failure
+		}))
+		_, err := repo.Create(ctx, user("outer")) // a failed statement does not abort the transaction
+		s.Equal(http.StatusConflict, err.Status())
+		return nil
+	})
+	s.Nil(err)
+	_, err = repo.GetUserByEmail(ctx, "outer"+suffix)
+	s.Nil(err)
+	_, err = repo.GetUserByEmail(ctx, "inner"+suffix)
+	s.Equal(http.StatusNotFound, err.Status())
+}
+
+func (s *databaseIntegrationSuite) Test_PurgeExpiredTokens() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	infra := &repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()}
+	tokens := repos_db.NewTokenRepository(infra)
+	actionTokens := repos_db.NewActionTokenRepository(infra)
+	suffix := fmt.Sprintf("%d", time.Now().Nanosecond())
+	now := time.Now()
+	refresh := func(name string, expiresAt time.Time) string {
+		id, err := tokens.CreateRefreshToken(ctx, &domain.RefreshToken{UserID: "purge" + suffix, FamilyID: name + suffix, TokenHash: name + suffix, ExpiresAt: expiresAt})
+		s.Nil(err)
+		return id
+	}
+	refresh("expired", now.Add(-time.Minute))
+	refresh("revoked", now.Add(time.Hour))
+	s.Nil(tokens.RevokeRefreshTokenFamily(ctx, "revoked"+suffix))
+	used := refresh("used", now.Add(time.Hour))
+	_, err := tokens.MarkRefreshTokenUsed(ctx, used)
+	s.Nil(err)
+	s.Nil(tokens.RevokeAccessToken(ctx, "expired"+suffix, "purge"+suffix, now.Add(-time.Minute)))
+	s.Nil(tokens.RevokeAccessToken(ctx, "valid"+suffix, "purge"+suffix, now.Add(time.Hour)))
+	_, err = actionTokens.CreateActionToken(ctx, &domain.ActionToken{UserID: "purge" + suffix, Purpose: domain.ActionVerifyEmail, TokenHash: "expired" + suffix, ExpiresAt: now.Add(-time.Minute)})
+	s.Nil(err)
+	_, err = actionTokens.CreateActionToken(ctx, &domain.ActionToken{UserID: "purge" + suffix, Purpose: domain.ActionVerifyEmail, TokenHash: "valid" + suffix, ExpiresAt: now.Add(time.Hour)})
+	s.Nil(err)
+
+	s.Nil(tokens.DeleteExpiredTokens(ctx, now))
+	s.Nil(actionTokens.DeleteExpiredActionTokens(ctx, now))
+	for name, kept := range map[string]bool{"expired": false, "revoked": false, "used": true} {
+		_, err := tokens.GetRefreshTokenByHash(ctx, name+suffix)
+		s.Equal(kept, err == nil, name)
+	}
+	for name, kept := range map[string]bool{"expired": false, "valid": true} {
+		revoked, err := tokens.IsAccessTokenRevoked(ctx, name+suffix)
+		s.Nil(err)
+		s.Equal(kept, revoked, name)
+		_, err = actionTokens.GetActionTokenByHash(ctx, domain.ActionVerifyEmail, name+suffix)
+		s.Equal(kept, err == nil, name)
+	}
+
+	s.Nil(tokens.DeleteUserRefreshTokens(ctx, "purge"+suffix)) // the revocation holds, without the user
+	revoked, err := tokens.IsAccessTokenRevoked(ctx, "valid"+suffix)
+	s.Nil(err)
+	s.True(revoked)
+	var count int64
+	s.Nil(s.db.Model(&repos_db.RevokedAccessToken{}).Where("user_id = ?", "purge"+suffix).Count(&count).Error)
+	s.Zero(count)
+}
+
+func (s *databaseIntegrationSuite) Test_LoginAttempts() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	repo := repos_db.NewLoginAttemptRepository(&repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()})
+	key := fmt.Sprintf("account:%d@mail.com", time.Now().Nanosecond())
+	now := time.Now()
+
+	for i := 1; i <= 3; i++ {
+		attempts, err := repo.AddLoginFailure(ctx, key, now, now.Add(-time.Hour))
+		s.Nil(err)
+		s.Equal(i, attempts.Failures)
+	}
+	attempts, err := repo.AddLoginFailure(ctx, key, now.Add(2*time.Hour), now.Add(time.Hour)) // the others are past the window
+	s.Nil(err)
+	s.Equal(1, attempts.Failures)
+	s.True(now.Add(2 * time.Hour).Equal(attempts.LastFailureAt))
+
+	locked, err := repo.LockLoginAttempts(ctx, key, 2, now.Add(time.Hour))
+	s.Nil(err)
+	s.False(locked)
+	_, err = repo.AddLoginFailure(ctx, key, now.Add(2*time.Hour), now)
+	s.Nil(err)
+	locked, err = repo.LockLoginAttempts(ctx, key, 2, now.Add(3*time.Hour))
+	s.Nil(err)
+	s.True(locked)
+	locked, err = repo.LockLoginAttempts(ctx, key, 2, now.Add(3*time.Hour)) // the failures were forgotten
+	s.Nil(err)
+	s.False(locked)
+	attempts, err = repo.GetLoginAttempts(ctx, key)
+	s.Nil(err)
+	s.Equal(0, attempts.Failures)
+	s.True(attempts.IsLocked(now.Add(2 * time.Hour)))
+	s.Nil(repo.DeleteLoginAttempts(ctx, key))
+}
+
+func (s *databaseIntegrationSuite) Test_MFARepository() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	repo := repos_db.NewMFARepository(&repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()})
+	userId := fmt.Sprintf("mfa%d", time.Now().Nanosecond())
+	now := time.Now()
+
+	s.Nil(repo.CreateMFAChallenge(ctx, &domain.MFAChallenge{TokenHash: "hash" + userId, UserID: userId, ExpiresAt: now.Add(time.Minute)}))
+	for i := 1; i <= 2; i++ {
+		challenge, err := repo.AddMFAChallengeAttempt(ctx, "hash"+userId, now)
+		s.Nil(err)
+		s.Equal(i, challenge.Attempts)
+		s.Equal(userId, challenge.UserID)
+	}
+	_, err := repo.AddMFAChallengeAttempt(ctx, "hash"+userId, now.Add(2*time.Minute)) // expired
+	s.Equal(http.StatusNotFound, err.Status())
+	deleted, err := repo.DeleteMFAChallenge(ctx, "hash"+userId)
+	s.Nil(err)
+	s.True(deleted)
+	deleted, err = repo.DeleteMFAChallenge(ctx, "hash"+userId)
+	s.Nil(err)
+	s.False(deleted)
+
+	for i, fresh := range []bool{true, false} {
+		used, err := repo.UseTOTPStep(ctx, userId, 42, now.Add(time.Minute))
+		s.Nil(err)
+		s.Equal(fresh, used, i)
+	}
+	s.Nil(repo.DeleteExpiredMFA(ctx, now.Add(2*time.Minute)))
+	used, err := repo.UseTOTPStep(ctx, userId, 42, now.Add(time.Minute)) // forgotten once expired
+	s.Nil(err)
+	s.True(used)
+	s.Nil(repo.DeleteUserMFA(ctx, userId))
+}
+
+func (s *databaseIntegrationSuite) Test_OIDCFlowRepository() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	repo := repos_db.NewOIDCFlowRepository(&repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()})
+	stateHash := fmt.Sprintf("state%d", time.Now().Nanosecond())
+	now := time.Now()
+
+	s.Nil(repo.CreateOIDCFlow(ctx, &domain.OIDCFlow{StateHash: stateHash, Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(time.Minute)}))
+	flow, err := repo.TakeOIDCFlow(ctx, stateHash, now)
+	s.Nil(err)
+	s.Equal("nonce", flow.Nonce)
+	s.Equal("verifier", flow.CodeVerifier)
+	_, err = repo.TakeOIDCFlow(ctx, stateHash, now) // single use
+	s.Equal(http.StatusNotFound, err.Status())
+
+	s.Nil(repo.CreateOIDCFlow(ctx, &domain.OIDCFlow{StateHash: stateHash, Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(time.Minute)}))
+	_, err = repo.TakeOIDCFlow(ctx, stateHash, now.Add(2*time.Minute)) // expired
+	s.Equal(http.StatusNotFound, err.Status())
+	s.Nil(repo.DeleteExpiredOIDCFlows(ctx, now.Add(2*time.Minute)))
+	s.Nil(repo.CreateOIDCFlow(ctx, &domain.OIDCFlow{StateHash: stateHash, Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(time.Minute)}))
+	s.Nil(repo.DeleteExpiredOIDCFlows(ctx, now.Add(2*time.Minute)))
+	_, err = repo.TakeOIDCFlow(ctx, stateHash, now) // purged
+	s.Equal(http.StatusNotFound, err.Status())
+}
+
+func (s *databaseIntegrationSuite) Test_SeedDevelopmentRoles() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	infra := &repos_db.DBReposInfra{Db: s.db, Logger: mylogger.GetNopLogger()}
+	userRepo := repos_db.NewUserRepository(infra)
+	permissionRepo := repos_db.NewPermissionRepository(infra)
+	granny := userRepo.GetUserIdByEmail(ctx, "granny@lancre.dw")
+	s.NotEqual("", granny)
+
+	roles, err := permissionRepo.GetUserRoles(ctx, granny)
+	s.Nil(err)
+	s.Empty(roles) // a known password, no role unless asked for
+	s.Nil(database.SeedDevelopmentRoles(ctx, userRepo, permissionRepo))
+	roles, err = permissionRepo.GetUserRoles(ctx, granny)
+	s.Nil(err)
+	s.Len(roles, 1)
+	s.Equal(domain.RoleAdmin, roles[0].Name)
+	s.Nil(permissionRepo.RemoveUserRole(ctx, granny, roles[0].ID))
+}
+
+func (s *databaseIntegrationSuite) Test_Migrations() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	s.Nil(database.Migrate(ctx, s.db)) // already migrated by SetupSuite, nothing to do
+	states, err := database.MigrationStatus(ctx, s.db)
+	s.Nil(err)
+	s.NotEmpty(states)
+	for _, state := range states {
+		s.NotNil(state.AppliedAt, state.Version)
+	}
+
+	last := states[len(states)-1]
+	s.Nil(database.MigrateDown(ctx, s.db, 1))
+	states, _ = database.MigrationStatus(ctx, s.db)
+	s.Nil(states[len(states)-1].AppliedAt, last.Version)
+	s.Nil(database.Migrate(ctx, s.db))
+}
+
+// A database of 1.0.0, before the migrations, is taken to the current models and back. Always on SQLite, in a database
+// of its own
+func (s *databaseIntegrationSuite) Test_MigrationsFromVersion1() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	db, err := database.Open(database.DriverSQLite, ":memory:", &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	s.Require().Nil(err)
+	type User struct { // as it was in 1.0.0
+		repos_db.BaseDBModel
+		FirstName      string
+		FirstLastName  string
+		SecondLastName sql.NullString
+		Email          string `gorm:"uniqueIndex"`
+		AuthMethod     domain.AuthMethod
+		HashedPassword sql.NullString
+	}
+	s.Require().Nil(db.AutoMigrate(&User{}, &database.VersionDBEntity{}))
+	s.Require().Nil(db.Create(&database.VersionDBEntity{Major: 1}).Error)
+
+	s.Require().Nil(database.Migrate(ctx, db))
+	models := []interface{}{&repos_db.User{}, &repos_db.RefreshToken{}, &repos_db.RevokedAccessToken{}, &repos_db.ActionToken{},
+		&repos_db.UserIdentity{}, &repos_db.LoginAttempt{}, &repos_db.AuditEvent{}, &repos_db.APIKey{}, &repos_db.Role{},
+		&repos_db.UserRole{}, &repos_db.Organization{}, &repos_db.Membership{}, &repos_db.Group{}, &repos_db.GroupMember{},
+		&repos_db.Grant{}, &repos_db.MFAChallenge{}, &repos_db.UsedTOTPCode{}, &repos_db.OIDCFlow{}}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		s.Require().Nil(stmt.Parse(model))
+		for _, column := range stmt.Schema.DBNames {
+			s.True(db.Migrator().HasColumn(model, column), "%s.%s", stmt.Schema.Table, column)
+		}
+	}
+
+	states, err := database.MigrationStatus(ctx, db)
+	s.Nil(err)
+	s.Nil(database.MigrateDown(ctx, db, len(states)))
+	tables, err := db.Migrator().GetTables()
+	s.Nil(err)
+	s.ElementsMatch([]string{"users", "schema_migrations"}, tables)
+	s.False(db.Migrator().HasColumn(&repos_db.User{}, "EmailVerifiedAt"))
+	s.False(db.Migrator().HasColumn(&repos_db.User{}, "MFASecret"))
+}
+
 func TestRunSuite(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping database integration suite in short mode") // text only seen with -v
@@ -3,14 +3,22 @@ package database
 import (
 	"context"
 
+	"github.com/Manolo-Esc/gommence/src/internal/adapters/repos_db"
 	"github.com/Manolo-Esc/gommence/src/internal/infra/database"
 	"gorm.io/gorm"
 )
 
+// Data owned by an organization, only used by the tests
+type tenantNote struct {
+	repos_db.BaseDBModel
+	repos_db.TenantModel
+	Text string
+}
+
 func createTestDatabase(ctx context.Context, db *gorm.DB) error {
 	err := database.Migrate(ctx, db)
 	if err != nil {
 		return err
 	}
-	return nil
+	return db.WithContext(ctx).AutoMigrate(&tenantNote{})
 }
@@ -0,0 +1,192 @@
+package libtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// FakeOIDCUser is the user that signs in in the FakeOIDCIssuer
+type FakeOIDCUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+type fakeAuthorization struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// FakeOIDCIssuer is a minimal OIDC provider for tests. Its authorization endpoint signs in User without asking
+// and redirects back with a code. The token endpoint checks the PKCE verifier and returns a RS256 ID token
+type FakeOIDCIssuer struct {
+	Server   *httptest.Server
+	ClientID string
+	User     FakeOIDCUser
+	Claims   map[string]interface{} // Overrides the claims of the next ID tokens, e.g. to test invalid ones
+
+	key   *rsa.PrivateKey
+	kid   string
+	lock  sync.Mutex
+	codes map[string]fakeAuthorization
+}
+
+func NewFakeOIDCIssuer(clientID string, user FakeOIDCUser) *FakeOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	issuer := &FakeOIDCIssuer{ClientID: clientID, User: user, key: key, kid: "fake-key", codes: make(map[string]fakeAuthorization)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/authorize", issuer.authorize)
+	mux.HandleFunc("/token", issuer.token)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+func (f *FakeOIDCIssuer) URL() string {
+	return f.Server.URL
+}
+
+func (f *FakeOIDCIssuer) Close() {
+	f.Server.Close()
+}
+
+// RotateKey replaces the signing key, as providers do from time to time
+func (f *FakeOIDCIssuer) RotateKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.key, f.kid = key, kid
+}
+
+// IDToken signs an ID token for User with the given nonce, applying the Claims overrides
+func (f *FakeOIDCIssuer) IDToken(nonce string) string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            f.URL(),
+		"sub":            f.User.Subject,
+		"aud":            f.ClientID,
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          f.User.Email,
+		"email_verified": f.User.EmailVerified,
+		"given_name":     f.User.GivenName,
+		"family_name":    f.User.FamilyName,
+	}
+	for name, value := range f.Claims {
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = f.kid
+	signed, err := token.SignedString(f.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (f *FakeOIDCIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 f.URL(),
+		"authorization_endpoint": f.URL() + "/authorize",
+		"token_endpoint":         f.URL() + "/token",
+		"jwks_uri":               f.URL() + "/jwks",
+	})
+}
+
+func (f *FakeOIDCIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != f.ClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	random := make([]byte, 16)
+	rand.Read(random)
+	code := base64.RawURLEncoding.EncodeToString(random)
+	f.lock.Lock()
+	f.codes[code] = fakeAuthorization{
+		clientID:      query.Get("client_id"),
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	f.lock.Unlock()
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (f *FakeOIDCIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	f.lock.Lock()
+	authorization, found := f.codes[r.PostForm.Get("code")]
+	delete(f.codes, r.PostForm.Get("code")) // codes are single use
+	f.lock.Unlock()
+	clientID := r.PostForm.Get("client_id")
+	if user, _, ok := r.BasicAuth(); ok { // confidential clients
+		clientID, _ = url.QueryUnescape(user)
+	}
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || authorization.clientID != clientID || authorization.redirectURI != r.PostForm.Get("redirect_uri") ||
+		authorization.codeChallenge != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "fake-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     f.IDToken(authorization.nonce),
+	})
+}
+
+func (f *FakeOIDCIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": f.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}